@@ -0,0 +1,129 @@
+package gollama
+
+import "strings"
+
+// Accumulator assembles the chunks of a ChatStream into the complete
+// assistant message and final response, so that callers who stream for
+// responsiveness still get a single message to append to the conversation
+// history. The zero value is ready to use. An Accumulator is not safe for
+// concurrent use.
+//
+// Example:
+//
+//	var acc gollama.Accumulator
+//	err := client.ChatStream(ctx, req, acc.ChatFunc(func(resp *gollama.ChatResponse) {
+//		fmt.Print(resp.Message.Content)
+//	}))
+//	history = append(history, acc.Message())
+type Accumulator struct {
+	content  strings.Builder
+	thinking strings.Builder
+	message  Message
+	response ChatResponse
+	chunks   int
+}
+
+// Add adds a chunk of the stream.
+func (a *Accumulator) Add(resp *ChatResponse) {
+	a.chunks++
+	a.content.WriteString(resp.Message.Content)
+	a.thinking.WriteString(resp.Message.Thinking)
+	if resp.Message.Role != "" {
+		a.message.Role = resp.Message.Role
+	}
+	a.message.Images = append(a.message.Images, resp.Message.Images...)
+	a.message.ToolCalls = append(a.message.ToolCalls, resp.Message.ToolCalls...)
+
+	// Statistics arrive with the final chunk
+	if resp.Done {
+		final := *resp
+		final.Model, final.CreatedAt = a.response.Model, a.response.CreatedAt
+		a.response = final
+	}
+	if resp.Model != "" {
+		a.response.Model = resp.Model
+	}
+	if !resp.CreatedAt.IsZero() {
+		a.response.CreatedAt = resp.CreatedAt
+	}
+}
+
+// ChatFunc returns a ChatStream callback that adds each chunk to the
+// Accumulator and then passes it to next, which may be nil.
+func (a *Accumulator) ChatFunc(next func(*ChatResponse)) func(*ChatResponse) {
+	return func(resp *ChatResponse) {
+		a.Add(resp)
+		if next != nil {
+			next(resp)
+		}
+	}
+}
+
+// Message returns the message assembled so far. Its role defaults to
+// "assistant" if no chunk carried one.
+func (a *Accumulator) Message() Message {
+	message := a.message
+	if message.Role == "" {
+		message.Role = "assistant"
+	}
+	message.Content = a.content.String()
+	message.Thinking = a.thinking.String()
+	return message
+}
+
+// Response returns the response assembled so far: the complete message,
+// with the model and timestamp of the latest chunk and, once the stream is
+// done, the statistics of the final chunk.
+func (a *Accumulator) Response() *ChatResponse {
+	response := a.response
+	response.Message = a.Message()
+	return &response
+}
+
+// Done reports whether the final chunk of the stream has been added.
+func (a *Accumulator) Done() bool {
+	return a.response.Done
+}
+
+// Chunks returns the number of chunks added.
+func (a *Accumulator) Chunks() int {
+	return a.chunks
+}
+
+// Reset clears the Accumulator for use with another stream.
+func (a *Accumulator) Reset() {
+	a.content.Reset()
+	a.thinking.Reset()
+	a.message = Message{}
+	a.response = ChatResponse{}
+	a.chunks = 0
+}
+
+// generateAccumulator assembles the chunks of a GenerateStream into the
+// complete response.
+type generateAccumulator struct {
+	text     strings.Builder
+	thinking strings.Builder
+	last     GenerateResponse
+	chunks   int
+}
+
+func (a *generateAccumulator) add(resp *GenerateResponse) {
+	a.chunks++
+	a.text.WriteString(resp.Response)
+	a.thinking.WriteString(resp.Thinking)
+	model := a.last.Model
+	a.last = *resp
+	if a.last.Model == "" {
+		a.last.Model = model
+	}
+}
+
+// response returns the response assembled so far, with the metadata of the
+// latest chunk.
+func (a *generateAccumulator) response() *GenerateResponse {
+	response := a.last
+	response.Response = a.text.String()
+	response.Thinking = a.thinking.String()
+	return &response
+}
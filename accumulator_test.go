@@ -0,0 +1,110 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAccumulator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunks := []string{
+			`{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"Need the "}}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"weather."}}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":"Checking","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]}}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":" now."}}`,
+			`{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"total_duration":500,"eval_count":12,"prompt_eval_count":30}`,
+		}
+		for _, chunk := range chunks {
+			w.Write([]byte(chunk + "\n"))
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var acc Accumulator
+	var streamed strings.Builder
+	err = client.ChatStream(context.Background(), &ChatRequest{
+		Model:    "qwen3",
+		Messages: []Message{{Role: "user", Content: "Weather in Paris?"}},
+	}, acc.ChatFunc(func(resp *ChatResponse) {
+		streamed.WriteString(resp.Message.Content)
+	}))
+	assertNoError(t, err)
+
+	if !acc.Done() || acc.Chunks() != 5 {
+		t.Errorf("Expected 5 chunks and done, got %d chunks, done=%v", acc.Chunks(), acc.Done())
+	}
+	if streamed.String() != "Checking now." {
+		t.Errorf("Expected chunks to be passed on, got %q", streamed.String())
+	}
+
+	want := Message{
+		Role:      "assistant",
+		Content:   "Checking now.",
+		Thinking:  "Need the weather.",
+		ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: "get_weather", Arguments: map[string]interface{}{"city": "Paris"}}}},
+	}
+	if got := acc.Message(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected message %+v, got %+v", want, got)
+	}
+
+	resp := acc.Response()
+	if resp.Model != "qwen3" || resp.TotalDuration != 500 || resp.EvalCount != 12 || resp.PromptEvalCount != 30 {
+		t.Errorf("Expected final statistics, got %+v", resp)
+	}
+	if resp.Message.Content != "Checking now." {
+		t.Errorf("Expected the complete message in the response, got %q", resp.Message.Content)
+	}
+
+	acc.Reset()
+	if acc.Chunks() != 0 || acc.Done() || acc.Message().Content != "" || acc.Message().Role != "assistant" {
+		t.Errorf("Expected a cleared accumulator, got %+v", acc.Response())
+	}
+}
+
+func TestClientStreamCollect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			w.Write([]byte(`{"model":"llama3","response":"The sky"}` + "\n"))
+			w.Write([]byte(`{"model":"llama3","response":" is blue."}` + "\n"))
+			w.Write([]byte(`{"model":"llama3","response":"","done":true,"context":[1,2,3],"eval_count":4}` + "\n"))
+		case "/api/chat":
+			w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"Hi"}}` + "\n"))
+			w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":" there"}}` + "\n"))
+			w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"eval_count":2}` + "\n"))
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	var chunks int
+	generated, err := client.GenerateStreamCollect(ctx, &GenerateRequest{Model: "llama3", Prompt: "Why?"}, func(*GenerateResponse) {
+		chunks++
+	})
+	assertNoError(t, err)
+	if chunks != 3 {
+		t.Errorf("Expected 3 chunks, got %d", chunks)
+	}
+	if generated.Response != "The sky is blue." || !generated.Done || generated.EvalCount != 4 || len(generated.Context) != 3 {
+		t.Errorf("Expected the complete response with final stats, got %+v", generated)
+	}
+
+	chatted, err := client.ChatStreamCollect(ctx, &ChatRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "Hi"}}}, nil)
+	assertNoError(t, err)
+	if chatted.Message.Content != "Hi there" || chatted.Message.Role != "assistant" || chatted.EvalCount != 2 {
+		t.Errorf("Expected the complete chat response, got %+v", chatted)
+	}
+
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "llama3", Prompt: "Why?"}, nil)
+	assertErrorContains(t, err, "callback function cannot be nil")
+}
@@ -0,0 +1,283 @@
+package gollama
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of AdaptiveTimeoutOptions.
+const (
+	defaultAdaptiveMin          = 10 * time.Second
+	defaultAdaptiveMax          = 10 * time.Minute
+	defaultAdaptiveMargin       = 3
+	defaultAdaptiveOutputTokens = 512
+)
+
+// Speeds assumed for a model before any of its responses have been seen,
+// scaled by its size in billions of parameters: a 7B model is assumed to
+// generate about 30 tokens per second, evaluate prompts ten times as fast,
+// and take 3.5 seconds to load.
+const (
+	assumedParameters     = 7   // billions, if the size is unknown
+	assumedEvalRate       = 200 // tokens per second, for a 1B model
+	assumedPromptSpeedup  = 10  // prompt evaluation over generation
+	assumedLoadPerBillion = 0.5 // seconds
+)
+
+// speedSmoothing is the weight of a new measurement in the moving averages
+// of a model's speeds.
+const speedSmoothing = 0.3
+
+// AdaptiveTimeoutOptions configures WithAdaptiveTimeout.
+type AdaptiveTimeoutOptions struct {
+	// Min and Max bound the suggested timeouts. The defaults are 10 seconds
+	// and 10 minutes.
+	Min time.Duration
+	Max time.Duration
+	// Margin multiplies the expected duration of a request, to allow for
+	// a busy server or a longer answer than expected. The default is 3.
+	Margin float64
+	// OutputTokens is the expected length of the answer to requests that
+	// do not set num_predict. The default is 512.
+	OutputTokens int
+}
+
+// WithAdaptiveTimeout limits Generate and Chat calls, and their streaming
+// variants, to a timeout suggested for each request, in place of the
+// client's WithTimeout. Calls whose context has a deadline, or that are
+// given WithRequestTimeout, are left alone.
+//
+// The suggestion is the expected time to load the model, evaluate the
+// prompt, as estimated by EstimateTokens, and generate num_predict tokens
+// or opts.OutputTokens, times opts.Margin. The speeds of a model are
+// measured from the statistics of its responses, as a moving average. Until
+// a response has been seen, they are assumed from the model's parameter
+// size, looked up once through Show, so that large models get more time
+// than small ones from the first request.
+func WithAdaptiveTimeout(opts *AdaptiveTimeoutOptions) ClientOption {
+	var o AdaptiveTimeoutOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Min <= 0 {
+		o.Min = defaultAdaptiveMin
+	}
+	if o.Max <= 0 {
+		o.Max = defaultAdaptiveMax
+	}
+	if o.Margin <= 0 {
+		o.Margin = defaultAdaptiveMargin
+	}
+	if o.OutputTokens <= 0 {
+		o.OutputTokens = defaultAdaptiveOutputTokens
+	}
+	return func(c *Client) {
+		c.adaptiveTimeout = &adaptiveTimeout{opts: o, models: make(map[ModelName]*modelSpeed)}
+	}
+}
+
+// adaptiveTimeout holds the WithAdaptiveTimeout settings and what is known
+// about the speed of each model.
+type adaptiveTimeout struct {
+	opts   AdaptiveTimeoutOptions
+	mu     sync.Mutex
+	models map[ModelName]*modelSpeed
+}
+
+// modelSpeed describes how fast a model runs. Rates are in tokens per
+// second, and zero until measured.
+type modelSpeed struct {
+	// parameters is the size of the model in billions of parameters, or
+	// zero if it is unknown
+	parameters float64
+	// lookedUp is set once the size has been looked up, even if the lookup
+	// failed, so that it is not repeated for every request
+	lookedUp   bool
+	promptRate float64
+	evalRate   float64
+	// load is the longest load time seen, since the model may be unloaded
+	// between requests
+	load time.Duration
+}
+
+// SuggestedTimeout returns the timeout WithAdaptiveTimeout would give a
+// request to model with a prompt of promptTokens tokens and an answer of
+// outputTokens tokens, or zero if the client was not created with
+// WithAdaptiveTimeout.
+func (c *Client) SuggestedTimeout(ctx context.Context, model string, promptTokens, outputTokens int) time.Duration {
+	a := c.adaptiveTimeout
+	if a == nil {
+		return 0
+	}
+	speed := a.speed(ctx, c, c.modelOrDefault(ctx, model))
+
+	parameters := speed.parameters
+	if parameters <= 0 {
+		parameters = assumedParameters
+	}
+	evalRate, promptRate := speed.evalRate, speed.promptRate
+	if evalRate <= 0 {
+		evalRate = assumedEvalRate / parameters
+	}
+	if promptRate <= 0 {
+		promptRate = evalRate * assumedPromptSpeedup
+	}
+	load := speed.load
+	if load <= 0 {
+		load = time.Duration(parameters * assumedLoadPerBillion * float64(time.Second))
+	}
+
+	expected := load.Seconds() + float64(promptTokens)/promptRate + float64(outputTokens)/evalRate
+	timeout := time.Duration(expected * a.opts.Margin * float64(time.Second))
+	if timeout < a.opts.Min {
+		timeout = a.opts.Min
+	}
+	if timeout > a.opts.Max {
+		timeout = a.opts.Max
+	}
+	return timeout
+}
+
+// speed returns a copy of what is known about a model, looking up its
+// parameter size with Show once, unless the model has been measured first.
+func (a *adaptiveTimeout) speed(ctx context.Context, c *Client, model string) modelSpeed {
+	name := ModelName(model).Normalize()
+
+	a.mu.Lock()
+	speed := a.models[name]
+	var known modelSpeed
+	if speed != nil {
+		known = *speed
+	}
+	a.mu.Unlock()
+	if known.parameters > 0 {
+		c.emit(Event{Type: EventCacheHit, Cache: "parameter_size", Key: string(name)})
+		return known
+	}
+	// The size is not needed once the model has been measured
+	if known.lookedUp || known.evalRate > 0 {
+		return known
+	}
+
+	// The call being timed has no deadline yet, so the lookup is bounded by
+	// the client's timeout
+	lookupCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var parameters float64
+	if info, err := c.Show(lookupCtx, model); err == nil {
+		parameters = parseParameterSize(info.Details.ParameterSize)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	speed = a.model(name)
+	// A lookup cut short by the caller is tried again by the next request
+	if ctx.Err() == nil {
+		speed.lookedUp = true
+	}
+	if parameters > 0 {
+		speed.parameters = parameters
+	}
+	return *speed
+}
+
+// model returns the entry of a model, creating it if needed. The caller
+// must hold a.mu.
+func (a *adaptiveTimeout) model(name ModelName) *modelSpeed {
+	speed := a.models[name]
+	if speed == nil {
+		speed = &modelSpeed{}
+		a.models[name] = speed
+	}
+	return speed
+}
+
+// observe updates the speeds of a model from the statistics of a response.
+// Durations are in nanoseconds, as reported by the server.
+func (a *adaptiveTimeout) observe(model string, load int64, promptCount int, promptDuration int64, evalCount int, evalDuration int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	speed := a.model(ModelName(model).Normalize())
+	if d := time.Duration(load); d > speed.load {
+		speed.load = d
+	}
+	if promptCount > 0 && promptDuration > 0 {
+		speed.promptRate = smoothRate(speed.promptRate, float64(promptCount)/time.Duration(promptDuration).Seconds())
+	}
+	if evalCount > 0 && evalDuration > 0 {
+		speed.evalRate = smoothRate(speed.evalRate, float64(evalCount)/time.Duration(evalDuration).Seconds())
+	}
+}
+
+// smoothRate adds a new measurement to a moving average that is zero
+// before the first one.
+func smoothRate(average, rate float64) float64 {
+	if average <= 0 {
+		return rate
+	}
+	return average + speedSmoothing*(rate-average)
+}
+
+// adaptTimeout adds the timeout suggested by WithAdaptiveTimeout to the
+// options of a generate or chat call, unless the call has a deadline or a
+// timeout already.
+func (c *Client) adaptTimeout(ctx context.Context, model string, promptTokens int, options Options, opts []RequestOption) []RequestOption {
+	a := c.adaptiveTimeout
+	if a == nil {
+		return opts
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return opts
+	}
+	if newRequestConfig(opts).timeout > 0 {
+		return opts
+	}
+
+	outputTokens := a.opts.OutputTokens
+	if n, ok := optionInt(options, "num_predict"); ok && n > 0 {
+		outputTokens = n
+	}
+	timeout := c.SuggestedTimeout(ctx, model, promptTokens, outputTokens)
+	return append(opts[:len(opts):len(opts)], WithRequestTimeout(timeout))
+}
+
+// observeGenerate records the speeds shown by a generate response.
+func (c *Client) observeGenerate(model string, resp *GenerateResponse) {
+	if c.adaptiveTimeout != nil {
+		c.adaptiveTimeout.observe(model, resp.LoadDuration, resp.PromptEvalCount, resp.PromptEvalDuration, resp.EvalCount, resp.EvalDuration)
+	}
+}
+
+// observeChat records the speeds shown by a chat response.
+func (c *Client) observeChat(model string, resp *ChatResponse) {
+	if c.adaptiveTimeout != nil {
+		c.adaptiveTimeout.observe(model, resp.LoadDuration, resp.PromptEvalCount, resp.PromptEvalDuration, resp.EvalCount, resp.EvalDuration)
+	}
+}
+
+// parseParameterSize parses a parameter size as reported by the server,
+// such as "7B", "8.0B" or "500M", into billions of parameters. It returns
+// zero if size is not in that form.
+func parseParameterSize(size string) float64 {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if size == "" {
+		return 0
+	}
+	scale := map[byte]float64{'K': 1e-6, 'M': 1e-3, 'B': 1, 'T': 1e3}[size[len(size)-1]]
+	if scale == 0 {
+		return 0
+	}
+	n, err := strconv.ParseFloat(size[:len(size)-1], 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n * scale
+}
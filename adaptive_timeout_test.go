@@ -0,0 +1,151 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseParameterSize(t *testing.T) {
+	tests := map[string]float64{"7B": 7, "8.0b": 8, "500M": 0.5, " 1.5T ": 1500, "": 0, "big": 0, "7": 0, "-1B": 0}
+	for input, expected := range tests {
+		if got := parseParameterSize(input); got != expected {
+			t.Errorf("parseParameterSize(%q): expected %v, got %v", input, expected, got)
+		}
+	}
+}
+
+// newSpeedServer starts a server whose models report the given parameter
+// sizes, and whose generate responses take delay and report a load of 2s,
+// 1000 prompt tokens in 1s and 100 tokens in 1s.
+func newSpeedServer(t *testing.T, sizes map[string]string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			var req ShowRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(ModelResponse{Name: req.Model, Details: ModelDetails{ParameterSize: sizes[req.Model]}})
+		case "/api/generate":
+			time.Sleep(delay)
+			json.NewEncoder(w).Encode(GenerateResponse{
+				Response:           "ok",
+				Done:               true,
+				LoadDuration:       int64(2 * time.Second),
+				PromptEvalCount:    1000,
+				PromptEvalDuration: int64(time.Second),
+				EvalCount:          100,
+				EvalDuration:       int64(time.Second),
+			})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSuggestedTimeout(t *testing.T) {
+	server := newSpeedServer(t, map[string]string{"big": "70B", "small": "1B"}, 0)
+	client, err := NewClientWithOptions(server.URL, WithAdaptiveTimeout(&AdaptiveTimeoutOptions{
+		Min:    time.Millisecond,
+		Max:    time.Hour,
+		Margin: 1,
+	}))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	// 1B: 0.5s load, 2000 prompt and 200 output tokens per second
+	if got := client.SuggestedTimeout(ctx, "small", 2000, 200); got != 2500*time.Millisecond {
+		t.Errorf("Expected 2.5s for the small model, got %v", got)
+	}
+	if big, small := client.SuggestedTimeout(ctx, "big", 100, 100), client.SuggestedTimeout(ctx, "small", 100, 100); big <= small {
+		t.Errorf("Expected more time for the big model, got %v and %v", big, small)
+	}
+
+	// Measured speeds replace the assumed ones
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "big", Prompt: "hi"})
+	assertNoError(t, err)
+	if got := client.SuggestedTimeout(ctx, "big", 1000, 100); got != 4*time.Second {
+		t.Errorf("Expected 4s from measured speeds, got %v", got)
+	}
+
+	plain, err := NewClient(server.URL)
+	assertNoError(t, err)
+	if got := plain.SuggestedTimeout(ctx, "big", 1000, 100); got != 0 {
+		t.Errorf("Expected no suggestion without WithAdaptiveTimeout, got %v", got)
+	}
+}
+
+func TestAdaptiveTimeoutApplied(t *testing.T) {
+	server := newSpeedServer(t, map[string]string{"llama2": "7B"}, 200*time.Millisecond)
+	client, err := NewClientWithOptions(server.URL, WithAdaptiveTimeout(&AdaptiveTimeoutOptions{
+		Min: 50 * time.Millisecond,
+		Max: 50 * time.Millisecond,
+	}))
+	assertNoError(t, err)
+	request := &GenerateRequest{Model: "llama2", Prompt: "hi"}
+
+	_, err = client.Generate(context.Background(), request)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the suggested timeout to apply, got %v", err)
+	}
+	err = client.GenerateStream(context.Background(), request, func(*GenerateResponse) {})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the suggested timeout to apply to streams, got %v", err)
+	}
+
+	// Timeouts set by the caller take precedence
+	_, err = client.Generate(context.Background(), request, WithRequestTimeout(5*time.Second))
+	assertNoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.Generate(ctx, request)
+	assertNoError(t, err)
+}
+
+func TestAdaptiveTimeoutLookup(t *testing.T) {
+	var shows int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&shows, 1)
+		var req ShowRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Model {
+		case "big":
+			json.NewEncoder(w).Encode(ModelResponse{Details: ModelDetails{ParameterSize: "70B"}})
+		case "slow":
+			<-r.Context().Done()
+		default:
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bus := NewEventBus()
+	var hits []string
+	bus.Subscribe(func(e Event) { hits = append(hits, e.Key) }, EventCacheHit)
+	client, err := NewClientWithOptions(server.URL, WithAdaptiveTimeout(nil), WithEventBus(bus), WithTimeout(50*time.Millisecond))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		client.SuggestedTimeout(ctx, "big", 100, 100)
+		client.SuggestedTimeout(ctx, "missing", 100, 100)
+	}
+	if n := atomic.LoadInt32(&shows); n != 2 {
+		t.Errorf("Expected each model to be looked up once, got %d lookups", n)
+	}
+	if len(hits) != 1 || hits[0] != "big:latest" {
+		t.Errorf("Expected a cache hit only for the known size, got %v", hits)
+	}
+
+	// The lookup is bounded by the client's timeout
+	start := time.Now()
+	client.SuggestedTimeout(ctx, "slow", 100, 100)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the lookup to time out, took %v", elapsed)
+	}
+}
@@ -0,0 +1,159 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+)
+
+// aliasBackupTag is appended to the tag of an alias for the backup kept
+// while it is repointed.
+const aliasBackupTag = "-alias-backup"
+
+// Alias describes a stable model name and the models it currently points
+// at.
+type Alias struct {
+	// Name is the alias, such as "prod-chat:latest".
+	Name string
+	// Digest is the digest of the model the alias points at.
+	Digest string
+	// Targets lists the other models with the same digest, in List order.
+	// It is usually the single version the alias was pointed at, but
+	// includes every other copy of that version.
+	Targets []string
+}
+
+// AliasManager maintains stable model names, such as "prod-chat", that
+// point at specific model versions, for blue/green rollouts: applications
+// use the alias while operators repoint it from one version to the next.
+//
+// Aliases are plain copies made with Copy, so they work with every client
+// and server and need no storage of their own; an alias resolves to the
+// models that share its digest.
+type AliasManager struct {
+	client *Client
+}
+
+// NewAliasManager returns an alias manager using client.
+func NewAliasManager(client *Client) *AliasManager {
+	return &AliasManager{client: client}
+}
+
+// Set points alias at target, creating the alias if needed, and returns the
+// alias as it was before, or nil if it did not exist.
+//
+// Repointing is as close to atomic as the API allows: the previous version
+// is kept in a backup copy while target is copied over the alias, and
+// restored if the alias does not end up with target's digest. Callers that
+// use the alias meanwhile see either the old or the new version.
+func (m *AliasManager) Set(ctx context.Context, alias, target string) (*Alias, error) {
+	if err := ModelName(alias).Validate(); err != nil {
+		return nil, err
+	}
+	if target == "" {
+		return nil, fmt.Errorf("target model name cannot be empty")
+	}
+	if ModelName(alias).Normalize() == ModelName(target).Normalize() {
+		return nil, fmt.Errorf("alias %q cannot point at itself", alias)
+	}
+
+	models, err := m.client.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set alias %q: %w", alias, err)
+	}
+	want := listedModel(models, target)
+	if want == nil {
+		return nil, fmt.Errorf("failed to set alias %q: target %w", alias, ErrModelNotFound)
+	}
+	previous := aliasOf(models, alias)
+	if previous != nil && previous.Digest == want.Digest {
+		return previous, nil
+	}
+
+	overwrite := &CopyOptions{Overwrite: true}
+	name := ModelName(alias).Normalize()
+	backup := string(name.WithTag(name.Tag() + aliasBackupTag))
+	if previous != nil {
+		if err := m.client.CopyWithOptions(ctx, alias, backup, overwrite); err != nil {
+			return nil, fmt.Errorf("failed to back up alias %q: %w", alias, err)
+		}
+	}
+
+	err = m.client.CopyWithOptions(ctx, target, alias, overwrite)
+	if err == nil {
+		err = m.verify(ctx, alias, want.Digest)
+	}
+	if err != nil {
+		if previous == nil {
+			return nil, fmt.Errorf("failed to set alias %q: %w", alias, err)
+		}
+		if restoreErr := m.client.restoreFromBackup(ctx, backup, alias); restoreErr != nil {
+			return nil, fmt.Errorf("failed to set alias %q: %w (restore from %q also failed: %v)", alias, err, backup, restoreErr)
+		}
+		return nil, fmt.Errorf("failed to set alias %q: %w", alias, err)
+	}
+
+	if previous != nil {
+		if err := m.client.Delete(ctx, backup); err != nil {
+			return previous, fmt.Errorf("alias %q set but backup %q could not be removed: %w", alias, backup, err)
+		}
+	}
+	return previous, nil
+}
+
+// verify checks that alias has the given digest.
+func (m *AliasManager) verify(ctx context.Context, alias, digest string) error {
+	model, err := m.client.findModel(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if model == nil {
+		return fmt.Errorf("%w: alias %q not found after copy", ErrDigestMismatch, alias)
+	}
+	if trimDigest(model.Digest) != trimDigest(digest) {
+		return fmt.Errorf("%w: alias %q has digest %s, expected %s", ErrDigestMismatch, alias, model.Digest, digest)
+	}
+	return nil
+}
+
+// Resolve looks up the models alias points at. It returns an error wrapping
+// ErrModelNotFound if the alias does not exist.
+func (m *AliasManager) Resolve(ctx context.Context, alias string) (*Alias, error) {
+	models, err := m.client.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve alias %q: %w", alias, err)
+	}
+	resolved := aliasOf(models, alias)
+	if resolved == nil {
+		return nil, fmt.Errorf("failed to resolve alias %q: %w", alias, ErrModelNotFound)
+	}
+	return resolved, nil
+}
+
+// Remove deletes alias. The models it points at are left in place.
+func (m *AliasManager) Remove(ctx context.Context, alias string) error {
+	if err := m.client.Delete(ctx, alias); err != nil {
+		return fmt.Errorf("failed to remove alias %q: %w", alias, err)
+	}
+	return nil
+}
+
+// aliasOf describes alias from a List response, or returns nil if it is not
+// listed. Backups of aliases are not reported as targets.
+func aliasOf(models *ListModelsResponse, alias string) *Alias {
+	model := listedModel(models, alias)
+	if model == nil {
+		return nil
+	}
+
+	name := ModelName(model.Name).Normalize()
+	backup := name.WithTag(name.Tag() + aliasBackupTag)
+	resolved := &Alias{Name: model.Name, Digest: model.Digest}
+	for _, other := range models.Models {
+		n := ModelName(other.Name).Normalize()
+		if n == name || n == backup || trimDigest(other.Digest) != trimDigest(model.Digest) {
+			continue
+		}
+		resolved.Targets = append(resolved.Targets, other.Name)
+	}
+	return resolved
+}
@@ -0,0 +1,119 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestAliasManager(t *testing.T) {
+	var mu sync.Mutex
+	digests := map[string]string{
+		"llama3:8b-v1": "sha256:aaaa",
+		"llama3:8b-v2": "sha256:bbbb",
+		"broken:v3":    "sha256:cccc",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			Model       string `json:"model"`
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		normalize := func(name string) string { return string(ModelName(name).Normalize()) }
+
+		switch r.URL.Path {
+		case "/api/tags":
+			var names []string
+			for name := range digests {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var list ListModelsResponse
+			for _, name := range names {
+				list.Models = append(list.Models, ModelResponse{Name: name, Digest: digests[name]})
+			}
+			json.NewEncoder(w).Encode(list)
+		case "/api/copy":
+			digest := digests[normalize(body.Source)]
+			if body.Source == "broken:v3" {
+				// Simulate a copy that ends up with the wrong content
+				digest = "sha256:dead"
+			}
+			digests[normalize(body.Destination)] = digest
+		case "/api/delete":
+			delete(digests, normalize(body.Model))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	aliases := NewAliasManager(client)
+
+	previous, err := aliases.Set(ctx, "prod-chat", "llama3:8b-v1")
+	assertNoError(t, err)
+	if previous != nil {
+		t.Errorf("Expected no previous alias, got %+v", previous)
+	}
+
+	alias, err := aliases.Resolve(ctx, "prod-chat")
+	assertNoError(t, err)
+	if len(alias.Targets) != 1 || alias.Targets[0] != "llama3:8b-v1" {
+		t.Errorf("Expected alias to point at llama3:8b-v1, got %+v", alias)
+	}
+
+	// Repointing returns the previous version and removes the backup
+	previous, err = aliases.Set(ctx, "prod-chat", "llama3:8b-v2")
+	assertNoError(t, err)
+	if previous == nil || previous.Targets[0] != "llama3:8b-v1" {
+		t.Errorf("Expected previous target llama3:8b-v1, got %+v", previous)
+	}
+	alias, err = aliases.Resolve(ctx, "prod-chat")
+	assertNoError(t, err)
+	if alias.Digest != "sha256:bbbb" {
+		t.Errorf("Expected alias to point at llama3:8b-v2, got %+v", alias)
+	}
+	if _, ok := digests["prod-chat:latest-alias-backup"]; ok {
+		t.Errorf("Expected backup to be removed")
+	}
+
+	// A copy that fails verification restores the previous version
+	_, err = aliases.Set(ctx, "prod-chat", "broken:v3")
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+	if digests["prod-chat:latest"] != "sha256:bbbb" {
+		t.Errorf("Expected alias to be restored, got %q", digests["prod-chat:latest"])
+	}
+	if _, ok := digests["prod-chat:latest-alias-backup"]; ok {
+		t.Errorf("Expected backup to be removed after restore")
+	}
+
+	_, err = aliases.Set(ctx, "prod-chat", "mistral")
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound for a missing target, got %v", err)
+	}
+
+	assertNoError(t, aliases.Remove(ctx, "prod-chat"))
+	_, err = aliases.Resolve(ctx, "prod-chat")
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound after removal, got %v", err)
+	}
+	if _, ok := digests["llama3:8b-v2"]; !ok {
+		t.Errorf("Expected target to be kept after removal")
+	}
+}
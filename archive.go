@@ -0,0 +1,625 @@
+package gollama
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvModels is the models directory of the Ollama server, as set for the
+// server itself. ExportModel reads models from it, and it defaults to
+// ".ollama/models" in the home directory.
+const EnvModels = "OLLAMA_MODELS"
+
+// defaultRegistryHost is the registry host of model names without one, as
+// it appears in the models directory.
+const defaultRegistryHost = "registry.ollama.ai"
+
+// archiveCopyBuffer is the size of the buffer used to copy blobs, which is
+// also the granularity of progress reports.
+const archiveCopyBuffer = 1 << 20
+
+// ArchiveProgress reports the progress of a model export or import.
+type ArchiveProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ExportOptions holds optional settings for ExportModelWithOptions.
+type ExportOptions struct {
+	// ModelsDir is the models directory of the server. It defaults to
+	// $OLLAMA_MODELS or ".ollama/models" in the home directory, which
+	// requires the client to run on the server's machine.
+	ModelsDir string
+	// FromRegistry downloads the manifest and blobs from the registry the
+	// model is published in instead of reading them from ModelsDir. The
+	// model need not be present on the server.
+	FromRegistry bool
+	// Insecure allows plain HTTP for registries named in the model, as for
+	// PullOptions.
+	Insecure bool
+}
+
+// ExportModel writes a model to w as a tar archive for offline transfer,
+// for example to machines without network access. The archive holds the
+// model's manifest and blobs laid out as in the server's models directory,
+// so it can be unpacked into the models directory of another server or
+// loaded with ImportModel.
+//
+// The Ollama API cannot download blobs, so the model is read from the
+// server's models directory; see ExportModelWithOptions for other
+// locations. fn, which may be nil, receives progress updates.
+//
+// Returns ErrModelNotFound if the server does not have the model, and
+// ErrDigestMismatch if a blob does not match its digest.
+func (c *Client) ExportModel(ctx context.Context, modelName string, w io.Writer, fn func(ArchiveProgress)) error {
+	return c.ExportModelWithOptions(ctx, modelName, w, nil, fn)
+}
+
+// ExportModelWithOptions behaves like ExportModel, applying the given
+// ExportOptions.
+func (c *Client) ExportModelWithOptions(ctx context.Context, modelName string, w io.Writer, opts *ExportOptions, fn func(ArchiveProgress)) error {
+	if modelName == "" {
+		return fmt.Errorf("model name cannot be empty")
+	}
+	if err := ModelName(modelName).Validate(); err != nil {
+		return err
+	}
+	if fn == nil {
+		fn = func(ArchiveProgress) {}
+	}
+
+	source, err := c.exportSource(opts)
+	if err != nil {
+		return fmt.Errorf("failed to export model %q: %w", modelName, err)
+	}
+
+	fn(ArchiveProgress{Status: "reading manifest"})
+	manifest, data, err := readManifest(ctx, source, modelName)
+	if err != nil {
+		return fmt.Errorf("failed to export model %q: %w", modelName, err)
+	}
+
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	err = tw.WriteHeader(&tar.Header{
+		Name:    manifestPath(ModelName(modelName)),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: now,
+	})
+	if err == nil {
+		_, err = tw.Write(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export model %q: %w", modelName, err)
+	}
+
+	buf := make([]byte, archiveCopyBuffer)
+	seen := make(map[string]bool)
+	for _, layer := range append([]RegistryLayer{manifest.Config}, manifest.Layers...) {
+		if layer.Digest == "" || seen[layer.Digest] {
+			continue
+		}
+		seen[layer.Digest] = true
+
+		if err := exportBlob(ctx, tw, source, modelName, layer, now, buf, fn); err != nil {
+			return fmt.Errorf("failed to export model %q: %w", modelName, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to export model %q: %w", modelName, err)
+	}
+	fn(ArchiveProgress{Status: "success"})
+	return nil
+}
+
+// exportSource returns the source of exported models selected by opts,
+// which may be nil.
+func (c *Client) exportSource(opts *ExportOptions) (blobSource, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	if opts.FromRegistry {
+		return &registryBlobSource{registry: c.Registry(), insecure: opts.Insecure}, nil
+	}
+
+	dir := opts.ModelsDir
+	if dir == "" {
+		var err error
+		if dir, err = defaultModelsDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &dirBlobSource{client: c, dir: dir}, nil
+}
+
+// readManifest reads the manifest of a model from source, returning it
+// both parsed and raw.
+func readManifest(ctx context.Context, source blobSource, modelName string) (*registryManifest, []byte, error) {
+	data, err := source.manifest(ctx, modelName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest registryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	return &manifest, data, nil
+}
+
+// exportBlob copies a blob into the archive, checking its digest.
+func exportBlob(ctx context.Context, tw *tar.Writer, source blobSource, modelName string, layer RegistryLayer, modTime time.Time, buf []byte, fn func(ArchiveProgress)) error {
+	r, err := source.blob(ctx, modelName, layer.Digest)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    blobPath(layer.Digest),
+		Mode:    0o644,
+		Size:    layer.Size,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	status := "exporting " + shortBlobDigest(layer.Digest)
+	progress := &progressReader{
+		r:    r,
+		hash: sha256.New(),
+		report: func(completed int64) {
+			fn(ArchiveProgress{Status: status, Digest: layer.Digest, Total: layer.Size, Completed: completed})
+		},
+	}
+	fn(ArchiveProgress{Status: status, Digest: layer.Digest, Total: layer.Size})
+	n, err := io.CopyBuffer(tw, io.LimitReader(progress, layer.Size), buf)
+	if err != nil {
+		return fmt.Errorf("failed to copy blob %s: %w", layer.Digest, err)
+	}
+	if n != layer.Size {
+		return fmt.Errorf("%w: blob %s has %d bytes, expected %d", ErrDigestMismatch, layer.Digest, n, layer.Size)
+	}
+	if got := "sha256:" + hex.EncodeToString(progress.hash.Sum(nil)); got != layer.Digest {
+		return fmt.Errorf("%w: blob %s has digest %s", ErrDigestMismatch, layer.Digest, got)
+	}
+	return nil
+}
+
+// progressReader hashes what it reads and reports the number of bytes read
+// so far after each read.
+type progressReader struct {
+	r      io.Reader
+	hash   hash.Hash
+	n      int64
+	report func(completed int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.hash.Write(b[:n])
+		p.n += int64(n)
+		p.report(p.n)
+	}
+	return n, err
+}
+
+// blobSource provides the manifest and blobs of models to export.
+type blobSource interface {
+	// manifest returns the raw manifest of a model.
+	manifest(ctx context.Context, modelName string) ([]byte, error)
+	// blob opens a blob of a model.
+	blob(ctx context.Context, modelName, digest string) (io.ReadCloser, error)
+}
+
+// dirBlobSource reads models from the models directory of a server running
+// on the same machine.
+type dirBlobSource struct {
+	client *Client
+	dir    string
+}
+
+func (s *dirBlobSource) manifest(ctx context.Context, modelName string) ([]byte, error) {
+	model, err := s.client.findModel(ctx, modelName)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, ErrModelNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(manifestPath(ModelName(modelName)))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	// The server reports the digest of the manifest, which tells whether
+	// the directory is the server's
+	sum := sha256.Sum256(data)
+	if digest := hex.EncodeToString(sum[:]); digest != trimDigest(model.Digest) {
+		return nil, fmt.Errorf("%w: manifest in %s has digest %s, but the server reports %s", ErrDigestMismatch, s.dir, digest, trimDigest(model.Digest))
+	}
+	return data, nil
+}
+
+func (s *dirBlobSource) blob(ctx context.Context, modelName, digest string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(blobPath(digest))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return f, nil
+}
+
+// registryBlobSource downloads models from their registry.
+type registryBlobSource struct {
+	registry *RegistryClient
+	insecure bool
+}
+
+func (s *registryBlobSource) manifest(ctx context.Context, modelName string) ([]byte, error) {
+	return s.registry.get(ctx, s.registry.manifestURL(modelName, s.insecure), "application/vnd.docker.distribution.manifest.v2+json")
+}
+
+func (s *registryBlobSource) blob(ctx context.Context, modelName, digest string) (io.ReadCloser, error) {
+	u := s.registry.repositoryURL(ModelName(modelName), s.insecure) + "/blobs/" + digest
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.registry.userAgent)
+
+	// Blobs take far longer to download than the registry client's
+	// timeout allows
+	client := &http.Client{Transport: s.registry.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute registry request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, err := readErrorBody(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("registry request failed with status %d and could not read response body: %w", resp.StatusCode, err)
+		}
+		return nil, parseErrorResponse(resp.StatusCode, body)
+	}
+	return resp.Body, nil
+}
+
+// defaultModelsDir returns the models directory of a server on this
+// machine.
+func defaultModelsDir() (string, error) {
+	if dir := os.Getenv(EnvModels); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ollama", "models"), nil
+}
+
+// manifestPath returns the path of a model's manifest in a models
+// directory, with forward slashes.
+func manifestPath(name ModelName) string {
+	host := name.Registry()
+	if host == "" {
+		host = defaultRegistryHost
+	}
+	return path.Join("manifests", host, name.Namespace(), name.Model(), name.Tag())
+}
+
+// blobPath returns the path of a blob in a models directory, with forward
+// slashes.
+func blobPath(digest string) string {
+	return path.Join("blobs", strings.Replace(digest, ":", "-", 1))
+}
+
+// shortBlobDigest abbreviates a digest for status messages.
+func shortBlobDigest(digest string) string {
+	digest = trimDigest(digest)
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// importInlineSize is the size up to which blobs are kept in memory during
+// an import, so that text layers can be written into the Modelfile.
+const importInlineSize = 1 << 20
+
+// ImportOptions holds optional settings for ImportModelWithOptions.
+type ImportOptions struct {
+	// Name is the name of the imported model. It defaults to the name the
+	// model was exported under, and can only be set for archives holding a
+	// single model.
+	Name string
+}
+
+// ImportModel loads the models of an archive written by ExportModel, or of
+// a tar archive of a models directory, into the server. It uploads the
+// blobs the server does not have yet and recreates each model from its
+// manifest. fn, which may be nil, receives progress updates.
+//
+// The API cannot write manifests, so models are recreated with Create from
+// a Modelfile that refers to the uploaded blobs. The imported model has the
+// same weights, adapters, template, system prompt, parameters and license,
+// but its manifest, and so its digest, may differ from the original.
+func (c *Client) ImportModel(ctx context.Context, r io.Reader, fn func(ArchiveProgress)) error {
+	return c.ImportModelWithOptions(ctx, r, nil, fn)
+}
+
+// ImportModelWithOptions behaves like ImportModel, applying the given
+// ImportOptions.
+func (c *Client) ImportModelWithOptions(ctx context.Context, r io.Reader, opts *ImportOptions, fn func(ArchiveProgress)) error {
+	if r == nil {
+		return fmt.Errorf("archive reader cannot be nil")
+	}
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	if opts.Name != "" {
+		if err := ModelName(opts.Name).Validate(); err != nil {
+			return err
+		}
+	}
+	if fn == nil {
+		fn = func(ArchiveProgress) {}
+	}
+
+	// Blobs are uploaded as they are read, so that the archive is streamed
+	// whatever the order of its entries
+	var names []string
+	manifests := make(map[string][]byte)
+	inline := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import model: failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		switch {
+		case strings.HasPrefix(name, "manifests/"):
+			modelName, ok := manifestModelName(name)
+			if !ok {
+				return fmt.Errorf("failed to import model: invalid manifest path %q", header.Name)
+			}
+			data, err := io.ReadAll(io.LimitReader(tr, importInlineSize))
+			if err != nil {
+				return fmt.Errorf("failed to import model: failed to read archive: %w", err)
+			}
+			names = append(names, modelName)
+			manifests[modelName] = data
+		case strings.HasPrefix(name, "blobs/"):
+			digest := strings.Replace(path.Base(name), "-", ":", 1)
+			if err := c.importBlob(ctx, tr, digest, header.Size, inline, fn); err != nil {
+				return fmt.Errorf("failed to import model: %w", err)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("failed to import model: archive holds no manifest")
+	}
+	if opts.Name != "" && len(names) > 1 {
+		return fmt.Errorf("failed to import model: a name can only be given for an archive holding a single model, not %d", len(names))
+	}
+
+	for _, name := range names {
+		modelfile, err := importModelfile(manifests[name], inline)
+		if err != nil {
+			return fmt.Errorf("failed to import model %q: %w", name, err)
+		}
+		if opts.Name != "" {
+			name = opts.Name
+		}
+
+		fn(ArchiveProgress{Status: "creating " + name})
+		err = c.Create(ctx, name, modelfile, func(p CreateProgress) {
+			fn(ArchiveProgress{Status: p.Status})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import model %q: %w", name, err)
+		}
+	}
+	fn(ArchiveProgress{Status: "success"})
+	return nil
+}
+
+// importBlob uploads a blob unless the server already has it. Small blobs
+// are also kept in inline.
+func (c *Client) importBlob(ctx context.Context, r io.Reader, digest string, size int64, inline map[string][]byte, fn func(ArchiveProgress)) error {
+	if size <= importInlineSize {
+		data, err := io.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		inline[digest] = data
+		r = bytes.NewReader(data)
+	}
+
+	exists, err := c.hasBlob(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	status := "uploading " + shortBlobDigest(digest)
+	progress := &progressReader{
+		r:    io.LimitReader(r, size),
+		hash: sha256.New(),
+		report: func(completed int64) {
+			fn(ArchiveProgress{Status: status, Digest: digest, Total: size, Completed: completed})
+		},
+	}
+	fn(ArchiveProgress{Status: status, Digest: digest, Total: size})
+
+	// Uploads are not subject to the client's total timeout, since large
+	// blobs take a long time to send. The buffer makes progress reports as
+	// coarse as for exports
+	body := &rawBody{r: bufio.NewReaderSize(progress, archiveCopyBuffer), size: size}
+	resp, err := c.Do(ctx, http.MethodPost, "/api/blobs/"+digest, body)
+	if got := "sha256:" + hex.EncodeToString(progress.hash.Sum(nil)); progress.n == size && got != digest {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return fmt.Errorf("%w: blob %s has digest %s", ErrDigestMismatch, digest, got)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", digest, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// hasBlob reports whether the server has a blob.
+func (c *Client) hasBlob(ctx context.Context, digest string) (bool, error) {
+	err := c.do(ctx, http.MethodHead, "/api/blobs/"+digest, nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	var ollamaErr *OllamaError
+	if errors.As(err, &ollamaErr) && ollamaErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check blob %s: %w", digest, err)
+}
+
+// importModelfile builds a Modelfile that recreates the model described by
+// a manifest from its uploaded blobs. The contents of text layers are taken
+// from inline.
+func importModelfile(data []byte, inline map[string][]byte) (string, error) {
+	var manifest registryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	var from, rest strings.Builder
+	for _, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case MediaTypeModel, MediaTypeProjector:
+			fmt.Fprintf(&from, "FROM @%s\n", layer.Digest)
+			continue
+		case MediaTypeAdapter:
+			fmt.Fprintf(&rest, "ADAPTER @%s\n", layer.Digest)
+			continue
+		}
+
+		text, ok := inline[layer.Digest]
+		if !ok {
+			return "", fmt.Errorf("layer %s is missing from the archive", layer.Digest)
+		}
+		switch layer.MediaType {
+		case MediaTypeTemplate:
+			if err := writeModelfileText(&rest, "TEMPLATE", string(text)); err != nil {
+				return "", err
+			}
+		case MediaTypeSystem:
+			if err := writeModelfileText(&rest, "SYSTEM", string(text)); err != nil {
+				return "", err
+			}
+		case MediaTypeLicense:
+			if err := writeModelfileText(&rest, "LICENSE", string(text)); err != nil {
+				return "", err
+			}
+		case MediaTypeParams:
+			var params map[string]interface{}
+			if err := json.Unmarshal(text, &params); err != nil {
+				return "", fmt.Errorf("failed to unmarshal parameters: %w", err)
+			}
+			keys := make([]string, 0, len(params))
+			for key := range params {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				values, ok := params[key].([]interface{})
+				if !ok {
+					values = []interface{}{params[key]}
+				}
+				for _, value := range values {
+					if s, ok := value.(string); ok {
+						value = strconv.Quote(s)
+					}
+					fmt.Fprintf(&rest, "PARAMETER %s %v\n", key, value)
+				}
+			}
+		case MediaTypeMessages:
+			var messages []Message
+			if err := json.Unmarshal(text, &messages); err != nil {
+				return "", fmt.Errorf("failed to unmarshal messages: %w", err)
+			}
+			for _, message := range messages {
+				if err := writeModelfileText(&rest, "MESSAGE "+message.Role, message.Content); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	if from.Len() == 0 {
+		return "", fmt.Errorf("manifest has no model layer")
+	}
+	return from.String() + rest.String(), nil
+}
+
+// writeModelfileText writes a Modelfile instruction with a triple-quoted
+// argument.
+func writeModelfileText(b *strings.Builder, instruction, text string) error {
+	if strings.Contains(text, `"""`) {
+		return fmt.Errorf("%s cannot be written to a Modelfile because it contains \"\"\"", strings.ToLower(strings.Fields(instruction)[0]))
+	}
+	fmt.Fprintf(b, "%s \"\"\"%s\"\"\"\n", instruction, text)
+	return nil
+}
+
+// manifestModelName returns the name of the model whose manifest is at the
+// given path of a models directory.
+func manifestModelName(p string) (string, bool) {
+	parts := strings.Split(p, "/")
+	if len(parts) != 5 || parts[0] != "manifests" {
+		return "", false
+	}
+	host, namespace, model, tag := parts[1], parts[2], parts[3], parts[4]
+
+	name := model + ":" + tag
+	if namespace != "library" || host != defaultRegistryHost {
+		name = namespace + "/" + name
+	}
+	if host != defaultRegistryHost {
+		name = host + "/" + name
+	}
+	return name, ModelName(name).Validate() == nil
+}
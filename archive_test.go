@@ -0,0 +1,259 @@
+package gollama
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testModel is a model stored in a temporary models directory.
+type testModel struct {
+	dir      string
+	manifest []byte
+	blobs    map[string][]byte
+}
+
+func newTestModel(t *testing.T) *testModel {
+	t.Helper()
+	m := &testModel{dir: t.TempDir(), blobs: make(map[string][]byte)}
+
+	digest := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		d := "sha256:" + hex.EncodeToString(sum[:])
+		m.blobs[d] = data
+		return d
+	}
+	config := []byte(`{"model_format":"gguf"}`)
+	weights := bytes.Repeat([]byte("weights"), 1<<18)
+	template := []byte(`{{ .Prompt }}`)
+	params := []byte(`{"stop":["<|end|>","User:"],"temperature":0.7}`)
+	m.manifest = []byte(fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":%q,"size":%d},"layers":[`+
+		`{"mediaType":%q,"digest":%q,"size":%d},{"mediaType":%q,"digest":%q,"size":%d},{"mediaType":%q,"digest":%q,"size":%d}]}`,
+		digest(config), len(config),
+		MediaTypeModel, digest(weights), len(weights),
+		MediaTypeTemplate, digest(template), len(template),
+		MediaTypeParams, digest(params), len(params)))
+
+	write := func(name string, data []byte) {
+		path := filepath.Join(m.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("manifests/registry.ollama.ai/library/tiny/latest", m.manifest)
+	for d, data := range m.blobs {
+		write(blobPath(d), data)
+	}
+	return m
+}
+
+func (m *testModel) digest() string {
+	sum := sha256.Sum256(m.manifest)
+	return hex.EncodeToString(sum[:])
+}
+
+// readArchive returns the files of a tar archive by name.
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		assertNoError(t, err)
+		files[header.Name], err = io.ReadAll(tr)
+		assertNoError(t, err)
+	}
+}
+
+func TestClientExportModel(t *testing.T) {
+	model := newTestModel(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"models":[{"name":"tiny:latest","digest":%q}]}`, model.digest())
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	t.Setenv(EnvModels, model.dir)
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	var events []ArchiveProgress
+	err = client.ExportModel(ctx, "tiny", &buf, func(p ArchiveProgress) {
+		events = append(events, p)
+	})
+	assertNoError(t, err)
+
+	files := readArchive(t, buf.Bytes())
+	if !bytes.Equal(files["manifests/registry.ollama.ai/library/tiny/latest"], model.manifest) {
+		t.Errorf("Expected the manifest in the archive, got files %v", len(files))
+	}
+	for digest, data := range model.blobs {
+		if !bytes.Equal(files[blobPath(digest)], data) {
+			t.Errorf("Expected blob %s in the archive", digest)
+		}
+	}
+	if len(events) < 4 || events[len(events)-1].Status != "success" {
+		t.Errorf("Expected progress ending in success, got %+v", events)
+	}
+
+	if err := client.ExportModel(ctx, "mistral", io.Discard, nil); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound, got %v", err)
+	}
+
+	// Corrupt a blob
+	for digest := range model.blobs {
+		os.WriteFile(filepath.Join(model.dir, filepath.FromSlash(blobPath(digest))), []byte(`{"model_format":"ggml"}`), 0o644)
+	}
+	if err := client.ExportModel(ctx, "tiny", io.Discard, nil); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestClientExportModelFromRegistry(t *testing.T) {
+	model := newTestModel(t)
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/library/tiny/manifests/latest" {
+			w.Write(model.manifest)
+			return
+		}
+		for digest, data := range model.blobs {
+			if r.URL.Path == "/v2/library/tiny/blobs/"+digest {
+				w.Write(data)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer registry.Close()
+
+	client, err := createTestClient("http://127.0.0.1:1")
+	assertNoError(t, err)
+	client.registryURL = registry.URL
+
+	var buf bytes.Buffer
+	err = client.ExportModelWithOptions(context.Background(), "tiny", &buf, &ExportOptions{FromRegistry: true}, nil)
+	assertNoError(t, err)
+
+	if files := readArchive(t, buf.Bytes()); len(files) != 5 {
+		t.Errorf("Expected a manifest and 4 blobs, got %d files", len(files))
+	}
+}
+
+func TestClientImportModel(t *testing.T) {
+	model := newTestModel(t)
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"models":[{"name":"tiny:latest","digest":%q}]}`, model.digest())
+	}))
+	defer source.Close()
+
+	client, err := createTestClient(source.URL)
+	assertNoError(t, err)
+
+	var archive bytes.Buffer
+	err = client.ExportModelWithOptions(context.Background(), "tiny", &archive, &ExportOptions{ModelsDir: model.dir}, nil)
+	assertNoError(t, err)
+
+	var weights string
+	for digest, data := range model.blobs {
+		if len(data) > 1000 {
+			weights = digest
+		}
+	}
+
+	uploaded := make(map[string][]byte)
+	var created CreateRequest
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/create":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		case r.Method == http.MethodHead:
+			// The target already has the weights
+			if strings.TrimPrefix(r.URL.Path, "/api/blobs/") != weights {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPost:
+			uploaded[strings.TrimPrefix(r.URL.Path, "/api/blobs/")], _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer target.Close()
+
+	client, err = createTestClient(target.URL)
+	assertNoError(t, err)
+
+	var statuses []string
+	err = client.ImportModel(context.Background(), bytes.NewReader(archive.Bytes()), func(p ArchiveProgress) {
+		statuses = append(statuses, p.Status)
+	})
+	assertNoError(t, err)
+
+	if _, ok := uploaded[weights]; ok || len(uploaded) != 3 {
+		t.Errorf("Expected the 3 missing blobs to be uploaded, got %d", len(uploaded))
+	}
+	for digest, data := range uploaded {
+		if !bytes.Equal(data, model.blobs[digest]) {
+			t.Errorf("Expected blob %s to be uploaded intact", digest)
+		}
+	}
+
+	expected := "FROM @" + weights + "\n" +
+		"TEMPLATE \"\"\"{{ .Prompt }}\"\"\"\n" +
+		"PARAMETER stop \"<|end|>\"\n" +
+		"PARAMETER stop \"User:\"\n" +
+		"PARAMETER temperature 0.7\n"
+	if created.Model != "tiny:latest" || created.Modelfile != expected {
+		t.Errorf("Expected tiny:latest created from\n%s\ngot %q from\n%s", expected, created.Model, created.Modelfile)
+	}
+	if statuses[len(statuses)-1] != "success" {
+		t.Errorf("Expected progress ending in success, got %v", statuses)
+	}
+
+	err = client.ImportModelWithOptions(context.Background(), bytes.NewReader(archive.Bytes()), &ImportOptions{Name: "tiny:offline"}, nil)
+	assertNoError(t, err)
+	if created.Model != "tiny:offline" {
+		t.Errorf("Expected the model to be renamed, got %q", created.Model)
+	}
+
+	err = client.ImportModel(context.Background(), bytes.NewReader(nil), nil)
+	assertErrorContains(t, err, "archive holds no manifest")
+}
+
+func TestManifestModelName(t *testing.T) {
+	tests := []struct {
+		path string
+		name string
+	}{
+		{"manifests/registry.ollama.ai/library/llama3/8b", "llama3:8b"},
+		{"manifests/registry.ollama.ai/user/model/latest", "user/model:latest"},
+		{"manifests/registry.local:5000/team/model/q4_0", "registry.local:5000/team/model:q4_0"},
+	}
+
+	for _, tt := range tests {
+		name, ok := manifestModelName(tt.path)
+		if !ok || name != tt.name {
+			t.Errorf("manifestModelName(%q) = %q, %v, expected %q", tt.path, name, ok, tt.name)
+		}
+		if got := manifestPath(ModelName(name)); got != tt.path {
+			t.Errorf("manifestPath(%q) = %q, expected %q", name, got, tt.path)
+		}
+	}
+}
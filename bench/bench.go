@@ -0,0 +1,263 @@
+// Package bench measures the throughput and latency of a model under load.
+//
+// Run sends a number of streaming generation requests with a fixed
+// concurrency and reports time to first token (TTFT), decoding speed and
+// latency percentiles:
+//
+//	result, err := bench.Run(ctx, client, bench.Config{
+//		Model:       "llama3",
+//		Requests:    50,
+//		Concurrency: 4,
+//		PromptWords: 500,
+//		MaxTokens:   128,
+//	})
+//	fmt.Println(result)
+//
+// The gollama-bench command runs a matrix of concurrencies and prompt sizes
+// from the command line.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// Config describes a benchmark run.
+type Config struct {
+	Model string
+	// Requests is the total number of requests to send. Defaults to 10.
+	Requests int
+	// Concurrency is the number of requests in flight at once. Defaults to 1.
+	Concurrency int
+	// Prompt is sent with every request. If it is empty, a prompt of
+	// PromptWords words of filler text is generated.
+	Prompt string
+	// PromptWords is the size of the generated prompt. Defaults to 100.
+	PromptWords int
+	// MaxTokens limits the number of generated tokens through num_predict.
+	// Zero leaves the limit to the model.
+	MaxTokens int
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.Requests <= 0 {
+		c.Requests = 10
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 1
+	}
+	if c.PromptWords <= 0 {
+		c.PromptWords = 100
+	}
+	if c.Prompt == "" {
+		c.Prompt = FillerPrompt(c.PromptWords)
+	}
+	return c
+}
+
+// Percentiles summarizes a distribution of durations.
+type Percentiles struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// String formats the percentiles as "p50/p95/p99".
+func (p Percentiles) String() string {
+	return fmt.Sprintf("%v/%v/%v", round(p.P50), round(p.P95), round(p.P99))
+}
+
+// Result is the outcome of a benchmark run.
+type Result struct {
+	Config   Config
+	Requests int
+	Errors   int
+	// FirstError is the error of the first failed request, if any.
+	FirstError error
+	Duration   time.Duration
+	// TTFT is the time from sending a request to receiving its first token.
+	TTFT Percentiles
+	// Latency is the time from sending a request to receiving its last token.
+	Latency Percentiles
+	// TokensPerSecond is the mean decoding speed of a single request.
+	TokensPerSecond float64
+	// Throughput is the number of tokens generated per second across all
+	// concurrent requests.
+	Throughput float64
+}
+
+// String formats the result as a single line.
+func (r *Result) String() string {
+	return fmt.Sprintf("concurrency=%d prompt=%dw requests=%d errors=%d ttft=%v latency=%v tok/s=%.1f throughput=%.1f tok/s",
+		r.Config.Concurrency, r.Config.PromptWords, r.Requests, r.Errors, r.TTFT, r.Latency, r.TokensPerSecond, r.Throughput)
+}
+
+// sample is the measurement of a single request.
+type sample struct {
+	ttft    time.Duration
+	latency time.Duration
+	tokens  int
+	decode  time.Duration
+	err     error
+}
+
+// Run executes a benchmark run. Failed requests are counted in the result;
+// an error is only returned if the context is done before any request
+// completes.
+func Run(ctx context.Context, client *gollama.Client, cfg Config) (*Result, error) {
+	cfg = cfg.withDefaults()
+
+	jobs := make(chan struct{})
+	samples := make(chan sample, cfg.Requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				samples <- measure(ctx, client, cfg)
+			}
+		}()
+	}
+
+	start := time.Now()
+send:
+	for i := 0; i < cfg.Requests; i++ {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(samples)
+
+	result := &Result{Config: cfg, Duration: time.Since(start)}
+	var ttfts, latencies []time.Duration
+	var tokens int
+	var rates float64
+	for s := range samples {
+		result.Requests++
+		if s.err != nil {
+			result.Errors++
+			if result.FirstError == nil {
+				result.FirstError = s.err
+			}
+			continue
+		}
+		ttfts = append(ttfts, s.ttft)
+		latencies = append(latencies, s.latency)
+		tokens += s.tokens
+		if s.decode > 0 {
+			rates += float64(s.tokens) / s.decode.Seconds()
+		}
+	}
+
+	if result.Requests == 0 {
+		return nil, ctx.Err()
+	}
+	if ok := len(latencies); ok > 0 {
+		result.TTFT = percentiles(ttfts)
+		result.Latency = percentiles(latencies)
+		result.TokensPerSecond = rates / float64(ok)
+		result.Throughput = float64(tokens) / result.Duration.Seconds()
+	}
+	return result, nil
+}
+
+// measure sends a single streaming request and times it.
+func measure(ctx context.Context, client *gollama.Client, cfg Config) sample {
+	req := &gollama.GenerateRequest{Model: cfg.Model, Prompt: cfg.Prompt}
+	if cfg.MaxTokens > 0 {
+		req.Options = gollama.Options{"num_predict": cfg.MaxTokens}
+	}
+
+	var s sample
+	var chunks int
+	start := time.Now()
+	s.err = client.GenerateStream(ctx, req, func(resp *gollama.GenerateResponse) {
+		if resp.Response != "" {
+			if chunks == 0 {
+				s.ttft = time.Since(start)
+			}
+			chunks++
+		}
+		if resp.Done {
+			s.latency = time.Since(start)
+			s.tokens = chunks
+			s.decode = s.latency - s.ttft
+			// Prefer the server's own count and timing when available
+			if resp.EvalCount > 0 {
+				s.tokens = resp.EvalCount
+			}
+			if resp.EvalDuration > 0 {
+				s.decode = time.Duration(resp.EvalDuration)
+			}
+		}
+	})
+	if s.err == nil && s.latency == 0 {
+		s.latency = time.Since(start)
+	}
+	return s
+}
+
+// percentiles computes the percentiles of durations, which it sorts in place.
+func percentiles(durations []time.Duration) Percentiles {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return Percentiles{
+		P50: percentile(durations, 50),
+		P95: percentile(durations, 95),
+		P99: percentile(durations, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// round shortens a duration for display.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	default:
+		return d
+	}
+}
+
+// fillerWords are repeated to build prompts of a given size.
+var fillerWords = strings.Fields(`the quick brown fox jumps over the lazy dog while
+a curious cat watches from the old stone wall near the river bank`)
+
+// FillerPrompt returns a summarization prompt over words words of filler
+// text, for benchmarks where the content of the prompt does not matter.
+func FillerPrompt(words int) string {
+	var b strings.Builder
+	b.WriteString("Summarize the following text in one sentence:\n\n")
+	for i := 0; i < words; i++ {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(fillerWords[i%len(fillerWords)])
+	}
+	return b.String()
+}
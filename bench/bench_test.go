@@ -0,0 +1,104 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+func TestRun(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		var req gollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Options["num_predict"] == nil {
+			http.Error(w, `{"error":"missing num_predict"}`, http.StatusBadRequest)
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		enc := json.NewEncoder(w)
+		for _, word := range []string{"a", " b", " c"} {
+			enc.Encode(gollama.GenerateResponse{Response: word})
+			w.(http.Flusher).Flush()
+		}
+		enc.Encode(gollama.GenerateResponse{Done: true, EvalCount: 3, EvalDuration: int64(30 * time.Millisecond)})
+	}))
+	defer server.Close()
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	result, err := Run(context.Background(), client, Config{Model: "llama3", Requests: 8, Concurrency: 4, MaxTokens: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Requests != 8 || result.Errors != 0 {
+		t.Fatalf("Expected 8 successful requests, got %d with %d errors (%v)", result.Requests, result.Errors, result.FirstError)
+	}
+	if maxInFlight > 4 {
+		t.Errorf("Expected at most 4 requests in flight, got %d", maxInFlight)
+	}
+	if result.TTFT.P50 < 5*time.Millisecond || result.Latency.P99 < result.TTFT.P50 {
+		t.Errorf("Unexpected timings: ttft %v, latency %v", result.TTFT, result.Latency)
+	}
+	if result.TokensPerSecond < 99 || result.TokensPerSecond > 101 {
+		t.Errorf("Expected 100 tok/s from server timings, got %.1f", result.TokensPerSecond)
+	}
+	if result.Throughput <= 0 {
+		t.Errorf("Expected positive throughput, got %.1f", result.Throughput)
+	}
+	if !strings.Contains(result.String(), "concurrency=4") {
+		t.Errorf("Unexpected summary %q", result.String())
+	}
+
+	result, err = Run(context.Background(), client, Config{Model: "llama3", Requests: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Errors != 2 || result.FirstError == nil {
+		t.Errorf("Expected failed requests to be counted, got %+v", result)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	p := percentiles(durations)
+	if p.P50 != 50*time.Millisecond || p.P95 != 95*time.Millisecond || p.P99 != 99*time.Millisecond {
+		t.Errorf("Unexpected percentiles %+v", p)
+	}
+	if got := percentile([]time.Duration{time.Second}, 99); got != time.Second {
+		t.Errorf("Expected single sample, got %v", got)
+	}
+}
+
+func TestFillerPrompt(t *testing.T) {
+	prompt := FillerPrompt(50)
+	body := prompt[strings.Index(prompt, "\n\n")+2:]
+	if n := len(strings.Fields(body)); n != 50 {
+		t.Errorf("Expected 50 words, got %d", n)
+	}
+}
@@ -0,0 +1,63 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// embeddingServer serves a realistically sized embedding response.
+func embeddingServer() *httptest.Server {
+	embedding := make([]float64, 4096)
+	for i := range embedding {
+		embedding[i] = float64(i) / 4096
+	}
+	body, _ := json.Marshal(EmbeddingResponse{Embedding: embedding})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+}
+
+func BenchmarkEmbeddings(b *testing.B) {
+	server := embeddingServer()
+	defer server.Close()
+
+	client, _ := createTestClient(server.URL)
+	ctx := context.Background()
+	req := &EmbeddingRequest{Model: "nomic-embed-text", Prompt: "The quick brown fox jumps over the lazy dog"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Embeddings(ctx, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateStream(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoder := json.NewEncoder(w)
+		for i := 0; i < 100; i++ {
+			encoder.Encode(GenerateResponse{Model: "llama2", Response: "token "})
+		}
+		encoder.Encode(GenerateResponse{Model: "llama2", Done: true})
+	}))
+	defer server.Close()
+
+	client, _ := createTestClient(server.URL)
+	ctx := context.Background()
+	req := &GenerateRequest{Model: "llama2", Prompt: "Tell me a story"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := client.GenerateStream(ctx, req, func(*GenerateResponse) {})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package gollama
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// streamedFieldSize is the size from which a string field of a streamed
+// request body is written piecewise rather than marshaled with the rest of
+// the request.
+const streamedFieldSize = 64 << 10
+
+// streamedChunkSize is the size of the pieces in which a large string is
+// escaped and written.
+const streamedChunkSize = 32 << 10
+
+// WithStreamingRequestBodies makes the client encode requests whose prompt,
+// messages and images add up to at least minBytes straight into the
+// connection, instead of marshaling the whole request into memory before
+// sending it. This reduces the peak memory of requests with multi-megabyte
+// documents or many images, at the cost of sending them with chunked
+// transfer encoding. A value of zero or less disables it, which is the
+// default.
+func WithStreamingRequestBodies(minBytes int64) ClientOption {
+	return func(c *Client) {
+		c.streamingBodySize = minBytes
+	}
+}
+
+// rawBody is a request body that is sent as is instead of being encoded as
+// JSON, such as a blob upload. It is read while it is sent, so the request
+// cannot be retried.
+type rawBody struct {
+	r    io.Reader
+	size int64
+}
+
+// streamableRequest is implemented by requests that can hold large fields.
+type streamableRequest interface {
+	// bodySize estimates the size of the large fields of the request.
+	bodySize() int64
+	// hasImageSources reports whether the request holds images that must
+	// be read while it is sent.
+	hasImageSources() bool
+	// hasImageReaders reports whether the request holds image sources that
+	// can only be read once, so that it cannot be sent again.
+	hasImageReaders() bool
+	// withPlaceholders returns a copy of the request whose large fields
+	// have been registered with s and replaced by their placeholders.
+	withPlaceholders(s *bodyStreamer) interface{}
+}
+
+// newRequestBody returns the body of a request for v: raw for a *rawBody,
+// streamed if v holds image sources or is large enough for the client's
+// WithStreamingRequestBodies setting, and buffered otherwise. A streamed
+// body has a length of -1.
+func (c *Client) newRequestBody(v interface{}) (io.ReadCloser, int64, error) {
+	if raw, ok := v.(*rawBody); ok {
+		return io.NopCloser(raw.r), raw.size, nil
+	}
+	if r, ok := v.(streamableRequest); ok {
+		if r.hasImageSources() || (c.streamingBodySize > 0 && r.bodySize() >= c.streamingBodySize) {
+			return newStreamedBody(r)
+		}
+	}
+	return newJSONBody(v)
+}
+
+// newStreamedBody marshals r without its large fields, then returns a body
+// that writes them into the marshaled JSON as it is read.
+func newStreamedBody(r streamableRequest) (io.ReadCloser, int64, error) {
+	s, err := newBodyStreamer()
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := json.Marshal(r.withPlaceholders(s))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The transport closes the body when it is done with it, even if the
+	// request fails, which stops the writing goroutine
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.writeTo(pw, data))
+	}()
+	return pr, -1, nil
+}
+
+// bodyStreamer replaces the large fields of a request with placeholders
+// and writes them in place of the placeholders later. Placeholders contain
+// a random nonce, so they cannot collide with text in the request.
+type bodyStreamer struct {
+	nonce  string
+	fields []func(w io.Writer) error
+}
+
+func newBodyStreamer() (*bodyStreamer, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate placeholder: %w", err)
+	}
+	return &bodyStreamer{nonce: hex.EncodeToString(nonce)}, nil
+}
+
+// add registers a function that writes a JSON value and returns the
+// placeholder string to put in its place.
+func (s *bodyStreamer) add(write func(w io.Writer) error) string {
+	s.fields = append(s.fields, write)
+	return "\x00gollama-" + s.nonce + "-" + strconv.Itoa(len(s.fields)-1) + "\x00"
+}
+
+// str returns a placeholder for v if it is large enough to be worth
+// streaming, and v itself otherwise.
+func (s *bodyStreamer) str(v string) string {
+	if len(v) < streamedFieldSize {
+		return v
+	}
+	return s.add(func(w io.Writer) error {
+		return writeJSONString(w, v)
+	})
+}
+
+// strs applies str to each element of values.
+func (s *bodyStreamer) strs(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = s.str(v)
+	}
+	return out
+}
+
+// images returns placeholders for images followed by those for sources.
+func (s *bodyStreamer) images(images []string, sources []ImageSource) []string {
+	out := s.strs(images)
+	for _, source := range sources {
+		out = append(out, s.add(source.writeJSON))
+	}
+	return out
+}
+
+// writeTo writes data to w, writing the registered fields in place of their
+// placeholders.
+func (s *bodyStreamer) writeTo(w io.Writer, data []byte) error {
+	// Marshaling escapes the NUL bytes that delimit placeholders
+	prefix := []byte(`"\u0000gollama-` + s.nonce + `-`)
+	suffix := []byte(`\u0000"`)
+	for {
+		i := bytes.Index(data, prefix)
+		if i < 0 {
+			_, err := w.Write(data)
+			return err
+		}
+		if _, err := w.Write(data[:i]); err != nil {
+			return err
+		}
+		data = data[i+len(prefix):]
+
+		end := bytes.Index(data, suffix)
+		if end < 0 {
+			return fmt.Errorf("malformed body placeholder")
+		}
+		n, err := strconv.Atoi(string(data[:end]))
+		if err != nil || n < 0 || n >= len(s.fields) {
+			return fmt.Errorf("malformed body placeholder")
+		}
+		if err := s.fields[n](w); err != nil {
+			return err
+		}
+		data = data[end+len(suffix):]
+	}
+}
+
+// writeJSONString writes v to w as a JSON string, escaping it in pieces so
+// that no full escaped copy is held in memory.
+func writeJSONString(w io.Writer, v string) error {
+	if _, err := io.WriteString(w, `"`); err != nil {
+		return err
+	}
+	for len(v) > 0 {
+		n := len(v)
+		if n > streamedChunkSize {
+			// Split on a rune boundary so that each piece escapes as it
+			// would as part of the whole string
+			n = streamedChunkSize
+			for n > 0 && !utf8.RuneStart(v[n]) {
+				n--
+			}
+			if n == 0 {
+				n = streamedChunkSize
+			}
+		}
+		escaped, err := json.Marshal(v[:n])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(escaped[1 : len(escaped)-1]); err != nil {
+			return err
+		}
+		v = v[n:]
+	}
+	_, err := io.WriteString(w, `"`)
+	return err
+}
+
+func (r *GenerateRequest) bodySize() int64 {
+	size := len(r.Prompt) + len(r.Suffix)
+	for _, image := range r.Images {
+		size += len(image)
+	}
+	return int64(size)
+}
+
+func (r *GenerateRequest) withPlaceholders(s *bodyStreamer) interface{} {
+	out := *r
+	out.Prompt = s.str(r.Prompt)
+	out.Suffix = s.str(r.Suffix)
+	out.Images = s.images(r.Images, r.ImageSources)
+	return &out
+}
+
+func (r *GenerateRequest) hasImageSources() bool {
+	return len(r.ImageSources) > 0
+}
+
+func (r *GenerateRequest) hasImageReaders() bool {
+	return hasImageReaders(r.ImageSources)
+}
+
+func (r *ChatRequest) bodySize() int64 {
+	var size int
+	for _, message := range r.Messages {
+		size += len(message.Content) + len(message.Thinking)
+		for _, image := range message.Images {
+			size += len(image)
+		}
+	}
+	return int64(size)
+}
+
+func (r *ChatRequest) withPlaceholders(s *bodyStreamer) interface{} {
+	out := *r
+	if r.Messages == nil {
+		return &out
+	}
+	out.Messages = make([]Message, len(r.Messages))
+	for i, message := range r.Messages {
+		message.Content = s.str(message.Content)
+		message.Thinking = s.str(message.Thinking)
+		message.Images = s.images(message.Images, message.ImageSources)
+		out.Messages[i] = message
+	}
+	return &out
+}
+
+func (r *ChatRequest) hasImageSources() bool {
+	for _, message := range r.Messages {
+		if len(message.ImageSources) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EmbeddingRequest) bodySize() int64 {
+	return int64(len(r.Prompt))
+}
+
+func (r *EmbeddingRequest) withPlaceholders(s *bodyStreamer) interface{} {
+	out := *r
+	out.Prompt = s.str(r.Prompt)
+	return &out
+}
+
+func (r *ChatRequest) hasImageReaders() bool {
+	for _, message := range r.Messages {
+		if hasImageReaders(message.ImageSources) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EmbeddingRequest) hasImageSources() bool {
+	return false
+}
+
+func (r *EmbeddingRequest) hasImageReaders() bool {
+	return false
+}
+
+// hasImageReaders reports whether any of sources was created by
+// ImageReader.
+func hasImageReaders(sources []ImageSource) bool {
+	for _, source := range sources {
+		if source.reader != nil && source.path == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sendsOnce reports whether a request body can only be sent once, because
+// it is read while it is sent.
+func sendsOnce(v interface{}) bool {
+	if _, ok := v.(*rawBody); ok {
+		return true
+	}
+	r, ok := v.(streamableRequest)
+	return ok && r.hasImageReaders()
+}
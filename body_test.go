@@ -0,0 +1,134 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamedBodyMatchesMarshal(t *testing.T) {
+	// Multi-byte runes straddle the chunk boundaries, and HTML characters and
+	// control characters must be escaped as json.Marshal escapes them
+	large := strings.Repeat("héllo <wörld> & \"quotes\"\n\t€", 20000)
+
+	requests := []interface{}{
+		&GenerateRequest{Model: "llama3", Prompt: large, Suffix: "small", Images: []string{large[:streamedFieldSize], "aGk="}},
+		&ChatRequest{Model: "llama3", Messages: []Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: large, Images: []string{strings.Repeat("QUJD", 30000)}},
+		}, Options: Options{"temperature": 0.2}},
+		&ChatRequest{Model: "llama3"},
+		&EmbeddingRequest{Model: "nomic-embed-text", Prompt: large},
+	}
+
+	for _, req := range requests {
+		want, err := json.Marshal(req)
+		assertNoError(t, err)
+
+		body, length, err := newStreamedBody(req.(streamableRequest))
+		assertNoError(t, err)
+		got, err := io.ReadAll(body)
+		assertNoError(t, err)
+		body.Close()
+
+		if length != -1 {
+			t.Errorf("Expected unknown length, got %d", length)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Expected streamed body to match json.Marshal for %T (%d vs %d bytes)", req, len(got), len(want))
+		}
+	}
+}
+
+func TestClientStreamingRequestBodies(t *testing.T) {
+	var lengths []int64
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lengths = append(lengths, r.ContentLength)
+		prompts = append(prompts, req.Prompt)
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithStreamingRequestBodies(1<<20))
+	assertNoError(t, err)
+
+	large := strings.Repeat("a long document. ", 100000)
+	for _, prompt := range []string{"short", large} {
+		_, err = client.Generate(context.Background(), &GenerateRequest{Model: "llama3", Prompt: prompt})
+		assertNoError(t, err)
+	}
+
+	if lengths[0] <= 0 || lengths[1] != -1 {
+		t.Errorf("Expected only the large request to be streamed, got lengths %v", lengths)
+	}
+	if prompts[1] != large {
+		t.Errorf("Expected the server to receive the full prompt, got %d bytes", len(prompts[1]))
+	}
+}
+
+func TestImageSources(t *testing.T) {
+	var got [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, message := range req.Messages {
+			got = append(got, message.Images)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "a cat"}, Done: true})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cat.png")
+	if err := os.WriteFile(path, []byte("file image"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	_, err = client.Chat(context.Background(), &ChatRequest{
+		Model: "llava",
+		Messages: []Message{
+			{Role: "system", Content: "Describe images."},
+			{Role: "user", Content: "What is this?", Images: []string{"aW5saW5l"}, ImageSources: []ImageSource{
+				ImageFile(path),
+				ImageReader(strings.NewReader("reader image")),
+			}},
+		},
+	})
+	assertNoError(t, err)
+
+	want := []string{
+		"aW5saW5l",
+		base64.StdEncoding.EncodeToString([]byte("file image")),
+		base64.StdEncoding.EncodeToString([]byte("reader image")),
+	}
+	if len(got) != 2 || got[0] != nil || !reflect.DeepEqual(got[1], want) {
+		t.Errorf("Expected images %v, got %v", want, got)
+	}
+
+	_, err = client.Generate(context.Background(), &GenerateRequest{
+		Model:        "llava",
+		Prompt:       "What is this?",
+		ImageSources: []ImageSource{ImageFile(filepath.Join(t.TempDir(), "missing.png"))},
+	})
+	assertErrorContains(t, err, "failed to load image")
+}
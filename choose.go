@@ -0,0 +1,114 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoChoice is returned by Choose when the model does not answer with one
+// of the choices.
+var ErrNoChoice = errors.New("model did not pick one of the choices")
+
+// chooseAttempts is the number of times Choose asks before giving up.
+const chooseAttempts = 3
+
+// Choose asks the model a question and returns the index of the choice it
+// picks. The answer is constrained to the choices with a JSON schema, and
+// checked afterwards in case the server does not enforce the schema; an
+// invalid answer is pointed out to the model and the question is asked
+// again, up to three times in all. If no valid answer is given, Choose
+// returns ErrNoChoice. An empty model uses the client's default model.
+//
+// Example:
+//
+//	i, err := client.Choose(ctx, "llama3", "Which language is this? "+text, []string{"English", "French", "German"})
+func (c *Client) Choose(ctx context.Context, model, question string, choices []string, opts ...RequestOption) (int, error) {
+	if err := validateChoices(choices); err != nil {
+		return -1, err
+	}
+
+	var list strings.Builder
+	for _, choice := range choices {
+		fmt.Fprintf(&list, "- %s\n", choice)
+	}
+	req := &ChatRequest{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: "Answer the user's question with exactly one of the following options, written exactly as shown:\n" + list.String()},
+			{Role: "user", Content: question},
+		},
+		Format: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"choice": map[string]interface{}{"type": "string", "enum": choices},
+			},
+			"required": []string{"choice"},
+		},
+		Options: Options{"temperature": 0},
+	}
+
+	var answer string
+	for attempt := 0; attempt < chooseAttempts; attempt++ {
+		resp, err := c.Chat(ctx, req, opts...)
+		if err != nil {
+			return -1, fmt.Errorf("failed to choose: %w", err)
+		}
+		answer = resp.Message.Content
+		if i := matchChoice(answer, choices); i >= 0 {
+			return i, nil
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", Content: answer},
+			Message{Role: "user", Content: "That is not one of the options. Answer with exactly one of:\n" + list.String()},
+		)
+	}
+	return -1, fmt.Errorf("%w: last answer was %q", ErrNoChoice, answer)
+}
+
+// validateChoices checks that there is at least one choice and that the
+// choices can be told apart.
+func validateChoices(choices []string) error {
+	if len(choices) == 0 {
+		return fmt.Errorf("at least one choice is required")
+	}
+	seen := make(map[string]bool, len(choices))
+	for _, choice := range choices {
+		key := strings.ToLower(strings.TrimSpace(choice))
+		if key == "" {
+			return fmt.Errorf("choices cannot be empty")
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate choice %q", choice)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// matchChoice returns the index of the choice an answer names, or -1. The
+// answer may be the JSON object requested by Choose or plain text, and is
+// matched ignoring case, surrounding quotes and a trailing period.
+func matchChoice(answer string, choices []string) int {
+	var structured struct {
+		Choice string `json:"choice"`
+	}
+	if json.Unmarshal([]byte(answer), &structured) == nil && structured.Choice != "" {
+		answer = structured.Choice
+	}
+	answer = strings.TrimSuffix(strings.Trim(strings.TrimSpace(answer), `"'`), ".")
+
+	for i, choice := range choices {
+		if answer == choice {
+			return i
+		}
+	}
+	for i, choice := range choices {
+		if strings.EqualFold(answer, strings.TrimSpace(choice)) {
+			return i
+		}
+	}
+	return -1
+}
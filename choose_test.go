@@ -0,0 +1,89 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newAnswerServer starts a chat server that replies with the given answers
+// in turn, repeating the last one, and records the requests it receives.
+func newAnswerServer(t *testing.T, answers []string, requests *[]ChatRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		*requests = append(*requests, req)
+
+		answer := answers[len(answers)-1]
+		if n := len(*requests); n <= len(answers) {
+			answer = answers[n-1]
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: answer}, Done: true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientChoose(t *testing.T) {
+	choices := []string{"English", "French", "German"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		answers  []string
+		want     int
+		requests int
+	}{
+		{"Structured answer", []string{`{"choice":"French"}`}, 1, 1},
+		{"Plain answer", []string{" german.\n"}, 2, 1},
+		{"Retry after invalid answer", []string{`{"choice":"Spanish"}`, `{"choice":"English"}`}, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []ChatRequest
+			client, err := createTestClient(newAnswerServer(t, tt.answers, &requests).URL)
+			assertNoError(t, err)
+
+			got, err := client.Choose(ctx, "llama3", "Bonjour?", choices)
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("Expected choice %d, got %d", tt.want, got)
+			}
+			if len(requests) != tt.requests {
+				t.Errorf("Expected %d requests, got %d", tt.requests, len(requests))
+			}
+			if requests[0].Format == nil {
+				t.Error("Expected the answer to be constrained with a format")
+			}
+		})
+	}
+
+	t.Run("No valid answer", func(t *testing.T) {
+		var requests []ChatRequest
+		client, err := createTestClient(newAnswerServer(t, []string{"Klingon"}, &requests).URL)
+		assertNoError(t, err)
+
+		_, err = client.Choose(ctx, "llama3", "Bonjour?", choices)
+		if !errors.Is(err, ErrNoChoice) {
+			t.Errorf("Expected ErrNoChoice, got %v", err)
+		}
+		if len(requests) != chooseAttempts {
+			t.Errorf("Expected %d attempts, got %d", chooseAttempts, len(requests))
+		}
+		if n := len(requests[1].Messages); n != 4 {
+			t.Errorf("Expected the invalid answer to be pointed out, got %d messages", n)
+		}
+	})
+
+	client, err := NewClient()
+	assertNoError(t, err)
+	_, err = client.Choose(ctx, "llama3", "?", nil)
+	assertErrorContains(t, err, "at least one choice is required")
+	_, err = client.Choose(ctx, "llama3", "?", []string{"yes", "Yes"})
+	assertErrorContains(t, err, "duplicate choice")
+}
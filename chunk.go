@@ -0,0 +1,88 @@
+package gollama
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// chunkSeparators are the boundaries ChunkText prefers to break text at, in
+// order of preference.
+var chunkSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// ChunkText splits text into chunks of at most maxTokens tokens, as
+// estimated by EstimateTokens, for processing text that does not fit in a
+// model's context window. Chunks break at paragraph boundaries where
+// possible, then at line, sentence and word boundaries. Consecutive chunks
+// repeat up to overlap tokens of text, so that content cut at a boundary
+// is seen whole in one of them. A maxTokens of zero or less returns the
+// whole text as one chunk.
+func ChunkText(text string, maxTokens, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxTokens <= 0 {
+		return []string{text}
+	}
+
+	maxChars := maxTokens * 4
+	overlapChars := overlap * 4
+	var chunks, current []string
+	var length int
+	for _, segment := range splitSegments(text, maxChars, chunkSeparators) {
+		n := utf8.RuneCountInString(segment)
+		if length+n > maxChars && len(current) > 0 {
+			chunks = appendChunk(chunks, current)
+
+			// Carry the end of the chunk over into the next
+			var kept []string
+			var keptLength int
+			for i := len(current) - 1; i >= 0; i-- {
+				l := utf8.RuneCountInString(current[i])
+				if keptLength+l > overlapChars || keptLength+l+n > maxChars {
+					break
+				}
+				kept = append([]string{current[i]}, kept...)
+				keptLength += l
+			}
+			current, length = kept, keptLength
+		}
+		current = append(current, segment)
+		length += n
+	}
+	return appendChunk(chunks, current)
+}
+
+// appendChunk joins segments into a chunk and appends it, unless it is
+// blank.
+func appendChunk(chunks, segments []string) []string {
+	if chunk := strings.TrimSpace(strings.Join(segments, "")); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// splitSegments splits text after each separator, trying the separators in
+// order until every segment has at most maxChars characters. Text without
+// any of the separators is cut at maxChars characters.
+func splitSegments(text string, maxChars int, separators []string) []string {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+	if len(separators) == 0 {
+		var segments []string
+		runes := []rune(text)
+		for i := 0; i < len(runes); i += maxChars {
+			segments = append(segments, string(runes[i:min(i+maxChars, len(runes))]))
+		}
+		return segments
+	}
+
+	var segments []string
+	for _, part := range strings.SplitAfter(text, separators[0]) {
+		if part != "" {
+			segments = append(segments, splitSegments(part, maxChars, separators[1:])...)
+		}
+	}
+	return segments
+}
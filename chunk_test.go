@@ -0,0 +1,60 @@
+package gollama
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxTokens int
+		overlap   int
+		want      []string
+	}{
+		{"Empty", "  \n", 10, 0, nil},
+		{"Fits", "Short text.", 10, 0, []string{"Short text."}},
+		{"No limit", "Some longer text here.", 0, 0, []string{"Some longer text here."}},
+		{
+			name:      "Paragraphs",
+			text:      "First paragraph.\n\nSecond paragraph.\n\nThird one.",
+			maxTokens: 5,
+			want:      []string{"First paragraph.", "Second paragraph.", "Third one."},
+		},
+		{
+			name:      "Sentences",
+			text:      "One two three. Four five six. Seven eight.",
+			maxTokens: 4,
+			want:      []string{"One two three.", "Four five six.", "Seven eight."},
+		},
+		{
+			name:      "Overlap",
+			text:      "aaa bbb ccc ddd eee fff",
+			maxTokens: 3,
+			overlap:   1,
+			want:      []string{"aaa bbb ccc", "ccc ddd eee", "eee fff"},
+		},
+		{
+			name:      "Unbroken text",
+			text:      strings.Repeat("x", 10),
+			maxTokens: 1,
+			want:      []string{"xxxx", "xxxx", "xx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChunkText(tt.text, tt.maxTokens, tt.overlap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			for _, chunk := range got {
+				if tt.maxTokens > 0 && EstimateTokens(chunk) > tt.maxTokens {
+					t.Errorf("Chunk %q exceeds %d tokens", chunk, tt.maxTokens)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Label is a class that Classify can assign.
+type Label struct {
+	Name string
+	// Description tells the model when the label applies.
+	Description string
+}
+
+// Classification is the result of Classify.
+type Classification struct {
+	// Label is the name of the assigned label, and Index its position in
+	// the labels passed to Classify.
+	Label string
+	Index int
+	// Confidence is the model's own estimate, from 0 to 1. It is not
+	// calibrated, but is useful for routing uncertain cases to review.
+	Confidence float64
+	// Rationale is the model's short explanation of its choice.
+	Rationale string
+}
+
+// Classify assigns one of the labels to text. The model is shown each
+// label's description and asked for a JSON object holding the label, its
+// confidence and a rationale; answers naming an unknown label are pointed
+// out and asked again like in Choose. If no valid answer is given, Classify
+// returns ErrNoChoice. An empty model uses the client's default model.
+//
+// Example:
+//
+//	result, err := client.Classify(ctx, "llama3", ticket, []gollama.Label{
+//		{Name: "billing", Description: "Invoices, payments and refunds"},
+//		{Name: "bug", Description: "Something in the product does not work"},
+//		{Name: "other", Description: "Anything else"},
+//	})
+func (c *Client) Classify(ctx context.Context, model, text string, labels []Label, opts ...RequestOption) (*Classification, error) {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = label.Name
+	}
+	if err := validateChoices(names); err != nil {
+		return nil, err
+	}
+
+	var list strings.Builder
+	for _, label := range labels {
+		if label.Description == "" {
+			fmt.Fprintf(&list, "- %s\n", label.Name)
+			continue
+		}
+		fmt.Fprintf(&list, "- %s: %s\n", label.Name, label.Description)
+	}
+	system := "Classify the text given by the user with exactly one of the following labels:\n" + list.String() +
+		"\nRespond with a JSON object with the label name as \"label\", your confidence from 0 to 1 as \"confidence\"" +
+		" and a one-sentence explanation as \"rationale\"."
+
+	req := &ChatRequest{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: text},
+		},
+		Format: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"label":      map[string]interface{}{"type": "string", "enum": names},
+				"confidence": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+				"rationale":  map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"label", "confidence", "rationale"},
+		},
+		Options: Options{"temperature": 0},
+	}
+
+	var answer string
+	for attempt := 0; attempt < chooseAttempts; attempt++ {
+		resp, err := c.Chat(ctx, req, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify: %w", err)
+		}
+		answer = resp.Message.Content
+		if result := parseClassification(answer, names); result != nil {
+			return result, nil
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", Content: answer},
+			Message{Role: "user", Content: "That is not a valid answer. The label must be exactly one of: " + strings.Join(names, ", ")},
+		)
+	}
+	return nil, fmt.Errorf("%w: last answer was %q", ErrNoChoice, answer)
+}
+
+// parseClassification decodes an answer to Classify, or returns nil if it
+// does not name one of the labels.
+func parseClassification(answer string, names []string) *Classification {
+	var raw struct {
+		Label      string  `json:"label"`
+		Confidence float64 `json:"confidence"`
+		Rationale  string  `json:"rationale"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(answer)), &raw); err != nil {
+		return nil
+	}
+	i := matchChoice(raw.Label, names)
+	if i < 0 {
+		return nil
+	}
+
+	confidence := raw.Confidence
+	if confidence < 0 {
+		confidence = 0
+	} else if confidence > 1 {
+		confidence = 1
+	}
+	return &Classification{
+		Label:      names[i],
+		Index:      i,
+		Confidence: confidence,
+		Rationale:  strings.TrimSpace(raw.Rationale),
+	}
+}
@@ -0,0 +1,51 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClientClassify(t *testing.T) {
+	labels := []Label{
+		{Name: "billing", Description: "Invoices and refunds"},
+		{Name: "bug", Description: "Something does not work"},
+		{Name: "other"},
+	}
+	ctx := context.Background()
+
+	var requests []ChatRequest
+	client, err := createTestClient(newAnswerServer(t, []string{
+		`{"label":"refund","confidence":0.9,"rationale":"Asks for money back."}`,
+		`{"label":"Billing","confidence":1.4,"rationale":" Asks for money back. "}`,
+	}, &requests).URL)
+	assertNoError(t, err)
+
+	result, err := client.Classify(ctx, "llama3", "I want my money back", labels)
+	assertNoError(t, err)
+	if result.Label != "billing" || result.Index != 0 {
+		t.Errorf("Expected billing, got %+v", result)
+	}
+	if result.Confidence != 1 || result.Rationale != "Asks for money back." {
+		t.Errorf("Expected clamped confidence and trimmed rationale, got %+v", result)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected a retry after the unknown label, got %d requests", len(requests))
+	}
+	system := requests[0].Messages[0].Content
+	if !strings.Contains(system, "- bug: Something does not work") || !strings.Contains(system, "- other\n") {
+		t.Errorf("Expected label descriptions in the prompt, got %q", system)
+	}
+
+	requests = nil
+	client, err = createTestClient(newAnswerServer(t, []string{"billing"}, &requests).URL)
+	assertNoError(t, err)
+	_, err = client.Classify(ctx, "llama3", "I want my money back", labels)
+	if !errors.Is(err, ErrNoChoice) {
+		t.Errorf("Expected ErrNoChoice for answers that are not JSON, got %v", err)
+	}
+
+	_, err = client.Classify(ctx, "llama3", "text", []Label{{Name: "a"}, {Name: "A"}})
+	assertErrorContains(t, err, "duplicate choice")
+}
@@ -7,9 +7,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -23,33 +26,153 @@ type Client struct {
 	httpClient *http.Client
 	// baseURL is the base URL of the Ollama server
 	baseURL string
+	// registryURL is the base URL of the default model registry, used for
+	// models whose names do not include a registry host
+	registryURL string
+	// userAgent is sent as the User-Agent header of every request
+	userAgent string
+	// header holds extra headers sent with every request
+	header http.Header
+	// defaultModel is used by requests that do not name a model
+	defaultModel string
+	// defaultOptions are merged under the options of every request
+	defaultOptions Options
+	// postProcessors rewrite the final text of generations, in order
+	postProcessors []PostProcessor
+	// adaptiveTimeout, if set, suggests timeouts for generate and chat
+	// calls
+	adaptiveTimeout *adaptiveTimeout
+	// outputLimit, if set, caps the length of generated text
+	outputLimit *OutputLimit
+	// moderators are applied to generated text, in order
+	moderators []Moderator
+	// completionHooks are called when long-running operations finish
+	completionHooks []func(CompletionEvent)
+	// webhooks, if set, tracks webhook deliveries in progress
+	webhooks *webhookGroup
+	// onDecodeError, if set, decides what happens to undecodable lines of
+	// streaming responses
+	onDecodeError func(*DecodeError) error
+	// retryPolicies holds the retry policy of each operation
+	retryPolicies map[Operation]RetryPolicy
+	// autoContext, if set, fills in num_ctx from model metadata
+	autoContext *autoContext
+	// maxResponseBytes limits the size of a response body, or of a single
+	// streamed object, when greater than zero
+	maxResponseBytes int64
+	// timeout limits the total duration of non-streaming calls
+	timeout time.Duration
+	// streamIdleTimeout limits the time between reads of a streaming response
+	streamIdleTimeout time.Duration
+	// dialTimeout, tlsHandshakeTimeout and responseHeaderTimeout configure
+	// the transport built by NewClientWithOptions
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	// tlsConfig, if set, replaces the transport's default TLS configuration
+	tlsConfig *tls.Config
+	// streamingBodySize, if greater than zero, is the size from which
+	// request bodies are streamed rather than buffered
+	streamingBodySize int64
+	// dialContext, if set, replaces the transport's default dialer
+	dialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// events, if set, receives the client's events
+	events *EventBus
 }
 
+// Default timeouts of a new client. Streaming calls are not subject to
+// defaultTimeout, since generations and pulls can legitimately run for much
+// longer.
+const (
+	defaultTimeout             = 30 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
 // NewClient creates a new Ollama API client.
 //
 // It accepts optional host URL as a parameter. If no host is provided or an empty string
-// is given, it defaults to "http://localhost:11434".
+// is given, it defaults to the OLLAMA_HOST environment variable, and then to
+// "http://localhost:11434". See NewClientFromEnv for the other variables.
 //
 // Examples:
-//   client, err := gollama.NewClient()                           // Uses default localhost:11434
+//   client, err := gollama.NewClient()                           // Uses $OLLAMA_HOST or localhost:11434
 //   client, err := gollama.NewClient("http://192.168.1.100:11434") // Custom host
 //
 // It returns a pointer to a `Client` and an error if the client cannot be initialized.
 func NewClient(host ...string) (*Client, error) {
+	baseURL := ""
+	if len(host) > 0 {
+		baseURL = host[0]
+	}
+	if baseURL == "" {
+		baseURL = envHost()
+	}
+	return NewClientWithOptions(baseURL)
+}
+
+// NewClientWithOptions creates a new Ollama API client for the given host,
+// configured by the given options. An empty host defaults to
+// "http://localhost:11434".
+//
+// Example:
+//
+//	client, err := gollama.NewClientWithOptions("http://gpu-box:11434",
+//		gollama.WithMaxResponseBytes(16<<20),
+//	)
+func NewClientWithOptions(host string, opts ...ClientOption) (*Client, error) {
 	baseURL := "http://localhost:11434"
 
-	if len(host) > 0 && host[0] != "" {
-		baseURL = host[0]
+	if host != "" {
+		baseURL = host
 	}
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+	c := &Client{
+		baseURL:             baseURL,
+		registryURL:         defaultRegistryURL,
+		userAgent:           defaultUserAgent,
+		timeout:             defaultTimeout,
+		dialTimeout:         defaultDialTimeout,
+		tlsHandshakeTimeout: defaultTLSHandshakeTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
-	}, nil
+	// The HTTP client has no overall timeout of its own: each phase of a
+	// request is limited separately, and the total timeout is applied per
+	// call so that streaming calls can be exempt from it
+	c.httpClient = &http.Client{
+		Transport: c.newTransport(),
+	}
+	return c, nil
+}
+
+// newTransport builds the HTTP transport from the client's dial, TLS and
+// response header timeouts.
+func (c *Client) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   c.dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	if dial := c.dialContext; dial != nil {
+		timeout := c.dialTimeout
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return dial(ctx, network, addr)
+		}
+	}
+	transport.TLSHandshakeTimeout = c.tlsHandshakeTimeout
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig
+	}
+	transport.ResponseHeaderTimeout = c.responseHeaderTimeout
+	return transport
 }
 
 // BaseURL returns the base URL of the Ollama server that the client is configured to use.
@@ -66,60 +189,300 @@ func (c *Client) BaseURL() string {
 //   - path: API endpoint path (e.g., "/api/tags")
 //   - reqBody: Request body to be JSON-serialized (can be nil)
 //   - resBody: Response body to deserialize JSON into (can be nil)
+//   - opts: Request-scoped options such as timeouts
 //
 // Returns an error if the request fails or the response indicates an error.
-func (c *Client) do(ctx context.Context, method, path string, reqBody, resBody interface{}) error {
+func (c *Client) do(ctx context.Context, method, path string, reqBody, resBody interface{}, opts ...RequestOption) error {
+	if c.timeout > 0 {
+		opts = append([]RequestOption{WithRequestTimeout(c.timeout)}, opts...)
+	}
+	resp, err := c.Do(ctx, method, path, reqBody, opts...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body into a pooled buffer
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(c.limitReader(resp.Body)); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	respBody := buf.Bytes()
+
+	// Deserialize response body if a target is provided
+	if resBody != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, resBody); err != nil {
+			return fmt.Errorf("failed to unmarshal response body: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Do sends a request to an arbitrary API endpoint and returns the raw
+// response. It is an escape hatch for endpoints that gollama does not wrap
+// yet, and reuses the client's base URL, headers and error handling.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - method: HTTP method (GET, POST, DELETE, etc.)
+//   - path: API endpoint path (e.g., "/api/version")
+//   - reqBody: Request body to be JSON-serialized (can be nil)
+//   - opts: Request-scoped options such as timeouts
+//
+// Returns the response on a 2xx status, which the caller must close, or an
+// error if the request fails. Non-2xx responses are returned as an
+// *OllamaError with their body already consumed and closed.
+func (c *Client) Do(ctx context.Context, method, path string, reqBody interface{}, opts ...RequestOption) (*http.Response, error) {
+	resp, err := c.roundTrip(ctx, "", method, path, reqBody, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for non-2xx status codes
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, err := readErrorBody(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("request failed with status %d and could not read response body: %w", resp.StatusCode, err)
+		}
+		return nil, parseErrorResponse(resp.StatusCode, respBody)
+	}
+
+	return resp, nil
+}
+
+// DoStream sends a request to an arbitrary streaming API endpoint and calls
+// fn with each non-empty line of the newline-delimited response, leaving
+// decoding to the caller. Like Do, it is intended for endpoints that gollama
+// does not wrap yet.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - method: HTTP method (usually POST)
+//   - path: API endpoint path
+//   - reqBody: Request body to be JSON-serialized (can be nil)
+//   - fn: Callback invoked with each line, without its line ending; the bytes
+//     are only valid during the call, and returning an error aborts the
+//     stream with that error
+//   - opts: Request-scoped options such as timeouts
+//
+// Returns an error if the request fails, the response indicates an error,
+// the stream cannot be read, or the callback returns an error.
+func (c *Client) DoStream(ctx context.Context, method, path string, reqBody interface{}, fn func(line []byte) error, opts ...RequestOption) error {
+	resp, err := c.Do(ctx, method, path, reqBody, c.streamOptions(opts)...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.readLines(ctx, resp.Body, "response stream", func(line []byte) error {
+		c.emit(Event{Type: EventStreamChunk, Method: method, Path: path, Bytes: len(line)})
+		return fn(line)
+	})
+}
+
+// readLines calls fn with each non-empty line of body, without its line
+// ending, reusing the same buffer for every line. Lines are not subject to a
+// length limit other than the client's WithMaxResponseBytes, which applies
+// to each line separately. It returns nil at the end of body, and otherwise
+// the context's error if it is done, the error of fn, or a read error
+// wrapped as an error reading what.
+func (c *Client) readLines(ctx context.Context, body io.Reader, what string, fn func(line []byte) error) error {
+	reader := bufio.NewReader(body)
+	var line []byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line = line[:0]
+		var readErr error
+		for {
+			chunk, isPrefix, err := reader.ReadLine()
+			line = append(line, chunk...)
+			if err != nil {
+				readErr = err
+				break
+			}
+			if c.maxResponseBytes > 0 && int64(len(line)) > c.maxResponseBytes {
+				readErr = ErrResponseTooLarge
+				break
+			}
+			if !isPrefix {
+				break
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF {
+				return nil
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("error reading %s: %w", what, readErr)
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// roundTrip builds and executes a request without checking the response
+// status. Request options are applied for the lifetime of the response,
+// which ends when its body is closed. The name of the operation, if any, is
+// used in error messages.
+func (c *Client) roundTrip(ctx context.Context, name, method, path string, reqBody interface{}, opts []RequestOption) (*http.Response, error) {
+	opts = contextOptions(ctx, opts)
+	if key := newRequestConfig(opts).idempotencyKey; key != "" {
+		ctx = ContextWithHeader(ctx, IdempotencyKeyHeader, key)
+	}
+	ctx, scope := newRequestScope(ctx, opts)
+	start := time.Now()
+	c.emit(Event{Type: EventRequestStarted, Method: method, Path: path})
+
+	resp, err := c.send(ctx, method, path, reqBody)
+	if err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			scope.release()
+			c.emit(Event{Type: EventRequestFinished, Method: method, Path: path, Duration: time.Since(start), Err: reqErr.err})
+			return nil, reqErr.err
+		}
+		err = scope.err(err)
+		scope.release()
+		c.emit(Event{Type: EventRequestFinished, Method: method, Path: path, Duration: time.Since(start), Err: err})
+		if name != "" {
+			return nil, fmt.Errorf("failed to execute %s request: %w", name, err)
+		}
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	scope.headersReceived()
+	if scope != nil {
+		resp.Body = &scopedBody{ReadCloser: resp.Body, scope: scope}
+	}
+	if c.events != nil {
+		resp.Body = &eventBody{
+			ReadCloser: resp.Body,
+			client:     c,
+			event:      Event{Type: EventRequestFinished, Method: method, Path: path, Status: resp.StatusCode},
+			start:      start,
+		}
+	}
+	return resp, nil
+}
+
+// newRequest builds an HTTP request for an API endpoint, serializing reqBody
+// as JSON if it is not nil, into a pooled buffer or, for large requests,
+// straight into the connection.
+func (c *Client) newRequest(ctx context.Context, method, path string, reqBody interface{}) (*http.Request, error) {
 	// Construct the full URL
 	u, err := url.JoinPath(c.baseURL, path)
 	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
+		return nil, fmt.Errorf("failed to construct URL: %w", err)
 	}
 
-	var body io.Reader
+	var body io.ReadCloser
+	var contentLength int64
 	if reqBody != nil {
-		jsonData, err := json.Marshal(reqBody)
+		body, contentLength, err = c.newRequestBody(reqBody)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		body = bytes.NewReader(jsonData)
 	}
 
 	// Create the HTTP request
 	req, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		if body != nil {
+			body.Close()
+		}
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.ContentLength = contentLength
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	for _, header := range []http.Header{contextHeader(ctx), c.header} {
+		for name, values := range header {
+			if _, ok := req.Header[name]; !ok {
+				req.Header[name] = values
+			}
+		}
+	}
 
+	return req, nil
+}
+
+// errStreamDone is returned by stream callbacks to stop reading the
+// response stream without reporting an error.
+var errStreamDone = errors.New("stream done")
+
+// stream is an internal helper method for calling the streaming endpoints of
+// the Ollama API, which respond with newline-delimited JSON objects. Lines
+// reporting a server-side error end the stream with a *StreamError, and
+// lines that cannot be decoded end it with a *DecodeError unless the
+// client's WithOnDecodeError handler skips them.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - name: Operation name used in error messages (e.g., "pull")
+//   - path: API endpoint path (e.g., "/api/pull")
+//   - reqBody: Request body to be JSON-serialized
+//   - fn: Callback invoked with the raw bytes of each JSON object, which are
+//     only valid during the call; returning errStreamDone stops the stream,
+//     a *DecodeError (see decodeStreamLine) is subject to the decode error
+//     handler, and any other error aborts it
+//   - opts: Request-scoped options such as timeouts
+//
+// Returns an error if the request fails, the response indicates an error,
+// the stream cannot be decoded, or the callback returns an error.
+func (c *Client) stream(ctx context.Context, name, path string, reqBody interface{}, fn func([]byte) error, opts ...RequestOption) error {
 	// Execute the request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.roundTrip(ctx, name, http.MethodPost, path, reqBody, c.streamOptions(opts))
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	// Read the response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-
 	// Check for non-2xx status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, readErr := readErrorBody(resp.Body)
+		if readErr != nil {
+			return fmt.Errorf("%s request failed with status %d and could not read response body: %w", name, resp.StatusCode, readErr)
+		}
 		return parseErrorResponse(resp.StatusCode, respBody)
 	}
 
-	// Deserialize response body if a target is provided
-	if resBody != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, resBody); err != nil {
-			return fmt.Errorf("failed to unmarshal response body: %w", err)
+	err = c.readLines(ctx, resp.Body, name+" response stream", func(line []byte) error {
+		c.emit(Event{Type: EventStreamChunk, Method: http.MethodPost, Path: path, Bytes: len(line)})
+		if err := streamLineError(line); err != nil {
+			return err
 		}
-	}
 
-	return nil
+		err := fn(line)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			return err
+		}
+		if c.onDecodeError != nil {
+			decodeErr.Line = append([]byte(nil), line...)
+			return c.onDecodeError(decodeErr)
+		}
+		return fmt.Errorf("error reading %s response stream: %w", name, err)
+	})
+	if err == errStreamDone {
+		return nil
+	}
+	return err
 }
 
 // List retrieves all available models from the Ollama server.
@@ -145,11 +508,27 @@ func (c *Client) List(ctx context.Context) (*ListModelsResponse, error) {
 //
 // Returns a ModelResponse with detailed model information, or an error if the request fails.
 func (c *Client) Show(ctx context.Context, modelName string) (*ModelResponse, error) {
+	return c.ShowWithOptions(ctx, modelName, nil)
+}
+
+// ShowWithOptions retrieves detailed information about a model like Show,
+// applying the given ShowOptions. A nil opts is equivalent to calling Show.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - modelName: The name of the model to show details for
+//   - opts: Optional show settings (can be nil)
+//
+// Returns a ModelResponse with detailed model information, or an error if the request fails.
+func (c *Client) ShowWithOptions(ctx context.Context, modelName string, opts *ShowOptions) (*ModelResponse, error) {
 	if modelName == "" {
 		return nil, fmt.Errorf("model name cannot be empty")
 	}
 
 	req := ShowRequest{Model: modelName}
+	if opts != nil {
+		req.Verbose = opts.Verbose
+	}
 	var response ModelResponse
 	err := c.do(ctx, http.MethodPost, "/api/show", req, &response)
 	if err != nil {
@@ -166,18 +545,48 @@ func (c *Client) Show(ctx context.Context, modelName string) (*ModelResponse, er
 //   - source: The name of the source model to copy
 //   - destination: The name for the new copied model
 //
-// Returns an error if the copy operation fails.
+// Returns ErrModelNotFound if the source model does not exist,
+// ErrModelExists if the destination does (see CopyWithOptions to overwrite
+// it), or another error if the copy operation fails.
 func (c *Client) Copy(ctx context.Context, source, destination string) error {
+	return c.CopyWithOptions(ctx, source, destination, nil)
+}
+
+// CopyWithOptions behaves like Copy, applying the given CopyOptions.
+func (c *Client) CopyWithOptions(ctx context.Context, source, destination string, opts *CopyOptions) error {
 	if source == "" {
 		return fmt.Errorf("source model name cannot be empty")
 	}
 	if destination == "" {
 		return fmt.Errorf("destination model name cannot be empty")
 	}
+	if err := ModelName(destination).Validate(); err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	// The server neither reports a missing source clearly nor refuses to
+	// replace an existing destination, so both are checked beforehand
+	models, err := c.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to copy model from %q to %q: %w", source, destination, err)
+	}
+	if listedModel(models, source) == nil {
+		return fmt.Errorf("failed to copy model from %q to %q: source %w", source, destination, ErrModelNotFound)
+	}
+	if !opts.Overwrite && listedModel(models, destination) != nil {
+		return fmt.Errorf("failed to copy model from %q to %q: destination %w", source, destination, ErrModelExists)
+	}
 
 	req := CopyRequest{Source: source, Destination: destination}
-	err := c.do(ctx, http.MethodPost, "/api/copy", req, nil)
+	err = c.do(ctx, http.MethodPost, "/api/copy", req, nil)
 	if err != nil {
+		var ollamaErr *OllamaError
+		if errors.As(err, &ollamaErr) && ollamaErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("failed to copy model from %q to %q: source %w", source, destination, ErrModelNotFound)
+		}
 		return fmt.Errorf("failed to copy model from %q to %q: %w", source, destination, err)
 	}
 	return nil
@@ -215,6 +624,20 @@ func (c *Client) Delete(ctx context.Context, modelName string) error {
 // The callback function is called for each progress update received from the server.
 // Returns an error if the pull operation fails.
 func (c *Client) Pull(ctx context.Context, modelName string, fn func(PullProgress)) error {
+	return c.PullWithOptions(ctx, modelName, nil, fn)
+}
+
+// PullWithOptions downloads a model like Pull, applying the given PullOptions
+// to the request. A nil opts is equivalent to calling Pull.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - modelName: The name of the model to pull/download
+//   - opts: Optional pull settings (can be nil)
+//   - fn: Callback function that receives progress updates during the pull operation
+//
+// Returns an error if the pull operation fails.
+func (c *Client) PullWithOptions(ctx context.Context, modelName string, opts *PullOptions, fn func(PullProgress)) (err error) {
 	if modelName == "" {
 		return fmt.Errorf("model name cannot be empty")
 	}
@@ -222,65 +645,53 @@ func (c *Client) Pull(ctx context.Context, modelName string, fn func(PullProgres
 		return fmt.Errorf("progress callback function cannot be nil")
 	}
 
-	req := PullRequest{Model: modelName}
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal pull request: %w", err)
-	}
-
-	// Construct the full URL
-	u, err := url.JoinPath(c.baseURL, "/api/pull")
-	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
-	}
-
-	// Create the HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	start := time.Now()
+	totals := make(layerTotals)
+	defer func() {
+		c.notifyOperation("pull", modelName, start, totals.sum(), err)
+	}()
 
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	// Execute the request
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute pull request: %w", err)
+	if opts != nil && opts.DiskBudget > 0 {
+		if err := c.checkDiskBudget(ctx, modelName, opts); err != nil {
+			return err
+		}
 	}
-	defer resp.Body.Close()
 
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return fmt.Errorf("pull request failed with status %d and could not read response body: %w", resp.StatusCode, readErr)
+	req := PullRequest{Model: modelName}
+	if opts != nil {
+		req.Insecure = opts.Insecure
+		req.Username = opts.Username
+		req.Password = opts.Password
+		if opts.DisableStream {
+			stream := false
+			req.Stream = &stream
 		}
-		return parseErrorResponse(resp.StatusCode, respBody)
 	}
 
-	// Stream the response line by line
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	// Remember the layer digests reported by the server for optional
+	// verification afterwards
+	var layers []string
+	err = c.stream(ctx, "pull", "/api/pull", req, func(data []byte) error {
+		var progress PullProgress
+		if err := decodeStreamLine(data, &progress); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
 		}
+		totals.add(progress.Digest, progress.Total)
 
-		var progress PullProgress
-		if err := json.Unmarshal([]byte(line), &progress); err != nil {
-			// Log the error but continue processing other lines
-			continue
+		if progress.Digest != "" {
+			layers = appendUnique(layers, progress.Digest)
 		}
 
 		// Call the callback function with the progress update
 		fn(progress)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading pull response stream: %w", err)
+	if opts != nil && (opts.VerifyDigest || opts.ExpectedDigest != "") {
+		return c.verifyPulledModel(ctx, modelName, opts.ExpectedDigest, layers)
 	}
 
 	return nil
@@ -297,10 +708,13 @@ func (c *Client) Pull(ctx context.Context, modelName string, fn func(PullProgres
 //
 // The callback function is called for each progress update received from the server.
 // Returns an error if the create operation fails.
-func (c *Client) Create(ctx context.Context, modelName, modelfileContent string, fn func(CreateProgress)) error {
+func (c *Client) Create(ctx context.Context, modelName, modelfileContent string, fn func(CreateProgress)) (err error) {
 	if modelName == "" {
 		return fmt.Errorf("model name cannot be empty")
 	}
+	if err := ModelName(modelName).Validate(); err != nil {
+		return err
+	}
 	if modelfileContent == "" {
 		return fmt.Errorf("modelfile content cannot be empty")
 	}
@@ -308,68 +722,22 @@ func (c *Client) Create(ctx context.Context, modelName, modelfileContent string,
 		return fmt.Errorf("progress callback function cannot be nil")
 	}
 
-	req := CreateRequest{Model: modelName, Modelfile: modelfileContent}
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal create request: %w", err)
-	}
-
-	// Construct the full URL
-	u, err := url.JoinPath(c.baseURL, "/api/create")
-	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
-	}
-
-	// Create the HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	// Execute the request
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute create request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return fmt.Errorf("create request failed with status %d and could not read response body: %w", resp.StatusCode, readErr)
-		}
-		return parseErrorResponse(resp.StatusCode, respBody)
-	}
-
-	// Stream the response line by line
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	start := time.Now()
+	defer func() {
+		c.notifyOperation("create", modelName, start, 0, err)
+	}()
 
+	req := CreateRequest{Model: modelName, Modelfile: modelfileContent}
+	return c.stream(ctx, "create", "/api/create", req, func(data []byte) error {
 		var progress CreateProgress
-		if err := json.Unmarshal([]byte(line), &progress); err != nil {
-			// Log the error but continue processing other lines
-			continue
+		if err := decodeStreamLine(data, &progress); err != nil {
+			return fmt.Errorf("failed to decode create progress: %w", err)
 		}
 
 		// Call the callback function with the progress update
 		fn(progress)
-	}
-
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading create response stream: %w", err)
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // Push uploads a model to a registry with streaming progress updates.
@@ -383,75 +751,52 @@ func (c *Client) Create(ctx context.Context, modelName, modelfileContent string,
 // The callback function is called for each progress update received from the server.
 // Returns an error if the push operation fails.
 func (c *Client) Push(ctx context.Context, modelName string, fn func(PushProgress)) error {
+	return c.PushWithOptions(ctx, modelName, nil, fn)
+}
+
+// PushWithOptions uploads a model like Push, applying the given PushOptions
+// to the request. A nil opts is equivalent to calling Push.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - modelName: The name of the model to push to the registry
+//   - opts: Optional push settings (can be nil)
+//   - fn: Callback function that receives progress updates during the push operation
+//
+// Returns an error if the push operation fails.
+func (c *Client) PushWithOptions(ctx context.Context, modelName string, opts *PushOptions, fn func(PushProgress)) (err error) {
 	if modelName == "" {
 		return fmt.Errorf("model name cannot be empty")
 	}
+	if err := ModelName(modelName).Validate(); err != nil {
+		return err
+	}
 	if fn == nil {
 		return fmt.Errorf("progress callback function cannot be nil")
 	}
 
-	req := PushRequest{Model: modelName}
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal push request: %w", err)
-	}
-
-	// Construct the full URL
-	u, err := url.JoinPath(c.baseURL, "/api/push")
-	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
-	}
-
-	// Create the HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	// Execute the request
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute push request: %w", err)
-	}
-	defer resp.Body.Close()
+	start := time.Now()
+	totals := make(layerTotals)
+	defer func() {
+		c.notifyOperation("push", modelName, start, totals.sum(), err)
+	}()
 
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return fmt.Errorf("push request failed with status %d and could not read response body: %w", resp.StatusCode, readErr)
-		}
-		return parseErrorResponse(resp.StatusCode, respBody)
+	req := PushRequest{Model: modelName}
+	if opts != nil {
+		req.Insecure = opts.Insecure
 	}
 
-	// Stream the response line by line
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
+	return c.stream(ctx, "push", "/api/push", req, func(data []byte) error {
 		var progress PushProgress
-		if err := json.Unmarshal([]byte(line), &progress); err != nil {
-			// Log the error but continue processing other lines
-			continue
+		if err := decodeStreamLine(data, &progress); err != nil {
+			return fmt.Errorf("failed to decode push progress: %w", err)
 		}
+		totals.add(progress.Digest, progress.Total)
 
 		// Call the callback function with the progress update
 		fn(progress)
-	}
-
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading push response stream: %w", err)
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // Generate performs text generation using the specified model and prompt.
@@ -461,25 +806,45 @@ func (c *Client) Push(ctx context.Context, modelName string, fn func(PushProgres
 // Parameters:
 //   - ctx: Context for request cancellation and timeouts
 //   - req: The generation request containing model, prompt, and options
+//   - opts: Request-scoped options such as timeouts
 //
 // Returns a GenerateResponse with the generated text and metadata, or an error if the request fails.
-func (c *Client) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+func (c *Client) Generate(ctx context.Context, req *GenerateRequest, opts ...RequestOption) (*GenerateResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("generate request cannot be nil")
 	}
-	if req.Model == "" {
-		return nil, fmt.Errorf("model name cannot be empty")
-	}
+
+	// Overrides carried by the context apply after the call's own options
+	opts = contextOptions(ctx, opts)
 
 	// Ensure this is a non-streaming request
 	reqCopy := *req
 	reqCopy.Stream = false
+	reqCopy.Model = c.modelOrDefault(ctx, req.Model)
+	reqCopy.Options = c.mergeOptions(req.Options, opts)
+	if reqCopy.Model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+	if err := c.fitGenerateRequest(ctx, &reqCopy); err != nil {
+		return nil, err
+	}
+	opts = c.adaptTimeout(ctx, reqCopy.Model, EstimateTokens(reqCopy.Prompt), reqCopy.Options, opts)
 
 	var response GenerateResponse
-	err := c.do(ctx, http.MethodPost, "/api/generate", &reqCopy, &response)
+	err := c.do(ctx, http.MethodPost, "/api/generate", &reqCopy, &response, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate text: %w", err)
 	}
+	c.observeGenerate(reqCopy.Model, &response)
+	if thinkingEnabled(req.Think) && response.Thinking == "" {
+		response.Thinking, response.Response = SplitThinking(response.Response)
+	}
+	response.Response = truncateAtStop(response.Response, opts)
+	response.Response, response.Truncated = c.truncateOutput(response.Response, response.EvalCount, opts)
+	response.Response = c.postProcess(response.Response, opts)
+	if response.Response, err = c.moderateText(ctx, response.Response); err != nil {
+		return nil, err
+	}
 	return &response, nil
 }
 
@@ -491,97 +856,128 @@ func (c *Client) Generate(ctx context.Context, req *GenerateRequest) (*GenerateR
 //   - ctx: Context for request cancellation and timeouts
 //   - req: The generation request containing model, prompt, and options
 //   - fn: Callback function that receives each partial response during generation
+//   - opts: Request-scoped options such as timeouts
 //
 // The callback function is called for each partial response received from the server.
 // Returns an error if the generation fails or if the request/callback parameters are invalid.
-func (c *Client) GenerateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse)) error {
-	if req == nil {
-		return fmt.Errorf("generate request cannot be nil")
-	}
-	if req.Model == "" {
-		return fmt.Errorf("model name cannot be empty")
-	}
+func (c *Client) GenerateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse), opts ...RequestOption) error {
 	if fn == nil {
 		return fmt.Errorf("callback function cannot be nil")
 	}
+	_, err := c.generateStream(ctx, req, fn, opts)
+	return err
+}
 
-	// Ensure this is a streaming request
-	reqCopy := *req
-	reqCopy.Stream = true
-
-	jsonData, err := json.Marshal(&reqCopy)
-	if err != nil {
-		return fmt.Errorf("failed to marshal generate request: %w", err)
-	}
-
-	// Construct the full URL
-	u, err := url.JoinPath(c.baseURL, "/api/generate")
+// GenerateStreamCollect behaves like GenerateStream, and also returns the
+// complete response once the stream ends: the concatenated text and
+// thinking trace, with the context and statistics of the final chunk. The
+// text is post-processed like that of Generate. fn may be nil to only
+// collect the response.
+func (c *Client) GenerateStreamCollect(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse), opts ...RequestOption) (*GenerateResponse, error) {
+	response, err := c.generateStream(ctx, req, fn, opts)
 	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
+		return nil, err
 	}
+	response.Response = c.postProcess(response.Response, contextOptions(ctx, opts))
+	return response, nil
+}
 
-	// Create the HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// generateStream implements GenerateStream and GenerateStreamCollect.
+func (c *Client) generateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse), opts []RequestOption) (*GenerateResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("generate request cannot be nil")
 	}
 
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
+	// Overrides carried by the context apply after the call's own options
+	opts = contextOptions(ctx, opts)
 
-	// Execute the request
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute generate request: %w", err)
+	// Ensure this is a streaming request
+	reqCopy := *req
+	reqCopy.Stream = true
+	reqCopy.Model = c.modelOrDefault(ctx, req.Model)
+	reqCopy.Options = c.mergeOptions(req.Options, opts)
+	if reqCopy.Model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
 	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return fmt.Errorf("generate request failed with status %d and could not read response body: %w", resp.StatusCode, readErr)
-		}
-		return parseErrorResponse(resp.StatusCode, respBody)
+	if err := c.fitGenerateRequest(ctx, &reqCopy); err != nil {
+		return nil, err
 	}
+	opts = c.adaptTimeout(ctx, reqCopy.Model, EstimateTokens(reqCopy.Prompt), reqCopy.Options, opts)
 
-	// Stream the response line by line
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		// Check if context was canceled
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	// The raw output is kept to prime the request if the stream is resumed
+	var acc generateAccumulator
+	var output strings.Builder
+	var done bool
+	thinking := newThinkingSplitter(req.Think)
+	stop := newStopMatcher(opts)
+	limiter := c.newOutputLimiter(opts)
+	moderation := c.newModerationStream()
+	handle := func(data []byte) error {
+		var response GenerateResponse
+		if err := decodeStreamLine(data, &response); err != nil {
+			return fmt.Errorf("failed to decode generate response: %w", err)
 		}
-
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+		output.WriteString(response.Response)
+		if thinking != nil {
+			trace, answer := thinking.push(response.Response, response.Done)
+			response.Thinking += trace
+			response.Response = answer
 		}
-
-		var response GenerateResponse
-		if err := json.Unmarshal([]byte(line), &response); err != nil {
-			// Log the error but continue processing other lines
-			continue
+		text, stopped := stop.push(response.Response)
+		if stopped {
+			response.Done = true
+		}
+		text, truncated := limiter.push(text)
+		if truncated {
+			response.Done = true
+			response.Truncated = true
+		}
+		text, err := moderation.push(ctx, text, response.Done)
+		if err != nil {
+			return err
 		}
+		response.Response = text
 
 		// Call the callback function with the response
-		fn(&response)
+		acc.add(&response)
+		if fn != nil {
+			fn(&response)
+		}
 
 		// Check if generation is complete
 		if response.Done {
-			break
+			done = true
+			return errStreamDone
 		}
+		return nil
 	}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading generate response stream: %w", err)
+	resumeAttempts := newRequestConfig(opts).resumeAttempts
+	if thinkingEnabled(req.Think) || req.Suffix != "" || len(req.Context) > 0 || req.hasImageReaders() {
+		resumeAttempts = 0
 	}
-
-	return nil
+	body := &reqCopy
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.stream(ctx, "generate", "/api/generate", body, handle, opts...)
+		if done || !shouldResume(ctx, err, attempt, resumeAttempts) {
+			break
+		}
+		resumed, resumeErr := c.resumeGenerateRequest(ctx, &reqCopy, output.String())
+		if resumeErr != nil {
+			break
+		}
+		body = resumed
+	}
+	if err != nil {
+		if acc.chunks > 0 {
+			return nil, &PartialResponseError{Err: err, Chunks: acc.chunks, Generate: acc.response()}
+		}
+		return nil, err
+	}
+	response := acc.response()
+	c.observeGenerate(reqCopy.Model, response)
+	return response, nil
 }
 
 // Chat performs a chat conversation using the specified model and message history.
@@ -591,28 +987,48 @@ func (c *Client) GenerateStream(ctx context.Context, req *GenerateRequest, fn fu
 // Parameters:
 //   - ctx: Context for request cancellation and timeouts
 //   - req: The chat request containing model, messages, and options
+//   - opts: Request-scoped options such as timeouts
 //
 // Returns a ChatResponse with the assistant's message and metadata, or an error if the request fails.
-func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+func (c *Client) Chat(ctx context.Context, req *ChatRequest, opts ...RequestOption) (*ChatResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("chat request cannot be nil")
 	}
-	if req.Model == "" {
-		return nil, fmt.Errorf("model name cannot be empty")
-	}
 	if len(req.Messages) == 0 {
 		return nil, fmt.Errorf("at least one message is required")
 	}
 
+	// Overrides carried by the context apply after the call's own options
+	opts = contextOptions(ctx, opts)
+
 	// Ensure this is a non-streaming request
 	reqCopy := *req
 	reqCopy.Stream = false
+	reqCopy.Model = c.modelOrDefault(ctx, req.Model)
+	reqCopy.Options = c.mergeOptions(req.Options, opts)
+	if reqCopy.Model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+	if err := c.fitChatRequest(ctx, &reqCopy); err != nil {
+		return nil, err
+	}
+	opts = c.adaptTimeout(ctx, reqCopy.Model, EstimateTokens(chatPromptText(reqCopy.Messages)), reqCopy.Options, opts)
 
 	var response ChatResponse
-	err := c.do(ctx, http.MethodPost, "/api/chat", &reqCopy, &response)
+	err := c.do(ctx, http.MethodPost, "/api/chat", &reqCopy, &response, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to chat: %w", err)
 	}
+	c.observeChat(reqCopy.Model, &response)
+	if thinkingEnabled(req.Think) && response.Message.Thinking == "" {
+		response.Message.Thinking, response.Message.Content = SplitThinking(response.Message.Content)
+	}
+	response.Message.Content = truncateAtStop(response.Message.Content, opts)
+	response.Message.Content, response.Truncated = c.truncateOutput(response.Message.Content, response.EvalCount, opts)
+	response.Message.Content = c.postProcess(response.Message.Content, opts)
+	if response.Message.Content, err = c.moderateText(ctx, response.Message.Content); err != nil {
+		return nil, err
+	}
 	return &response, nil
 }
 
@@ -624,100 +1040,126 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 //   - ctx: Context for request cancellation and timeouts
 //   - req: The chat request containing model, messages, and options
 //   - fn: Callback function that receives each partial response during the conversation
+//   - opts: Request-scoped options such as timeouts
 //
 // The callback function is called for each partial response received from the server.
 // Returns an error if the chat fails or if the request/callback parameters are invalid.
-func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse)) error {
-	if req == nil {
-		return fmt.Errorf("chat request cannot be nil")
-	}
-	if req.Model == "" {
-		return fmt.Errorf("model name cannot be empty")
-	}
-	if len(req.Messages) == 0 {
-		return fmt.Errorf("at least one message is required")
-	}
+func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse), opts ...RequestOption) error {
 	if fn == nil {
 		return fmt.Errorf("callback function cannot be nil")
 	}
+	_, err := c.chatStream(ctx, req, fn, opts)
+	return err
+}
 
-	// Ensure this is a streaming request
-	reqCopy := *req
-	reqCopy.Stream = true
-
-	jsonData, err := json.Marshal(&reqCopy)
+// ChatStreamCollect behaves like ChatStream, and also returns the complete
+// response once the stream ends, as assembled by an Accumulator, with its
+// text post-processed like that of Chat. fn may be nil to only collect the
+// response.
+func (c *Client) ChatStreamCollect(ctx context.Context, req *ChatRequest, fn func(*ChatResponse), opts ...RequestOption) (*ChatResponse, error) {
+	response, err := c.chatStream(ctx, req, fn, opts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal chat request: %w", err)
+		return nil, err
 	}
+	response.Message.Content = c.postProcess(response.Message.Content, contextOptions(ctx, opts))
+	return response, nil
+}
 
-	// Construct the full URL
-	u, err := url.JoinPath(c.baseURL, "/api/chat")
-	if err != nil {
-		return fmt.Errorf("failed to construct URL: %w", err)
+// chatStream implements ChatStream and ChatStreamCollect.
+func (c *Client) chatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse), opts []RequestOption) (*ChatResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("chat request cannot be nil")
 	}
-
-	// Create the HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("at least one message is required")
 	}
 
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
+	// Overrides carried by the context apply after the call's own options
+	opts = contextOptions(ctx, opts)
 
-	// Execute the request
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute chat request: %w", err)
+	// Ensure this is a streaming request
+	reqCopy := *req
+	reqCopy.Stream = true
+	reqCopy.Model = c.modelOrDefault(ctx, req.Model)
+	reqCopy.Options = c.mergeOptions(req.Options, opts)
+	if reqCopy.Model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
 	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return fmt.Errorf("chat request failed with status %d and could not read response body: %w", resp.StatusCode, readErr)
-		}
-		return parseErrorResponse(resp.StatusCode, respBody)
+	if err := c.fitChatRequest(ctx, &reqCopy); err != nil {
+		return nil, err
 	}
+	opts = c.adaptTimeout(ctx, reqCopy.Model, EstimateTokens(chatPromptText(reqCopy.Messages)), reqCopy.Options, opts)
 
-	// Stream the response line by line
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		// Check if context was canceled
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	// The raw output is kept to prime the request if the stream is resumed
+	var acc Accumulator
+	var output strings.Builder
+	var done bool
+	thinking := newThinkingSplitter(req.Think)
+	stop := newStopMatcher(opts)
+	limiter := c.newOutputLimiter(opts)
+	moderation := c.newModerationStream()
+	handle := func(data []byte) error {
+		var response ChatResponse
+		if err := decodeStreamLine(data, &response); err != nil {
+			return fmt.Errorf("failed to decode chat response: %w", err)
 		}
-
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+		output.WriteString(response.Message.Content)
+		if thinking != nil {
+			trace, answer := thinking.push(response.Message.Content, response.Done)
+			response.Message.Thinking += trace
+			response.Message.Content = answer
 		}
-
-		var response ChatResponse
-		if err := json.Unmarshal([]byte(line), &response); err != nil {
-			// Log the error but continue processing other lines
-			continue
+		text, stopped := stop.push(response.Message.Content)
+		if stopped {
+			response.Done = true
+		}
+		text, truncated := limiter.push(text)
+		if truncated {
+			response.Done = true
+			response.Truncated = true
 		}
+		text, err := moderation.push(ctx, text, response.Done)
+		if err != nil {
+			return err
+		}
+		response.Message.Content = text
 
 		// Call the callback function with the response
-		fn(&response)
+		acc.Add(&response)
+		if fn != nil {
+			fn(&response)
+		}
 
 		// Check if conversation is complete
 		if response.Done {
-			break
+			done = true
+			return errStreamDone
 		}
+		return nil
 	}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading chat response stream: %w", err)
+	resumeAttempts := newRequestConfig(opts).resumeAttempts
+	if thinkingEnabled(req.Think) || req.hasImageReaders() {
+		resumeAttempts = 0
 	}
-
-	return nil
+	body := &reqCopy
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.stream(ctx, "chat", "/api/chat", body, handle, opts...)
+		if done || !shouldResume(ctx, err, attempt, resumeAttempts) {
+			break
+		}
+		body = resumeChatRequest(&reqCopy, output.String())
+	}
+	if err != nil {
+		if acc.Chunks() > 0 {
+			return nil, &PartialResponseError{Err: err, Chunks: acc.Chunks(), Chat: acc.Response()}
+		}
+		return nil, err
+	}
+	response := acc.Response()
+	c.observeChat(reqCopy.Model, response)
+	return response, nil
 }
 
 // Embeddings generates vector embeddings for the given text using the specified model.
@@ -726,21 +1168,27 @@ func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, fn func(*Chat
 // Parameters:
 //   - ctx: Context for request cancellation and timeouts
 //   - req: The embedding request containing model and text to embed
+//   - opts: Request-scoped options such as timeouts
 //
 // Returns an EmbeddingResponse containing the generated embedding vector, or an error if the request fails.
-func (c *Client) Embeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+func (c *Client) Embeddings(ctx context.Context, req *EmbeddingRequest, opts ...RequestOption) (*EmbeddingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("embedding request cannot be nil")
 	}
-	if req.Model == "" {
-		return nil, fmt.Errorf("model name cannot be empty")
-	}
 	if req.Prompt == "" {
 		return nil, fmt.Errorf("prompt cannot be empty")
 	}
 
+	// Overrides carried by the context apply after the call's own options
+	opts = contextOptions(ctx, opts)
+	reqCopy := *req
+	reqCopy.Model = c.modelOrDefault(ctx, req.Model)
+	if reqCopy.Model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+
 	var response EmbeddingResponse
-	err := c.do(ctx, http.MethodPost, "/api/embeddings", req, &response)
+	err := c.do(ctx, http.MethodPost, "/api/embeddings", &reqCopy, &response, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 	}
@@ -763,11 +1211,48 @@ func (c *Client) PS(ctx context.Context) (*PSResponse, error) {
 	return &response, nil
 }
 
+// VersionResponse represents the response of the version endpoint.
+type VersionResponse struct {
+	Version string `json:"version"`
+}
+
+// Version returns the version of the Ollama server, such as "0.5.7".
+// It makes a GET request to the `/api/version` endpoint.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var response VersionResponse
+	err := c.do(ctx, http.MethodGet, "/api/version", nil, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	return response.Version, nil
+}
+
 // Message represents a single chat message, comprising a role (e.g., "user", "assistant")
 // and the content of the message.
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Thinking holds the reasoning trace of a thinking model, when the
+	// request enabled Think
+	Thinking string `json:"thinking,omitempty"`
+	// Images holds base64-encoded images for multimodal models
+	Images []string `json:"images,omitempty"`
+	// ImageSources holds images that are read and encoded while the
+	// request is sent, after those in Images
+	ImageSources []ImageSource `json:"-"`
+	// ToolCalls holds the tools an assistant message asks to call
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a request by the model to call a tool.
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the function of a ToolCall and its arguments.
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
 }
 
 // ModelDetails contains specific metadata about an Ollama model, such as
@@ -781,13 +1266,98 @@ type ModelDetails struct {
 }
 
 // ModelResponse represents the detailed information for a single model
-// returned by the Ollama API's list models endpoint.
+// returned by the Ollama API's list models and show endpoints. The fields
+// from License onwards are only populated by Show.
 type ModelResponse struct {
-	Name       string       `json:"name"`
-	ModifiedAt time.Time    `json:"modified_at"`
-	Size       int64        `json:"size"`
-	Digest     string       `json:"digest"`
-	Details    ModelDetails `json:"details,omitempty"`
+	Name       string                 `json:"name"`
+	ModifiedAt time.Time              `json:"modified_at"`
+	Size       int64                  `json:"size"`
+	Digest     string                 `json:"digest"`
+	Details    ModelDetails           `json:"details,omitempty"`
+	License    string                 `json:"license,omitempty"`
+	Modelfile  string                 `json:"modelfile,omitempty"`
+	Parameters string                 `json:"parameters,omitempty"`
+	Template   string                 `json:"template,omitempty"`
+	System     string                 `json:"system,omitempty"`
+	ModelInfo  map[string]interface{} `json:"model_info,omitempty"`
+	// ProjectorInfo describes the multimodal projector of vision models.
+	ProjectorInfo map[string]interface{} `json:"projector_info,omitempty"`
+	// Capabilities lists what the model can do, e.g. "completion", "vision",
+	// "tools" or "embedding". Older servers do not report capabilities.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Model capabilities as reported in ModelResponse.Capabilities.
+const (
+	CapabilityCompletion = "completion"
+	CapabilityVision     = "vision"
+	CapabilityTools      = "tools"
+	CapabilityEmbedding  = "embedding"
+	CapabilityInsert     = "insert"
+)
+
+// HasCapability reports whether the model lists the given capability.
+func (m *ModelResponse) HasCapability(capability string) bool {
+	for _, c := range m.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsVision reports whether the model accepts images. Servers that do
+// not report capabilities are detected through the presence of a projector.
+func (m *ModelResponse) SupportsVision() bool {
+	return m.HasCapability(CapabilityVision) || len(m.ProjectorInfo) > 0
+}
+
+// SupportsTools reports whether the model supports tool calling.
+func (m *ModelResponse) SupportsTools() bool {
+	return m.HasCapability(CapabilityTools)
+}
+
+// SupportsEmbedding reports whether the model can generate embeddings.
+func (m *ModelResponse) SupportsEmbedding() bool {
+	return m.HasCapability(CapabilityEmbedding)
+}
+
+// Architecture returns the model architecture (e.g. "llama") from ModelInfo,
+// or an empty string if it is not known.
+func (m *ModelResponse) Architecture() string {
+	arch, _ := m.ModelInfo["general.architecture"].(string)
+	return arch
+}
+
+// ContextLength returns the maximum context length the model was trained
+// with, as reported in ModelInfo, or 0 if it is not known.
+func (m *ModelResponse) ContextLength() int {
+	return m.modelInfoInt("context_length")
+}
+
+// EmbeddingLength returns the size of the model's embedding vectors, as
+// reported in ModelInfo, or 0 if it is not known.
+func (m *ModelResponse) EmbeddingLength() int {
+	return m.modelInfoInt("embedding_length")
+}
+
+// modelInfoInt looks up an architecture-specific integer entry of ModelInfo,
+// such as "llama.context_length".
+func (m *ModelResponse) modelInfoInt(key string) int {
+	arch := m.Architecture()
+	if arch == "" {
+		return 0
+	}
+	switch v := m.ModelInfo[arch+"."+key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
 }
 
 // ListModelsResponse encapsulates the response structure for listing
@@ -796,13 +1366,59 @@ type ListModelsResponse struct {
 	Models []ModelResponse `json:"models"`
 }
 
+// Options holds model parameters such as temperature, seed or num_ctx that
+// are sent with generate and chat requests. When decoded from JSON, numbers
+// are kept as json.Number rather than float64, so integer options and
+// high-precision values survive a round-trip exactly.
+type Options map[string]interface{}
+
+// UnmarshalJSON decodes options, preserving numbers as json.Number.
+func (o *Options) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return err
+	}
+	*o = m
+	return nil
+}
+
 // GenerateRequest defines the structure for a request to the Ollama API's
 // `/api/generate` endpoint, used for generating text completions.
 type GenerateRequest struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream,omitempty"`
-	Options map[string]interface{} `json:"options,omitempty"`
+	Model   string  `json:"model"`
+	Prompt  string  `json:"prompt"`
+	Stream  bool    `json:"stream,omitempty"`
+	Options Options `json:"options,omitempty"`
+	// Suffix is the text after the insertion point for fill-in-the-middle
+	// completion with code models
+	Suffix string `json:"suffix,omitempty"`
+	// Images holds base64-encoded images for multimodal models
+	Images []string `json:"images,omitempty"`
+	// ImageSources holds images that are read and encoded while the
+	// request is sent, after those in Images
+	ImageSources []ImageSource `json:"-"`
+	// Format constrains the output: "json", or a JSON schema
+	Format interface{} `json:"format,omitempty"`
+	// Think enables or disables the reasoning trace of thinking models such
+	// as deepseek-r1. When enabled, the trace is returned in Thinking rather
+	// than in Response. Nil leaves the choice to the server.
+	Think *bool `json:"think,omitempty"`
+	// Grammar constrains the output with a GBNF grammar, such as
+	// JSONGrammar or one built by ChoiceGrammar, on servers that support it
+	Grammar string `json:"grammar,omitempty"`
+	// Raw sends Prompt to the model as is, without applying the model's
+	// template. RenderTemplate builds a prompt the way the server would.
+	Raw bool `json:"raw,omitempty"`
+	// System overrides the system prompt of the model
+	System string `json:"system,omitempty"`
+	// Template overrides the prompt template of the model
+	Template string `json:"template,omitempty"`
+	// Context holds the context tokens returned by a previous generation,
+	// to continue that conversation
+	Context []int `json:"context,omitempty"`
 }
 
 // GenerateResponse represents the response structure from the Ollama API's
@@ -812,6 +1428,7 @@ type GenerateResponse struct {
 	Model              string    `json:"model"`
 	CreatedAt          time.Time `json:"created_at"`
 	Response           string    `json:"response"`
+	Thinking           string    `json:"thinking,omitempty"`
 	Done               bool      `json:"done"`
 	Context            []int     `json:"context,omitempty"`
 	TotalDuration      int64     `json:"total_duration,omitempty"`
@@ -820,15 +1437,26 @@ type GenerateResponse struct {
 	PromptEvalDuration int64     `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int       `json:"eval_count,omitempty"`
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
+	// Truncated reports that the output was cut short by an OutputLimit
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // ChatRequest defines the structure for a request to the Ollama API's
 // `/api/chat` endpoint, used for multi-turn conversations with models.
 type ChatRequest struct {
-	Model    string                 `json:"model"`
-	Messages []Message              `json:"messages"`
-	Stream   bool                   `json:"stream,omitempty"`
-	Options  map[string]interface{} `json:"options,omitempty"`
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream,omitempty"`
+	Options  Options   `json:"options,omitempty"`
+	// Format constrains the output: "json", or a JSON schema
+	Format interface{} `json:"format,omitempty"`
+	// Think enables or disables the reasoning trace of thinking models. When
+	// enabled, the trace is returned in Message.Thinking. Nil leaves the
+	// choice to the server.
+	Think *bool `json:"think,omitempty"`
+	// Grammar constrains the output with a GBNF grammar, such as
+	// JSONGrammar or one built by ChoiceGrammar, on servers that support it
+	Grammar string `json:"grammar,omitempty"`
 }
 
 // ChatResponse represents the response structure from the Ollama API's
@@ -845,6 +1473,8 @@ type ChatResponse struct {
 	PromptEvalDuration int64     `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int       `json:"eval_count,omitempty"`
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
+	// Truncated reports that the output was cut short by an OutputLimit
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // EmbeddingRequest defines the structure for a request to the Ollama API's
@@ -862,7 +1492,15 @@ type EmbeddingResponse struct {
 
 // ShowRequest defines the structure for a request to show model details.
 type ShowRequest struct {
-	Model string `json:"model"`
+	Model   string `json:"model"`
+	Verbose bool   `json:"verbose,omitempty"`
+}
+
+// ShowOptions holds optional settings for ShowWithOptions.
+type ShowOptions struct {
+	// Verbose asks the server to include large ModelInfo entries, such as
+	// the tokenizer vocabulary, that are omitted by default.
+	Verbose bool
 }
 
 // CopyRequest defines the structure for copying a model.
@@ -871,6 +1509,13 @@ type CopyRequest struct {
 	Destination string `json:"destination"`
 }
 
+// CopyOptions holds optional settings for CopyWithOptions.
+type CopyOptions struct {
+	// Overwrite replaces the destination model if it already exists,
+	// instead of failing with ErrModelExists.
+	Overwrite bool
+}
+
 // DeleteRequest defines the structure for deleting a model.
 type DeleteRequest struct {
 	Model string `json:"model"`
@@ -878,7 +1523,36 @@ type DeleteRequest struct {
 
 // PullRequest defines the structure for pulling a model.
 type PullRequest struct {
-	Model string `json:"model"`
+	Model    string `json:"model"`
+	Insecure bool   `json:"insecure,omitempty"`
+	Stream   *bool  `json:"stream,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// PullOptions holds optional settings for PullWithOptions.
+type PullOptions struct {
+	// Insecure allows pulling from registries with self-signed or otherwise
+	// unverifiable TLS certificates. Use only with registries you trust.
+	Insecure bool
+	// DisableStream asks the server to send a single final status instead of
+	// a stream of progress updates. The callback is then invoked once.
+	DisableStream bool
+	// Username and Password are forwarded to the server for registries or
+	// proxies that require basic credentials.
+	Username string
+	Password string
+	// VerifyDigest checks after the pull completes that every layer digest
+	// reported in the progress events is present on the server.
+	VerifyDigest bool
+	// ExpectedDigest, if set, is compared against the digest of the pulled
+	// model as reported by List. Implies VerifyDigest.
+	ExpectedDigest string
+	// DiskBudget, if greater than zero, is the number of bytes the server
+	// may use for models. Before pulling, the model size is looked up in the
+	// registry and the pull is refused with ErrInsufficientSpace if the
+	// models already listed plus the new model would exceed the budget.
+	DiskBudget int64
 }
 
 // PullProgress represents the progress information during model pulling.
@@ -902,7 +1576,15 @@ type CreateProgress struct {
 
 // PushRequest defines the structure for pushing a model to a registry.
 type PushRequest struct {
-	Model string `json:"name"`
+	Model    string `json:"name"`
+	Insecure bool   `json:"insecure,omitempty"`
+}
+
+// PushOptions holds optional settings for PushWithOptions.
+type PushOptions struct {
+	// Insecure allows pushing to registries with self-signed or otherwise
+	// unverifiable TLS certificates. Use only with registries you trust.
+	Insecure bool
 }
 
 // PushProgress represents the progress information during model pushing.
@@ -915,9 +1597,59 @@ type PushProgress struct {
 
 // PSResponse represents the response from the process status endpoint.
 type PSResponse struct {
-	Models []ModelResponse `json:"models"`
+	Models []RunningModel `json:"models"`
 }
 
+// RunningModel describes a model currently loaded into memory, as returned
+// by the process status endpoint.
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	Digest    string       `json:"digest"`
+	Details   ModelDetails `json:"details,omitempty"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	// SizeVRAM is the part of Size that is held in GPU memory. The
+	// remainder is held in system memory.
+	SizeVRAM int64 `json:"size_vram"`
+}
+
+// ErrDigestMismatch is returned when a pulled model does not match the
+// expected digest or its layers cannot be found on the server.
+var ErrDigestMismatch = errors.New("model digest mismatch")
+
+// ErrModelNotFound is returned when a model that an operation requires does
+// not exist on the server.
+var ErrModelNotFound = errors.New("model not found")
+
+// ErrModelExists is returned when an operation would replace an existing
+// model without being allowed to.
+var ErrModelExists = errors.New("model already exists")
+
+// ErrNoHealthyHost is returned by a ClusterClient when no healthy host can
+// serve a request.
+var ErrNoHealthyHost = errors.New("no healthy host")
+
+// ErrInsufficientSpace is returned when a pull is refused because the model
+// would not fit into the configured disk budget.
+var ErrInsufficientSpace = errors.New("insufficient disk space for model")
+
+// ErrResponseTooLarge is returned when a response, or a single object of a
+// streamed response, exceeds the limit set with WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrHeaderTimeout is returned when the server does not start responding
+// within the limit set with WithHeaderTimeout.
+var ErrHeaderTimeout = errors.New("timed out waiting for response headers")
+
+// ErrIdleTimeout is returned when a streaming response produces no data for
+// longer than the limit set with WithStreamIdleTimeout.
+var ErrIdleTimeout = errors.New("timed out waiting for response data")
+
+// ErrStreamTimeout is returned when reading a response takes longer than the
+// limit set with WithStreamTimeout.
+var ErrStreamTimeout = errors.New("timed out reading response")
+
 // OllamaError represents a custom error type for errors returned by the Ollama API.
 // It includes the HTTP status code and a descriptive message.
 type OllamaError struct {
@@ -936,6 +1668,24 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// maxErrorBodyBytes is the most of an error response body that is read.
+// Anything beyond it, such as the rest of a large HTML error page from a
+// proxy, is discarded.
+const maxErrorBodyBytes = 1 << 20
+
+// readErrorBody reads the body of a non-2xx response, up to
+// maxErrorBodyBytes. A longer body is cut off and marked as truncated.
+func readErrorBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxErrorBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxErrorBodyBytes {
+		body = append(body[:maxErrorBodyBytes], "... (truncated)"...)
+	}
+	return body, nil
+}
+
 // parseErrorResponse attempts to parse a raw byte slice into an OllamaError.
 // It takes the HTTP status code and the response body. If the body can be
 // unmarshaled into an `ErrorResponse`, its `Error` field is used as the message.
@@ -2,6 +2,8 @@ package gollama
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -166,6 +168,79 @@ func TestClientEmbeddingsAdvanced(t *testing.T) {
 	}
 }
 
+func TestClientShowDetails(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	model, err := client.Show(ctx, "llama2")
+	assertNoError(t, err)
+
+	if model.Template == "" || model.License == "" || model.Modelfile == "" || model.Parameters == "" {
+		t.Errorf("Expected template, license, modelfile and parameters to be populated, got %+v", model)
+	}
+	if model.Architecture() != "llama" {
+		t.Errorf("Expected architecture llama, got %q", model.Architecture())
+	}
+	if model.ContextLength() != 4096 {
+		t.Errorf("Expected context length 4096, got %d", model.ContextLength())
+	}
+	if model.EmbeddingLength() != 4096 {
+		t.Errorf("Expected embedding length 4096, got %d", model.EmbeddingLength())
+	}
+	if _, ok := model.ModelInfo["tokenizer.ggml.tokens"]; ok {
+		t.Errorf("Expected tokenizer data to be omitted without verbose")
+	}
+
+	model, err = client.ShowWithOptions(ctx, "llama2", &ShowOptions{Verbose: true})
+	assertNoError(t, err)
+	if _, ok := model.ModelInfo["tokenizer.ggml.tokens"]; !ok {
+		t.Errorf("Expected tokenizer data with verbose")
+	}
+
+	_, err = client.Show(ctx, "nonexistent")
+	if err == nil {
+		t.Errorf("Expected error for nonexistent model")
+	}
+
+	empty := ModelResponse{}
+	if empty.Architecture() != "" || empty.ContextLength() != 0 {
+		t.Errorf("Expected zero values without model info")
+	}
+}
+
+func TestClientShowCapabilities(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	model, err := client.Show(ctx, "llama2")
+	assertNoError(t, err)
+	if model.SupportsVision() || !model.SupportsTools() || model.SupportsEmbedding() {
+		t.Errorf("Unexpected capabilities for llama2: %v", model.Capabilities)
+	}
+
+	model, err = client.Show(ctx, "llava")
+	assertNoError(t, err)
+	if !model.SupportsVision() || model.SupportsTools() {
+		t.Errorf("Unexpected capabilities for llava: %v", model.Capabilities)
+	}
+
+	// Servers without capability reporting fall back to the projector
+	legacy := ModelResponse{ProjectorInfo: map[string]interface{}{"clip.has_vision_encoder": true}}
+	if !legacy.SupportsVision() {
+		t.Errorf("Expected projector info to imply vision support")
+	}
+}
+
 func TestClientPSAdvanced(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
@@ -198,6 +273,14 @@ func TestClientPSAdvanced(t *testing.T) {
 	if model.Digest == "" {
 		t.Errorf("Model digest should not be empty")
 	}
+
+	if model.ExpiresAt.IsZero() {
+		t.Errorf("Model expiry should be set")
+	}
+
+	if model.SizeVRAM != model.Size {
+		t.Errorf("Expected model fully in VRAM, got %d of %d bytes", model.SizeVRAM, model.Size)
+	}
 }
 
 func TestClientCreateAdvanced(t *testing.T) {
@@ -256,6 +339,63 @@ func TestClientPushAdvanced(t *testing.T) {
 	assertNoError(t, err)
 }
 
+func TestClientPullPushOptions(t *testing.T) {
+	var pullReq PullRequest
+	var pushReq PushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pull":
+			pullReq = PullRequest{}
+			json.NewDecoder(r.Body).Decode(&pullReq)
+		case "/api/push":
+			pushReq = PushRequest{}
+			json.NewDecoder(r.Body).Decode(&pushReq)
+		}
+		json.NewEncoder(w).Encode(PullProgress{Status: "success"})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	err = client.PullWithOptions(ctx, "registry.local/llama2", &PullOptions{Insecure: true}, func(PullProgress) {})
+	assertNoError(t, err)
+	if !pullReq.Insecure {
+		t.Errorf("Expected pull request to be sent with insecure=true")
+	}
+
+	err = client.PushWithOptions(ctx, "registry.local/custom-model", &PushOptions{Insecure: true}, func(PushProgress) {})
+	assertNoError(t, err)
+	if !pushReq.Insecure {
+		t.Errorf("Expected push request to be sent with insecure=true")
+	}
+
+	err = client.PullWithOptions(ctx, "private/llama2", &PullOptions{
+		DisableStream: true,
+		Username:      "alice",
+		Password:      "secret",
+	}, func(PullProgress) {})
+	assertNoError(t, err)
+	if pullReq.Stream == nil || *pullReq.Stream {
+		t.Errorf("Expected pull request to be sent with stream=false")
+	}
+	if pullReq.Username != "alice" || pullReq.Password != "secret" {
+		t.Errorf("Expected credentials to be forwarded, got %q/%q", pullReq.Username, pullReq.Password)
+	}
+
+	// Plain Pull must not opt into insecure transport
+	err = client.Pull(ctx, "llama2", func(PullProgress) {})
+	assertNoError(t, err)
+	if pullReq.Insecure {
+		t.Errorf("Expected plain pull request to be sent with insecure=false")
+	}
+	if pullReq.Stream != nil {
+		t.Errorf("Expected plain pull request to leave streaming to the server default")
+	}
+}
+
 func TestClientConcurrency(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
@@ -388,6 +528,60 @@ func TestClientStreamingInterruption(t *testing.T) {
 	}
 }
 
+func TestClientStreamLargeChunks(t *testing.T) {
+	// A single chunk well beyond bufio.Scanner's 64KB default token limit
+	bigContext := make([]int, 50000)
+	for i := range bigContext {
+		bigContext[i] = 100000 + i
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoder := json.NewEncoder(w)
+		encoder.Encode(GenerateResponse{Model: "llama2", Response: "Hello"})
+		encoder.Encode(GenerateResponse{Model: "llama2", Done: true, Context: bigContext})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var final *GenerateResponse
+	err = client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(resp *GenerateResponse) {
+		if resp.Done {
+			final = resp
+		}
+	})
+	assertNoError(t, err)
+
+	if final == nil || len(final.Context) != len(bigContext) {
+		t.Fatalf("Expected final chunk with %d context tokens", len(bigContext))
+	}
+}
+
+func TestClientStreamMalformedData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llama2","response":"Hel"}` + "\n"))
+		w.Write([]byte(`{"model":"llama2","respo` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var chunks int
+	err = client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(resp *GenerateResponse) {
+		chunks++
+	})
+	assertErrorContains(t, err, "error reading generate response stream")
+
+	if chunks != 1 {
+		t.Errorf("Expected the valid chunk to be delivered before the error, got %d", chunks)
+	}
+
+	err = client.Pull(context.Background(), "llama2", func(PullProgress) {})
+	assertErrorContains(t, err, "pull")
+}
+
 func TestClientErrorHandlingEdgeCases(t *testing.T) {
 	// Test with invalid server URL
 	client, err := NewClient("http://nonexistent.localhost:99999")
@@ -412,6 +606,124 @@ func TestClientErrorHandlingEdgeCases(t *testing.T) {
 	}
 }
 
+func TestClientLargeErrorBody(t *testing.T) {
+	page := "<html>" + strings.Repeat("x", 2*maxErrorBodyBytes) + "</html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	checkErr := func(t *testing.T, err error) {
+		var ollamaErr *OllamaError
+		if !errors.As(err, &ollamaErr) {
+			t.Fatalf("Expected OllamaError, got %v", err)
+		}
+		if ollamaErr.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected status 502, got %d", ollamaErr.StatusCode)
+		}
+		if len(ollamaErr.Message) > maxErrorBodyBytes+64 {
+			t.Errorf("Expected message to be capped, got %d bytes", len(ollamaErr.Message))
+		}
+		if !strings.HasSuffix(ollamaErr.Message, "(truncated)") {
+			t.Errorf("Expected message to be marked as truncated")
+		}
+	}
+
+	t.Run("Unary", func(t *testing.T) {
+		_, err := client.List(ctx)
+		checkErr(t, err)
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(*GenerateResponse) {})
+		checkErr(t, err)
+	})
+}
+
+func TestClientDo(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, http.MethodGet, "/api/tags", nil)
+	assertNoError(t, err)
+	defer resp.Body.Close()
+
+	var models ListModelsResponse
+	err = json.NewDecoder(resp.Body).Decode(&models)
+	assertNoError(t, err)
+	if len(models.Models) != 2 {
+		t.Errorf("Expected 2 models, got %d", len(models.Models))
+	}
+
+	resp, err = client.Do(ctx, http.MethodPost, "/api/show", map[string]string{"model": "llama2"})
+	assertNoError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Do(ctx, http.MethodGet, "/api/unreleased", nil)
+	var ollamaErr *OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 OllamaError, got %v", err)
+	}
+}
+
+func TestClientDoStream(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/experimental" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{\"n\":1}\n\n{\"n\":2}\r\n" + `{"s":"` + long + `"}` + "\n{\"n\":3}"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	var lines []string
+	err = client.DoStream(ctx, http.MethodPost, "/api/experimental", map[string]string{"model": "llama2"}, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	assertNoError(t, err)
+
+	expected := []string{`{"n":1}`, `{"n":2}`, `{"s":"` + long + `"}`, `{"n":3}`}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d", len(expected), len(lines))
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Expected line %d to be %.20q, got %.20q", i, expected[i], lines[i])
+		}
+	}
+
+	stop := errors.New("stop")
+	var calls int
+	err = client.DoStream(ctx, http.MethodPost, "/api/experimental", nil, func([]byte) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected callback error after 1 call, got %v after %d calls", err, calls)
+	}
+
+	err = client.DoStream(ctx, http.MethodPost, "/api/missing", nil, func([]byte) error { return nil })
+	assertErrorContains(t, err, "not found")
+}
+
 func TestClientHeaderPersistence(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
@@ -0,0 +1,678 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ClusterClient spreads requests over several Ollama servers serving the
+// same models, skipping servers that are down:
+//
+//	cluster, err := gollama.NewClusterClient([]string{
+//		"http://gpu-1:11434",
+//		"http://gpu-2:11434",
+//	})
+//	if err := cluster.CheckHealth(ctx); err != nil {
+//		return err
+//	}
+//	resp, err := cluster.Generate(ctx, req)
+//
+// Requests for a model go to the healthy hosts that serve it, in proportion
+// to their weights (see HostStatus), so faster hosts take more of them;
+// requests tagged with WithSessionID stick to one host instead. A
+// host that cannot be reached, or answers with a gateway error, is marked
+// unhealthy and the request is retried on the next host; CheckHealth brings
+// it back. Until the first CheckHealth or ReplicateModel, every host is
+// assumed to be healthy and to serve every model.
+//
+// A ClusterClient is safe for concurrent use.
+type ClusterClient struct {
+	mu    sync.Mutex
+	hosts []*clusterHost
+	// clientOpts configure the clients of hosts added by discovery
+	clientOpts []ClientOption
+	// discoverer, if set, finds the hosts on Refresh
+	discoverer Discoverer
+}
+
+// clusterHost holds the state of one host of a ClusterClient, guarded by
+// the cluster's mutex.
+type clusterHost struct {
+	client  *Client
+	healthy bool
+	// err is the failure that made the host unhealthy
+	err error
+	// models holds the normalized names of the models the host serves, or
+	// nil until they are known
+	models map[ModelName]bool
+
+	// latency is the moving average of the duration of successful
+	// requests, or zero before the first one
+	latency time.Duration
+	// inFlight is the number of requests the cluster has sent to the host
+	// that have not finished
+	inFlight int
+	// vram and gpuFraction describe the models loaded on the host at the
+	// last health check; gpuFraction is zero if none were loaded
+	vram        int64
+	gpuFraction float64
+	// current is the host's running total for smooth weighted round-robin
+	current float64
+	// draining is set once discovery no longer finds the host; it is
+	// removed when its last request finishes
+	draining bool
+}
+
+// latencySmoothing is the weight of a new observation in the moving average
+// of a host's latency.
+const latencySmoothing = 0.3
+
+// minGPUFactor is the least the hardware factor of a host's weight can be,
+// for hosts running their models entirely on the CPU.
+const minGPUFactor = 0.1
+
+// HostStatus describes a host of a ClusterClient.
+//
+// A host's share of the requests is its Weight divided by the total weight
+// of the hosts serving the model. The weight is
+//
+//	gpu / (latency × (1 + in-flight requests))
+//
+// in which latency is the host's Latency, or the average over the other
+// hosts until the host has served a request, and gpu is its GPUFraction, or
+// 1 if no model was loaded at the last health check, but at least 0.1.
+type HostStatus struct {
+	URL     string
+	Healthy bool
+	// Models lists the models the host serves, or is nil if they have not
+	// been checked yet.
+	Models []string
+	// Err is the failure that made the host unhealthy.
+	Err error
+	// Draining reports that discovery no longer finds the host, which only
+	// finishes the requests it has in flight before it is removed.
+	Draining bool
+
+	// Latency is the moving average of the duration of the host's
+	// successful requests.
+	Latency time.Duration
+	// InFlight is the number of requests sent to the host that have not
+	// finished.
+	InFlight int
+	// VRAM is the GPU memory held by the models loaded on the host, and
+	// GPUFraction the part of them held in GPU memory, as of the last
+	// health check.
+	VRAM        int64
+	GPUFraction float64
+	// Weight is the host's current routing weight.
+	Weight float64
+}
+
+// NewClusterClient creates a client for the given hosts, each configured
+// by the given options.
+func NewClusterClient(hosts []string, opts ...ClientOption) (*ClusterClient, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("cluster needs at least one host")
+	}
+
+	cc := &ClusterClient{clientOpts: opts}
+	for _, host := range hosts {
+		if host == "" {
+			return nil, fmt.Errorf("cluster host cannot be empty")
+		}
+		client, err := NewClientWithOptions(host, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for %q: %w", host, err)
+		}
+		cc.hosts = append(cc.hosts, &clusterHost{client: client, healthy: true})
+	}
+	return cc, nil
+}
+
+// Hosts returns the status of each host, in the order they were given.
+func (cc *ClusterClient) Hosts() []HostStatus {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	defaultLatency := cc.averageLatency(cc.hosts)
+	statuses := make([]HostStatus, len(cc.hosts))
+	for i, h := range cc.hosts {
+		statuses[i] = HostStatus{
+			URL:         h.client.BaseURL(),
+			Healthy:     h.healthy,
+			Err:         h.err,
+			Draining:    h.draining,
+			Latency:     h.latency,
+			InFlight:    h.inFlight,
+			VRAM:        h.vram,
+			GPUFraction: h.gpuFraction,
+			Weight:      h.weight(defaultLatency),
+		}
+		if h.models != nil {
+			statuses[i].Models = []string{}
+			for name := range h.models {
+				statuses[i].Models = append(statuses[i].Models, string(name))
+			}
+			sort.Strings(statuses[i].Models)
+		}
+	}
+	return statuses
+}
+
+// CheckHealth lists the models of every host, marking the hosts that answer
+// as healthy and recording the models they serve, and the others as
+// unhealthy. It also records the memory use of the models loaded on each
+// host, for weighting; hosts whose process status cannot be read keep
+// their previous figures. It returns an error wrapping ErrNoHealthyHost if
+// no host answers.
+func (cc *ClusterClient) CheckHealth(ctx context.Context) error {
+	hosts := cc.snapshot()
+	checks := make([]hostCheck, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h *clusterHost) {
+			defer wg.Done()
+			checks[i] = checkHost(ctx, h.client)
+		}(i, h)
+	}
+	wg.Wait()
+
+	cc.mu.Lock()
+	var healthy int
+	var ejected []int
+	for i, h := range hosts {
+		if h.apply(checks[i]) {
+			ejected = append(ejected, i)
+		}
+		if h.healthy {
+			healthy++
+		}
+	}
+	cc.mu.Unlock()
+	for _, i := range ejected {
+		hosts[i].ejected(checks[i].err)
+	}
+
+	if healthy == 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to check cluster health: %w (%v)", ErrNoHealthyHost, checks[0].err)
+	}
+	return nil
+}
+
+// hostCheck is the outcome of checking the health of a host.
+type hostCheck struct {
+	list    *ListModelsResponse
+	running *PSResponse
+	err     error
+}
+
+// checkHost lists the models of a host and, if that succeeds, the models
+// it has loaded.
+func checkHost(ctx context.Context, client *Client) hostCheck {
+	var check hostCheck
+	check.list, check.err = client.List(ctx)
+	if check.err == nil {
+		check.running, _ = client.PS(ctx)
+	}
+	return check
+}
+
+// apply records the outcome of a health check of the host and reports
+// whether it ejected the host.
+func (h *clusterHost) apply(check hostCheck) bool {
+	if check.err != nil {
+		return h.eject(check.err)
+	}
+	h.healthy, h.err = true, nil
+	h.models = make(map[ModelName]bool, len(check.list.Models))
+	for _, model := range check.list.Models {
+		h.models[ModelName(model.Name).Normalize()] = true
+	}
+	if check.running != nil {
+		h.setRunning(check.running)
+	}
+	return false
+}
+
+// snapshot returns the cluster's hosts.
+func (cc *ClusterClient) snapshot() []*clusterHost {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return append([]*clusterHost(nil), cc.hosts...)
+}
+
+// setRunning records the memory use of the models loaded on the host.
+func (h *clusterHost) setRunning(ps *PSResponse) {
+	var size, vram int64
+	for _, model := range ps.Models {
+		size += model.Size
+		vram += model.SizeVRAM
+	}
+	h.vram, h.gpuFraction = vram, 0
+	if size > 0 {
+		h.gpuFraction = float64(vram) / float64(size)
+	}
+}
+
+// weight returns the routing weight of the host, using defaultLatency if
+// it has not served a request yet.
+func (h *clusterHost) weight(defaultLatency time.Duration) float64 {
+	latency := h.latency
+	if latency == 0 {
+		latency = defaultLatency
+	}
+	gpu := 1.0
+	if h.gpuFraction > 0 {
+		gpu = h.gpuFraction
+	}
+	gpu = math.Max(gpu, minGPUFactor)
+	return gpu / (latency.Seconds() * float64(1+h.inFlight))
+}
+
+// averageLatency returns the average latency of the hosts that have served
+// requests, or one second if none has.
+func (cc *ClusterClient) averageLatency(hosts []*clusterHost) time.Duration {
+	var total time.Duration
+	var n int
+	for _, h := range hosts {
+		if h.latency > 0 {
+			total += h.latency
+			n++
+		}
+	}
+	if n == 0 {
+		return time.Second
+	}
+	return total / time.Duration(n)
+}
+
+// pick returns a healthy host serving model that is not in tried, or nil if
+// there is none. Requests of a session go to the host that the session
+// hashes to; others are spread over the hosts by weight with smooth weighted
+// round-robin. The picked host's request is counted as in flight.
+func (cc *ClusterClient) pick(model, session string, tried map[*clusterHost]bool) *clusterHost {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	name := ModelName(model).Normalize()
+	var eligible []*clusterHost
+	for _, h := range cc.hosts {
+		if h.healthy && !h.draining && !tried[h] && (h.models == nil || h.models[name]) {
+			eligible = append(eligible, h)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	if session != "" {
+		h := sessionHost(eligible, session)
+		h.inFlight++
+		return h
+	}
+
+	defaultLatency := cc.averageLatency(eligible)
+	var best *clusterHost
+	var total float64
+	for _, h := range eligible {
+		w := h.weight(defaultLatency)
+		h.current += w
+		total += w
+		if best == nil || h.current > best.current {
+			best = h
+		}
+	}
+	best.current -= total
+	best.inFlight++
+	return best
+}
+
+// sessionHost returns the host a session is bound to among hosts, using
+// rendezvous hashing: the session goes to the host with the highest hash of
+// the session and the host's URL. A session only moves when its host is
+// left out, and moves back when it returns, while other sessions stay put.
+func sessionHost(hosts []*clusterHost, session string) *clusterHost {
+	var best *clusterHost
+	var bestHash uint64
+	for _, h := range hosts {
+		hash := fnv.New64a()
+		hash.Write([]byte(session))
+		hash.Write([]byte{0})
+		hash.Write([]byte(h.client.BaseURL()))
+		if sum := hash.Sum64(); best == nil || sum > bestHash {
+			best, bestHash = h, sum
+		}
+	}
+	return best
+}
+
+// finish records the end of a request picked for h, and its latency if it
+// succeeded. A draining host is removed once it has no requests left.
+func (cc *ClusterClient) finish(h *clusterHost, latency time.Duration, err error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	h.inFlight--
+	if h.draining && h.inFlight == 0 {
+		cc.removeLocked(h)
+	}
+	if err != nil {
+		return
+	}
+	latency = max(latency, time.Millisecond)
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency += time.Duration(latencySmoothing * float64(latency-h.latency))
+	}
+}
+
+// removeLocked removes a host from the cluster. cc.mu must be held.
+func (cc *ClusterClient) removeLocked(h *clusterHost) {
+	for i, other := range cc.hosts {
+		if other == h {
+			cc.hosts = append(cc.hosts[:i:i], cc.hosts[i+1:]...)
+			return
+		}
+	}
+}
+
+// markDown marks a host as unhealthy after a failed request.
+func (cc *ClusterClient) markDown(h *clusterHost, err error) {
+	cc.mu.Lock()
+	ejected := h.eject(err)
+	cc.mu.Unlock()
+	if ejected {
+		h.ejected(err)
+	}
+}
+
+// eject marks the host as unhealthy and reports whether it was healthy.
+func (h *clusterHost) eject(err error) bool {
+	wasHealthy := h.healthy
+	h.healthy, h.err = false, err
+	return wasHealthy
+}
+
+// ejected publishes EventHostEjected for the host, ejected because of err.
+// It must be called without holding the cluster's mutex, so that
+// subscribers can query the cluster.
+func (h *clusterHost) ejected(err error) {
+	h.client.emit(Event{Type: EventHostEjected, Err: err})
+}
+
+// route calls fn with a client for a host serving model, moving on to the
+// next host while hosts are down. The failure of the last host tried is
+// returned if none succeeds. opts are the request options of the call,
+// which may set its session.
+func (cc *ClusterClient) route(ctx context.Context, model string, opts []RequestOption, fn func(*Client) error) error {
+	session := newRequestConfig(contextOptions(ctx, opts)).sessionID
+	tried := make(map[*clusterHost]bool)
+	var lastErr error
+	for {
+		h := cc.pick(model, session, tried)
+		if h == nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return fmt.Errorf("%w for model %q", ErrNoHealthyHost, model)
+		}
+
+		start := time.Now()
+		err := fn(h.client)
+		cc.finish(h, time.Since(start), err)
+		var final *unroutableError
+		if errors.As(err, &final) {
+			return final.err
+		}
+		if err == nil || !hostDown(ctx, err) {
+			return err
+		}
+		cc.markDown(h, err)
+		tried[h] = true
+		lastErr = err
+	}
+}
+
+// hostDown reports whether a request failed because its host could not be
+// reached or is not serving, rather than because of the request.
+func hostDown(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var apiErr *OllamaError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// WithSessionID tags a call with the ID of a session, such as a chat
+// conversation, which a ClusterClient uses to send all calls of the session
+// to the same host, keeping its loaded model and cached prompt. If that
+// host becomes unhealthy, the session moves to another host, and moves
+// back once the host is healthy again. Other methods ignore it.
+func WithSessionID(id string) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.sessionID = id
+	}
+}
+
+// Generate sends a generation request to a host serving the requested
+// model.
+func (cc *ClusterClient) Generate(ctx context.Context, req *GenerateRequest, opts ...RequestOption) (*GenerateResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("generate request cannot be nil")
+	}
+	var resp *GenerateResponse
+	err := cc.route(ctx, req.Model, opts, func(c *Client) error {
+		var err error
+		resp, err = c.Generate(ctx, req, opts...)
+		return err
+	})
+	return resp, err
+}
+
+// GenerateStream streams a generation from a host serving the requested
+// model. Only failures before the first chunk move the request to another
+// host.
+func (cc *ClusterClient) GenerateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse), opts ...RequestOption) error {
+	if req == nil {
+		return fmt.Errorf("generate request cannot be nil")
+	}
+	var started bool
+	return cc.route(ctx, req.Model, opts, func(c *Client) error {
+		err := c.GenerateStream(ctx, req, func(chunk *GenerateResponse) {
+			started = true
+			if fn != nil {
+				fn(chunk)
+			}
+		}, opts...)
+		if started {
+			return unroutable(err)
+		}
+		return err
+	})
+}
+
+// Chat sends a chat request to a host serving the requested model.
+func (cc *ClusterClient) Chat(ctx context.Context, req *ChatRequest, opts ...RequestOption) (*ChatResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("chat request cannot be nil")
+	}
+	var resp *ChatResponse
+	err := cc.route(ctx, req.Model, opts, func(c *Client) error {
+		var err error
+		resp, err = c.Chat(ctx, req, opts...)
+		return err
+	})
+	return resp, err
+}
+
+// ChatStream streams a chat completion from a host serving the requested
+// model, like GenerateStream.
+func (cc *ClusterClient) ChatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse), opts ...RequestOption) error {
+	if req == nil {
+		return fmt.Errorf("chat request cannot be nil")
+	}
+	var started bool
+	return cc.route(ctx, req.Model, opts, func(c *Client) error {
+		err := c.ChatStream(ctx, req, func(chunk *ChatResponse) {
+			started = true
+			if fn != nil {
+				fn(chunk)
+			}
+		}, opts...)
+		if started {
+			return unroutable(err)
+		}
+		return err
+	})
+}
+
+// Embeddings sends an embedding request to a host serving the requested
+// model.
+func (cc *ClusterClient) Embeddings(ctx context.Context, req *EmbeddingRequest, opts ...RequestOption) (*EmbeddingResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("embedding request cannot be nil")
+	}
+	var resp *EmbeddingResponse
+	err := cc.route(ctx, req.Model, opts, func(c *Client) error {
+		var err error
+		resp, err = c.Embeddings(ctx, req, opts...)
+		return err
+	})
+	return resp, err
+}
+
+// unroutableError keeps a failure from moving a request to another host.
+type unroutableError struct {
+	err error
+}
+
+func (e *unroutableError) Error() string { return e.err.Error() }
+func (e *unroutableError) Unwrap() error { return e.err }
+
+// unroutable marks err, if not nil, as a failure that is not retried on
+// another host, for streams that already delivered chunks.
+func unroutable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &unroutableError{err}
+}
+
+// ReplicaProgress reports the progress of replicating a model to one host.
+type ReplicaProgress struct {
+	Host      string `json:"host"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ReplicaResult describes the outcome of ReplicateModel for one host.
+type ReplicaResult struct {
+	Host string
+	// Pulled reports whether the host had to pull the model.
+	Pulled bool
+	// Skipped reports that the host was left out because it is unhealthy
+	// or draining, in which case Err holds the reason.
+	Skipped bool
+	// Err is set if the host could not be given the model.
+	Err error
+}
+
+// ReplicateModel makes every healthy host serve a model, pulling it on the
+// hosts that lack it, so the cluster can take its traffic. Hosts start
+// receiving requests for the model once their pull has completed, never
+// while it runs.
+//
+// The health of all hosts is checked first, and unhealthy and draining
+// hosts are reported as skipped. Pulls run on all hosts at once; fn, which may be
+// nil, receives their progress, one update at a time. Failures on some
+// hosts do not stop the others; they are recorded in the results and
+// reflected in the returned error.
+func (cc *ClusterClient) ReplicateModel(ctx context.Context, name string, fn func(ReplicaProgress)) ([]ReplicaResult, error) {
+	if name == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+	if fn == nil {
+		fn = func(ReplicaProgress) {}
+	}
+	if err := cc.CheckHealth(ctx); err != nil {
+		return nil, fmt.Errorf("failed to replicate model %q: %w", name, err)
+	}
+
+	hosts := cc.snapshot()
+	results := make([]ReplicaResult, len(hosts))
+	var progressMu sync.Mutex
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		cc.mu.Lock()
+		healthy, draining, hostErr := h.healthy, h.draining, h.err
+		cc.mu.Unlock()
+
+		host := h.client.BaseURL()
+		results[i].Host = host
+		switch {
+		case draining:
+			results[i].Skipped, results[i].Err = true, errHostDraining
+			continue
+		case !healthy:
+			results[i].Skipped, results[i].Err = true, hostErr
+			continue
+		}
+
+		wg.Add(1)
+		go func(h *clusterHost, result *ReplicaResult) {
+			defer wg.Done()
+			result.Pulled, result.Err = h.client.EnsureModel(ctx, name, func(p PullProgress) {
+				progressMu.Lock()
+				defer progressMu.Unlock()
+				fn(ReplicaProgress{Host: host, Status: p.Status, Digest: p.Digest, Total: p.Total, Completed: p.Completed})
+			})
+			if result.Err == nil {
+				cc.mu.Lock()
+				if h.models != nil {
+					h.models[ModelName(name).Normalize()] = true
+				}
+				cc.mu.Unlock()
+			}
+		}(h, &results[i])
+	}
+	wg.Wait()
+
+	var failed, replicated int
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+		case result.Err != nil:
+			failed++
+		default:
+			replicated++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("failed to replicate model %q to %d of %d hosts", name, failed, failed+replicated)
+	}
+	return results, nil
+}
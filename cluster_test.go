@@ -0,0 +1,371 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clusterServer is a mock Ollama host that serves a set of models, answers
+// generations with its name after delay, and pulls models unless they are
+// in failPulls. While unavailable is set, it fails every request with 503.
+type clusterServer struct {
+	*httptest.Server
+	name string
+
+	mu          sync.Mutex
+	models      map[string]bool
+	failPulls   map[string]bool
+	pulls       []string
+	running     []RunningModel
+	delay       time.Duration
+	unavailable bool
+}
+
+func newClusterServer(t *testing.T, name string, models ...string) *clusterServer {
+	t.Helper()
+	s := &clusterServer{name: name, models: make(map[string]bool), failPulls: make(map[string]bool)}
+	for _, model := range models {
+		s.models[model] = true
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		unavailable := s.unavailable
+		s.mu.Unlock()
+		if unavailable {
+			http.Error(w, "loading", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.URL.Path == "/api/generate" {
+			var req GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			s.mu.Lock()
+			delay := s.delay
+			s.mu.Unlock()
+			time.Sleep(delay)
+			json.NewEncoder(w).Encode(GenerateResponse{Model: req.Model, Response: s.name, Done: true})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/tags":
+			list := ListModelsResponse{Models: []ModelResponse{}}
+			for model := range s.models {
+				list.Models = append(list.Models, ModelResponse{Name: model, Digest: "d-" + model})
+			}
+			json.NewEncoder(w).Encode(list)
+		case "/api/pull":
+			var req PullRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			s.pulls = append(s.pulls, req.Model)
+			if s.failPulls[req.Model] {
+				w.Write([]byte(`{"error":"pull model manifest: file does not exist"}` + "\n"))
+				return
+			}
+			s.models[req.Model] = true
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"status":"success"}` + "\n"))
+		case "/api/ps":
+			json.NewEncoder(w).Encode(PSResponse{Models: s.running})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// downServer returns the URL of a host that refuses connections.
+func downServer() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func TestNewClusterClient(t *testing.T) {
+	if _, err := NewClusterClient(nil); err == nil {
+		t.Error("Expected error for empty host list")
+	}
+	if _, err := NewClusterClient([]string{"http://a:11434", ""}); err == nil {
+		t.Error("Expected error for empty host")
+	}
+
+	cluster, err := NewClusterClient([]string{"http://a:11434", "http://b:11434"})
+	assertNoError(t, err)
+	hosts := cluster.Hosts()
+	if len(hosts) != 2 || hosts[0].URL != "http://a:11434" || hosts[1].URL != "http://b:11434" {
+		t.Fatalf("Expected hosts a and b, got %+v", hosts)
+	}
+	for _, host := range hosts {
+		if !host.Healthy || host.Models != nil {
+			t.Errorf("Expected unchecked healthy host, got %+v", host)
+		}
+	}
+}
+
+func TestClusterClientGenerate(t *testing.T) {
+	a := newClusterServer(t, "a", "llama2:latest")
+	b := newClusterServer(t, "b", "llama2:latest", "codellama:latest")
+	down := downServer()
+
+	cluster, err := NewClusterClient([]string{a.URL, down, b.URL})
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	// Requests are spread, failing over from the host that is down
+	served := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+		assertNoError(t, err)
+		served[resp.Response]++
+	}
+	if served["a"] == 0 || served["b"] == 0 || served["a"]+served["b"] != 10 {
+		t.Errorf("Expected requests on both healthy hosts, got %v", served)
+	}
+	if hosts := cluster.Hosts(); hosts[1].Healthy || hosts[1].Err == nil {
+		t.Errorf("Expected host that is down to be unhealthy with an error, got %+v", hosts[1])
+	}
+
+	// After a health check, requests only go to hosts serving the model
+	assertNoError(t, cluster.CheckHealth(ctx))
+	for i := 0; i < 2; i++ {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "codellama", Prompt: "hi"})
+		assertNoError(t, err)
+		if resp.Response != "b" {
+			t.Errorf("Expected codellama to be served by b, got %q", resp.Response)
+		}
+	}
+
+	_, err = cluster.Generate(ctx, &GenerateRequest{Model: "mistral", Prompt: "hi"})
+	if !errors.Is(err, ErrNoHealthyHost) {
+		t.Errorf("Expected ErrNoHealthyHost for model no host serves, got %v", err)
+	}
+	if _, err := cluster.Generate(ctx, nil); err == nil {
+		t.Error("Expected error for nil request")
+	}
+}
+
+func TestClusterClientCheckHealth(t *testing.T) {
+	cluster, err := NewClusterClient([]string{downServer(), downServer()})
+	assertNoError(t, err)
+
+	err = cluster.CheckHealth(context.Background())
+	if !errors.Is(err, ErrNoHealthyHost) {
+		t.Errorf("Expected ErrNoHealthyHost, got %v", err)
+	}
+	for _, host := range cluster.Hosts() {
+		if host.Healthy {
+			t.Errorf("Expected %s to be unhealthy", host.URL)
+		}
+	}
+}
+
+func TestClusterClientReplicateModel(t *testing.T) {
+	has := newClusterServer(t, "has", "llama2:latest")
+	lacks := newClusterServer(t, "lacks")
+	broken := newClusterServer(t, "broken")
+	broken.failPulls["llama2:latest"] = true
+	down := downServer()
+
+	cluster, err := NewClusterClient([]string{has.URL, lacks.URL, broken.URL, down})
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	progress := make(map[string]int)
+	results, err := cluster.ReplicateModel(ctx, "llama2:latest", func(p ReplicaProgress) {
+		progress[p.Host]++
+	})
+	assertErrorContains(t, err, "to 1 of 3 hosts")
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	if r := results[0]; r.Host != has.URL || r.Pulled || r.Skipped || r.Err != nil {
+		t.Errorf("Expected host with the model to be left alone, got %+v", r)
+	}
+	if r := results[1]; r.Host != lacks.URL || !r.Pulled || r.Err != nil {
+		t.Errorf("Expected host without the model to pull it, got %+v", r)
+	}
+	if r := results[2]; r.Host != broken.URL || r.Err == nil || r.Skipped {
+		t.Errorf("Expected failed pull to be reported, got %+v", r)
+	}
+	if r := results[3]; r.Host != down || !r.Skipped || r.Err == nil {
+		t.Errorf("Expected host that is down to be skipped, got %+v", r)
+	}
+	if len(has.pulls) != 0 || len(lacks.pulls) != 1 || len(broken.pulls) != 1 {
+		t.Errorf("Expected pulls only where the model is missing, got %v, %v and %v", has.pulls, lacks.pulls, broken.pulls)
+	}
+	if progress[lacks.URL] == 0 || progress[has.URL] != 0 {
+		t.Errorf("Expected progress from the pulling host only, got %v", progress)
+	}
+
+	// The model is now routed to both hosts that have it, and never to the
+	// host whose pull failed
+	served := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+		assertNoError(t, err)
+		served[resp.Response]++
+	}
+	if served["has"] == 0 || served["lacks"] == 0 || served["has"]+served["lacks"] != 10 {
+		t.Errorf("Expected requests on both replicas, got %v", served)
+	}
+}
+
+func TestClusterClientReplicateModelAllHealthy(t *testing.T) {
+	a := newClusterServer(t, "a")
+	b := newClusterServer(t, "b")
+	cluster, err := NewClusterClient([]string{a.URL, b.URL})
+	assertNoError(t, err)
+
+	results, err := cluster.ReplicateModel(context.Background(), "codellama", nil)
+	assertNoError(t, err)
+	for _, r := range results {
+		if !r.Pulled {
+			t.Errorf("Expected %s to pull the model, got %+v", r.Host, r)
+		}
+	}
+	for _, host := range cluster.Hosts() {
+		if len(host.Models) != 1 || host.Models[0] != "codellama:latest" {
+			t.Errorf("Expected %s to serve codellama:latest, got %v", host.URL, host.Models)
+		}
+	}
+
+	if _, err := cluster.ReplicateModel(context.Background(), "", nil); err == nil {
+		t.Error("Expected error for empty model name")
+	}
+}
+
+func TestClusterClientWeights(t *testing.T) {
+	fast := newClusterServer(t, "fast", "llama2:latest")
+	slow := newClusterServer(t, "slow", "llama2:latest")
+	slow.delay = 40 * time.Millisecond
+	cluster, err := NewClusterClient([]string{slow.URL, fast.URL})
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	served := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+		assertNoError(t, err)
+		served[resp.Response]++
+	}
+	if served["slow"] == 0 || served["fast"] <= 2*served["slow"] {
+		t.Errorf("Expected the fast host to take most requests, got %v", served)
+	}
+
+	hosts := cluster.Hosts()
+	if hosts[0].Latency < slow.delay || hosts[1].Latency >= slow.delay {
+		t.Errorf("Expected latencies to reflect the delay, got %v and %v", hosts[0].Latency, hosts[1].Latency)
+	}
+	if hosts[0].Weight >= hosts[1].Weight {
+		t.Errorf("Expected the slow host to weigh less, got %v and %v", hosts[0].Weight, hosts[1].Weight)
+	}
+	if hosts[0].InFlight != 0 || hosts[1].InFlight != 0 {
+		t.Errorf("Expected no requests in flight, got %d and %d", hosts[0].InFlight, hosts[1].InFlight)
+	}
+}
+
+func TestClusterClientWeightsGPU(t *testing.T) {
+	gpu := newClusterServer(t, "gpu", "llama2:latest")
+	gpu.running = []RunningModel{{Name: "llama2:latest", Size: 4000, SizeVRAM: 4000}}
+	cpu := newClusterServer(t, "cpu", "llama2:latest")
+	cpu.running = []RunningModel{{Name: "llama2:latest", Size: 4000, SizeVRAM: 1000}}
+	idle := newClusterServer(t, "idle", "llama2:latest")
+
+	cluster, err := NewClusterClient([]string{gpu.URL, cpu.URL, idle.URL})
+	assertNoError(t, err)
+	assertNoError(t, cluster.CheckHealth(context.Background()))
+
+	hosts := cluster.Hosts()
+	if hosts[0].VRAM != 4000 || hosts[0].GPUFraction != 1 {
+		t.Errorf("Expected full GPU offload on gpu host, got %d and %v", hosts[0].VRAM, hosts[0].GPUFraction)
+	}
+	if hosts[1].VRAM != 1000 || hosts[1].GPUFraction != 0.25 {
+		t.Errorf("Expected quarter GPU offload on cpu host, got %d and %v", hosts[1].VRAM, hosts[1].GPUFraction)
+	}
+	if hosts[2].GPUFraction != 0 {
+		t.Errorf("Expected unknown GPU offload on idle host, got %v", hosts[2].GPUFraction)
+	}
+	if hosts[0].Weight != 4*hosts[1].Weight || hosts[2].Weight != hosts[0].Weight {
+		t.Errorf("Expected weights in proportion to GPU offload, got %v, %v and %v", hosts[0].Weight, hosts[1].Weight, hosts[2].Weight)
+	}
+}
+
+// setUnavailable sets whether s fails every request.
+func (s *clusterServer) setUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+func TestClusterClientSessionAffinity(t *testing.T) {
+	servers := map[string]*clusterServer{}
+	var urls []string
+	for _, name := range []string{"a", "b", "c"} {
+		servers[name] = newClusterServer(t, name, "llama2:latest")
+		urls = append(urls, servers[name].URL)
+	}
+	cluster, err := NewClusterClient(urls)
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	serve := func(session string) string {
+		t.Helper()
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"}, WithSessionID(session))
+		assertNoError(t, err)
+		return resp.Response
+	}
+
+	// Each session sticks to one host, and sessions are spread over hosts
+	sessions := []string{"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9"}
+	home := make(map[string]string)
+	used := make(map[string]bool)
+	for _, session := range sessions {
+		home[session] = serve(session)
+		used[home[session]] = true
+		for i := 0; i < 3; i++ {
+			if host := serve(session); host != home[session] {
+				t.Fatalf("Expected session %s to stay on %s, got %s", session, home[session], host)
+			}
+		}
+	}
+	if len(used) < 2 {
+		t.Errorf("Expected sessions on several hosts, got %v", home)
+	}
+
+	// Sessions of a failed host move, the others stay put
+	failed := home["s0"]
+	servers[failed].setUnavailable(true)
+	moved := make(map[string]string)
+	for _, session := range sessions {
+		host := serve(session)
+		switch {
+		case home[session] == failed && host == failed:
+			t.Errorf("Expected session %s to leave unhealthy host %s", session, failed)
+		case home[session] != failed && host != home[session]:
+			t.Errorf("Expected session %s to stay on %s, got %s", session, home[session], host)
+		}
+		moved[session] = host
+	}
+	if host := serve("s0"); host != moved["s0"] {
+		t.Errorf("Expected moved session to stay on %s, got %s", moved["s0"], host)
+	}
+
+	// Once the host is healthy again, its sessions return
+	servers[failed].setUnavailable(false)
+	assertNoError(t, cluster.CheckHealth(ctx))
+	for _, session := range sessions {
+		if host := serve(session); host != home[session] {
+			t.Errorf("Expected session %s back on %s, got %s", session, home[session], host)
+		}
+	}
+}
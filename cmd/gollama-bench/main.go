@@ -0,0 +1,92 @@
+// Command gollama-bench measures the throughput and latency of a model.
+//
+// It runs every combination of the given concurrencies and prompt sizes and
+// prints one line per run:
+//
+//	gollama-bench -model llama3 -requests 20 -concurrency 1,4,8 -prompt-words 100,2000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/astrica1/gollama"
+	"github.com/astrica1/gollama/bench"
+)
+
+func main() {
+	host := flag.String("host", "", "Ollama server URL (default $OLLAMA_HOST or http://localhost:11434)")
+	model := flag.String("model", "", "model to benchmark")
+	requests := flag.Int("requests", 10, "requests per run")
+	concurrency := flag.String("concurrency", "1", "comma-separated concurrencies to run")
+	promptWords := flag.String("prompt-words", "100", "comma-separated prompt sizes in words to run")
+	maxTokens := flag.Int("max-tokens", 128, "maximum tokens to generate per request, 0 for no limit")
+	flag.Parse()
+
+	if *model == "" {
+		fmt.Fprintln(os.Stderr, "gollama-bench: -model is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	concurrencies, err := parseInts(*concurrency)
+	if err != nil {
+		log.Fatalf("Invalid -concurrency: %v", err)
+	}
+	sizes, err := parseInts(*promptWords)
+	if err != nil {
+		log.Fatalf("Invalid -prompt-words: %v", err)
+	}
+
+	var hosts []string
+	if *host != "" {
+		hosts = append(hosts, *host)
+	}
+	client, err := gollama.NewClient(hosts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, size := range sizes {
+		for _, n := range concurrencies {
+			result, err := bench.Run(ctx, client, bench.Config{
+				Model:       *model,
+				Requests:    *requests,
+				Concurrency: n,
+				PromptWords: size,
+				MaxTokens:   *maxTokens,
+			})
+			if err != nil {
+				log.Fatalf("Benchmark interrupted: %v", err)
+			}
+			fmt.Println(result)
+			if result.FirstError != nil {
+				fmt.Printf("  first error: %v\n", result.FirstError)
+			}
+		}
+	}
+}
+
+// parseInts parses a comma-separated list of positive integers.
+func parseInts(s string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		if v <= 0 {
+			return nil, fmt.Errorf("%d is not positive", v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
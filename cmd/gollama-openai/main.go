@@ -0,0 +1,33 @@
+// Command gollama-openai serves the OpenAI API on top of an Ollama server.
+//
+//	gollama-openai -host http://gpu-box:11434 -addr :8080
+//
+// Point OpenAI tools at http://localhost:8080/v1 and use Ollama model names.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/astrica1/gollama"
+	"github.com/astrica1/gollama/gollamaopenai"
+)
+
+func main() {
+	host := flag.String("host", "", "Ollama server URL (default $OLLAMA_HOST or http://localhost:11434)")
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	var hosts []string
+	if *host != "" {
+		hosts = append(hosts, *host)
+	}
+	client, err := gollama.NewClient(hosts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	log.Printf("Serving the OpenAI API for %s on %s", client.BaseURL(), *addr)
+	log.Fatal(http.ListenAndServe(*addr, gollamaopenai.New(client)))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/astrica1/gollama"
+	"github.com/astrica1/gollama/repl"
+)
+
+// generate streams a completion for a prompt.
+func (c *cli) generate(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("generate")
+	model := flags.String("model", "", "model to use")
+	format := flags.String("format", "", `output format, e.g. "json"`)
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	prompt, err := c.input(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	req := &gollama.GenerateRequest{Model: *model, Prompt: prompt}
+	if *format != "" {
+		req.Format = *format
+	}
+
+	if c.json {
+		resp, err := c.client.Generate(ctx, req)
+		if err != nil {
+			return err
+		}
+		return c.printJSON(resp)
+	}
+
+	err = c.client.GenerateStream(ctx, req, func(resp *gollama.GenerateResponse) {
+		fmt.Fprint(c.stdout, resp.Response)
+	})
+	fmt.Fprintln(c.stdout)
+	return err
+}
+
+// chat streams the reply to a single chat message, or starts an interactive
+// chat if no message is given on a terminal.
+func (c *cli) chat(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("chat")
+	model := flags.String("model", "", "model to use")
+	system := flags.String("system", "", "system prompt")
+	interactive := flags.Bool("i", false, "start an interactive chat")
+	load := flags.String("load", "", "resume an interactive chat saved with /save")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	if *interactive || *load != "" || (flags.NArg() == 0 && isTerminal(c.stdin)) {
+		session := gollama.NewChatSession(c.client, *model)
+		session.System = *system
+		if *load != "" {
+			f, err := os.Open(*load)
+			if err != nil {
+				return err
+			}
+			err = session.Load(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return repl.Run(ctx, session, c.stdin, c.stdout)
+	}
+
+	message, err := c.input(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	var messages []gollama.Message
+	if *system != "" {
+		messages = append(messages, gollama.Message{Role: "system", Content: *system})
+	}
+	messages = append(messages, gollama.Message{Role: "user", Content: message})
+	req := &gollama.ChatRequest{Model: *model, Messages: messages}
+
+	if c.json {
+		resp, err := c.client.Chat(ctx, req)
+		if err != nil {
+			return err
+		}
+		return c.printJSON(resp)
+	}
+
+	err = c.client.ChatStream(ctx, req, func(resp *gollama.ChatResponse) {
+		fmt.Fprint(c.stdout, resp.Message.Content)
+	})
+	fmt.Fprintln(c.stdout)
+	return err
+}
+
+// embed prints the embedding of a text as a JSON array.
+func (c *cli) embed(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("embed")
+	model := flags.String("model", "", "model to use")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	text, err := c.input(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Embeddings(ctx, &gollama.EmbeddingRequest{Model: *model, Prompt: text})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	return c.printJSON(resp.Embedding)
+}
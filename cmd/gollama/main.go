@@ -0,0 +1,177 @@
+// Command gollama is a command-line client for Ollama servers built on the
+// gollama library.
+//
+// Usage:
+//
+//	gollama [-host URL] [-json] <command> [arguments]
+//
+// The commands are:
+//
+//	list      list local models
+//	show      show details of a model
+//	pull      download a model from the registry
+//	push      upload a model to the registry
+//	delete    remove models
+//	export    write a model to a tar archive
+//	import    load the models of a tar archive
+//	generate  generate a completion for a prompt
+//	chat      send a chat message or chat interactively
+//	embed     print the embedding of a text
+//
+// With -json, commands print the API responses as JSON instead of text, and
+// pull, push, export and import print one progress object per line.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/astrica1/gollama"
+)
+
+// cli holds the state shared by the commands.
+type cli struct {
+	client *gollama.Client
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	usage string
+	short string
+	run   func(c *cli, ctx context.Context, args []string) error
+}
+
+// commands maps command names to their implementation. It is set in init
+// because the commands refer to it for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"list":     {"list", "list local models", (*cli).list},
+		"show":     {"show MODEL", "show details of a model", (*cli).show},
+		"pull":     {"pull MODEL", "download a model from the registry", (*cli).pull},
+		"push":     {"push MODEL", "upload a model to the registry", (*cli).push},
+		"delete":   {"delete MODEL...", "remove models", (*cli).delete},
+		"export":   {"export MODEL FILE", "write a model to a tar archive", (*cli).export},
+		"import":   {"import [-name NAME] FILE", "load the models of a tar archive", (*cli).importArchive},
+		"generate": {"generate [-model MODEL] [PROMPT]", "generate a completion for a prompt", (*cli).generate},
+		"chat":     {"chat [-model MODEL] [-system PROMPT] [-i] [-load FILE] [MESSAGE]", "send a chat message or chat interactively", (*cli).chat},
+		"embed":    {"embed [-model MODEL] [TEXT]", "print the embedding of a text", (*cli).embed},
+	}
+}
+
+// errUsage is returned for invalid command lines after usage was printed.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gollama: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and runs the command.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("gollama", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	host := flags.String("host", "", "Ollama server URL (default $OLLAMA_HOST or http://localhost:11434)")
+	jsonOutput := flags.Bool("json", false, "print JSON instead of text")
+	flags.Usage = func() { usage(stderr, flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	if flags.NArg() == 0 {
+		usage(stderr, flags)
+		return errUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "gollama: unknown command %q\n", flags.Arg(0))
+		usage(stderr, flags)
+		return errUsage
+	}
+
+	var hosts []string
+	if *host != "" {
+		hosts = append(hosts, *host)
+	}
+	client, err := gollama.NewClient(hosts...)
+	if err != nil {
+		return err
+	}
+
+	c := &cli{client: client, json: *jsonOutput, stdin: stdin, stdout: stdout, stderr: stderr}
+	return cmd.run(c, ctx, flags.Args()[1:])
+}
+
+// usage prints the top-level help.
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: gollama [-host URL] [-json] <command> [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s%s\n", name, commands[name].short)
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+// newFlagSet creates the flag set of a command.
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: gollama %s\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the arguments of a command, mapping parse errors to errUsage.
+func (c *cli) parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// input returns the arguments joined by spaces, or standard input if there
+// are none.
+func (c *cli) input(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		switch r.URL.Path {
+		case "/api/tags":
+			enc.Encode(gollama.ListModelsResponse{Models: []gollama.ModelResponse{{
+				Name:       "llama2:latest",
+				Size:       3825819519,
+				Digest:     "sha256:1a838c4c0b7b",
+				ModifiedAt: time.Now().Add(-2 * time.Hour),
+			}}})
+		case "/api/pull":
+			enc.Encode(gollama.PullProgress{Status: "pulling manifest"})
+			enc.Encode(gollama.PullProgress{Status: "pulling", Digest: "sha256:aaaa", Total: 100, Completed: 50})
+			enc.Encode(gollama.PullProgress{Status: "pulling", Digest: "sha256:aaaa", Total: 100, Completed: 100})
+			enc.Encode(gollama.PullProgress{Status: "success"})
+		case "/api/generate":
+			var req gollama.GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.Stream {
+				enc.Encode(gollama.GenerateResponse{Model: req.Model, Response: "echo: " + req.Prompt, Done: true})
+				return
+			}
+			enc.Encode(gollama.GenerateResponse{Response: "echo: "})
+			enc.Encode(gollama.GenerateResponse{Response: req.Prompt, Done: true})
+		case "/api/chat":
+			var req gollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			last := req.Messages[len(req.Messages)-1].Content
+			enc.Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: "echo: " + last}, Done: true})
+		case "/api/delete":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRun(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		stdout []string
+		stderr []string
+	}{
+		{
+			name:   "List",
+			args:   []string{"list"},
+			stdout: []string{"NAME", "llama2:latest", "1a838c4c0b7b", "3.8 GB", "2 hours ago"},
+		},
+		{
+			name:   "List as JSON",
+			args:   []string{"-json", "list"},
+			stdout: []string{`"name": "llama2:latest"`},
+		},
+		{
+			name:   "Pull",
+			args:   []string{"pull", "llama2"},
+			stderr: []string{"pulling manifest\n", "pulling aaaa\n", "success\n"},
+		},
+		{
+			name:   "Pull as JSON",
+			args:   []string{"-json", "pull", "llama2"},
+			stdout: []string{`"completed":50`, `"status":"success"`},
+		},
+		{
+			name:   "Generate streams",
+			args:   []string{"generate", "-model", "llama2", "hello", "world"},
+			stdout: []string{"echo: hello world\n"},
+		},
+		{
+			name:   "Generate reads stdin",
+			args:   []string{"-json", "generate", "-model", "llama2"},
+			stdin:  "from stdin\n",
+			stdout: []string{`"response": "echo: from stdin"`},
+		},
+		{
+			name:   "Chat",
+			args:   []string{"chat", "-model", "llama2", "hi"},
+			stdout: []string{"echo: hi\n"},
+		},
+		{
+			name:   "Interactive chat",
+			args:   []string{"chat", "-model", "llama2", "-i"},
+			stdin:  "hi\n/model\n",
+			stdout: []string{">>> echo: hi\n", "model: llama2"},
+		},
+		{
+			name:   "Delete dry run",
+			args:   []string{"delete", "-dry-run", "llama*", "llama2"},
+			stdout: []string{"would delete llama2:latest\n"},
+		},
+		{
+			name:   "Delete",
+			args:   []string{"delete", "llama2"},
+			stdout: []string{"deleted llama2:latest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-host", server.URL}, tt.args...)
+			if err := run(ctx, args, strings.NewReader(tt.stdin), &stdout, &stderr); err != nil {
+				t.Fatalf("Expected no error, got %v (stderr: %s)", err, stderr.String())
+			}
+			for _, want := range tt.stdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected stdout to contain %q, got %q", want, stdout.String())
+				}
+			}
+			for _, want := range tt.stderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("Expected stderr to contain %q, got %q", want, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	var stdout, stderr bytes.Buffer
+	if err := run(ctx, []string{"frobnicate"}, nil, &stdout, &stderr); err != errUsage {
+		t.Errorf("Expected errUsage for unknown command, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("Expected unknown command message, got %q", stderr.String())
+	}
+
+	if err := run(ctx, []string{"-host", server.URL, "show"}, nil, &stdout, &stderr); err != errUsage {
+		t.Errorf("Expected errUsage for missing model, got %v", err)
+	}
+
+	err := run(ctx, []string{"-host", server.URL, "delete", "mistral"}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "no models match") {
+		t.Errorf("Expected no match error, got %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512 B"},
+		{1500, "1.5 kB"},
+		{3825819519, "3.8 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, expected %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// list prints the local models.
+func (c *cli) list(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("list")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+
+	models, err := c.client.List(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(models)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tSIZE\tMODIFIED")
+	for _, model := range models.Models {
+		id := strings.TrimPrefix(model.Digest, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", model.Name, id, formatBytes(model.Size), formatAge(time.Since(model.ModifiedAt)))
+	}
+	return w.Flush()
+}
+
+// show prints the details of a model.
+func (c *cli) show(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("show")
+	verbose := flags.Bool("verbose", false, "include large model info entries")
+	modelfile := flags.Bool("modelfile", false, "print only the Modelfile")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	model, err := c.client.ShowWithOptions(ctx, flags.Arg(0), &gollama.ShowOptions{Verbose: *verbose})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(model)
+	}
+	if *modelfile {
+		fmt.Fprintln(c.stdout, model.Modelfile)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 3, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s\t%s\n", name, value)
+		}
+	}
+	field("family", model.Details.Family)
+	field("parameters", model.Details.ParameterSize)
+	field("quantization", model.Details.QuantizationLevel)
+	field("format", model.Details.Format)
+	field("capabilities", strings.Join(model.Capabilities, ", "))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if model.Parameters != "" {
+		fmt.Fprintf(c.stdout, "\nParameters:\n%s\n", indent(model.Parameters))
+	}
+	if model.System != "" {
+		fmt.Fprintf(c.stdout, "\nSystem:\n%s\n", indent(model.System))
+	}
+	if model.License != "" {
+		license := model.License
+		if i := strings.IndexByte(license, '\n'); i >= 0 {
+			license = license[:i]
+		}
+		fmt.Fprintf(c.stdout, "\nLicense:\n%s\n", indent(license))
+	}
+	return nil
+}
+
+// pull downloads a model and shows its progress.
+func (c *cli) pull(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("pull")
+	insecure := flags.Bool("insecure", false, "allow insecure connections to the registry")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	progress := c.newProgress()
+	err := c.client.PullWithOptions(ctx, flags.Arg(0), &gollama.PullOptions{Insecure: *insecure}, func(p gollama.PullProgress) {
+		progress.update(p, p.Status, p.Digest, p.Completed, p.Total)
+	})
+	progress.done()
+	return err
+}
+
+// push uploads a model and shows its progress.
+func (c *cli) push(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("push")
+	insecure := flags.Bool("insecure", false, "allow insecure connections to the registry")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	progress := c.newProgress()
+	err := c.client.PushWithOptions(ctx, flags.Arg(0), &gollama.PushOptions{Insecure: *insecure}, func(p gollama.PushProgress) {
+		progress.update(p, p.Status, p.Digest, p.Completed, p.Total)
+	})
+	progress.done()
+	return err
+}
+
+// export writes a model to a tar archive and shows its progress.
+func (c *cli) export(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("export")
+	registry := flags.Bool("registry", false, "download the model from its registry instead of the models directory")
+	modelsDir := flags.String("models", "", "models directory of the server (default $OLLAMA_MODELS or ~/.ollama/models)")
+	insecure := flags.Bool("insecure", false, "allow insecure connections to the registry")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errUsage
+	}
+
+	f, err := os.Create(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	progress := c.newProgress()
+	opts := &gollama.ExportOptions{ModelsDir: *modelsDir, FromRegistry: *registry, Insecure: *insecure}
+	err = c.client.ExportModelWithOptions(ctx, flags.Arg(0), f, opts, func(p gollama.ArchiveProgress) {
+		// The status already names the blob
+		progress.update(p, p.Status, "", p.Completed, p.Total)
+	})
+	progress.done()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(flags.Arg(1))
+	}
+	return err
+}
+
+// importArchive loads the models of a tar archive and shows its progress.
+func (c *cli) importArchive(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("import")
+	name := flags.String("name", "", "name of the imported model (default: the exported name)")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	progress := c.newProgress()
+	err = c.client.ImportModelWithOptions(ctx, f, &gollama.ImportOptions{Name: *name}, func(p gollama.ArchiveProgress) {
+		progress.update(p, p.Status, "", p.Completed, p.Total)
+	})
+	progress.done()
+	return err
+}
+
+// delete removes models. Arguments may be glob patterns.
+func (c *cli) delete(ctx context.Context, args []string) error {
+	flags := c.newFlagSet("delete")
+	dryRun := flags.Bool("dry-run", false, "list the models that would be removed without removing them")
+	if err := c.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	var report *gollama.DeleteReport
+	var err error
+	if *dryRun {
+		report, err = c.dryRunDelete(ctx, flags.Args())
+	} else {
+		report, err = c.client.DeleteAll(ctx, flags.Args())
+	}
+	if report == nil {
+		return err
+	}
+	if c.json {
+		errs := make(map[string]string, len(report.Errors))
+		for name, err := range report.Errors {
+			errs[name] = err.Error()
+		}
+		if jsonErr := c.printJSON(map[string]interface{}{
+			"deleted":     report.Deleted,
+			"freed_bytes": report.FreedBytes,
+			"errors":      errs,
+			"dry_run":     report.DryRun,
+		}); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+
+	verb := "deleted"
+	if report.DryRun {
+		verb = "would delete"
+	}
+	for _, name := range report.Deleted {
+		fmt.Fprintf(c.stdout, "%s %s\n", verb, name)
+	}
+	for name, err := range report.Errors {
+		fmt.Fprintf(c.stderr, "failed to delete %s: %v\n", name, err)
+	}
+	if len(report.Deleted) == 0 && err == nil {
+		return fmt.Errorf("no models match %s", strings.Join(flags.Args(), ", "))
+	}
+	return err
+}
+
+// dryRunDelete combines the dry-run reports of several patterns, counting
+// models matched by more than one pattern once.
+func (c *cli) dryRunDelete(ctx context.Context, patterns []string) (*gollama.DeleteReport, error) {
+	combined := &gollama.DeleteReport{Errors: make(map[string]error), DryRun: true}
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		report, err := c.client.DeleteMatching(ctx, pattern, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range report.Deleted {
+			if !seen[name] {
+				seen[name] = true
+				combined.Deleted = append(combined.Deleted, name)
+			}
+		}
+	}
+	if len(combined.Deleted) > 0 {
+		// Sizes come from the listing, which each report repeats
+		models, err := c.client.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range models.Models {
+			if seen[model.Name] {
+				combined.FreedBytes += model.Size
+			}
+		}
+	}
+	return combined, nil
+}
+
+// printJSON writes v as indented JSON to standard output.
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// indent indents every line of s by four spaces.
+func indent(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatBytes formats a byte count with a decimal unit, as Ollama does.
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// formatAge formats a duration as a coarse relative time.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	default:
+		return plural(int(d/(30*24*time.Hour)), "month") + " ago"
+	}
+}
+
+// plural formats a count with a unit, adding an s if needed.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// progressWidth is the width of a progress bar in characters.
+const progressWidth = 30
+
+// progress renders pull and push progress. On a terminal it redraws a bar
+// in place; otherwise it prints each new status on its own line. In JSON
+// mode it prints every progress object.
+type progress struct {
+	w      io.Writer
+	json   bool
+	tty    bool
+	status string
+	drawn  bool
+}
+
+// newProgress creates a progress renderer writing to standard error, or to
+// standard output in JSON mode.
+func (c *cli) newProgress() *progress {
+	if c.json {
+		return &progress{w: c.stdout, json: true}
+	}
+	return &progress{w: c.stderr, tty: isTerminal(c.stderr)}
+}
+
+// update renders a progress event. v is the raw event for JSON output.
+func (p *progress) update(v interface{}, status, digest string, completed, total int64) {
+	if p.json {
+		json.NewEncoder(p.w).Encode(v)
+		return
+	}
+
+	label := status
+	if digest != "" && !strings.Contains(status, digest) {
+		label = status + " " + shortDigest(digest)
+	}
+
+	if total <= 0 || !p.tty {
+		if label != p.status {
+			p.finishLine()
+			fmt.Fprintln(p.w, label)
+			p.status = label
+		}
+		return
+	}
+
+	if label != p.status {
+		p.finishLine()
+		p.status = label
+	}
+	if completed > total {
+		completed = total
+	}
+	filled := int(completed * progressWidth / total)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+	fmt.Fprintf(p.w, "\r%s [%s] %3d%% %s/%s", label, bar, completed*100/total, formatBytes(completed), formatBytes(total))
+	p.drawn = true
+}
+
+// finishLine ends a bar that was drawn in place.
+func (p *progress) finishLine() {
+	if p.drawn {
+		fmt.Fprintln(p.w)
+		p.drawn = false
+	}
+}
+
+// done ends the output of the progress renderer.
+func (p *progress) done() {
+	p.finishLine()
+}
+
+// shortDigest shortens a digest for display.
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return digest
+}
+
+// isTerminal reports whether v, a reader or writer, is a terminal.
+func isTerminal(v interface{}) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,150 @@
+package gollama
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Coalescer buffers streamed text and passes it on in larger segments: whole
+// words, whole sentences, or whatever arrived within a time interval. This
+// suits consumers that cannot handle per-token updates, such as speech
+// synthesis, subtitles or rate-limited UI updates.
+//
+// Segments keep their trailing whitespace, so concatenating them yields the
+// original text. A Coalescer is not safe for concurrent use.
+//
+// Example:
+//
+//	speak := gollama.NewSentenceCoalescer(func(sentence string) {
+//		tts.Say(sentence)
+//	})
+//	err := client.GenerateStream(ctx, req, speak.GenerateFunc())
+type Coalescer struct {
+	emit     func(string)
+	boundary func(string) int
+	interval time.Duration
+	last     time.Time
+	buf      strings.Builder
+}
+
+// NewWordCoalescer returns a Coalescer that emits whole words, each with the
+// whitespace that follows it.
+func NewWordCoalescer(emit func(string)) *Coalescer {
+	return &Coalescer{emit: emit, boundary: nextWordBoundary}
+}
+
+// NewSentenceCoalescer returns a Coalescer that emits whole sentences. A
+// sentence ends at '.', '!', '?' or '…', optionally followed by closing
+// quotes or brackets, and then whitespace; a line break also ends one.
+func NewSentenceCoalescer(emit func(string)) *Coalescer {
+	return &Coalescer{emit: emit, boundary: nextSentenceBoundary}
+}
+
+// NewIntervalCoalescer returns a Coalescer that emits the buffered text at
+// most once per interval. Text is emitted when a write arrives at least
+// interval after the previous emission, and by Flush.
+func NewIntervalCoalescer(interval time.Duration, emit func(string)) *Coalescer {
+	return &Coalescer{emit: emit, interval: interval, last: time.Now()}
+}
+
+// Write adds a chunk of text and emits any segments it completes.
+func (c *Coalescer) Write(text string) {
+	c.buf.WriteString(text)
+
+	if c.boundary == nil {
+		if now := time.Now(); now.Sub(c.last) >= c.interval {
+			c.last = now
+			c.Flush()
+		}
+		return
+	}
+
+	buffered := c.buf.String()
+	rest := buffered
+	for {
+		cut := c.boundary(rest)
+		if cut <= 0 {
+			break
+		}
+		c.emit(rest[:cut])
+		rest = rest[cut:]
+	}
+	if len(rest) < len(buffered) {
+		c.buf.Reset()
+		c.buf.WriteString(rest)
+	}
+}
+
+// Flush emits any buffered text, such as a final sentence without trailing
+// whitespace. It should be called when the stream ends.
+func (c *Coalescer) Flush() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	text := c.buf.String()
+	c.buf.Reset()
+	c.emit(text)
+}
+
+// GenerateFunc returns a GenerateStream callback that writes each chunk to
+// the Coalescer and flushes it when the generation is done.
+func (c *Coalescer) GenerateFunc() func(*GenerateResponse) {
+	return func(resp *GenerateResponse) {
+		c.Write(resp.Response)
+		if resp.Done {
+			c.Flush()
+		}
+	}
+}
+
+// ChatFunc returns a ChatStream callback that writes each chunk to the
+// Coalescer and flushes it when the response is done.
+func (c *Coalescer) ChatFunc() func(*ChatResponse) {
+	return func(resp *ChatResponse) {
+		c.Write(resp.Message.Content)
+		if resp.Done {
+			c.Flush()
+		}
+	}
+}
+
+// nextWordBoundary returns the offset just past the whitespace that follows
+// the first word in text, or 0 if there is none.
+func nextWordBoundary(text string) int {
+	inWord := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if inWord {
+				return i + utf8.RuneLen(r)
+			}
+			continue
+		}
+		inWord = true
+	}
+	return 0
+}
+
+// nextSentenceBoundary returns the offset just past the whitespace that ends
+// the first sentence in text, or 0 if there is none.
+func nextSentenceBoundary(text string) int {
+	terminated := false
+	for i, r := range text {
+		switch {
+		case r == '\n':
+			return i + 1
+		case unicode.IsSpace(r):
+			if terminated {
+				return i + utf8.RuneLen(r)
+			}
+		case r == '.' || r == '!' || r == '?' || r == '…':
+			terminated = true
+		case terminated && strings.ContainsRune(`"')]»”’`, r):
+			// Closing quotes and brackets belong to the sentence
+		default:
+			terminated = false
+		}
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package gollama
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoalescer(t *testing.T) {
+	chunks := []string{"He", "llo the", "re. It", "'s 3", ".14 today", "! Isn't", " it", "?\" she asked.", "\nNext line", " without end"}
+	original := strings.Join(chunks, "")
+
+	tests := []struct {
+		name     string
+		new      func(func(string)) *Coalescer
+		expected []string
+	}{
+		{
+			name: "Words",
+			new:  NewWordCoalescer,
+			expected: []string{"Hello ", "there. ", "It's ", "3.14 ", "today! ", "Isn't ", "it?\" ", "she ",
+				"asked.\n", "Next ", "line ", "without ", "end"},
+		},
+		{
+			name:     "Sentences",
+			new:      NewSentenceCoalescer,
+			expected: []string{"Hello there. ", "It's 3.14 today! ", "Isn't it?\" ", "she asked.\n", "Next line without end"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var segments []string
+			c := tt.new(func(s string) {
+				segments = append(segments, s)
+			})
+			for _, chunk := range chunks {
+				c.Write(chunk)
+			}
+			c.Flush()
+
+			if strings.Join(segments, "") != original {
+				t.Errorf("Expected segments to add up to the original text, got %q", segments)
+			}
+			if !reflect.DeepEqual(segments, tt.expected) {
+				t.Errorf("Expected segments %q, got %q", tt.expected, segments)
+			}
+		})
+	}
+}
+
+func TestIntervalCoalescer(t *testing.T) {
+	var segments []string
+	c := NewIntervalCoalescer(30*time.Millisecond, func(s string) {
+		segments = append(segments, s)
+	})
+
+	for _, chunk := range []string{"a", "b", "c"} {
+		c.Write(chunk)
+	}
+	if len(segments) != 0 {
+		t.Errorf("Expected no segments before the interval elapsed, got %q", segments)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	c.Write("d")
+	c.Write("e")
+	c.Flush()
+
+	expected := []string{"abcd", "e"}
+	if !reflect.DeepEqual(segments, expected) {
+		t.Errorf("Expected segments %q, got %q", expected, segments)
+	}
+}
+
+func TestCoalescerGenerateFunc(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var segments []string
+	c := NewWordCoalescer(func(s string) {
+		segments = append(segments, s)
+	})
+
+	var direct strings.Builder
+	fn := c.GenerateFunc()
+	err = client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(resp *GenerateResponse) {
+		direct.WriteString(resp.Response)
+		fn(resp)
+	})
+	assertNoError(t, err)
+
+	if strings.Join(segments, "") != direct.String() {
+		t.Errorf("Expected coalesced text %q, got %q", direct.String(), strings.Join(segments, ""))
+	}
+}
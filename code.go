@@ -0,0 +1,70 @@
+package gollama
+
+import "context"
+
+// CodeOptions holds optional settings for CompleteCode.
+type CodeOptions struct {
+	// MaxTokens limits the length of the completion. Defaults to 256.
+	MaxTokens int
+	// SingleLine stops the completion at the end of the current line, as
+	// editors do for inline suggestions.
+	SingleLine bool
+	// Stop adds stop sequences to the defaults.
+	Stop []string
+	// Options are model options layered over the defaults of
+	// CompleteCode.
+	Options Options
+	// RequestOptions are applied to the request.
+	RequestOptions []RequestOption
+}
+
+// fimStopSequences are the fill-in-the-middle control tokens of common code
+// models. A model that emits one of them has finished the middle part.
+var fimStopSequences = []string{
+	"<|fim_prefix|>", "<|fim_suffix|>", "<|fim_middle|>", "<|fim_pad|>",
+	"<fim_prefix>", "<fim_suffix>", "<fim_middle>",
+	"<|endoftext|>", "<|file_separator|>", "<EOT>",
+}
+
+// CompleteCode returns the code that belongs between prefix and suffix,
+// using the fill-in-the-middle support of code models such as codellama,
+// qwen2.5-coder or starcoder2. It uses a low temperature and stops at the
+// models' control tokens. An empty model uses the client's default model.
+//
+// Example:
+//
+//	middle, err := client.CompleteCode(ctx, "qwen2.5-coder", "func add(a, b int) int {\n\t", "\n}", nil)
+func (c *Client) CompleteCode(ctx context.Context, model, prefix, suffix string, opts *CodeOptions) (string, error) {
+	if opts == nil {
+		opts = &CodeOptions{}
+	}
+
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+	stop := append(append([]string{}, fimStopSequences...), opts.Stop...)
+	if opts.SingleLine {
+		stop = append(stop, "\n")
+	}
+
+	options := Options{
+		"temperature": 0.2,
+		"num_predict": maxTokens,
+		"stop":        stop,
+	}
+	for k, v := range opts.Options {
+		options[k] = v
+	}
+
+	resp, err := c.Generate(ctx, &GenerateRequest{
+		Model:   model,
+		Prompt:  prefix,
+		Suffix:  suffix,
+		Options: options,
+	}, opts.RequestOptions...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Response, nil
+}
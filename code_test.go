@@ -0,0 +1,53 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientCompleteCode(t *testing.T) {
+	var got GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "return a + b", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	middle, err := client.CompleteCode(ctx, "qwen2.5-coder", "func add(a, b int) int {\n\t", "\n}", nil)
+	assertNoError(t, err)
+	if middle != "return a + b" {
+		t.Errorf("Expected completion, got %q", middle)
+	}
+	if got.Prompt != "func add(a, b int) int {\n\t" || got.Suffix != "\n}" {
+		t.Errorf("Expected prefix and suffix to be sent, got %q and %q", got.Prompt, got.Suffix)
+	}
+	if got.Options["temperature"].(json.Number).String() != "0.2" || got.Options["num_predict"].(json.Number).String() != "256" {
+		t.Errorf("Expected default options, got %v", got.Options)
+	}
+	stop, _ := got.Options["stop"].([]interface{})
+	if len(stop) != len(fimStopSequences) {
+		t.Errorf("Expected FIM stop sequences, got %v", stop)
+	}
+
+	_, err = client.CompleteCode(ctx, "qwen2.5-coder", "x := ", "", &CodeOptions{
+		MaxTokens:  32,
+		SingleLine: true,
+		Stop:       []string{";"},
+		Options:    Options{"temperature": 0},
+	})
+	assertNoError(t, err)
+	stop, _ = got.Options["stop"].([]interface{})
+	if len(stop) != len(fimStopSequences)+2 || stop[len(stop)-1] != "\n" || stop[len(stop)-2] != ";" {
+		t.Errorf("Expected extra stop sequences, got %v", stop)
+	}
+	if got.Options["temperature"].(json.Number).String() != "0" || got.Options["num_predict"].(json.Number).String() != "32" {
+		t.Errorf("Expected options to be overridden, got %v", got.Options)
+	}
+}
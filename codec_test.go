@@ -0,0 +1,167 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	think := true
+	session := ChatSession{
+		Model:  "llama3",
+		System: "be brief",
+		Messages: []Message{
+			{Role: "user", Content: "Hi", Images: []string{"aGVsbG8="}},
+			{Role: "assistant", Content: strings.Repeat("long reply ", 40), ToolCalls: []ToolCall{
+				{Function: ToolCallFunction{Name: "lookup", Arguments: map[string]interface{}{"city": "Oslo", "days": 3.0}}},
+			}},
+		},
+		Options: Options{"temperature": 0.7, "num_ctx": 8192.0, "seed": -42.0, "stop": []interface{}{"\n\n"}},
+	}
+	request := GenerateRequest{
+		Model:   "llama3",
+		Prompt:  "Hi",
+		Think:   &think,
+		Format:  map[string]interface{}{"type": "object"},
+		Options: Options{"big": float64(math.MaxUint32) * 4, "tiny": 1e-9, "neg": -70000.0},
+	}
+
+	for _, codec := range []Codec{JSONCodec{}, MsgpackCodec{}} {
+		t.Run(codec.Extension(), func(t *testing.T) {
+			data, err := codec.Marshal(&session)
+			assertNoError(t, err)
+			var decodedSession ChatSession
+			assertNoError(t, codec.Unmarshal(data, &decodedSession))
+			if !sameJSON(t, &decodedSession, &session) {
+				t.Errorf("Expected session to round-trip, got %+v", decodedSession)
+			}
+
+			data, err = codec.Marshal(&request)
+			assertNoError(t, err)
+			var decodedRequest GenerateRequest
+			assertNoError(t, codec.Unmarshal(data, &decodedRequest))
+			if !sameJSON(t, &decodedRequest, &request) {
+				t.Errorf("Expected request to round-trip, got %+v", decodedRequest)
+			}
+		})
+	}
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+	dataA, err := json.Marshal(a)
+	assertNoError(t, err)
+	dataB, err := json.Marshal(b)
+	assertNoError(t, err)
+	return bytes.Equal(dataA, dataB)
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	codec := MsgpackCodec{}
+
+	// Every size class of strings, arrays, maps and integers
+	long := strings.Repeat("x", 70000)
+	many := make([]interface{}, 20)
+	wide := make(map[string]interface{})
+	for i := range many {
+		many[i] = float64(i)
+		wide[strings.Repeat("k", i+1)] = i % 2
+	}
+	value := map[string]interface{}{
+		"strings": []interface{}{"", "short", strings.Repeat("s", 40), strings.Repeat("m", 300), long},
+		"ints":    []interface{}{0.0, 127.0, 128.0, 255.0, 65535.0, 65536.0, 1 << 40, -1.0, -32.0, -33.0, -128.0, -129.0, -40000.0, -(1 << 40)},
+		"many":    many,
+		"wide":    wide,
+		"other":   []interface{}{nil, true, false, 3.25},
+	}
+
+	data, err := codec.Marshal(value)
+	assertNoError(t, err)
+	jsonData, _ := json.Marshal(value)
+	if len(data) >= len(jsonData) {
+		t.Errorf("Expected msgpack to be smaller than JSON, got %d and %d bytes", len(data), len(jsonData))
+	}
+
+	var decoded map[string]interface{}
+	assertNoError(t, codec.Unmarshal(data, &decoded))
+	var expected map[string]interface{}
+	json.Unmarshal(jsonData, &expected)
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("Expected value to round-trip")
+	}
+
+	// Encodings of the MessagePack spec
+	tests := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{5, []byte{0x05}},
+		{-1, []byte{0xff}},
+		{200, []byte{0xcc, 0xc8}},
+		{-200, []byte{0xd1, 0xff, 0x38}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{map[string]int{"a": 1}, []byte{0x81, 0xa1, 'a', 0x01}},
+	}
+	for _, tt := range tests {
+		data, err := codec.Marshal(tt.value)
+		assertNoError(t, err)
+		if !bytes.Equal(data, tt.expected) {
+			t.Errorf("Expected %v to encode as % x, got % x", tt.value, tt.expected, data)
+		}
+	}
+
+	// Types other encoders produce
+	var other struct {
+		F float32 `json:"f"`
+		U uint64  `json:"u"`
+		B string  `json:"b"`
+	}
+	data = []byte{0x83,
+		0xa1, 'f', 0xca, 0x3f, 0xc0, 0, 0,
+		0xa1, 'u', 0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xa1, 'b', 0xc4, 0x02, 'h', 'i'}
+	assertNoError(t, codec.Unmarshal(data, &other))
+	if other.F != 1.5 || other.U != math.MaxUint64 || other.B != "aGk=" {
+		t.Errorf("Expected float32, uint64 and binary to decode, got %+v", other)
+	}
+
+	var v interface{}
+	for _, invalid := range [][]byte{{}, {0xa3, 'a'}, {0x92, 0x01}, {0x81, 0x01, 0x01}, {0xc1}, {0x01, 0x02}} {
+		if err := codec.Unmarshal(invalid, &v); err == nil {
+			t.Errorf("Expected error decoding % x", invalid)
+		}
+	}
+	if _, err := codec.Marshal(func() {}); err == nil {
+		t.Error("Expected error encoding a function")
+	}
+}
+
+func TestChatSessionCodec(t *testing.T) {
+	session := NewChatSession(nil, "llama3")
+	session.Messages = []Message{{Role: "user", Content: "Hi", ImageSources: []ImageSource{ImageFile("cat.png")}}}
+	session.Options = Options{"temperature": 0.2}
+
+	var buf bytes.Buffer
+	assertNoError(t, session.SaveWithCodec(&buf, MsgpackCodec{}))
+
+	loaded := NewChatSession(nil, "")
+	assertNoError(t, loaded.LoadWithCodec(&buf, MsgpackCodec{}))
+	if loaded.Model != "llama3" || len(loaded.Messages) != 1 || loaded.Messages[0].Content != "Hi" || loaded.Options["temperature"] != json.Number("0.2") {
+		t.Errorf("Expected loaded session to match, got %+v", loaded)
+	}
+	if len(loaded.Messages[0].ImageSources) != 0 {
+		t.Errorf("Expected image sources not to be stored, like with JSON")
+	}
+
+	err := loaded.LoadWithCodec(strings.NewReader("{}"), MsgpackCodec{})
+	assertErrorContains(t, err, "failed to load chat session")
+}
@@ -0,0 +1,772 @@
+package gollama
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Environment variables that select a config file and profile for
+// NewClientFromProfile.
+const (
+	// EnvConfig is the path of the config file. It defaults to
+	// config.yaml, config.yml, config.toml or config.json in the
+	// "gollama" directory of os.UserConfigDir.
+	EnvConfig = "GOLLAMA_CONFIG"
+	// EnvProfile is the profile used when none is named.
+	EnvProfile = "GOLLAMA_PROFILE"
+)
+
+// Config holds named connection profiles, so that teams can share the
+// settings of their servers across tools. It is usually loaded with
+// LoadConfig from a file such as:
+//
+//	default_profile: local
+//	profiles:
+//	  local:
+//	    host: localhost
+//	  prod:
+//	    host: https://ollama.internal:8443
+//	    timeout: 2m
+//	    auth_token_env: OLLAMA_PROD_TOKEN
+//	    default_model: llama3:70b
+//	    tls:
+//	      ca_file: /etc/ssl/internal-ca.pem
+//	    options:
+//	      temperature: 0.2
+//	      num_ctx: 8192
+type Config struct {
+	// DefaultProfile is the profile used when none is named.
+	DefaultProfile string `json:"default_profile,omitempty"`
+	// Profiles maps profile names to their settings.
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile holds the connection settings of one server.
+type Profile struct {
+	// Host is the server address, in any form accepted by OLLAMA_HOST.
+	Host string `json:"host,omitempty"`
+	// Timeout is the total timeout of non-streaming calls, as a Go
+	// duration such as "2m" or a number of seconds. Numbers in the config
+	// file are kept in their decimal form, such as "30".
+	Timeout string `json:"timeout,omitempty"`
+	// TLS configures certificates for https hosts.
+	TLS *ProfileTLS `json:"tls,omitempty"`
+	// AuthToken is sent as a bearer token. To keep secrets out of shared
+	// files, prefer AuthTokenEnv, the name of an environment variable
+	// holding the token.
+	AuthToken    string `json:"auth_token,omitempty"`
+	AuthTokenEnv string `json:"auth_token_env,omitempty"`
+	// Headers are sent with every request.
+	Headers map[string]string `json:"headers,omitempty"`
+	// DefaultModel is used by requests that do not name a model.
+	DefaultModel string `json:"default_model,omitempty"`
+	// Options are default model options, as set by WithDefaultOptions.
+	Options Options `json:"options,omitempty"`
+}
+
+// UnmarshalJSON decodes a profile, accepting a timeout given as a number of
+// seconds as well as a duration string.
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	type profile Profile
+	var raw struct {
+		profile
+		Timeout json.RawMessage `json:"timeout,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = Profile(raw.profile)
+
+	timeout := bytes.TrimSpace(raw.Timeout)
+	switch {
+	case len(timeout) == 0 || string(timeout) == "null":
+	case timeout[0] == '"':
+		return json.Unmarshal(timeout, &p.Timeout)
+	default:
+		var seconds json.Number
+		if err := json.Unmarshal(timeout, &seconds); err != nil {
+			return fmt.Errorf("timeout must be a duration or a number of seconds: %w", err)
+		}
+		p.Timeout = seconds.String()
+	}
+	return nil
+}
+
+// ProfileTLS holds the TLS settings of a profile. Relative paths are
+// resolved against the directory of the config file.
+type ProfileTLS struct {
+	// CAFile is a PEM file of certificate authorities to trust in addition
+	// to the system ones.
+	CAFile string `json:"ca_file,omitempty"`
+	// CertFile and KeyFile are a PEM client certificate and key.
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// ServerName overrides the name the server certificate is checked for.
+	ServerName string `json:"server_name,omitempty"`
+	// InsecureSkipVerify disables certificate checks. Use only for testing.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// LoadConfig reads a config file. The format is chosen by the extension:
+// ".yaml" or ".yml" for YAML, ".toml" for TOML and ".json" for JSON.
+//
+// YAML and TOML are read by a parser for the subset needed by a config
+// file, which rejects anything outside of it rather than misreading it:
+//
+//   - YAML files hold a single document of nested block maps, whose values
+//     are scalars, flow lists ("[a, b]") or block lists of scalars. Anchors,
+//     aliases, tags, flow maps, block scalars and multi-line strings are
+//     not supported, and the YAML 1.1 booleans yes, no, on and off must be
+//     quoted or written as true and false.
+//   - TOML files hold tables and dotted keys, whose values are scalars or
+//     single-line arrays of scalars. Arrays of tables, inline tables,
+//     multi-line strings and dates are not supported.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var doc map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		doc, err = parseYAML(string(data))
+	case ".toml":
+		doc, err = parseTOML(string(data))
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	// The parsed document is decoded into a Config through JSON, so all
+	// three formats share the same field names and type checks
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for name, profile := range config.Profiles {
+		if tls := profile.TLS; tls != nil {
+			tls.CAFile = resolveConfigPath(dir, tls.CAFile)
+			tls.CertFile = resolveConfigPath(dir, tls.CertFile)
+			tls.KeyFile = resolveConfigPath(dir, tls.KeyFile)
+		}
+		config.Profiles[name] = profile
+	}
+	return &config, nil
+}
+
+// resolveConfigPath resolves a relative path against dir.
+func resolveConfigPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// DefaultConfigPath returns the path of the config file used by
+// NewClientFromProfile: GOLLAMA_CONFIG if set, otherwise the first existing
+// config file in the "gollama" directory of os.UserConfigDir.
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml", "config.json"} {
+		path := filepath.Join(dir, "gollama", name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no config file found in %s", filepath.Join(dir, "gollama"))
+}
+
+// NewClientFromProfile creates a client from a profile of the default
+// config file (see DefaultConfigPath). An empty name selects the profile
+// named by GOLLAMA_PROFILE, or else the file's default profile. The given
+// options take precedence over the profile.
+//
+// Example:
+//
+//	client, err := gollama.NewClientFromProfile("prod")
+func NewClientFromProfile(name string, opts ...ClientOption) (*Client, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	return config.NewClient(name, opts...)
+}
+
+// Profile returns the named profile, or the default profile if name is
+// empty.
+func (cfg *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		if len(cfg.Profiles) != 1 {
+			return nil, fmt.Errorf("no profile named and no default profile set")
+		}
+		for only := range cfg.Profiles {
+			name = only
+		}
+	}
+
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Profiles))
+		for n := range cfg.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q (have %s)", name, strings.Join(names, ", "))
+	}
+	return &profile, nil
+}
+
+// NewClient creates a client from the named profile, or the default profile
+// if name is empty. The given options take precedence over the profile.
+func (cfg *Config) NewClient(name string, opts ...ClientOption) (*Client, error) {
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	profileOpts, err := profile.ClientOptions()
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile %q: %w", name, err)
+	}
+	return NewClientWithOptions(ParseHost(profile.Host), append(profileOpts, opts...)...)
+}
+
+// ClientOptions returns the client options that apply the profile's
+// settings other than its host.
+func (p *Profile) ClientOptions() ([]ClientOption, error) {
+	var opts []ClientOption
+
+	if p.Timeout != "" {
+		timeout, err := parseEnvDuration(p.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if p.TLS != nil {
+		config, err := p.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTLSConfig(config))
+	}
+	for name, value := range p.Headers {
+		opts = append(opts, WithHeader(name, value))
+	}
+
+	token := p.AuthToken
+	if p.AuthTokenEnv != "" {
+		token = os.Getenv(p.AuthTokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("auth token variable %s is not set", p.AuthTokenEnv)
+		}
+	}
+	if token != "" {
+		opts = append(opts, WithHeader("Authorization", "Bearer "+token))
+	}
+
+	if p.DefaultModel != "" {
+		opts = append(opts, WithDefaultModel(p.DefaultModel))
+	}
+	if len(p.Options) > 0 {
+		opts = append(opts, WithDefaultOptions(p.Options))
+	}
+	return opts, nil
+}
+
+// config builds a tls.Config from the settings.
+func (t *ProfileTLS) config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// yamlLine is a significant line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses the block-style subset of YAML used by config files:
+// nested maps, scalars, flow lists ("[a, b]") and block lists of scalars.
+func parseYAML(src string) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(src, "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" {
+			continue
+		}
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") || trimmed == "..." {
+			if trimmed == "---" && len(lines) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: only a single document is supported", i+1)
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	doc, err := p.parseMap(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.pos].num)
+	}
+	return doc, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseMap parses the map whose keys are at the given indentation.
+func (p *yamlParser) parseMap(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if strings.HasPrefix(line.text, "- ") || line.text == "-" {
+			return nil, fmt.Errorf("line %d: unexpected list item", line.num)
+		}
+
+		key, value, ok := cutYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", line.num)
+		}
+		if !isQuoted(key) {
+			if err := checkPlainYAML(key); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+		}
+		key, err := unquote(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if value != "" {
+			if m[key], err = parseScalar(value, true); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			continue
+		}
+
+		// A key without a value holds the block indented below it, if any
+		m[key] = nil
+		if p.pos >= len(p.lines) {
+			continue
+		}
+		next := p.lines[p.pos]
+		isList := strings.HasPrefix(next.text, "- ") || next.text == "-"
+		switch {
+		case isList && next.indent >= indent:
+			m[key], err = p.parseList(next.indent)
+		case next.indent > indent:
+			m[key], err = p.parseMap(next.indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// parseList parses a block list of scalars at the given indentation.
+func (p *yamlParser) parseList(indent int) ([]interface{}, error) {
+	var list []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(strings.HasPrefix(line.text, "- ") || line.text == "-") {
+			break
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if _, _, isMap := cutYAMLKey(item); isMap || item == "" {
+			return nil, fmt.Errorf("line %d: only scalar list items are supported", line.num)
+		}
+		value, err := parseScalar(item, true)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		list = append(list, value)
+		p.pos++
+	}
+	return list, nil
+}
+
+// cutYAMLKey splits "key: value" or "key:" at the first colon outside of
+// quotes that ends the line or is followed by a space.
+func cutYAMLKey(s string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i == len(s)-1 || s[i+1] == ' '):
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseTOML parses the subset of TOML used by config files: tables,
+// dotted keys, scalars and single-line arrays of scalars.
+func parseTOML(src string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	current := root
+	defined := make(map[string]bool)
+
+	for i, raw := range strings.Split(src, "\n") {
+		num := i + 1
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", num)
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", num)
+			}
+			path, err := splitTOMLKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			name := strings.Join(path, ".")
+			if defined[name] {
+				return nil, fmt.Errorf("line %d: table %q defined twice", num, name)
+			}
+			defined[name] = true
+			if current, err = tomlTable(root, path); err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			continue
+		}
+
+		parts := splitOutsideQuotes(line, '=')
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", num)
+		}
+		key, value := parts[0], line[len(parts[0])+1:]
+		path, err := splitTOMLKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		table, err := tomlTable(current, path[:len(path)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		last := path[len(path)-1]
+		if _, dup := table[last]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", num, last)
+		}
+		if table[last], err = parseScalar(strings.TrimSpace(value), false); err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+	}
+	return root, nil
+}
+
+// splitTOMLKey splits a dotted key into its parts.
+func splitTOMLKey(s string) ([]string, error) {
+	parts := splitOutsideQuotes(s, '.')
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if !isQuoted(part) && !isBareTOMLKey(part) {
+			return nil, fmt.Errorf("invalid key %q", part)
+		}
+		key, err := unquote(part)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty key in %q", s)
+		}
+		parts[i] = key
+	}
+	return parts, nil
+}
+
+// isBareTOMLKey reports whether s is a key that TOML allows without quotes.
+func isBareTOMLKey(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// tomlTable returns the table at path below t, creating missing tables.
+func tomlTable(t map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, key := range path {
+		switch v := t[key].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			t[key] = child
+			t = child
+		case map[string]interface{}:
+			t = v
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return t, nil
+}
+
+// parseScalar parses a config value: a quoted string, a boolean, a number
+// or a flow list of scalars. In YAML, other text within the supported
+// subset is a plain string and "null" or "~" is nil.
+func parseScalar(s string, yaml bool) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list %s", s)
+		}
+		list := []interface{}{}
+		inner := strings.TrimSpace(s[1 : len(s)-1])
+		if inner == "" {
+			return list, nil
+		}
+		for _, item := range splitOutsideQuotes(inner, ',') {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			value, err := parseScalar(item, yaml)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case isQuoted(s):
+		return unquote(s)
+	case s == "true", yaml && (s == "True" || s == "TRUE"):
+		return true, nil
+	case s == "false", yaml && (s == "False" || s == "FALSE"):
+		return false, nil
+	case yaml && (s == "null" || s == "Null" || s == "NULL" || s == "~"):
+		return nil, nil
+	}
+
+	number := s
+	if !yaml {
+		number = strings.ReplaceAll(s, "_", "")
+	}
+	if n, ok := parseNumber(number); ok {
+		return n, nil
+	}
+	if !yaml {
+		return nil, fmt.Errorf("invalid value %s", s)
+	}
+	if err := checkPlainYAML(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseNumber parses a decimal integer or float, or an integer with a 0x,
+// 0o or 0b prefix. Infinities and NaN are not numbers, since JSON cannot
+// hold them.
+func parseNumber(s string) (interface{}, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, true
+	}
+	if digits := strings.TrimLeft(s, "+-"); len(digits) > 2 && digits[0] == '0' && strings.IndexByte("xob", digits[1]) >= 0 {
+		n, err := strconv.ParseInt(s, 0, 64)
+		return n, err == nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f, true
+	}
+	return nil, false
+}
+
+// checkPlainYAML rejects unquoted YAML text that YAML would not read as a
+// plain string: anchors, aliases, tags, flow maps, block scalars, list
+// items and maps in a value, the YAML 1.1 booleans, and infinities and NaN,
+// which JSON cannot hold.
+func checkPlainYAML(s string) error {
+	switch {
+	case strings.IndexByte("{}&*!|>%@`", s[0]) >= 0:
+		return fmt.Errorf("unsupported YAML syntax %s", s)
+	case s == "-" || s == "?" || strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "? "):
+		return fmt.Errorf("unsupported YAML syntax %s", s)
+	case strings.Contains(s, ": ") || strings.HasSuffix(s, ":"):
+		return fmt.Errorf("unexpected map in value %s", s)
+	}
+	switch strings.ToLower(s) {
+	case "yes", "no", "on", "off":
+		return fmt.Errorf("ambiguous value %s, quote it or use true or false", s)
+	case ".inf", "+.inf", "-.inf", ".nan":
+		return fmt.Errorf("unsupported value %s", s)
+	}
+	return nil
+}
+
+// isQuoted reports whether s starts with a double or single quote.
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'")
+}
+
+// unquote removes double or single quotes from s, if present. Double
+// quoted strings may contain backslash escapes; single quoted ones are
+// literal, except that YAML doubles a quote inside them.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') {
+		return s, nil
+	}
+	if s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return unquoted, nil
+}
+
+// stripComment removes a "#" comment that is outside of quotes and starts
+// the line or follows whitespace.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && opensQuote(s, i):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// splitOutsideQuotes splits s at each sep that is outside of quotes.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && opensQuote(s, i):
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// opensQuote reports whether the quote character at s[i] starts a quoted
+// string, as opposed to being an apostrophe inside a plain word.
+func opensQuote(s string, i int) bool {
+	return i == 0 || strings.IndexByte(" \t:[,=.", s[i-1]) >= 0
+}
@@ -0,0 +1,270 @@
+package gollama
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testYAMLConfig = `# Shared connection settings
+default_profile: local
+profiles:
+  local:
+    host: localhost
+  prod:
+    host: "https://ollama.internal:8443"   # behind the gateway
+    timeout: 2m
+    auth_token_env: GOLLAMA_TEST_TOKEN
+    default_model: llama3:70b
+    headers:
+      X-Team: 'search # infra'
+    tls:
+      ca_file: certs/ca.pem
+      server_name: ollama
+    options:
+      temperature: 0.2
+      num_ctx: 8192
+      stop: ["</s>", "User:"]
+`
+
+const testTOMLConfig = `# Shared connection settings
+default_profile = "local"
+
+[profiles.local]
+host = "localhost"
+
+[profiles.prod]
+host = "https://ollama.internal:8443" # behind the gateway
+timeout = "2m"
+auth_token_env = "GOLLAMA_TEST_TOKEN"
+default_model = "llama3:70b"
+headers."X-Team" = 'search # infra'
+tls.ca_file = "certs/ca.pem"
+tls.server_name = "ollama"
+
+[profiles.prod.options]
+temperature = 0.2
+num_ctx = 8_192
+stop = ["</s>", "User:"]
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			content := testYAMLConfig
+			if filepath.Ext(name) == ".toml" {
+				content = testTOMLConfig
+			}
+			path := writeConfig(t, name, content)
+
+			config, err := LoadConfig(path)
+			assertNoError(t, err)
+
+			if config.DefaultProfile != "local" || config.Profiles["local"].Host != "localhost" {
+				t.Errorf("Expected local default profile, got %+v", config)
+			}
+
+			prod := config.Profiles["prod"]
+			want := Profile{
+				Host:         "https://ollama.internal:8443",
+				Timeout:      "2m",
+				AuthTokenEnv: "GOLLAMA_TEST_TOKEN",
+				DefaultModel: "llama3:70b",
+				Headers:      map[string]string{"X-Team": "search # infra"},
+				TLS: &ProfileTLS{
+					CAFile:     filepath.Join(filepath.Dir(path), "certs", "ca.pem"),
+					ServerName: "ollama",
+				},
+			}
+			options := map[string]string{"temperature": "0.2", "num_ctx": "8192", "stop": "[</s> User:]"}
+			for k, v := range options {
+				if fmt.Sprint(prod.Options[k]) != v {
+					t.Errorf("Expected option %s=%s, got %v", k, v, prod.Options[k])
+				}
+			}
+			prod.Options = nil
+			if !reflect.DeepEqual(prod, want) {
+				t.Errorf("Expected prod profile %#v, got %#v", want, prod)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		errMsg  string
+	}{
+		{"Unknown format", "config.ini", "", "unsupported config format"},
+		{"Bad YAML indentation", "config.yaml", "profiles:\n  a:\n      host: x\n    timeout: 1s\n", "line 4: unexpected indentation"},
+		{"YAML without colon", "config.yaml", "profiles\n", "line 1: expected"},
+		{"Duplicate YAML key", "config.yaml", "a: 1\na: 2\n", "duplicate key"},
+		{"Bare TOML value", "config.toml", "host = localhost\n", "invalid value"},
+		{"TOML key redefined as table", "config.toml", "a = 1\n[a.b]\n", "is not a table"},
+		{"Wrong field type", "config.yaml", "profiles:\n  a:\n    host: [x]\n", "failed to parse config"},
+		{"YAML anchor", "config.yaml", "profiles:\n  a: &base\n    host: x\n", "line 2: unsupported YAML syntax &base"},
+		{"YAML alias", "config.yaml", "profiles:\n  b: *base\n", "unsupported YAML syntax"},
+		{"YAML flow map", "config.yaml", "profiles: {a: {host: x}}\n", "unsupported YAML syntax"},
+		{"YAML block scalar", "config.yaml", "headers:\n  X-Note: |\n    text\n", "line 2: unsupported YAML syntax"},
+		{"YAML map in value", "config.yaml", "host: a: b\n", "unexpected map in value"},
+		{"YAML 1.1 boolean", "config.yaml", "insecure_skip_verify: yes\n", "ambiguous value yes"},
+		{"YAML documents", "config.yaml", "---\na: 1\n---\nb: 2\n", "line 3: only a single document"},
+		{"YAML infinity", "config.yaml", "temperature: .inf\n", "unsupported value .inf"},
+		{"YAML empty key", "config.yaml", ": 1\n", "empty key"},
+		{"TOML inline table", "config.toml", "tls = {ca_file = \"ca.pem\"}\n", "invalid value"},
+		{"TOML date", "config.toml", "created = 2024-01-01\n", "invalid value"},
+		{"TOML table defined twice", "config.toml", "[a]\nx = 1\n[a]\ny = 2\n", "table \"a\" defined twice"},
+		{"TOML invalid bare key", "config.toml", "default model = \"x\"\n", "invalid key"},
+		{"TOML infinity", "config.toml", "temperature = inf\n", "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.file, tt.content))
+			assertErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestParseConfigValues(t *testing.T) {
+	yaml, err := parseYAML("a: True\nb: NULL\nc: 0x1F\nd: 010\ne: 1.5\nf: 2m\nh: 'it''s'\n\"i: j\": k\n")
+	assertNoError(t, err)
+	want := map[string]interface{}{"a": true, "b": nil, "c": int64(31), "d": int64(10), "e": 1.5, "f": "2m", "h": "it's", "i: j": "k"}
+	if !reflect.DeepEqual(yaml, want) {
+		t.Errorf("Expected YAML values %v, got %v", want, yaml)
+	}
+
+	toml, err := parseTOML("a = 0o17\nb = 1_000\n\"c = d\" = \"e\"\n")
+	assertNoError(t, err)
+	want = map[string]interface{}{"a": int64(15), "b": int64(1000), "c = d": "e"}
+	if !reflect.DeepEqual(toml, want) {
+		t.Errorf("Expected TOML values %v, got %v", want, toml)
+	}
+}
+
+func TestLoadConfigTimeout(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"config.yaml", "profiles:\n  a:\n    timeout: 30\n"},
+		{"config.toml", "[profiles.a]\ntimeout = 30\n"},
+		{"config.json", `{"profiles": {"a": {"timeout": 30}}}`},
+		{"config.yaml", "profiles:\n  a:\n    timeout: 30s\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			config, err := LoadConfig(writeConfig(t, tt.file, tt.content))
+			assertNoError(t, err)
+			client, err := config.NewClient("a")
+			assertNoError(t, err)
+			if client.timeout != 30*time.Second {
+				t.Errorf("Expected a timeout of 30s, got %v", client.timeout)
+			}
+		})
+	}
+
+	_, err := LoadConfig(writeConfig(t, "config.json", `{"profiles": {"a": {"timeout": true}}}`))
+	assertErrorContains(t, err, "timeout must be a duration or a number of seconds")
+}
+
+func TestNewClientFromProfile(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	path := writeConfig(t, "config.yaml", `
+profiles:
+  dev:
+    host: `+server.URL+`
+    timeout: 5s
+    auth_token_env: GOLLAMA_TEST_TOKEN
+    headers:
+      X-Team: search
+    default_model: mistral
+  other:
+    host: other
+`)
+	t.Setenv(EnvConfig, path)
+	t.Setenv("GOLLAMA_TEST_TOKEN", "secret")
+
+	_, err := NewClientFromProfile("")
+	assertErrorContains(t, err, "no default profile")
+
+	_, err = NewClientFromProfile("staging")
+	assertErrorContains(t, err, `unknown profile "staging" (have dev, other)`)
+
+	t.Setenv(EnvProfile, "dev")
+	client, err := NewClientFromProfile("", WithTimeout(time.Minute))
+	assertNoError(t, err)
+
+	if client.defaultModel != "mistral" {
+		t.Errorf("Expected default model mistral, got %s", client.defaultModel)
+	}
+	if client.timeout != time.Minute {
+		t.Errorf("Expected explicit option to override the profile timeout, got %v", client.timeout)
+	}
+
+	_, err = client.List(context.Background())
+	assertNoError(t, err)
+	if header.Get("Authorization") != "Bearer secret" || header.Get("X-Team") != "search" {
+		t.Errorf("Expected profile headers, got %v", header)
+	}
+
+	t.Setenv("GOLLAMA_TEST_TOKEN", "")
+	_, err = NewClientFromProfile("dev")
+	assertErrorContains(t, err, "GOLLAMA_TEST_TOKEN is not set")
+}
+
+func TestProfileTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.toml")
+	content := "[profiles.tls]\nhost = \"" + server.URL + "\"\ntls.ca_file = \"ca.pem\"\n\n[profiles.plain]\nhost = \"" + server.URL + "\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	assertNoError(t, err)
+
+	client, err := config.NewClient("tls")
+	assertNoError(t, err)
+	_, err = client.List(context.Background())
+	assertNoError(t, err)
+
+	client, err = config.NewClient("plain")
+	assertNoError(t, err)
+	_, err = client.List(context.Background())
+	assertErrorContains(t, err, "certificate")
+}
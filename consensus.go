@@ -0,0 +1,148 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ConsensusOptions holds optional settings for Consensus.
+type ConsensusOptions struct {
+	// Temperature is the sampling temperature of each sample. Answers only
+	// vary, and voting only helps, at a temperature above zero. Defaults
+	// to 0.7.
+	Temperature float64
+	// Concurrency is the number of samples taken at once. Defaults to all
+	// of them.
+	Concurrency int
+}
+
+// ConsensusAnswer is a distinct answer in a vote and the number of samples
+// that gave it.
+type ConsensusAnswer[T any] struct {
+	Value T
+	Votes int
+}
+
+// ConsensusResult is the outcome of a vote by Consensus.
+type ConsensusResult[T any] struct {
+	// Value is the answer given most often. Ties go to the answer of the
+	// lowest-numbered sample.
+	Value T
+	// Votes is the number of samples that gave Value, out of Samples
+	// successful samples.
+	Votes   int
+	Samples int
+	// Agreement is Votes divided by Samples, a measure of how sure the
+	// model is.
+	Agreement float64
+	// Answers lists each distinct answer, most votes first.
+	Answers []ConsensusAnswer[T]
+	// Errors is the number of samples that failed.
+	Errors int
+}
+
+// Consensus improves the reliability of classification and extraction with
+// self-consistency: it takes k samples of an answer and returns the one
+// given most often, along with how strongly the samples agree. Answers are
+// equal if their JSON encodings are.
+//
+// sample is called concurrently, with request options that give each call
+// its own seed and the configured temperature; it must pass them on to the
+// client. An error is only returned if every sample failed.
+//
+// Example:
+//
+//	result, err := gollama.Consensus(ctx, 5, nil, func(ctx context.Context, opts ...gollama.RequestOption) (string, error) {
+//		c, err := client.Classify(ctx, "llama3", ticket, labels, opts...)
+//		if err != nil {
+//			return "", err
+//		}
+//		return c.Label, nil
+//	})
+//	if result.Agreement < 0.6 {
+//		// send to a human
+//	}
+func Consensus[T any](ctx context.Context, k int, opts *ConsensusOptions, sample func(ctx context.Context, opts ...RequestOption) (T, error)) (*ConsensusResult[T], error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1")
+	}
+	if opts == nil {
+		opts = &ConsensusOptions{}
+	}
+	temperature := opts.Temperature
+	if temperature <= 0 {
+		temperature = 0.7
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 || concurrency > k {
+		concurrency = k
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	outcomes := make([]outcome, k)
+	seed := rand.Intn(1 << 30)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range outcomes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			options := WithModelOptions(Options{"seed": seed + i, "temperature": temperature})
+			outcomes[i].value, outcomes[i].err = sample(ctx, options)
+		}(i)
+	}
+	wg.Wait()
+
+	result := &ConsensusResult[T]{}
+	index := make(map[string]int)
+	var firstErr error
+	for _, o := range outcomes {
+		if o.err != nil {
+			result.Errors++
+			if firstErr == nil {
+				firstErr = o.err
+			}
+			continue
+		}
+		result.Samples++
+
+		key := consensusKey(o.value)
+		i, ok := index[key]
+		if !ok {
+			i = len(result.Answers)
+			index[key] = i
+			result.Answers = append(result.Answers, ConsensusAnswer[T]{Value: o.value})
+		}
+		result.Answers[i].Votes++
+	}
+	if result.Samples == 0 {
+		return nil, fmt.Errorf("all %d samples failed: %w", k, firstErr)
+	}
+
+	sort.SliceStable(result.Answers, func(i, j int) bool {
+		return result.Answers[i].Votes > result.Answers[j].Votes
+	})
+	result.Value = result.Answers[0].Value
+	result.Votes = result.Answers[0].Votes
+	result.Agreement = float64(result.Votes) / float64(result.Samples)
+	return result, nil
+}
+
+// consensusKey returns the key that answers are compared by.
+func consensusKey(v interface{}) string {
+	key, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(key)
+}
@@ -0,0 +1,94 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestConsensus(t *testing.T) {
+	// Seeds are random, so the server answers by the order of requests
+	var mu sync.Mutex
+	var requests []ChatRequest
+	answers := []string{"billing", "bug", "billing", "billing", "error"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		answer := answers[len(requests)-1]
+		mu.Unlock()
+
+		if answer == "error" {
+			http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+			return
+		}
+		content := `{"label":"` + answer + `","confidence":0.8,"rationale":"r"}`
+		json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: content}, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	labels := []Label{{Name: "billing"}, {Name: "bug"}}
+	result, err := Consensus(context.Background(), 5, &ConsensusOptions{Concurrency: 1, Temperature: 0.5},
+		func(ctx context.Context, opts ...RequestOption) (string, error) {
+			c, err := client.Classify(ctx, "llama3", "Refund please", labels, opts...)
+			if err != nil {
+				return "", err
+			}
+			return c.Label, nil
+		})
+	assertNoError(t, err)
+
+	if result.Value != "billing" || result.Votes != 3 || result.Samples != 4 || result.Errors != 1 {
+		t.Errorf("Expected billing with 3 of 4 votes and 1 error, got %+v", result)
+	}
+	if result.Agreement != 0.75 {
+		t.Errorf("Expected agreement 0.75, got %v", result.Agreement)
+	}
+	if len(result.Answers) != 2 || result.Answers[1].Value != "bug" || result.Answers[1].Votes != 1 {
+		t.Errorf("Expected bug as the second answer, got %+v", result.Answers)
+	}
+
+	seeds := make(map[string]bool)
+	for _, req := range requests {
+		if req.Options["temperature"].(json.Number).String() != "0.5" {
+			t.Errorf("Expected the sample temperature to override Classify's, got %v", req.Options["temperature"])
+		}
+		seeds[req.Options["seed"].(json.Number).String()] = true
+	}
+	if len(seeds) != 5 {
+		t.Errorf("Expected a different seed for each sample, got %v", seeds)
+	}
+}
+
+func TestConsensusErrors(t *testing.T) {
+	failure := errors.New("boom")
+	_, err := Consensus(context.Background(), 3, nil, func(ctx context.Context, opts ...RequestOption) (int, error) {
+		return 0, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the sample error, got %v", err)
+	}
+
+	_, err = Consensus(context.Background(), 0, nil, func(ctx context.Context, opts ...RequestOption) (int, error) {
+		return 0, nil
+	})
+	assertErrorContains(t, err, "k must be at least 1")
+
+	// Struct answers are compared by value
+	type pair struct{ A, B int }
+	result, err := Consensus(context.Background(), 3, nil, func(ctx context.Context, opts ...RequestOption) (pair, error) {
+		return pair{1, 2}, nil
+	})
+	assertNoError(t, err)
+	if result.Votes != 3 || result.Value != (pair{1, 2}) {
+		t.Errorf("Expected unanimous vote, got %+v", result)
+	}
+}
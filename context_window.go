@@ -0,0 +1,293 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// AutoContextOptions configures WithAutoContext.
+type AutoContextOptions struct {
+	// Max caps num_ctx, since a model's full context window can need far
+	// more memory than the server default. Zero means no cap.
+	Max int
+	// OnOverflow is called before sending a request whose prompt, as
+	// estimated by EstimateTokens, does not fit in num_ctx less Reserve.
+	// The server would drop the start of such a prompt.
+	OnOverflow func(model string, promptTokens, numCtx int)
+	// Policy decides what happens to a prompt that does not fit. The
+	// default, OverflowAllow, sends it anyway.
+	Policy OverflowPolicy
+	// Reserve is the number of tokens of the context window kept free for
+	// the response when checking whether a prompt fits.
+	Reserve int
+}
+
+// OverflowPolicy decides what WithAutoContext does with a prompt that does
+// not fit in the model's context window.
+type OverflowPolicy int
+
+const (
+	// OverflowAllow sends the prompt unchanged, leaving the server to drop
+	// its start.
+	OverflowAllow OverflowPolicy = iota
+	// OverflowReject fails the request with a *PromptTooLongError.
+	OverflowReject
+	// OverflowTruncate cuts the start of a generate prompt, or drops the
+	// oldest chat messages other than leading system messages and the last
+	// message. A chat that does not fit even then fails with a
+	// *PromptTooLongError.
+	OverflowTruncate
+)
+
+// ErrPromptTooLong is matched by the *PromptTooLongError returned for
+// prompts that do not fit in the model's context window.
+var ErrPromptTooLong = errors.New("prompt too long")
+
+// PromptTooLongError reports a prompt that does not fit in the model's
+// context window.
+type PromptTooLongError struct {
+	Model string
+	// PromptTokens is the estimated size of the prompt.
+	PromptTokens int
+	// Limit is the number of tokens available for the prompt: the context
+	// window less AutoContextOptions.Reserve.
+	Limit int
+}
+
+// Error implements the error interface.
+func (e *PromptTooLongError) Error() string {
+	return fmt.Sprintf("prompt too long: about %d tokens for model %q, limit is %d", e.PromptTokens, e.Model, e.Limit)
+}
+
+// Is reports whether target is ErrPromptTooLong.
+func (e *PromptTooLongError) Is(target error) bool {
+	return target == ErrPromptTooLong
+}
+
+// WithAutoContext sets num_ctx on Generate and Chat requests that do not set
+// it, to the context length the model reports through Show, capped at
+// opts.Max. Context lengths are looked up once per model and cached. If the
+// lookup fails or the model does not report a context length, num_ctx is
+// left to the server and prompts are not checked.
+//
+// Prompts are checked against num_ctx, whether set by the request or looked
+// up, and handled according to opts.Policy if they do not fit.
+func WithAutoContext(opts *AutoContextOptions) ClientOption {
+	if opts == nil {
+		opts = &AutoContextOptions{}
+	}
+	return func(c *Client) {
+		c.autoContext = &autoContext{
+			opts:    *opts,
+			lengths: make(map[ModelName]int),
+		}
+	}
+}
+
+// autoContext holds the WithAutoContext settings and the context lengths
+// looked up so far.
+type autoContext struct {
+	opts    AutoContextOptions
+	mu      sync.Mutex
+	lengths map[ModelName]int
+}
+
+// ContextLength returns the context length of a model as reported by Show,
+// or 0 if the model does not report one.
+func (c *Client) ContextLength(ctx context.Context, model string) (int, error) {
+	info, err := c.Show(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get context length of model %q: %w", model, err)
+	}
+	return info.ContextLength(), nil
+}
+
+// cachedContextLength returns the context length of a model, looking it up
+// with ContextLength the first time.
+func (a *autoContext) cachedContextLength(ctx context.Context, c *Client, model string) int {
+	name := ModelName(model).Normalize()
+
+	a.mu.Lock()
+	length, ok := a.lengths[name]
+	a.mu.Unlock()
+	if ok {
+		c.emit(Event{Type: EventCacheHit, Cache: "context_length", Key: string(name)})
+		return length
+	}
+
+	length, err := c.ContextLength(ctx, model)
+	if err != nil {
+		// Not cached, so that a model pulled later is picked up
+		return 0
+	}
+	a.mu.Lock()
+	a.lengths[name] = length
+	a.mu.Unlock()
+	return length
+}
+
+// apply sets num_ctx in *opts if it is not set and returns the context
+// window the request will run with, or 0 if it is not known. The options map
+// is replaced rather than modified.
+func (a *autoContext) apply(ctx context.Context, c *Client, model string, opts *Options) int {
+	if numCtx, ok := optionInt(*opts, "num_ctx"); ok {
+		return numCtx
+	}
+
+	numCtx := a.cachedContextLength(ctx, c, model)
+	if numCtx <= 0 {
+		return 0
+	}
+	if a.opts.Max > 0 && numCtx > a.opts.Max {
+		numCtx = a.opts.Max
+	}
+	merged := make(Options, len(*opts)+1)
+	for k, v := range *opts {
+		merged[k] = v
+	}
+	merged["num_ctx"] = numCtx
+	*opts = merged
+	return numCtx
+}
+
+// budget returns the number of prompt tokens that fit in a context window
+// of numCtx tokens.
+func (a *autoContext) budget(numCtx int) int {
+	if n := numCtx - a.opts.Reserve; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// overflows reports whether a prompt of the given size does not fit, calling
+// OnOverflow if so.
+func (a *autoContext) overflows(model string, tokens, numCtx int) bool {
+	if numCtx <= 0 || tokens <= a.budget(numCtx) {
+		return false
+	}
+	if a.opts.OnOverflow != nil {
+		a.opts.OnOverflow(model, tokens, numCtx)
+	}
+	return true
+}
+
+// fitGenerateRequest applies WithAutoContext to a copy of a generate request,
+// checking its prompt against the context window.
+func (c *Client) fitGenerateRequest(ctx context.Context, req *GenerateRequest) error {
+	a := c.autoContext
+	if a == nil {
+		return nil
+	}
+
+	numCtx := a.apply(ctx, c, req.Model, &req.Options)
+	tokens := EstimateTokens(req.Prompt)
+	if !a.overflows(req.Model, tokens, numCtx) {
+		return nil
+	}
+	switch a.opts.Policy {
+	case OverflowReject:
+		return &PromptTooLongError{Model: req.Model, PromptTokens: tokens, Limit: a.budget(numCtx)}
+	case OverflowTruncate:
+		req.Prompt = truncateHead(req.Prompt, a.budget(numCtx))
+	}
+	return nil
+}
+
+// fitChatRequest applies WithAutoContext to a copy of a chat request,
+// checking its messages against the context window.
+func (c *Client) fitChatRequest(ctx context.Context, req *ChatRequest) error {
+	a := c.autoContext
+	if a == nil {
+		return nil
+	}
+
+	numCtx := a.apply(ctx, c, req.Model, &req.Options)
+	tokens := EstimateTokens(chatPromptText(req.Messages))
+	if !a.overflows(req.Model, tokens, numCtx) {
+		return nil
+	}
+	tooLong := &PromptTooLongError{Model: req.Model, PromptTokens: tokens, Limit: a.budget(numCtx)}
+	switch a.opts.Policy {
+	case OverflowReject:
+		return tooLong
+	case OverflowTruncate:
+		messages, ok := dropOldestMessages(req.Messages, a.budget(numCtx))
+		if !ok {
+			return tooLong
+		}
+		req.Messages = messages
+	}
+	return nil
+}
+
+// dropOldestMessages removes the oldest messages until the rest fit in
+// budget tokens, keeping leading system messages and the last message. It
+// reports false if even those do not fit. The given slice is not modified.
+func dropOldestMessages(messages []Message, budget int) ([]Message, bool) {
+	head := 0
+	for head < len(messages)-1 && messages[head].Role == "system" {
+		head++
+	}
+
+	for start := head; start < len(messages); start++ {
+		kept := make([]Message, 0, head+len(messages)-start)
+		kept = append(kept, messages[:head]...)
+		kept = append(kept, messages[start:]...)
+		if EstimateTokens(chatPromptText(kept)) <= budget {
+			return kept, true
+		}
+	}
+	return nil, false
+}
+
+// truncateHead cuts the start of text so that it is estimated at no more
+// than tokens tokens.
+func truncateHead(text string, tokens int) string {
+	skip := utf8.RuneCountInString(text) - tokens*4
+	i := 0
+	for ; skip > 0; skip-- {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return text[i:]
+}
+
+// EstimateTokens returns a rough estimate of the number of tokens in text,
+// at about four characters per token. Tokenize gives exact counts at the
+// cost of a request.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// chatPromptText joins the content of chat messages, for estimating their
+// size.
+func chatPromptText(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Content)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// optionInt returns an integer option, whichever numeric type it is held
+// as.
+func optionInt(opts Options, key string) (int, bool) {
+	switch v := opts[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
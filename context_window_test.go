@@ -0,0 +1,190 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newContextServer starts a server whose models report a context length of
+// 8192, except "unknown", and records the num_ctx option of each generation.
+func newContextServer(t *testing.T, shows *int32, numCtx *[]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			atomic.AddInt32(shows, 1)
+			var req ShowRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			info := map[string]interface{}{"general.architecture": "llama"}
+			if req.Model != "unknown" {
+				info["llama.context_length"] = 8192
+			}
+			json.NewEncoder(w).Encode(ModelResponse{Name: req.Model, ModelInfo: info})
+		case "/api/generate", "/api/chat":
+			var req struct {
+				Options Options `json:"options"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			*numCtx = append(*numCtx, req.Options["num_ctx"])
+			json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientContextLength(t *testing.T) {
+	var shows int32
+	var numCtx []interface{}
+	server := newContextServer(t, &shows, &numCtx)
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	length, err := client.ContextLength(context.Background(), "llama3")
+	assertNoError(t, err)
+	if length != 8192 {
+		t.Errorf("Expected 8192, got %d", length)
+	}
+}
+
+func TestWithAutoContext(t *testing.T) {
+	var shows int32
+	var numCtx []interface{}
+	server := newContextServer(t, &shows, &numCtx)
+
+	var overflows []int
+	client, err := NewClientWithOptions(server.URL, WithAutoContext(&AutoContextOptions{
+		Max: 4096,
+		OnOverflow: func(model string, promptTokens, numCtx int) {
+			overflows = append(overflows, promptTokens)
+		},
+	}))
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	generate := func(model, prompt string, opts Options) {
+		t.Helper()
+		_, err := client.Generate(ctx, &GenerateRequest{Model: model, Prompt: prompt, Options: opts})
+		assertNoError(t, err)
+	}
+
+	generate("llama3", "hi", nil)
+	generate("llama3:latest", "hi", nil)
+	generate("llama3", "hi", Options{"num_ctx": 2048})
+	generate("unknown", "hi", nil)
+	_, err = client.Chat(ctx, &ChatRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: strings.Repeat("word ", 4000)}}})
+	assertNoError(t, err)
+
+	want := []string{"4096", "4096", "2048", "<nil>", "4096"}
+	if len(numCtx) != len(want) {
+		t.Fatalf("Expected %d requests, got %d", len(want), len(numCtx))
+	}
+	for i := range want {
+		if fmt.Sprint(numCtx[i]) != want[i] {
+			t.Errorf("Request %d: expected num_ctx %v, got %v", i, want[i], numCtx[i])
+		}
+	}
+
+	// llama3 is looked up once; unknown models are cached too
+	if n := atomic.LoadInt32(&shows); n != 2 {
+		t.Errorf("Expected 2 Show requests, got %d", n)
+	}
+	if len(overflows) != 1 || overflows[0] != 5001 {
+		t.Errorf("Expected one overflow of 5001 tokens, got %v", overflows)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := map[string]int{
+		"":              0,
+		"hi":            1,
+		"four":          1,
+		"hello world!":  3,
+		"héllo wörld!!": 4,
+	}
+	for text, want := range tests {
+		if got := EstimateTokens(text); got != want {
+			t.Errorf("EstimateTokens(%q) = %d, expected %d", text, got, want)
+		}
+	}
+}
+
+func TestAutoContextOverflowPolicy(t *testing.T) {
+	var prompt string
+	var messages []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt   string    `json:"prompt"`
+			Messages []Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt, messages = req.Prompt, req.Messages
+		json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	// A context of 20 tokens with 10 reserved leaves room for 40 characters
+	opts := Options{"num_ctx": 20}
+	long := strings.Repeat("a", 30) + strings.Repeat("b", 40)
+	history := []Message{
+		{Role: "system", Content: strings.Repeat("s", 9)},
+		{Role: "user", Content: strings.Repeat("u", 40)},
+		{Role: "assistant", Content: strings.Repeat("x", 9)},
+		{Role: "user", Content: strings.Repeat("y", 19)},
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		client, err := NewClientWithOptions(server.URL, WithAutoContext(&AutoContextOptions{Policy: OverflowReject, Reserve: 10}))
+		assertNoError(t, err)
+
+		_, err = client.Generate(ctx, &GenerateRequest{Model: "llama3", Prompt: long, Options: opts})
+		var tooLong *PromptTooLongError
+		if !errors.Is(err, ErrPromptTooLong) || !errors.As(err, &tooLong) {
+			t.Fatalf("Expected ErrPromptTooLong, got %v", err)
+		}
+		if tooLong.PromptTokens != 18 || tooLong.Limit != 10 || tooLong.Model != "llama3" {
+			t.Errorf("Expected counts 18 and 10, got %+v", tooLong)
+		}
+
+		_, err = client.Generate(ctx, &GenerateRequest{Model: "llama3", Prompt: "short", Options: opts})
+		assertNoError(t, err)
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		client, err := NewClientWithOptions(server.URL, WithAutoContext(&AutoContextOptions{Policy: OverflowTruncate, Reserve: 10}))
+		assertNoError(t, err)
+
+		_, err = client.Generate(ctx, &GenerateRequest{Model: "llama3", Prompt: long, Options: opts})
+		assertNoError(t, err)
+		if prompt != strings.Repeat("b", 40) {
+			t.Errorf("Expected the start of the prompt to be cut, got %q", prompt)
+		}
+
+		req := &ChatRequest{Model: "llama3", Messages: history, Options: opts}
+		_, err = client.Chat(ctx, req)
+		assertNoError(t, err)
+		if len(messages) != 3 || messages[0].Role != "system" || messages[1].Role != "assistant" {
+			t.Errorf("Expected the oldest user message to be dropped, got %+v", messages)
+		}
+		if len(req.Messages) != 4 {
+			t.Error("Expected the caller's messages to be unchanged")
+		}
+
+		huge := []Message{{Role: "user", Content: long}}
+		_, err = client.Chat(ctx, &ChatRequest{Model: "llama3", Messages: huge, Options: opts})
+		if !errors.Is(err, ErrPromptTooLong) {
+			t.Errorf("Expected ErrPromptTooLong when the last message does not fit, got %v", err)
+		}
+	})
+}
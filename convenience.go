@@ -0,0 +1,94 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// GenerateText generates a completion for prompt and returns only the
+// generated text. It is a shorthand for Generate in scripts and tests. An
+// empty model uses the client's default model.
+//
+// Example:
+//
+//	text, err := client.GenerateText(ctx, "llama3", "Why is the sky blue?")
+func (c *Client) GenerateText(ctx context.Context, model, prompt string, opts ...RequestOption) (string, error) {
+	resp, err := c.Generate(ctx, &GenerateRequest{Model: model, Prompt: prompt}, opts...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Response, nil
+}
+
+// Ask sends a single-turn chat, consisting of an optional system prompt and a
+// user prompt, and returns the assistant's reply. An empty systemPrompt is
+// omitted, and an empty model uses the client's default model.
+//
+// Example:
+//
+//	answer, err := client.Ask(ctx, "llama3", "Answer in one word.", "What color is the sky?")
+func (c *Client) Ask(ctx context.Context, model, systemPrompt, userPrompt string, opts ...RequestOption) (string, error) {
+	messages := make([]Message, 0, 2)
+	if systemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: systemPrompt})
+	}
+	messages = append(messages, Message{Role: "user", Content: userPrompt})
+
+	resp, err := c.Chat(ctx, &ChatRequest{Model: model, Messages: messages}, opts...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// EmbedText returns the embedding vector of text as float32 values, the
+// precision most vector stores expect. An empty model uses the client's
+// default model.
+func (c *Client) EmbedText(ctx context.Context, model, text string, opts ...RequestOption) ([]float32, error) {
+	resp, err := c.Embeddings(ctx, &EmbeddingRequest{Model: model, Prompt: text}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	embedding := make([]float32, len(resp.Embedding))
+	for i, v := range resp.Embedding {
+		embedding[i] = float32(v)
+	}
+	return embedding, nil
+}
+
+// EmbedTexts returns the embedding vectors of a small batch of texts, in the
+// same order. The texts are embedded one at a time, and the first failure
+// aborts the batch.
+func (c *Client) EmbedTexts(ctx context.Context, model string, texts []string, opts ...RequestOption) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := c.EmbedText(ctx, model, text, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// CosineSimilarity returns the cosine similarity of two embedding vectors,
+// from -1 to 1. It returns 0 if the vectors differ in length or either is
+// zero.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,117 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientGenerateText(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	text, err := client.GenerateText(ctx, "llama2", "Why is the sky blue?")
+	assertNoError(t, err)
+	if text == "" {
+		t.Errorf("Expected generated text, got empty string")
+	}
+
+	_, err = client.GenerateText(ctx, "", "Why is the sky blue?")
+	assertErrorContains(t, err, "model name cannot be empty")
+}
+
+func TestClientAsk(t *testing.T) {
+	var received []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		received = req.Messages
+		json.NewEncoder(w).Encode(ChatResponse{
+			Model:   req.Model,
+			Message: Message{Role: "assistant", Content: "Blue"},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	answer, err := client.Ask(ctx, "llama2", "Answer in one word.", "What color is the sky?")
+	assertNoError(t, err)
+	if answer != "Blue" {
+		t.Errorf("Expected answer Blue, got %q", answer)
+	}
+	if len(received) != 2 || received[0].Role != "system" || received[1].Role != "user" {
+		t.Errorf("Expected system and user messages, got %+v", received)
+	}
+
+	_, err = client.Ask(ctx, "llama2", "", "What color is the sky?")
+	assertNoError(t, err)
+	if len(received) != 1 || received[0].Content != "What color is the sky?" {
+		t.Errorf("Expected only the user message, got %+v", received)
+	}
+}
+
+func TestClientEmbedText(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	embedding, err := client.EmbedText(ctx, "llama2", "Hello world")
+	assertNoError(t, err)
+	if len(embedding) == 0 {
+		t.Errorf("Expected non-empty embedding")
+	}
+
+	embeddings, err := client.EmbedTexts(ctx, "llama2", []string{"Hello", "world", "again"})
+	assertNoError(t, err)
+	if len(embeddings) != 3 {
+		t.Fatalf("Expected 3 embeddings, got %d", len(embeddings))
+	}
+	for i, e := range embeddings {
+		if len(e) != len(embedding) {
+			t.Errorf("Expected embedding %d to have %d dimensions, got %d", i, len(embedding), len(e))
+		}
+	}
+
+	_, err = client.EmbedTexts(ctx, "llama2", []string{"Hello", ""})
+	assertErrorContains(t, err, "failed to embed text 1")
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"Identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"Scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"Orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"Opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"Length mismatch", []float32{1, 0}, []float32{1}, 0},
+		{"Zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
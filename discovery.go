@@ -0,0 +1,402 @@
+package gollama
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discoverer finds the hosts of a cluster, for NewDiscoveredClusterClient.
+type Discoverer interface {
+	// Discover returns the base URLs of the hosts, such as
+	// "http://10.0.0.7:11434".
+	Discover(ctx context.Context) ([]string, error)
+}
+
+// DiscovererFunc adapts a function to a Discoverer.
+type DiscovererFunc func(ctx context.Context) ([]string, error)
+
+// Discover calls f.
+func (f DiscovererFunc) Discover(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// defaultDiscoveryInterval is the time between refreshes of a discovered
+// cluster if DiscoveryOptions does not set one.
+const defaultDiscoveryInterval = 30 * time.Second
+
+// errHostDraining is the reason ReplicateModel gives for skipping a host
+// that discovery no longer finds.
+var errHostDraining = errors.New("host is draining")
+
+// DiscoveryOptions holds optional settings for NewDiscoveredClusterClient.
+type DiscoveryOptions struct {
+	// Interval is the time between refreshes. The default is 30 seconds.
+	Interval time.Duration
+	// OnError, if set, receives the failures of periodic refreshes and
+	// health checks.
+	OnError func(error)
+	// ClientOptions configure the client of each host.
+	ClientOptions []ClientOption
+}
+
+// NewDiscoveredClusterClient creates a cluster client whose hosts are found
+// by d rather than listed up front, so that hosts can come and go:
+//
+//	cluster, err := gollama.NewDiscoveredClusterClient(ctx,
+//		&gollama.SRVDiscoverer{Service: "ollama", Proto: "tcp", Name: "gpu.example.com"}, nil)
+//
+// Hosts are discovered once before it returns, and then every interval until
+// ctx is canceled, when the health of all hosts is checked as well. New
+// hosts take requests once they have passed a health check. Hosts that are
+// no longer found stop taking requests and are removed once the requests
+// they have in flight finish. A failed or empty discovery leaves the hosts
+// as they are.
+func NewDiscoveredClusterClient(ctx context.Context, d Discoverer, opts *DiscoveryOptions) (*ClusterClient, error) {
+	if d == nil {
+		return nil, fmt.Errorf("discoverer cannot be nil")
+	}
+	if opts == nil {
+		opts = &DiscoveryOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultDiscoveryInterval
+	}
+	onError := opts.OnError
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	cc := &ClusterClient{clientOpts: opts.ClientOptions, discoverer: d}
+	if err := cc.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := cc.Refresh(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+			if err := cc.CheckHealth(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}()
+	return cc, nil
+}
+
+// Refresh discovers the hosts of a cluster created with
+// NewDiscoveredClusterClient and updates the cluster to match, as described
+// there. It fails for other clusters.
+func (cc *ClusterClient) Refresh(ctx context.Context) error {
+	if cc.discoverer == nil {
+		return fmt.Errorf("cluster has no discoverer")
+	}
+	hosts, err := cc.discoverer.Discover(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover hosts: %w", err)
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("failed to discover hosts: none found")
+	}
+	return cc.setHosts(ctx, hosts)
+}
+
+// setHosts makes urls the hosts of the cluster, draining the hosts that are
+// not among them and adding the new ones after checking their health.
+func (cc *ClusterClient) setHosts(ctx context.Context, urls []string) error {
+	want := make(map[string]bool, len(urls))
+	var added []string
+
+	cc.mu.Lock()
+	current := make(map[string]*clusterHost, len(cc.hosts))
+	for _, h := range cc.hosts {
+		current[h.client.BaseURL()] = h
+	}
+	for _, u := range urls {
+		if u == "" || want[u] {
+			continue
+		}
+		want[u] = true
+		if h, ok := current[u]; ok {
+			h.draining = false
+		} else {
+			added = append(added, u)
+		}
+	}
+	kept := cc.hosts[:0:0]
+	for _, h := range cc.hosts {
+		if !want[h.client.BaseURL()] {
+			if h.inFlight == 0 {
+				continue
+			}
+			h.draining = true
+		}
+		kept = append(kept, h)
+	}
+	cc.hosts = kept
+	cc.mu.Unlock()
+
+	hosts := make([]*clusterHost, len(added))
+	errs := make([]error, len(added))
+	var wg sync.WaitGroup
+	for i, u := range added {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			client, err := NewClientWithOptions(u, cc.clientOpts...)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to create client for %q: %w", u, err)
+				return
+			}
+			hosts[i] = &clusterHost{client: client}
+			hosts[i].apply(checkHost(ctx, client))
+		}(i, u)
+	}
+	wg.Wait()
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	present := make(map[string]bool, len(cc.hosts))
+	for _, h := range cc.hosts {
+		present[h.client.BaseURL()] = true
+	}
+	for _, h := range hosts {
+		// Skip hosts that a concurrent refresh has added meanwhile
+		if h != nil && !present[h.client.BaseURL()] {
+			cc.hosts = append(cc.hosts, h)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SRVDiscoverer finds hosts from DNS SRV records, such as those of a
+// headless Kubernetes service or a Consul service.
+type SRVDiscoverer struct {
+	// Service, Proto and Name are looked up as in net.LookupSRV: with
+	// Service "ollama" and Proto "tcp", the records of
+	// _ollama._tcp.<Name> are read; with both empty, those of Name.
+	Service string
+	Proto   string
+	Name    string
+	// Scheme is the scheme of the host URLs. The default is "http".
+	Scheme string
+	// Resolver, if set, replaces net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// Discover looks up the SRV records and returns a host for each.
+func (d *SRVDiscoverer) Discover(ctx context.Context) ([]string, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, d.Service, d.Proto, d.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up SRV records of %q: %w", d.Name, err)
+	}
+	return srvHosts(d.Scheme, records), nil
+}
+
+// srvHosts returns the host URLs of SRV records, sorted.
+func srvHosts(scheme string, records []*net.SRV) []string {
+	if scheme == "" {
+		scheme = "http"
+	}
+	hosts := make([]string, 0, len(records))
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		hosts = append(hosts, scheme+"://"+net.JoinHostPort(target, strconv.Itoa(int(record.Port))))
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Locations of the service account credentials mounted into Kubernetes pods.
+const (
+	serviceAccountDir       = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken     = serviceAccountDir + "/token"
+	serviceAccountCA        = serviceAccountDir + "/ca.crt"
+	serviceAccountNamespace = serviceAccountDir + "/namespace"
+)
+
+// KubernetesDiscoverer finds hosts from the Endpoints of a Kubernetes
+// Service, which list the ready pods behind it. Its zero value, apart from
+// Service, works inside a pod whose service account may read Endpoints.
+// A KubernetesDiscoverer must not be copied after first use.
+type KubernetesDiscoverer struct {
+	// Service is the name of the Service.
+	Service string
+	// Namespace is the Service's namespace. The default is the pod's own
+	// namespace.
+	Namespace string
+	// Port is the name of the Service port to use. The default is the
+	// first port.
+	Port string
+	// Scheme is the scheme of the host URLs. The default is "http".
+	Scheme string
+
+	// APIServer is the URL of the Kubernetes API. The default is taken
+	// from KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT.
+	APIServer string
+	// Token is the bearer token for the API. The default is the pod's
+	// service account token, read anew for each request since it rotates.
+	Token string
+	// HTTPClient, if set, is used for API requests. The default trusts the
+	// pod's service account CA.
+	HTTPClient *http.Client
+
+	once       sync.Once
+	httpClient *http.Client
+	clientErr  error
+}
+
+// kubernetesEndpoints is the part of a Kubernetes Endpoints object that
+// KubernetesDiscoverer reads.
+type kubernetesEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// Discover reads the Service's Endpoints and returns a host for each ready
+// address.
+func (d *KubernetesDiscoverer) Discover(ctx context.Context) ([]string, error) {
+	if d.Service == "" {
+		return nil, fmt.Errorf("kubernetes service name cannot be empty")
+	}
+	client, err := d.client()
+	if err != nil {
+		return nil, err
+	}
+
+	apiServer := d.APIServer
+	if apiServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes API server not set and not running in a pod")
+		}
+		apiServer = "https://" + net.JoinHostPort(host, port)
+	}
+	namespace := d.Namespace
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	token := d.Token
+	if token == "" {
+		data, err := os.ReadFile(serviceAccountToken)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read service account token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	u, err := url.JoinPath(apiServer, "api/v1/namespaces", namespace, "endpoints", d.Service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoints of %s/%s: %w", namespace, d.Service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := readErrorBody(resp.Body)
+		return nil, fmt.Errorf("failed to read endpoints of %s/%s: %w", namespace, d.Service,
+			&OllamaError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))})
+	}
+
+	var endpoints kubernetesEndpoints
+	if err := json.NewDecoder(resp.Body).Decode(&endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints of %s/%s: %w", namespace, d.Service, err)
+	}
+	return endpointHosts(d.Scheme, d.Port, &endpoints), nil
+}
+
+// client returns the HTTP client for API requests.
+func (d *KubernetesDiscoverer) client() (*http.Client, error) {
+	if d.HTTPClient != nil {
+		return d.HTTPClient, nil
+	}
+	d.once.Do(func() {
+		pem, err := os.ReadFile(serviceAccountCA)
+		if err != nil {
+			d.clientErr = fmt.Errorf("failed to read service account CA: %w", err)
+			return
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			d.clientErr = fmt.Errorf("no certificates in %s", serviceAccountCA)
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		d.httpClient = &http.Client{Transport: transport, Timeout: defaultTimeout}
+	})
+	return d.httpClient, d.clientErr
+}
+
+// endpointHosts returns the host URLs of the ready addresses of Endpoints,
+// sorted, using the named port, or the first port if name is empty.
+func endpointHosts(scheme, portName string, endpoints *kubernetesEndpoints) []string {
+	if scheme == "" {
+		scheme = "http"
+	}
+	var hosts []string
+	for _, subset := range endpoints.Subsets {
+		port := 0
+		for _, p := range subset.Ports {
+			if portName == "" || p.Name == portName {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, address := range subset.Addresses {
+			hosts = append(hosts, scheme+"://"+net.JoinHostPort(address.IP, strconv.Itoa(port)))
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
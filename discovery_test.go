@@ -0,0 +1,251 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hostList is a Discoverer returning a settable list of hosts.
+type hostList struct {
+	mu    sync.Mutex
+	hosts []string
+	err   error
+}
+
+func (l *hostList) set(hosts []string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hosts, l.err = hosts, err
+}
+
+func (l *hostList) Discover(ctx context.Context) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.hosts...), l.err
+}
+
+// hostURLs returns the URLs of the cluster's hosts.
+func hostURLs(cluster *ClusterClient) []string {
+	var urls []string
+	for _, h := range cluster.Hosts() {
+		urls = append(urls, h.URL)
+	}
+	return urls
+}
+
+func TestNewDiscoveredClusterClient(t *testing.T) {
+	a := newClusterServer(t, "a", "llama2:latest")
+	b := newClusterServer(t, "b")
+	list := &hostList{hosts: []string{a.URL}}
+	ctx := context.Background()
+
+	cluster, err := NewDiscoveredClusterClient(ctx, list, nil)
+	assertNoError(t, err)
+	hosts := cluster.Hosts()
+	if len(hosts) != 1 || hosts[0].URL != a.URL || !hosts[0].Healthy {
+		t.Fatalf("Expected healthy host a, got %+v", hosts)
+	}
+	if !reflect.DeepEqual(hosts[0].Models, []string{"llama2:latest"}) {
+		t.Errorf("Expected new host to be checked before use, got models %v", hosts[0].Models)
+	}
+
+	// New hosts only take requests for the models they serve
+	list.set([]string{a.URL, b.URL}, nil)
+	assertNoError(t, cluster.Refresh(ctx))
+	if urls := hostURLs(cluster); !reflect.DeepEqual(urls, []string{a.URL, b.URL}) {
+		t.Errorf("Expected hosts a and b, got %v", urls)
+	}
+	for i := 0; i < 4; i++ {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+		assertNoError(t, err)
+		if resp.Response != "a" {
+			t.Errorf("Expected llama2 to be served by a, got %q", resp.Response)
+		}
+	}
+
+	// Failed or empty discoveries keep the hosts
+	list.set(nil, errors.New("dns down"))
+	assertErrorContains(t, cluster.Refresh(ctx), "dns down")
+	list.set(nil, nil)
+	assertErrorContains(t, cluster.Refresh(ctx), "none found")
+	if urls := hostURLs(cluster); len(urls) != 2 {
+		t.Errorf("Expected hosts to be kept, got %v", urls)
+	}
+
+	// Idle hosts that are no longer found are removed at once
+	list.set([]string{b.URL}, nil)
+	assertNoError(t, cluster.Refresh(ctx))
+	if urls := hostURLs(cluster); !reflect.DeepEqual(urls, []string{b.URL}) {
+		t.Errorf("Expected only host b, got %v", urls)
+	}
+
+	if _, err := NewDiscoveredClusterClient(ctx, nil, nil); err == nil {
+		t.Error("Expected error for nil discoverer")
+	}
+	if _, err := NewDiscoveredClusterClient(ctx, &hostList{}, nil); err == nil {
+		t.Error("Expected error when no hosts are found")
+	}
+	static, err := NewClusterClient([]string{a.URL})
+	assertNoError(t, err)
+	assertErrorContains(t, static.Refresh(ctx), "no discoverer")
+}
+
+func TestClusterClientDraining(t *testing.T) {
+	slow := newClusterServer(t, "slow", "llama2:latest")
+	slow.delay = 200 * time.Millisecond
+	other := newClusterServer(t, "other", "llama2:latest")
+	list := &hostList{hosts: []string{slow.URL}}
+	ctx := context.Background()
+
+	cluster, err := NewDiscoveredClusterClient(ctx, list, nil)
+	assertNoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+		if err == nil && resp.Response != "slow" {
+			err = errors.New("served by " + resp.Response)
+		}
+		done <- err
+	}()
+	for cluster.Hosts()[0].InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The removed host finishes its request but takes no new ones
+	list.set([]string{other.URL}, nil)
+	assertNoError(t, cluster.Refresh(ctx))
+	hosts := cluster.Hosts()
+	if len(hosts) != 2 || hosts[0].URL != slow.URL || !hosts[0].Draining {
+		t.Fatalf("Expected slow host to be draining, got %+v", hosts)
+	}
+	resp, err := cluster.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+	assertNoError(t, err)
+	if resp.Response != "other" {
+		t.Errorf("Expected new request on other host, got %q", resp.Response)
+	}
+	results, err := cluster.ReplicateModel(ctx, "llama2", nil)
+	assertNoError(t, err)
+	if !results[0].Skipped || !errors.Is(results[0].Err, errHostDraining) {
+		t.Errorf("Expected draining host to be skipped by replication, got %+v", results[0])
+	}
+
+	assertNoError(t, <-done)
+	if urls := hostURLs(cluster); !reflect.DeepEqual(urls, []string{other.URL}) {
+		t.Errorf("Expected drained host to be removed, got %v", urls)
+	}
+}
+
+func TestNewDiscoveredClusterClientRefreshes(t *testing.T) {
+	a := newClusterServer(t, "a")
+	b := newClusterServer(t, "b")
+	list := &hostList{hosts: []string{a.URL}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 10)
+	cluster, err := NewDiscoveredClusterClient(ctx, list, &DiscoveryOptions{
+		Interval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	assertNoError(t, err)
+
+	list.set([]string{a.URL, b.URL}, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(cluster.Hosts()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected refresh to add host b, got %v", hostURLs(cluster))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	list.set(nil, errors.New("lookup failed"))
+	select {
+	case err := <-errs:
+		assertErrorContains(t, err, "lookup failed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected refresh failure to be reported")
+	}
+}
+
+func TestSRVHosts(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "gpu-2.example.com.", Port: 11434},
+		{Target: "gpu-1.example.com.", Port: 8080},
+	}
+	want := []string{"http://gpu-1.example.com:8080", "http://gpu-2.example.com:11434"}
+	if got := srvHosts("", records); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := srvHosts("https", records[:1]); got[0] != "https://gpu-2.example.com:11434" {
+		t.Errorf("Expected https host, got %v", got)
+	}
+}
+
+func TestKubernetesDiscoverer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/ai/endpoints/ollama" {
+			http.Error(w, `{"kind":"Status","message":"endpoints not found"}`, http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"kind":"Endpoints","subsets":[
+			{"addresses":[{"ip":"10.0.0.2"},{"ip":"10.0.0.1"}],
+			 "notReadyAddresses":[{"ip":"10.0.0.9"}],
+			 "ports":[{"name":"metrics","port":9090},{"name":"http","port":11434}]},
+			{"addresses":[{"ip":"fd00::5"}],
+			 "ports":[{"name":"http","port":8080}]}
+		]}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	d := &KubernetesDiscoverer{
+		Service:    "ollama",
+		Namespace:  "ai",
+		Port:       "http",
+		APIServer:  server.URL,
+		Token:      "secret",
+		HTTPClient: server.Client(),
+	}
+	hosts, err := d.Discover(ctx)
+	assertNoError(t, err)
+	want := []string{"http://10.0.0.1:11434", "http://10.0.0.2:11434", "http://[fd00::5]:8080"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Expected %v, got %v", want, hosts)
+	}
+
+	// Without a port name, the first port of each subset is used
+	d.Port = ""
+	hosts, err = d.Discover(ctx)
+	assertNoError(t, err)
+	want = []string{"http://10.0.0.1:9090", "http://10.0.0.2:9090", "http://[fd00::5]:8080"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Expected %v, got %v", want, hosts)
+	}
+
+	d.Service = "missing"
+	_, err = d.Discover(ctx)
+	var apiErr *OllamaError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 error, got %v", err)
+	}
+
+	d.Service, d.Token = "ollama", "wrong"
+	assertErrorContains(t, func() error { _, err := d.Discover(ctx); return err }(), "401")
+}
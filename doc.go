@@ -57,7 +57,7 @@
 //   - EmbeddingRequest/EmbeddingResponse: Vector embeddings
 //   - CreateRequest/CreateProgress: Model creation from Modelfile
 //   - PushRequest/PushProgress: Model publishing to registries
-//   - PSResponse: Running model process status
+//   - PSResponse/RunningModel: Running model process status
 //   - OllamaError: Custom error type for API errors
 //
 // # Options
@@ -0,0 +1,190 @@
+// Package docqa answers questions about local documents: a turnkey
+// retrieval-augmented generation setup built from the gollama chunker,
+// embeddings and chat.
+//
+//	qa := docqa.New(client, "llama3").AddFiles("handbook.md", "faq.txt")
+//	answer, err := qa.Ask(ctx, "How many vacation days do new hires get?")
+//	fmt.Println(answer.Text) // "New hires get 25 days [1]."
+//	for i, source := range answer.Sources {
+//		fmt.Printf("[%d] %s, chunk %d\n", i+1, source.Path, source.Chunk)
+//	}
+//
+// Documents are split into chunks and embedded the first time a question is
+// asked after they were added. Each question is answered from the chunks
+// whose embeddings are most similar to its own, which the model is asked to
+// cite by number. The index is kept in memory.
+package docqa
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/astrica1/gollama"
+)
+
+// Source is a chunk of a document used to answer a question.
+type Source struct {
+	// Path is the file the chunk comes from, or the name given to AddText.
+	Path string
+	// Chunk is the position of the chunk in its document, from 0.
+	Chunk int
+	Text  string
+	// Score is the cosine similarity of the chunk to the question.
+	Score float64
+}
+
+// Answer is the model's answer to a question. Citations like [2] in Text
+// refer to Sources[1].
+type Answer struct {
+	Text    string
+	Sources []Source
+}
+
+// chunk is an indexed piece of a document.
+type chunk struct {
+	source    Source
+	embedding []float32
+}
+
+// QA answers questions about a set of documents. Its fields can be changed
+// after New and before documents are added. A QA is safe for concurrent use.
+type QA struct {
+	client *gollama.Client
+	model  string
+
+	// EmbeddingModel embeds chunks and questions. Defaults to
+	// "nomic-embed-text".
+	EmbeddingModel string
+	// ChunkTokens is the size of the chunks documents are split into, and
+	// Overlap the number of tokens consecutive chunks share.
+	ChunkTokens int
+	Overlap     int
+	// TopK is the number of chunks given to the model for each question.
+	TopK int
+
+	mu      sync.Mutex
+	chunks  []chunk
+	pending int // chunks at the end of chunks that are not embedded yet
+	err     error
+}
+
+// New creates a QA that answers with model, with default settings.
+func New(client *gollama.Client, model string) *QA {
+	return &QA{
+		client:         client,
+		model:          model,
+		EmbeddingModel: "nomic-embed-text",
+		ChunkTokens:    300,
+		Overlap:        30,
+		TopK:           4,
+	}
+}
+
+// AddFiles reads and adds documents. It returns q so that calls can be
+// chained; a file that cannot be read makes every later Ask fail.
+func (q *QA) AddFiles(paths ...string) *QA {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			q.mu.Lock()
+			if q.err == nil {
+				q.err = fmt.Errorf("failed to read document: %w", err)
+			}
+			q.mu.Unlock()
+			continue
+		}
+		q.AddText(path, string(data))
+	}
+	return q
+}
+
+// AddText adds a document held in memory under the given name, and returns
+// q.
+func (q *QA) AddText(name, text string) *QA {
+	pieces := gollama.ChunkText(text, q.ChunkTokens, q.Overlap)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, piece := range pieces {
+		q.chunks = append(q.chunks, chunk{source: Source{Path: name, Chunk: i, Text: piece}})
+	}
+	q.pending += len(pieces)
+	return q
+}
+
+// Ask answers a question from the documents.
+func (q *QA) Ask(ctx context.Context, question string) (*Answer, error) {
+	if err := q.index(ctx); err != nil {
+		return nil, err
+	}
+
+	embedding, err := q.client.EmbedText(ctx, q.EmbeddingModel, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+	sources := q.search(embedding)
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no documents have been added")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Answer the user's question using only the numbered sources below. " +
+		"Cite the sources you use by number in brackets, like [1]. " +
+		"If the sources do not contain the answer, say that you don't know.\n")
+	for i, source := range sources {
+		fmt.Fprintf(&prompt, "\n[%d] (%s)\n%s\n", i+1, source.Path, source.Text)
+	}
+
+	text, err := q.client.Ask(ctx, q.model, prompt.String(), question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer question: %w", err)
+	}
+	return &Answer{Text: strings.TrimSpace(text), Sources: sources}, nil
+}
+
+// index embeds the chunks added since the last call.
+func (q *QA) index(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.err != nil {
+		return q.err
+	}
+	for q.pending > 0 {
+		c := &q.chunks[len(q.chunks)-q.pending]
+		embedding, err := q.client.EmbedText(ctx, q.EmbeddingModel, c.source.Text)
+		if err != nil {
+			return fmt.Errorf("failed to embed chunk %d of %s: %w", c.source.Chunk, c.source.Path, err)
+		}
+		c.embedding = embedding
+		q.pending--
+	}
+	return nil
+}
+
+// search returns the TopK chunks most similar to an embedding.
+func (q *QA) search(embedding []float32) []Source {
+	q.mu.Lock()
+	sources := make([]Source, 0, len(q.chunks))
+	for _, c := range q.chunks {
+		if c.embedding == nil {
+			continue
+		}
+		source := c.source
+		source.Score = gollama.CosineSimilarity(embedding, c.embedding)
+		sources = append(sources, source)
+	}
+	q.mu.Unlock()
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Score > sources[j].Score
+	})
+	if q.TopK > 0 && len(sources) > q.TopK {
+		sources = sources[:q.TopK]
+	}
+	return sources
+}
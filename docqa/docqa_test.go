@@ -0,0 +1,114 @@
+package docqa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+// topics are the dimensions of the test embeddings.
+var topics = []string{"vacation", "salary", "parking"}
+
+// newTestClient starts a server that embeds text by the topics it mentions
+// and answers chats with a fixed reply, recording the system prompt.
+func newTestClient(t *testing.T, system *string, embeds *int32) *gollama.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/embeddings":
+			atomic.AddInt32(embeds, 1)
+			var req gollama.EmbeddingRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			embedding := make([]float64, len(topics))
+			for i, topic := range topics {
+				if strings.Contains(strings.ToLower(req.Prompt), topic) {
+					embedding[i] = 1
+				}
+			}
+			json.NewEncoder(w).Encode(gollama.EmbeddingResponse{Embedding: embedding})
+		case "/api/chat":
+			var req gollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			*system = req.Messages[0].Content
+			json.NewEncoder(w).Encode(gollama.ChatResponse{
+				Message: gollama.Message{Role: "assistant", Content: " New hires get 25 days [1]. "},
+				Done:    true,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+func TestQA(t *testing.T) {
+	dir := t.TempDir()
+	handbook := filepath.Join(dir, "handbook.md")
+	os.WriteFile(handbook, []byte("Vacation: new hires get 25 vacation days.\n\nSalary is paid monthly."), 0o644)
+
+	var system string
+	var embeds int32
+	qa := New(newTestClient(t, &system, &embeds), "llama3")
+	qa.ChunkTokens = 12
+	qa.TopK = 2
+	qa.AddFiles(handbook).AddText("notes", "Parking is free for staff.")
+
+	answer, err := qa.Ask(context.Background(), "How many vacation days?")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if answer.Text != "New hires get 25 days [1]." {
+		t.Errorf("Expected trimmed answer, got %q", answer.Text)
+	}
+	if len(answer.Sources) != 2 {
+		t.Fatalf("Expected 2 sources, got %+v", answer.Sources)
+	}
+	top := answer.Sources[0]
+	if top.Path != handbook || top.Chunk != 0 || top.Score != 1 || !strings.Contains(top.Text, "25 vacation days") {
+		t.Errorf("Expected the vacation chunk first, got %+v", top)
+	}
+	if !strings.Contains(system, "[1] ("+handbook+")\nVacation: new hires") {
+		t.Errorf("Expected numbered sources in the prompt, got %q", system)
+	}
+
+	// 3 chunks and the question; chunks are embedded only once
+	if n := atomic.LoadInt32(&embeds); n != 4 {
+		t.Errorf("Expected 4 embeddings, got %d", n)
+	}
+	qa.Ask(context.Background(), "Is parking free?")
+	if n := atomic.LoadInt32(&embeds); n != 5 {
+		t.Errorf("Expected only the second question to be embedded, got %d embeddings", n)
+	}
+}
+
+func TestQAErrors(t *testing.T) {
+	var system string
+	var embeds int32
+	client := newTestClient(t, &system, &embeds)
+
+	_, err := New(client, "llama3").Ask(context.Background(), "Anything?")
+	if err == nil || !strings.Contains(err.Error(), "no documents have been added") {
+		t.Errorf("Expected error without documents, got %v", err)
+	}
+
+	qa := New(client, "llama3").AddFiles(filepath.Join(t.TempDir(), "missing.txt"))
+	_, err = qa.Ask(context.Background(), "Anything?")
+	if err == nil || !strings.Contains(err.Error(), "failed to read document") {
+		t.Errorf("Expected read error, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnsembleResult is the response of one model in an ensemble.
+type EnsembleResult struct {
+	Model    string
+	Response *GenerateResponse
+	// Err is set if the model failed, in which case Response is nil.
+	Err error
+	// Duration is how long the model took to respond.
+	Duration time.Duration
+	// Rank is the position assigned by the judge, starting at 1, or 0 if
+	// the responses were not ranked.
+	Rank int
+}
+
+// EnsembleOptions holds optional settings for EnsembleGenerateWithOptions.
+type EnsembleOptions struct {
+	// JudgeModel, if set, is asked to rank the successful responses from
+	// best to worst, and the results are sorted accordingly.
+	JudgeModel string
+	// JudgeCriteria describes what makes a response better, for example
+	// "accuracy and brevity". It defaults to overall quality.
+	JudgeCriteria string
+	// RequestOptions are passed through to every call.
+	RequestOptions []RequestOption
+}
+
+// EnsembleGenerate sends the same generation request to several models
+// concurrently and returns all of their results, in the order of models.
+// Failures of individual models are reported in their results; an error is
+// only returned if every model failed.
+func (c *Client) EnsembleGenerate(ctx context.Context, models []string, req *GenerateRequest) ([]EnsembleResult, error) {
+	return c.EnsembleGenerateWithOptions(ctx, models, req, nil)
+}
+
+// EnsembleGenerateWithOptions behaves like EnsembleGenerate, applying the
+// given EnsembleOptions. With a judge model, results are sorted by rank,
+// with failed and unranked results last. If ranking fails, the unsorted
+// results are returned along with the error.
+func (c *Client) EnsembleGenerateWithOptions(ctx context.Context, models []string, req *GenerateRequest, opts *EnsembleOptions) ([]EnsembleResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("generate request cannot be nil")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("at least one model is required")
+	}
+	if opts == nil {
+		opts = &EnsembleOptions{}
+	}
+
+	results := make([]EnsembleResult, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(result *EnsembleResult, model string) {
+			defer wg.Done()
+			reqCopy := *req
+			reqCopy.Model = model
+			start := time.Now()
+			result.Model = model
+			result.Response, result.Err = c.Generate(ctx, &reqCopy, opts.RequestOptions...)
+			result.Duration = time.Since(start)
+		}(&results[i], model)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, result := range results {
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+	if succeeded == 0 {
+		return results, fmt.Errorf("all %d models failed: %w", len(models), results[0].Err)
+	}
+
+	if opts.JudgeModel == "" || succeeded < 2 {
+		return results, nil
+	}
+	if err := c.rankEnsemble(ctx, req.Prompt, results, opts); err != nil {
+		return results, fmt.Errorf("failed to rank responses: %w", err)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		ri, rj := results[i].Rank, results[j].Rank
+		if ri == 0 || rj == 0 {
+			return rj == 0 && ri != 0
+		}
+		return ri < rj
+	})
+	return results, nil
+}
+
+// ensembleRankingPattern extracts the ranking from the judge's reply.
+var ensembleRankingPattern = regexp.MustCompile(`(?i)ranking\s*:\s*([\d\s,>]+)`)
+
+// rankEnsemble asks the judge model to rank the successful results and sets
+// their Rank.
+func (c *Client) rankEnsemble(ctx context.Context, prompt string, results []EnsembleResult, opts *EnsembleOptions) error {
+	var candidates []*EnsembleResult
+	var responses []string
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		candidates = append(candidates, &results[i])
+		responses = append(responses, results[i].Response.Response)
+	}
+
+	ranks, err := c.judgeRanking(ctx, opts.JudgeModel, opts.JudgeCriteria, prompt, responses, opts.RequestOptions)
+	if err != nil {
+		return err
+	}
+	for i, rank := range ranks {
+		candidates[i].Rank = rank
+	}
+	return nil
+}
+
+// judgeRanking asks a judge model to rank responses to a prompt from best to
+// worst by the given criteria. It returns the rank of each response,
+// starting at 1, or 0 for responses the judge left out.
+func (c *Client) judgeRanking(ctx context.Context, judge, criteria, prompt string, responses []string, opts []RequestOption) ([]int, error) {
+	if criteria == "" {
+		criteria = "overall quality, correctness and helpfulness"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question:\n%s\n\n", prompt)
+	for i, response := range responses {
+		fmt.Fprintf(&b, "Response %d:\n%s\n\n", i+1, response)
+	}
+
+	system := fmt.Sprintf("You are judging responses to a question by %s. "+
+		"Rank all %d responses from best to worst. "+
+		"End your reply with a line of the form RANKING: <response numbers separated by commas>.", criteria, len(responses))
+
+	reply, err := c.Ask(ctx, judge, system, b.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := ensembleRankingPattern.FindAllStringSubmatch(reply, -1)
+	if matches == nil {
+		return nil, fmt.Errorf("unexpected judge reply %q", reply)
+	}
+	fields := strings.FieldsFunc(matches[len(matches)-1][1], func(r rune) bool {
+		return r == ',' || r == '>' || r == ' ' || r == '\n' || r == '\t'
+	})
+
+	ranks := make([]int, len(responses))
+	rank := 1
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(responses) || ranks[n-1] != 0 {
+			continue
+		}
+		ranks[n-1] = rank
+		rank++
+	}
+	if rank == 1 {
+		return nil, fmt.Errorf("unexpected judge reply %q", reply)
+	}
+	return ranks, nil
+}
@@ -0,0 +1,63 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientEnsembleGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			var req GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Model == "broken" {
+				http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(GenerateResponse{Model: req.Model, Response: "answer from " + req.Model, Done: true})
+		case "/api/chat":
+			json.NewEncoder(w).Encode(ChatResponse{
+				Message: Message{Role: "assistant", Content: "Response 2 is more precise.\nRANKING: 2, 1"},
+				Done:    true,
+			})
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	req := &GenerateRequest{Prompt: "Why is the sky blue?"}
+
+	results, err := client.EnsembleGenerate(ctx, []string{"llama2", "broken", "mistral"}, req)
+	assertNoError(t, err)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Response.Response != "answer from llama2" || results[2].Response.Response != "answer from mistral" {
+		t.Errorf("Expected results in model order, got %+v", results)
+	}
+	if results[1].Err == nil {
+		t.Errorf("Expected broken model to report an error")
+	}
+
+	results, err = client.EnsembleGenerateWithOptions(ctx, []string{"llama2", "broken", "mistral"}, req, &EnsembleOptions{JudgeModel: "judge"})
+	assertNoError(t, err)
+	order := []struct {
+		model string
+		rank  int
+	}{{"mistral", 1}, {"llama2", 2}, {"broken", 0}}
+	for i, want := range order {
+		if results[i].Model != want.model || results[i].Rank != want.rank {
+			t.Errorf("Expected %s with rank %d at position %d, got %s with rank %d", want.model, want.rank, i, results[i].Model, results[i].Rank)
+		}
+	}
+
+	_, err = client.EnsembleGenerate(ctx, []string{"broken"}, req)
+	assertErrorContains(t, err, "all 1 models failed")
+}
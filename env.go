@@ -0,0 +1,114 @@
+package gollama
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by NewClientFromEnv. OLLAMA_HOST follows the
+// conventions of the official ollama CLI and is also honored by NewClient.
+const (
+	// EnvHost is the server address, e.g. "gpu-box", "gpu-box:11434",
+	// ":11434" or "https://ollama.example.com".
+	EnvHost = "OLLAMA_HOST"
+	// EnvTimeout is the total timeout of non-streaming calls, as a Go
+	// duration ("45s") or a number of seconds ("45").
+	EnvTimeout = "GOLLAMA_TIMEOUT"
+	// EnvModel is the default model of requests that do not name one.
+	EnvModel = "GOLLAMA_MODEL"
+	// EnvAuthToken is sent as a bearer token in the Authorization header.
+	EnvAuthToken = "GOLLAMA_AUTH_TOKEN"
+)
+
+// defaultPort is the port of the Ollama server when OLLAMA_HOST leaves it
+// out and has no scheme.
+const defaultPort = "11434"
+
+// NewClientFromEnv creates a client configured from environment variables,
+// so that deployments can point an application at a server without any
+// configuration code of their own. It reads OLLAMA_HOST, GOLLAMA_TIMEOUT,
+// GOLLAMA_MODEL and GOLLAMA_AUTH_TOKEN; unset variables keep the defaults
+// of NewClientWithOptions. The given options are applied after the
+// environment and take precedence over it.
+//
+// Example:
+//
+//	// OLLAMA_HOST=gpu-box GOLLAMA_MODEL=llama3 ./app
+//	client, err := gollama.NewClientFromEnv()
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	var envOpts []ClientOption
+
+	if s := os.Getenv(EnvTimeout); s != "" {
+		timeout, err := parseEnvDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvTimeout, err)
+		}
+		envOpts = append(envOpts, WithTimeout(timeout))
+	}
+	if model := os.Getenv(EnvModel); model != "" {
+		envOpts = append(envOpts, WithDefaultModel(model))
+	}
+	if token := os.Getenv(EnvAuthToken); token != "" {
+		envOpts = append(envOpts, WithHeader("Authorization", "Bearer "+token))
+	}
+
+	return NewClientWithOptions(envHost(), append(envOpts, opts...)...)
+}
+
+// envHost returns the server URL given by OLLAMA_HOST, or an empty string
+// if it is not set.
+func envHost() string {
+	return ParseHost(os.Getenv(EnvHost))
+}
+
+// ParseHost turns a server address in the form accepted by OLLAMA_HOST into
+// a base URL. The scheme defaults to http, and the port to 11434 unless a
+// scheme is given, in which case the scheme's own port is used. An empty
+// host, or an unspecified one such as "0.0.0.0", means localhost. An empty
+// string is returned for an empty address.
+func ParseHost(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+
+	scheme, hostport, ok := strings.Cut(s, "://")
+	port := defaultPort
+	switch {
+	case !ok:
+		scheme, hostport = "http", s
+	case scheme == "http":
+		port = "80"
+	case scheme == "https":
+		port = "443"
+	}
+
+	hostport, path, _ := strings.Cut(hostport, "/")
+	host, p, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.Trim(hostport, "[]")
+	} else if p != "" {
+		port = p
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	baseURL := scheme + "://" + net.JoinHostPort(host, port)
+	if path = strings.TrimSuffix(path, "/"); path != "" {
+		baseURL += "/" + path
+	}
+	return baseURL
+}
+
+// parseEnvDuration parses a Go duration, or a plain number of seconds.
+func parseEnvDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,82 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"gpu-box", "http://gpu-box:11434"},
+		{"gpu-box:8080", "http://gpu-box:8080"},
+		{":11435", "http://localhost:11435"},
+		{"0.0.0.0", "http://localhost:11434"},
+		{"[::]:9000", "http://localhost:9000"},
+		{"http://gpu-box", "http://gpu-box:80"},
+		{"https://ollama.example.com", "https://ollama.example.com:443"},
+		{"https://ollama.example.com:8443/ollama/", "https://ollama.example.com:8443/ollama"},
+		{"[fe80::1]", "http://[fe80::1]:11434"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseHost(tt.in); got != tt.want {
+			t.Errorf("ParseHost(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvHost, server.URL)
+	t.Setenv(EnvTimeout, "45")
+	t.Setenv(EnvModel, "mistral")
+	t.Setenv(EnvAuthToken, "secret")
+
+	client, err := NewClientFromEnv(WithDefaultModel("llama3"))
+	assertNoError(t, err)
+
+	if client.BaseURL() != ParseHost(server.URL) {
+		t.Errorf("Expected base URL from OLLAMA_HOST, got %s", client.BaseURL())
+	}
+	if client.timeout != 45*time.Second {
+		t.Errorf("Expected 45s timeout, got %v", client.timeout)
+	}
+	if client.defaultModel != "llama3" {
+		t.Errorf("Expected explicit option to override GOLLAMA_MODEL, got %s", client.defaultModel)
+	}
+
+	_, err = client.List(context.Background())
+	assertNoError(t, err)
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", auth)
+	}
+
+	t.Setenv(EnvTimeout, "soon")
+	_, err = NewClientFromEnv()
+	assertErrorContains(t, err, "invalid GOLLAMA_TIMEOUT")
+
+	// NewClient only falls back to OLLAMA_HOST when no host is given
+	client, err = NewClient()
+	assertNoError(t, err)
+	if client.BaseURL() != ParseHost(server.URL) {
+		t.Errorf("Expected NewClient to honor OLLAMA_HOST, got %s", client.BaseURL())
+	}
+	client, err = NewClient("http://other:11434")
+	assertNoError(t, err)
+	if client.BaseURL() != "http://other:11434" {
+		t.Errorf("Expected explicit host to win, got %s", client.BaseURL())
+	}
+}
@@ -0,0 +1,211 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// EscalationCheck inspects the output of the small model of an Escalator.
+// It returns a non-empty reason if the request should be escalated to the
+// large model.
+type EscalationCheck func(ctx context.Context, prompt, output string) (reason string)
+
+// declinePattern matches common ways models decline or admit ignorance.
+var declinePattern = regexp.MustCompile(`(?i)\b(I('m| am) (sorry|unable|not able)|I can(no|')t (help|assist|answer|provide)|I don'?t know|as an AI( language model)?)\b`)
+
+// DeclineCheck escalates when the output is empty or the model declines to
+// answer, for example with "I'm sorry, I can't help with that".
+func DeclineCheck() EscalationCheck {
+	return func(ctx context.Context, prompt, output string) string {
+		if strings.TrimSpace(output) == "" {
+			return "empty output"
+		}
+		if match := declinePattern.FindString(output); match != "" {
+			return fmt.Sprintf("model declined (%q)", match)
+		}
+		return ""
+	}
+}
+
+// JSONCheck escalates when the output is not valid JSON, or when validate,
+// if not nil, rejects it. Use validate to check the document against the
+// expected schema, for example by unmarshaling it into a struct.
+func JSONCheck(validate func(output []byte) error) EscalationCheck {
+	return func(ctx context.Context, prompt, output string) string {
+		data := []byte(strings.TrimSpace(output))
+		if !json.Valid(data) {
+			return "output is not valid JSON"
+		}
+		if validate != nil {
+			if err := validate(data); err != nil {
+				return fmt.Sprintf("output failed validation: %v", err)
+			}
+		}
+		return ""
+	}
+}
+
+// judgeScorePattern extracts the score from the judge's reply.
+var judgeScorePattern = regexp.MustCompile(`(?i)score\s*:\s*(\d+(?:\.\d+)?)`)
+
+// JudgeCheck asks model to score the output from 1 to 10 and escalates when
+// the score is below minScore, or when the judge fails.
+func JudgeCheck(client *Client, model string, minScore float64) EscalationCheck {
+	const system = "You are grading an answer to a question for correctness, completeness and helpfulness. " +
+		"Reply with a line of the form SCORE: <number from 1 to 10>."
+
+	return func(ctx context.Context, prompt, output string) string {
+		reply, err := client.Ask(ctx, model, system, fmt.Sprintf("Question:\n%s\n\nAnswer:\n%s", prompt, output))
+		if err != nil {
+			return fmt.Sprintf("judge failed: %v", err)
+		}
+		match := judgeScorePattern.FindStringSubmatch(reply)
+		if match == nil {
+			return fmt.Sprintf("judge failed: unexpected reply %q", reply)
+		}
+		score, _ := strconv.ParseFloat(match[1], 64)
+		if score < minScore {
+			return fmt.Sprintf("judge scored %g, below %g", score, minScore)
+		}
+		return ""
+	}
+}
+
+// EscalationResult records how an Escalator served a request.
+type EscalationResult struct {
+	// Model is the model whose output was returned.
+	Model string
+	// Escalated is true if the large model served the request.
+	Escalated bool
+	// Reason explains why the request was escalated.
+	Reason string
+}
+
+// EscalationStats counts the requests served by an Escalator.
+type EscalationStats struct {
+	Requests    int64
+	Escalations int64
+}
+
+// Escalator first tries a fast small model and escalates to a larger one
+// when the small model fails or one of its checks rejects the output. Checks
+// run in order and the first rejection escalates.
+//
+// Example:
+//
+//	esc := gollama.NewEscalator(client, "llama3.2:3b", "llama3:70b",
+//		gollama.DeclineCheck(),
+//		gollama.JudgeCheck(client, "llama3:8b", 7),
+//	)
+//	resp, result, err := esc.Generate(ctx, req)
+//
+// An Escalator is safe for concurrent use.
+type Escalator struct {
+	client      *Client
+	small       string
+	large       string
+	checks      []EscalationCheck
+	requests    atomic.Int64
+	escalations atomic.Int64
+}
+
+// NewEscalator creates an escalator from small to large using the given
+// checks.
+func NewEscalator(client *Client, small, large string, checks ...EscalationCheck) *Escalator {
+	return &Escalator{client: client, small: small, large: large, checks: checks}
+}
+
+// Stats returns the number of requests served so far and how many of them
+// were escalated.
+func (e *Escalator) Stats() EscalationStats {
+	return EscalationStats{Requests: e.requests.Load(), Escalations: e.escalations.Load()}
+}
+
+// Generate serves a generation request. The Model field of req is ignored.
+func (e *Escalator) Generate(ctx context.Context, req *GenerateRequest, opts ...RequestOption) (*GenerateResponse, *EscalationResult, error) {
+	if req == nil {
+		return nil, nil, fmt.Errorf("generate request cannot be nil")
+	}
+
+	var resp *GenerateResponse
+	result, err := e.serve(ctx, req.Prompt, func(model string) (string, error) {
+		reqCopy := *req
+		reqCopy.Model = model
+		var err error
+		if resp, err = e.client.Generate(ctx, &reqCopy, opts...); err != nil {
+			return "", err
+		}
+		return resp.Response, nil
+	})
+	if err != nil {
+		return nil, result, err
+	}
+	return resp, result, nil
+}
+
+// Chat serves a chat request. The Model field of req is ignored, and checks
+// see the last user message as the prompt.
+func (e *Escalator) Chat(ctx context.Context, req *ChatRequest, opts ...RequestOption) (*ChatResponse, *EscalationResult, error) {
+	if req == nil {
+		return nil, nil, fmt.Errorf("chat request cannot be nil")
+	}
+
+	var prompt string
+	for _, msg := range req.Messages {
+		if msg.Role == "user" {
+			prompt = msg.Content
+		}
+	}
+
+	var resp *ChatResponse
+	result, err := e.serve(ctx, prompt, func(model string) (string, error) {
+		reqCopy := *req
+		reqCopy.Model = model
+		var err error
+		if resp, err = e.client.Chat(ctx, &reqCopy, opts...); err != nil {
+			return "", err
+		}
+		return resp.Message.Content, nil
+	})
+	if err != nil {
+		return nil, result, err
+	}
+	return resp, result, nil
+}
+
+// serve calls fn with the small model, runs the checks, and calls fn again
+// with the large model if needed.
+func (e *Escalator) serve(ctx context.Context, prompt string, fn func(model string) (string, error)) (*EscalationResult, error) {
+	e.requests.Add(1)
+
+	output, err := fn(e.small)
+	if err != nil && ctx.Err() != nil {
+		return &EscalationResult{Model: e.small}, err
+	}
+
+	var reason string
+	if err != nil {
+		reason = fmt.Sprintf("small model failed: %v", err)
+	} else {
+		for _, check := range e.checks {
+			if reason = check(ctx, prompt, output); reason != "" {
+				break
+			}
+		}
+	}
+	if reason == "" {
+		return &EscalationResult{Model: e.small}, nil
+	}
+
+	e.escalations.Add(1)
+	result := &EscalationResult{Model: e.large, Escalated: true, Reason: reason}
+	if _, err := fn(e.large); err != nil {
+		return result, err
+	}
+	return result, nil
+}
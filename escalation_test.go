@@ -0,0 +1,96 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEscalationChecks(t *testing.T) {
+	ctx := context.Background()
+
+	decline := DeclineCheck()
+	if reason := decline(ctx, "q", "The capital of France is Paris."); reason != "" {
+		t.Errorf("Expected answer to pass, got %q", reason)
+	}
+	if reason := decline(ctx, "q", "I'm sorry, but I can't help with that."); reason == "" {
+		t.Errorf("Expected refusal to escalate")
+	}
+
+	type answer struct {
+		City string `json:"city"`
+	}
+	check := JSONCheck(func(data []byte) error {
+		var a answer
+		if err := json.Unmarshal(data, &a); err != nil {
+			return err
+		}
+		if a.City == "" {
+			return errors.New("city is required")
+		}
+		return nil
+	})
+	if reason := check(ctx, "q", ` {"city":"Paris"} `); reason != "" {
+		t.Errorf("Expected valid JSON to pass, got %q", reason)
+	}
+	if reason := check(ctx, "q", `{"city":`); !strings.Contains(reason, "not valid JSON") {
+		t.Errorf("Expected invalid JSON to escalate, got %q", reason)
+	}
+	if reason := check(ctx, "q", `{"country":"France"}`); !strings.Contains(reason, "city is required") {
+		t.Errorf("Expected schema failure to escalate, got %q", reason)
+	}
+}
+
+func TestEscalator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var content string
+		switch {
+		case req.Model == "judge":
+			content = "SCORE: 4"
+			if strings.Contains(req.Messages[1].Content, "Paris") {
+				content = "SCORE: 9"
+			}
+		case req.Model == "small" && strings.Contains(req.Messages[0].Content, "France"):
+			content = "Paris"
+		case req.Model == "small":
+			content = "Maybe Rome?"
+		default:
+			content = "Canberra"
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Model: req.Model, Message: Message{Role: "assistant", Content: content}, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	esc := NewEscalator(client, "small", "large", DeclineCheck(), JudgeCheck(client, "judge", 7))
+	ctx := context.Background()
+
+	resp, result, err := esc.Chat(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "Capital of France?"}}})
+	assertNoError(t, err)
+	if result.Escalated || resp.Message.Content != "Paris" {
+		t.Errorf("Expected small model to serve, got %+v", result)
+	}
+
+	resp, result, err = esc.Chat(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "Capital of Australia?"}}})
+	assertNoError(t, err)
+	if !result.Escalated || result.Model != "large" || resp.Message.Content != "Canberra" {
+		t.Errorf("Expected escalation to large model, got %+v", result)
+	}
+	if !strings.Contains(result.Reason, "judge scored 4") {
+		t.Errorf("Expected judge reason, got %q", result.Reason)
+	}
+
+	stats := esc.Stats()
+	if stats.Requests != 2 || stats.Escalations != 1 {
+		t.Errorf("Expected 2 requests and 1 escalation, got %+v", stats)
+	}
+}
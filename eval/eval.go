@@ -0,0 +1,315 @@
+// Package eval regression-tests prompts against golden outputs.
+//
+// A Suite holds cases, each with an input and what a good answer looks like:
+// an expected output, a pattern it must match or a rubric for a judge model.
+// Run sends every case to every model and scores the outputs with the
+// suite's scorers:
+//
+//	suite := &eval.Suite{
+//		Name: "capitals",
+//		Cases: []eval.Case{
+//			{Name: "france", Input: "Capital of France? One word.", Expected: "Paris"},
+//			{Name: "explain", Input: "Why is Paris the capital?", Rubric: "Mentions history and politics"},
+//		},
+//		Scorers: []eval.Scorer{
+//			eval.Exact(),
+//			eval.Similarity(client, "nomic-embed-text"),
+//			eval.Judge(client, "llama3:70b"),
+//		},
+//	}
+//	report, err := suite.Run(ctx, client, "llama3", "mistral")
+//	json.NewEncoder(os.Stdout).Encode(report)
+//
+// Scorers return ErrSkip for cases they cannot score, such as Exact for a
+// case without an expected output, so one suite can mix kinds of cases.
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// ErrSkip is returned by a scorer that does not apply to a case.
+var ErrSkip = errors.New("scorer does not apply")
+
+// Case is a single evaluation case.
+type Case struct {
+	Name  string
+	Input string
+	// Expected is the golden output, used by Exact and Similarity.
+	Expected string
+	// Pattern is a regular expression the output must match, used by Regex.
+	Pattern string
+	// Rubric describes a good answer for Judge. Without a rubric the judge
+	// compares the output with Expected.
+	Rubric string
+}
+
+// Scorer scores the output of a model for a case, from 0 to 1.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c Case, output string) (float64, error)
+}
+
+// scorerFunc adapts a function to the Scorer interface.
+type scorerFunc struct {
+	name string
+	fn   func(ctx context.Context, c Case, output string) (float64, error)
+}
+
+func (s scorerFunc) Name() string { return s.name }
+
+func (s scorerFunc) Score(ctx context.Context, c Case, output string) (float64, error) {
+	return s.fn(ctx, c, output)
+}
+
+// ScorerFunc creates a named scorer from a function.
+func ScorerFunc(name string, fn func(ctx context.Context, c Case, output string) (float64, error)) Scorer {
+	return scorerFunc{name: name, fn: fn}
+}
+
+// Exact scores 1 if the output equals Expected, ignoring surrounding
+// whitespace, and 0 otherwise.
+func Exact() Scorer {
+	return ScorerFunc("exact", func(ctx context.Context, c Case, output string) (float64, error) {
+		if c.Expected == "" {
+			return 0, ErrSkip
+		}
+		if strings.TrimSpace(output) == strings.TrimSpace(c.Expected) {
+			return 1, nil
+		}
+		return 0, nil
+	})
+}
+
+// Regex scores 1 if the output matches Pattern and 0 otherwise.
+func Regex() Scorer {
+	return ScorerFunc("regex", func(ctx context.Context, c Case, output string) (float64, error) {
+		if c.Pattern == "" {
+			return 0, ErrSkip
+		}
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pattern: %w", err)
+		}
+		if re.MatchString(output) {
+			return 1, nil
+		}
+		return 0, nil
+	})
+}
+
+// Similarity scores the cosine similarity between the embeddings of the
+// output and Expected, computed with model. Negative similarities score 0.
+func Similarity(client *gollama.Client, model string) Scorer {
+	return ScorerFunc("similarity", func(ctx context.Context, c Case, output string) (float64, error) {
+		if c.Expected == "" {
+			return 0, ErrSkip
+		}
+		embeddings, err := client.EmbedTexts(ctx, model, []string{output, c.Expected})
+		if err != nil {
+			return 0, err
+		}
+		return clamp(gollama.CosineSimilarity(embeddings[0], embeddings[1])), nil
+	})
+}
+
+// judgeScorePattern extracts the score from the judge's reply.
+var judgeScorePattern = regexp.MustCompile(`(?i)score\s*:\s*(\d+(?:\.\d+)?)`)
+
+// Judge asks model to grade the output from 1 to 10 against the Rubric, or
+// against Expected if the case has no rubric. The grade is scaled to 0..1.
+func Judge(client *gollama.Client, model string) Scorer {
+	const system = "You are grading an answer to a question. " +
+		"Reply with a line of the form SCORE: <number from 1 to 10>, where 10 is a perfect answer."
+
+	return ScorerFunc("judge", func(ctx context.Context, c Case, output string) (float64, error) {
+		var criteria string
+		switch {
+		case c.Rubric != "":
+			criteria = "Grading rubric:\n" + c.Rubric
+		case c.Expected != "":
+			criteria = "Reference answer:\n" + c.Expected
+		default:
+			return 0, ErrSkip
+		}
+
+		prompt := fmt.Sprintf("Question:\n%s\n\n%s\n\nAnswer to grade:\n%s", c.Input, criteria, output)
+		reply, err := client.Ask(ctx, model, system, prompt)
+		if err != nil {
+			return 0, err
+		}
+		match := judgeScorePattern.FindStringSubmatch(reply)
+		if match == nil {
+			return 0, fmt.Errorf("unexpected judge reply %q", reply)
+		}
+		score, _ := strconv.ParseFloat(match[1], 64)
+		return clamp((score - 1) / 9), nil
+	})
+}
+
+// clamp limits a score to 0..1.
+func clamp(score float64) float64 {
+	switch {
+	case score < 0:
+		return 0
+	case score > 1:
+		return 1
+	default:
+		return score
+	}
+}
+
+// Suite is a set of cases scored by a set of scorers.
+type Suite struct {
+	Name    string
+	Cases   []Case
+	Scorers []Scorer
+	// PassThreshold is the mean score at which a result passes. Zero means
+	// every applicable scorer must give a perfect score.
+	PassThreshold float64
+	// Options are model parameters sent with every request. Pin the seed
+	// and a temperature of 0 for reproducible runs.
+	Options gollama.Options
+}
+
+// Result is the outcome of one case on one model.
+type Result struct {
+	Case     string             `json:"case"`
+	Model    string             `json:"model"`
+	Output   string             `json:"output"`
+	Scores   map[string]float64 `json:"scores"`
+	Score    float64            `json:"score"`
+	Passed   bool               `json:"passed"`
+	Duration time.Duration      `json:"duration"`
+	// Error describes a failed generation or scorer. A result with an
+	// error never passes.
+	Error string `json:"error,omitempty"`
+}
+
+// ModelSummary aggregates the results of a model.
+type ModelSummary struct {
+	Model     string  `json:"model"`
+	Cases     int     `json:"cases"`
+	Passed    int     `json:"passed"`
+	MeanScore float64 `json:"mean_score"`
+}
+
+// Report is the outcome of running a suite.
+type Report struct {
+	Suite   string         `json:"suite"`
+	Results []Result       `json:"results"`
+	Models  []ModelSummary `json:"models"`
+}
+
+// Passed reports whether every result passed.
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the results that did not pass.
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Run evaluates every case against every model. Failures of individual
+// cases are recorded in the report; an error is only returned if the suite
+// is invalid or the context is done.
+func (s *Suite) Run(ctx context.Context, client *gollama.Client, models ...string) (*Report, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("at least one model is required")
+	}
+	if len(s.Scorers) == 0 {
+		return nil, fmt.Errorf("at least one scorer is required")
+	}
+
+	threshold := s.PassThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	report := &Report{Suite: s.Name}
+	for _, model := range models {
+		summary := ModelSummary{Model: model}
+		var total float64
+
+		for _, c := range s.Cases {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			result := s.runCase(ctx, client, model, c)
+			result.Passed = result.Error == "" && result.Score >= threshold
+
+			summary.Cases++
+			total += result.Score
+			if result.Passed {
+				summary.Passed++
+			}
+			report.Results = append(report.Results, result)
+		}
+
+		if summary.Cases > 0 {
+			summary.MeanScore = total / float64(summary.Cases)
+		}
+		report.Models = append(report.Models, summary)
+	}
+	return report, nil
+}
+
+// runCase generates the output of a case and scores it.
+func (s *Suite) runCase(ctx context.Context, client *gollama.Client, model string, c Case) Result {
+	result := Result{Case: c.Name, Model: model, Scores: make(map[string]float64)}
+
+	start := time.Now()
+	resp, err := client.Generate(ctx, &gollama.GenerateRequest{Model: model, Prompt: c.Input, Options: s.Options})
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Sprintf("generate: %v", err)
+		return result
+	}
+	result.Output = resp.Response
+
+	var errs []string
+	var total float64
+	for _, scorer := range s.Scorers {
+		score, err := scorer.Score(ctx, c, result.Output)
+		if errors.Is(err, ErrSkip) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", scorer.Name(), err))
+			continue
+		}
+		result.Scores[scorer.Name()] = score
+		total += score
+	}
+
+	if len(errs) > 0 {
+		result.Error = strings.Join(errs, "; ")
+	} else if len(result.Scores) == 0 {
+		result.Error = "no scorer applies to the case"
+	}
+	if len(result.Scores) > 0 {
+		result.Score = total / float64(len(result.Scores))
+	}
+	return result
+}
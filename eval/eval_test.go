@@ -0,0 +1,164 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+func newTestServer(t *testing.T) *gollama.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			var req gollama.GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			answer := "Paris"
+			if req.Model == "weak" {
+				answer = "Lyon, I think"
+			}
+			if strings.Contains(req.Prompt, "Why") {
+				answer = "Because of history."
+			}
+			json.NewEncoder(w).Encode(gollama.GenerateResponse{Model: req.Model, Response: answer, Done: true})
+		case "/api/chat":
+			var req gollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			score := "SCORE: 10"
+			if strings.Contains(req.Messages[len(req.Messages)-1].Content, "Lyon") {
+				score = "SCORE: 1"
+			}
+			json.NewEncoder(w).Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: score}, Done: true})
+		case "/api/embeddings":
+			var req gollama.EmbeddingRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			embedding := []float64{1, 0}
+			if strings.Contains(req.Prompt, "Lyon") {
+				embedding = []float64{0, 1}
+			}
+			json.NewEncoder(w).Encode(gollama.EmbeddingResponse{Embedding: embedding})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+func TestScorers(t *testing.T) {
+	ctx := context.Background()
+	c := Case{Input: "Capital of France?", Expected: "Paris", Pattern: `(?i)\bparis\b`}
+
+	tests := []struct {
+		name   string
+		scorer Scorer
+		output string
+		want   float64
+	}{
+		{"Exact match", Exact(), " Paris\n", 1},
+		{"Exact mismatch", Exact(), "Paris, France", 0},
+		{"Regex match", Regex(), "It is paris.", 1},
+		{"Regex mismatch", Regex(), "It is Lyon.", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scorer.Score(ctx, c, tt.output)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected score %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := Exact().Score(ctx, Case{Rubric: "anything"}, "x"); err != ErrSkip {
+		t.Errorf("Expected ErrSkip without expected output, got %v", err)
+	}
+	if _, err := Regex().Score(ctx, Case{Pattern: "("}, "x"); err == nil || err == ErrSkip {
+		t.Errorf("Expected invalid pattern error, got %v", err)
+	}
+}
+
+func TestSuiteRun(t *testing.T) {
+	client := newTestServer(t)
+
+	suite := &Suite{
+		Name: "capitals",
+		Cases: []Case{
+			{Name: "france", Input: "Capital of France?", Expected: "Paris"},
+			{Name: "explain", Input: "Why is Paris the capital?", Rubric: "Mentions history"},
+		},
+		Scorers: []Scorer{
+			Exact(),
+			Similarity(client, "embed"),
+			Judge(client, "judge"),
+		},
+	}
+
+	report, err := suite.Run(context.Background(), client, "strong", "weak")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(report.Results))
+	}
+	if report.Passed() {
+		t.Error("Expected the weak model to fail")
+	}
+
+	first := report.Results[0]
+	if !first.Passed || len(first.Scores) != 3 || first.Score != 1 {
+		t.Errorf("Expected a perfect score from all scorers, got %+v", first)
+	}
+	if scores := report.Results[1].Scores; len(scores) != 1 || scores["judge"] != 1 {
+		t.Errorf("Expected only the judge to score the rubric case, got %v", scores)
+	}
+
+	failures := report.Failures()
+	if len(failures) != 1 || failures[0].Model != "weak" || failures[0].Case != "france" {
+		t.Fatalf("Expected only weak/france to fail, got %+v", failures)
+	}
+	if failures[0].Score != 0 {
+		t.Errorf("Expected score 0, got %v", failures[0].Score)
+	}
+
+	if len(report.Models) != 2 || report.Models[0].Passed != 2 || report.Models[1].Passed != 1 {
+		t.Errorf("Unexpected model summaries: %+v", report.Models)
+	}
+
+	if _, err := json.Marshal(report); err != nil {
+		t.Errorf("Expected report to marshal, got %v", err)
+	}
+}
+
+func TestSuiteRunErrors(t *testing.T) {
+	client := newTestServer(t)
+	ctx := context.Background()
+
+	suite := &Suite{Cases: []Case{{Name: "no golden", Input: "hi"}}, Scorers: []Scorer{Exact()}}
+	if _, err := suite.Run(ctx, client); err == nil {
+		t.Error("Expected error without models")
+	}
+
+	report, err := suite.Run(ctx, client, "strong")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Results[0].Passed || !strings.Contains(report.Results[0].Error, "no scorer applies") {
+		t.Errorf("Expected unscored case to fail, got %+v", report.Results[0])
+	}
+}
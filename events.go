@@ -0,0 +1,184 @@
+package gollama
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+// Event types published on an EventBus.
+const (
+	// EventRequestStarted is published when a call sends its request.
+	EventRequestStarted EventType = "request_started"
+	// EventRequestFinished is published when a call's response has been
+	// read and closed, or the request failed without a response.
+	EventRequestFinished EventType = "request_finished"
+	// EventStreamChunk is published for each object of a streamed
+	// response.
+	EventStreamChunk EventType = "stream_chunk"
+	// EventRetry is published when a failed attempt is going to be retried
+	// under the client's retry policy.
+	EventRetry EventType = "retry"
+	// EventHostEjected is published when a ClusterClient stops routing
+	// requests to an unhealthy host.
+	EventHostEjected EventType = "host_ejected"
+	// EventCacheHit is published when a lookup is answered from a cache,
+	// such as the context lengths kept by WithAutoContext.
+	EventCacheHit EventType = "cache_hit"
+)
+
+// Event describes something that happened in a client. Which fields are set
+// depends on the type.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Host is the base URL of the server the event concerns.
+	Host string
+	// Method and Path identify the request of request, retry and chunk
+	// events.
+	Method string
+	Path   string
+	// Status is the HTTP status of a finished request, or of the response
+	// of a retried attempt, and zero if none was received.
+	Status int
+	// Attempt is the number of the failed attempt of a retry event,
+	// starting at 1.
+	Attempt int
+	// Duration is the duration of a finished request, or the delay before
+	// the next attempt of a retry event.
+	Duration time.Duration
+	// Bytes is the size of a stream chunk.
+	Bytes int
+	// Cache and Key identify the entry of a cache hit, such as
+	// "context_length" and a model name.
+	Cache string
+	Key   string
+	// Err is the failure of a finished request, if its request could not
+	// be sent or its response not read, of a retried attempt, or the reason
+	// a host was ejected.
+	Err error
+}
+
+// EventBus delivers the events of one or more clients to any number of
+// subscribers, such as metrics, logs and a UI, so that each can observe the
+// client without wrapping it. Share a bus between clients by passing the
+// same bus to WithEventBus.
+//
+// Subscribers are called synchronously, in the goroutine the event happened
+// in and in the order they subscribed, so they should return quickly. An
+// EventBus is safe for concurrent use.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs []*subscription
+}
+
+// subscription is a subscriber of an EventBus.
+type subscription struct {
+	fn func(Event)
+	// types holds the event types the subscriber receives, or nil for all
+	types map[EventType]bool
+}
+
+// NewEventBus creates an event bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe calls fn with every event published on the bus, or only with
+// events of the given types if any are given. It returns a function that
+// ends the subscription.
+func (b *EventBus) Subscribe(fn func(Event), types ...EventType) (unsubscribe func()) {
+	sub := &subscription{fn: fn}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for i, other := range b.subs {
+				if other == sub {
+					b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+}
+
+// Publish delivers an event to the subscribers, setting its Time if it is
+// zero. Subscribers may subscribe and unsubscribe while it runs.
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.types == nil || sub.types[e.Type] {
+			sub.fn(e)
+		}
+	}
+}
+
+// WithEventBus publishes the client's events on bus. Clients created by a
+// ClusterClient with this option share the bus, which also receives the
+// cluster's host ejections.
+func WithEventBus(bus *EventBus) ClientOption {
+	return func(c *Client) {
+		c.events = bus
+	}
+}
+
+// emit publishes an event on the client's bus, if it has one, setting its
+// Host.
+func (c *Client) emit(e Event) {
+	if c.events == nil {
+		return
+	}
+	e.Host = c.baseURL
+	c.events.Publish(e)
+}
+
+// eventBody publishes EventRequestFinished when a response body is closed,
+// with the first error reading it.
+type eventBody struct {
+	io.ReadCloser
+	client *Client
+	event  Event
+	start  time.Time
+	err    error
+	once   sync.Once
+}
+
+func (b *eventBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *eventBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.event.Duration = time.Since(b.start)
+		b.event.Err = b.err
+		b.client.emit(b.event)
+	})
+	return err
+}
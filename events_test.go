@@ -0,0 +1,191 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventLog records the events of a bus.
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) record(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// ofType returns the recorded events of type t.
+func (l *eventLog) ofType(t EventType) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var events []Event
+	for _, e := range l.events {
+		if e.Type == t {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	var all, retries []EventType
+	unsubscribe := bus.Subscribe(func(e Event) { all = append(all, e.Type) })
+	bus.Subscribe(func(e Event) { retries = append(retries, e.Type) }, EventRetry)
+
+	bus.Publish(Event{Type: EventRequestStarted})
+	bus.Publish(Event{Type: EventRetry})
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(Event{Type: EventRetry})
+
+	if len(all) != 2 || all[0] != EventRequestStarted || all[1] != EventRetry {
+		t.Errorf("Expected started and retry events before unsubscribing, got %v", all)
+	}
+	if len(retries) != 2 {
+		t.Errorf("Expected only retry events, got %v", retries)
+	}
+
+	// Subscribers can unsubscribe themselves, and events get a time
+	var self func()
+	var got Event
+	self = bus.Subscribe(func(e Event) {
+		got = e
+		self()
+	})
+	bus.Publish(Event{Type: EventCacheHit})
+	bus.Publish(Event{Type: EventRequestStarted})
+	if got.Type != EventCacheHit || got.Time.IsZero() {
+		t.Errorf("Expected the first event with a time, got %+v", got)
+	}
+}
+
+func TestClientEvents(t *testing.T) {
+	var failures int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/generate":
+			w.Write([]byte(`{"response":"Hel","done":false}` + "\n" + `{"response":"lo","done":true}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	bus := NewEventBus()
+	log := &eventLog{}
+	bus.Subscribe(log.record)
+	client, err := NewClientWithOptions(server.URL, WithEventBus(bus), WithRetryPolicies(map[Operation]RetryPolicy{
+		OpList: {MaxAttempts: 2, Backoff: time.Millisecond},
+	}))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.List(ctx)
+	assertNoError(t, err)
+	retries := log.ofType(EventRetry)
+	if len(retries) != 1 || retries[0].Attempt != 1 || retries[0].Status != http.StatusServiceUnavailable || retries[0].Path != "/api/tags" {
+		t.Errorf("Expected one retry after a 503, got %+v", retries)
+	}
+
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(*GenerateResponse) {})
+	assertNoError(t, err)
+	chunks := log.ofType(EventStreamChunk)
+	if len(chunks) != 2 || chunks[0].Path != "/api/generate" || chunks[0].Bytes == 0 {
+		t.Errorf("Expected 2 chunk events, got %+v", chunks)
+	}
+
+	_, err = client.Show(ctx, "llama2")
+	if err == nil {
+		t.Fatal("Expected error for unknown endpoint")
+	}
+
+	started := log.ofType(EventRequestStarted)
+	finished := log.ofType(EventRequestFinished)
+	if len(started) != 3 || len(finished) != 3 {
+		t.Fatalf("Expected 3 started and finished requests, got %d and %d", len(started), len(finished))
+	}
+	expected := []struct {
+		path   string
+		status int
+	}{
+		{"/api/tags", http.StatusOK},
+		{"/api/generate", http.StatusOK},
+		{"/api/show", http.StatusNotFound},
+	}
+	for i, e := range finished {
+		if e.Path != expected[i].path || e.Status != expected[i].status || e.Host != server.URL || e.Err != nil {
+			t.Errorf("Expected finished %s with status %d, got %+v", expected[i].path, expected[i].status, e)
+		}
+	}
+
+	// Requests that get no response finish with an error
+	down, err := NewClientWithOptions(downServer(), WithEventBus(bus))
+	assertNoError(t, err)
+	_, err = down.List(ctx)
+	if err == nil {
+		t.Fatal("Expected error for unreachable server")
+	}
+	finished = log.ofType(EventRequestFinished)
+	if last := finished[len(finished)-1]; last.Err == nil || last.Status != 0 {
+		t.Errorf("Expected failed request without status, got %+v", last)
+	}
+}
+
+func TestClientEventsCacheHit(t *testing.T) {
+	var shows int32
+	var numCtx []interface{}
+	server := newContextServer(t, &shows, &numCtx)
+
+	bus := NewEventBus()
+	log := &eventLog{}
+	bus.Subscribe(log.record, EventCacheHit)
+	client, err := NewClientWithOptions(server.URL, WithEventBus(bus), WithAutoContext(nil))
+	assertNoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3", Prompt: "Hi"})
+		assertNoError(t, err)
+	}
+	hits := log.ofType(EventCacheHit)
+	if len(hits) != 2 || hits[0].Cache != "context_length" || hits[0].Key != "llama3:latest" {
+		t.Errorf("Expected 2 context length cache hits, got %+v", hits)
+	}
+}
+
+func TestClusterClientEvents(t *testing.T) {
+	a := newClusterServer(t, "a", "llama2:latest")
+	b := newClusterServer(t, "b", "llama2:latest")
+
+	bus := NewEventBus()
+	log := &eventLog{}
+	cluster, err := NewClusterClient([]string{a.URL, b.URL}, WithEventBus(bus))
+	assertNoError(t, err)
+	bus.Subscribe(func(e Event) {
+		log.record(e)
+		cluster.Hosts()
+	}, EventHostEjected)
+	ctx := context.Background()
+
+	b.setUnavailable(true)
+	assertNoError(t, cluster.CheckHealth(ctx))
+	assertNoError(t, cluster.CheckHealth(ctx))
+	ejected := log.ofType(EventHostEjected)
+	if len(ejected) != 1 || ejected[0].Host != b.URL || ejected[0].Err == nil {
+		t.Errorf("Expected b to be ejected once, got %+v", ejected)
+	}
+}
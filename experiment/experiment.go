@@ -0,0 +1,235 @@
+// Package experiment runs A/B tests between prompt variants.
+//
+// Each Variant pairs a prompt template with a model and a traffic weight.
+// Run picks a variant for each call in proportion to the weights, sends the
+// rendered prompt and records latency and token counts for the variant.
+// Scores from users or graders can be added afterwards with Score, and
+// Report aggregates everything for readout:
+//
+//	exp := experiment.New(client,
+//		experiment.Variant{Name: "terse", Weight: 1, Model: "llama3", Prompt: "Answer briefly: {input}"},
+//		experiment.Variant{Name: "stepwise", Weight: 1, Model: "llama3", Prompt: "Think step by step: {input}"},
+//	)
+//	result, err := exp.Run(ctx, userID, question)
+//	...
+//	exp.Score(result.Variant, rating)
+//	for _, r := range exp.Report() {
+//		fmt.Printf("%s: %d requests, mean score %.2f\n", r.Name, r.Requests, r.MeanScore)
+//	}
+package experiment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// Placeholder is replaced with the input in a variant's prompt. A prompt
+// without a placeholder has the input appended on a new line.
+const Placeholder = "{input}"
+
+// Variant is a prompt under test.
+type Variant struct {
+	// Name identifies the variant in results and reports.
+	Name string
+	// Weight is the variant's share of traffic relative to the other
+	// variants. Variants with a weight of zero receive no traffic.
+	Weight float64
+	// Model is the model to send the prompt to. It may be empty if the
+	// client has a default model.
+	Model string
+	// Prompt is the prompt template, see Placeholder.
+	Prompt string
+	// Options are model parameters sent with the request.
+	Options gollama.Options
+}
+
+// render builds the prompt for input.
+func (v Variant) render(input string) string {
+	if !strings.Contains(v.Prompt, Placeholder) {
+		if v.Prompt == "" {
+			return input
+		}
+		return v.Prompt + "\n" + input
+	}
+	return strings.ReplaceAll(v.Prompt, Placeholder, input)
+}
+
+// Result is the outcome of a single call.
+type Result struct {
+	// Variant is the name of the variant that served the call.
+	Variant  string
+	Response *gollama.GenerateResponse
+	Latency  time.Duration
+}
+
+// VariantReport holds the aggregated metrics of a variant.
+type VariantReport struct {
+	Name     string
+	Weight   float64
+	Requests int
+	Errors   int
+	// MeanLatency is the mean latency of successful requests.
+	MeanLatency time.Duration
+	// PromptTokens and CompletionTokens are totals over successful requests.
+	PromptTokens     int
+	CompletionTokens int
+	Scores           int
+	MeanScore        float64
+}
+
+// stats accumulates the metrics of a variant.
+type stats struct {
+	requests         int
+	errors           int
+	latency          time.Duration
+	promptTokens     int
+	completionTokens int
+	scores           int
+	scoreSum         float64
+}
+
+// Experiment routes calls between variants and aggregates their metrics.
+// An Experiment is safe for concurrent use.
+type Experiment struct {
+	client   *gollama.Client
+	variants []Variant
+	total    float64
+
+	mu    sync.Mutex
+	stats map[string]*stats
+}
+
+// New creates an experiment between the given variants.
+func New(client *gollama.Client, variants ...Variant) *Experiment {
+	e := &Experiment{
+		client:   client,
+		variants: variants,
+		stats:    make(map[string]*stats, len(variants)),
+	}
+	for _, v := range variants {
+		if v.Weight > 0 {
+			e.total += v.Weight
+		}
+		e.stats[v.Name] = &stats{}
+	}
+	return e
+}
+
+// Pick chooses a variant in proportion to the weights. Calls with the same
+// non-empty key always get the same variant, which keeps a user in one arm
+// of the experiment; an empty key picks at random.
+func (e *Experiment) Pick(key string) (Variant, error) {
+	if e.total <= 0 {
+		return Variant{}, fmt.Errorf("no variant has a positive weight")
+	}
+
+	var x float64
+	if key == "" {
+		x = rand.Float64()
+	} else {
+		sum := sha256.Sum256([]byte(key))
+		x = float64(binary.BigEndian.Uint64(sum[:])>>11) / (1 << 53)
+	}
+	x *= e.total
+
+	var last Variant
+	for _, v := range e.variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if x < v.Weight {
+			return v, nil
+		}
+		x -= v.Weight
+		last = v
+	}
+	return last, nil
+}
+
+// Run picks a variant for key, as Pick does, and generates a response to
+// input with it.
+func (e *Experiment) Run(ctx context.Context, key, input string, opts ...gollama.RequestOption) (*Result, error) {
+	variant, err := e.Pick(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &gollama.GenerateRequest{
+		Model:   variant.Model,
+		Prompt:  variant.render(input),
+		Options: variant.Options,
+	}
+
+	start := time.Now()
+	resp, err := e.client.Generate(ctx, req, opts...)
+	latency := time.Since(start)
+
+	e.mu.Lock()
+	s := e.stats[variant.Name]
+	s.requests++
+	if err != nil {
+		s.errors++
+	} else {
+		s.latency += latency
+		s.promptTokens += resp.PromptEvalCount
+		s.completionTokens += resp.EvalCount
+	}
+	e.mu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("variant %q failed: %w", variant.Name, err)
+	}
+	return &Result{Variant: variant.Name, Response: resp, Latency: latency}, nil
+}
+
+// Score records a score for a response of the named variant, for example a
+// user rating or the verdict of a grader.
+func (e *Experiment) Score(variant string, score float64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.stats[variant]
+	if !ok {
+		return fmt.Errorf("unknown variant %q", variant)
+	}
+	s.scores++
+	s.scoreSum += score
+	return nil
+}
+
+// Report returns the metrics of each variant, in the order the variants were
+// given to New.
+func (e *Experiment) Report() []VariantReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reports := make([]VariantReport, 0, len(e.variants))
+	for _, v := range e.variants {
+		s := e.stats[v.Name]
+		r := VariantReport{
+			Name:             v.Name,
+			Weight:           v.Weight,
+			Requests:         s.requests,
+			Errors:           s.errors,
+			PromptTokens:     s.promptTokens,
+			CompletionTokens: s.completionTokens,
+			Scores:           s.scores,
+		}
+		if ok := s.requests - s.errors; ok > 0 {
+			r.MeanLatency = s.latency / time.Duration(ok)
+		}
+		if s.scores > 0 {
+			r.MeanScore = s.scoreSum / float64(s.scores)
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
@@ -0,0 +1,129 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+func TestPickWeights(t *testing.T) {
+	exp := New(nil,
+		Variant{Name: "a", Weight: 3},
+		Variant{Name: "b", Weight: 1},
+		Variant{Name: "off", Weight: 0},
+	)
+
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		v, err := exp.Pick(fmt.Sprintf("user-%d", i))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		counts[v.Name]++
+	}
+	if counts["off"] != 0 {
+		t.Errorf("Expected no traffic for zero weight, got %d", counts["off"])
+	}
+	if counts["a"] < 2800 || counts["a"] > 3200 {
+		t.Errorf("Expected about 3000 picks of a, got %d", counts["a"])
+	}
+
+	first, _ := exp.Pick("sticky")
+	for i := 0; i < 10; i++ {
+		if v, _ := exp.Pick("sticky"); v.Name != first.Name {
+			t.Fatalf("Expected sticky assignment to %s, got %s", first.Name, v.Name)
+		}
+	}
+
+	if _, err := New(nil, Variant{Name: "off"}).Pick(""); err == nil {
+		t.Error("Expected error without positive weights")
+	}
+}
+
+func TestRunAndReport(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+		if req.Model == "broken" {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(gollama.GenerateResponse{
+			Model:           req.Model,
+			Response:        "ok",
+			Done:            true,
+			PromptEvalCount: 10,
+			EvalCount:       5,
+		})
+	}))
+	defer server.Close()
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	exp := New(client,
+		Variant{Name: "terse", Weight: 1, Model: "llama3", Prompt: "Answer briefly: {input}"},
+		Variant{Name: "broken", Weight: 0, Model: "broken"},
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := exp.Run(ctx, "", "why is the sky blue?")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Variant != "terse" {
+			t.Errorf("Expected variant terse, got %s", result.Variant)
+		}
+		if err := exp.Score(result.Variant, float64(i)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	if prompts[0] != "Answer briefly: why is the sky blue?" {
+		t.Errorf("Expected rendered prompt, got %q", prompts[0])
+	}
+	if err := exp.Score("missing", 1); err == nil || !strings.Contains(err.Error(), "unknown variant") {
+		t.Errorf("Expected unknown variant error, got %v", err)
+	}
+
+	report := exp.Report()
+	if len(report) != 2 {
+		t.Fatalf("Expected 2 variant reports, got %d", len(report))
+	}
+	r := report[0]
+	if r.Requests != 2 || r.Errors != 0 || r.PromptTokens != 20 || r.CompletionTokens != 10 {
+		t.Errorf("Unexpected report for terse: %+v", r)
+	}
+	if r.Scores != 2 || r.MeanScore != 0.5 {
+		t.Errorf("Expected mean score 0.5 over 2 scores, got %+v", r)
+	}
+	if r.MeanLatency <= 0 {
+		t.Errorf("Expected positive mean latency, got %v", r.MeanLatency)
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"", "hi"},
+		{"Translate to French:", "Translate to French:\nhi"},
+		{"<{input}> and <{input}>", "<hi> and <hi>"},
+	}
+	for _, tt := range tests {
+		if got := (Variant{Prompt: tt.prompt}).render("hi"); got != tt.want {
+			t.Errorf("render(%q) = %q, expected %q", tt.prompt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Extract pulls the fields of T out of unstructured text. The JSON schema of
+// T, as built by SchemaFor, constrains the model's answer, which is then
+// decoded into a T and checked for missing required fields. Invalid answers
+// are pointed out to the model and asked again, up to three times in all.
+// An empty model uses the client's default model.
+//
+// Example:
+//
+//	type Contact struct {
+//		Name  string `json:"name"`
+//		Email string `json:"email,omitempty" description:"Email address, if given"`
+//	}
+//
+//	contact, err := gollama.Extract[Contact](ctx, client, "llama3", signature)
+func Extract[T any](ctx context.Context, client *Client, model, text string, opts ...RequestOption) (T, error) {
+	var result T
+	schema := SchemaFor[T]()
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	req := &ChatRequest{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: "Extract information from the text given by the user. Respond with JSON matching this schema:\n" +
+				string(encoded) + "\nUse only information stated in the text. Use empty values for required fields the text does not mention."},
+			{Role: "user", Content: text},
+		},
+		Format:  schema,
+		Options: Options{"temperature": 0},
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < chooseAttempts; attempt++ {
+		resp, err := client.Chat(ctx, req, opts...)
+		if err != nil {
+			return result, fmt.Errorf("failed to extract: %w", err)
+		}
+		answer := resp.Message.Content
+		var value T
+		if lastErr = decodeExtraction(answer, schema, &value); lastErr == nil {
+			return value, nil
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", Content: answer},
+			Message{Role: "user", Content: fmt.Sprintf("That answer is invalid: %v. Respond again with JSON matching the schema.", lastErr)},
+		)
+	}
+	return result, fmt.Errorf("failed to extract %T: %w", result, lastErr)
+}
+
+// decodeExtraction decodes an answer to Extract into v, after checking that
+// it has the fields the schema requires.
+func decodeExtraction(answer string, schema map[string]interface{}, v interface{}) error {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(answer), &raw); err != nil {
+		return fmt.Errorf("not valid JSON")
+	}
+	if err := checkRequired(schema, raw, ""); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(answer), v)
+}
+
+// checkRequired reports the first field that a schema requires but value
+// lacks, looking into nested objects and arrays.
+func checkRequired(schema map[string]interface{}, value interface{}, path string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("missing field %q", strings.TrimPrefix(path+"."+name, "."))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, sub := range properties {
+			subSchema, _ := sub.(map[string]interface{})
+			if field, ok := v[name]; ok && subSchema != nil {
+				if err := checkRequired(subSchema, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := checkRequired(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SchemaFor returns a JSON schema describing the JSON encoding of T, for use
+// as the Format of a request. Struct fields follow encoding/json: they are
+// named by their json tag, skipped if tagged "-", and required unless tagged
+// omitempty. A description tag is included as the field's description.
+func SchemaFor[T any]() map[string]interface{} {
+	var v T
+	return schemaOf(reflect.TypeOf(&v).Elem(), map[reflect.Type]bool{})
+}
+
+// timeType is the type of time.Time, which encodes as a string.
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf builds the schema of a type. Types that are being built are
+// tracked in visiting, so that recursive types end in a plain object.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices encode as base64 strings
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := make(map[string]interface{})
+		required := []string{}
+		addStructFields(t, properties, &required, visiting)
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// addStructFields adds the fields of a struct to a schema, flattening
+// embedded structs as encoding/json does.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, properties, required, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaOf(field.Type, visiting)
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		properties[name] = schema
+		if !strings.Contains(","+flags+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
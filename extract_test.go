@@ -0,0 +1,90 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country,omitempty"`
+}
+
+type testContact struct {
+	Name     string        `json:"name" description:"Full name"`
+	Age      int           `json:"age,omitempty"`
+	Emails   []string      `json:"emails"`
+	Address  *testAddress  `json:"address"`
+	Met      time.Time     `json:"met,omitempty"`
+	Friends  []testContact `json:"friends,omitempty"`
+	Internal string        `json:"-"`
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Notes string `json:"notes,omitempty"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor[testContact]()
+
+	encoded, err := json.Marshal(schema)
+	assertNoError(t, err)
+	var got map[string]interface{}
+	json.Unmarshal(encoded, &got)
+
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":   map[string]interface{}{"type": "string", "description": "Full name"},
+			"age":    map[string]interface{}{"type": "integer"},
+			"emails": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"address": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"city":    map[string]interface{}{"type": "string"},
+					"country": map[string]interface{}{"type": "string"},
+				},
+				"required": []interface{}{"city"},
+			},
+			"met":     map[string]interface{}{"type": "string", "format": "date-time"},
+			"friends": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+			"notes":   map[string]interface{}{"type": "string"},
+		},
+		"required": []interface{}{"name", "emails", "address"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected schema:\n got: %s", encoded)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	var requests []ChatRequest
+	client, err := createTestClient(newAnswerServer(t, []string{
+		`{"name":"Ada"}`,
+		`{"name":"Ada","emails":["ada@example.com"],"address":{"country":"UK"}}`,
+		`{"name":"Ada","emails":["ada@example.com"],"address":{"city":"London"},"age":36}`,
+	}, &requests).URL)
+	assertNoError(t, err)
+
+	contact, err := Extract[testContact](context.Background(), client, "llama3", "Ada, 36, London, ada@example.com")
+	assertNoError(t, err)
+	if contact.Name != "Ada" || contact.Age != 36 || contact.Address == nil || contact.Address.City != "London" {
+		t.Errorf("Unexpected contact %+v", contact)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+	if feedback := requests[2].Messages[len(requests[2].Messages)-1].Content; feedback != `That answer is invalid: missing field "address.city". Respond again with JSON matching the schema.` {
+		t.Errorf("Expected nested field to be reported, got %q", feedback)
+	}
+
+	requests = nil
+	client, err = createTestClient(newAnswerServer(t, []string{"not json"}, &requests).URL)
+	assertNoError(t, err)
+	_, err = Extract[testContact](context.Background(), client, "llama3", "text")
+	assertErrorContains(t, err, "not valid JSON")
+}
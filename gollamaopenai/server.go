@@ -0,0 +1,368 @@
+// Package gollamaopenai serves the OpenAI API on top of an Ollama server, so
+// tools that only speak OpenAI can be pointed at any Ollama host.
+//
+// The server translates /v1/chat/completions, /v1/completions,
+// /v1/embeddings and /v1/models into calls on a gollama Client, including
+// streamed responses as server-sent events:
+//
+//	client, _ := gollama.NewClient("http://gpu-box:11434")
+//	http.ListenAndServe(":8080", gollamaopenai.New(client))
+//
+// Tools are then configured with http://localhost:8080/v1 as their base URL
+// and an Ollama model name as the model. The gollama-openai command runs
+// such a server.
+package gollamaopenai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// Server is an http.Handler serving the OpenAI API.
+type Server struct {
+	client *gollama.Client
+	mux    *http.ServeMux
+}
+
+// New creates a server that forwards requests to client.
+func New(client *gollama.Client) *Server {
+	s := &Server{client: client, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/v1/chat/completions", s.post(s.chatCompletions))
+	s.mux.HandleFunc("/v1/completions", s.post(s.completions))
+	s.mux.HandleFunc("/v1/embeddings", s.post(s.embeddings))
+	s.mux.HandleFunc("/v1/models", s.models)
+	return s
+}
+
+// ServeHTTP serves a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// post restricts a handler to POST requests.
+func (s *Server) post(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// chatCompletions serves POST /v1/chat/completions.
+func (s *Server) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	chatReq := &gollama.ChatRequest{Model: req.Model}
+	for _, msg := range req.Messages {
+		content, images, err := msg.parse()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		chatReq.Messages = append(chatReq.Messages, gollama.Message{Role: msg.Role, Content: content, Images: images})
+	}
+
+	maxTokens := req.MaxTokens
+	if req.MaxCompletion != nil {
+		maxTokens = req.MaxCompletion
+	}
+	chatReq.Options = modelOptions(maxTokens, req.Temperature, req.TopP, req.Seed, req.Stop)
+	if f := req.ResponseFormat; f != nil {
+		switch {
+		case f.Type == "json_object":
+			chatReq.Format = "json"
+		case f.Type == "json_schema" && f.JSONSchema != nil:
+			chatReq.Format = f.JSONSchema.Schema
+		}
+	}
+
+	id := newID("chatcmpl-")
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		resp, err := s.client.Chat(r.Context(), chatReq)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, chatCompletion{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   req.Model,
+			Choices: []chatChoice{{
+				Message:      &responseMessage{Role: "assistant", Content: resp.Message.Content},
+				FinishReason: finishReason(resp.EvalCount, maxTokens),
+			}},
+			Usage: newUsage(resp.PromptEvalCount, resp.EvalCount),
+		})
+		return
+	}
+
+	events := newEventWriter(w)
+	chunk := func(delta *responseMessage, finish *string) chatCompletion {
+		return chatCompletion{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   req.Model,
+			Choices: []chatChoice{{Delta: delta, FinishReason: finish}},
+		}
+	}
+
+	err := s.client.ChatStream(r.Context(), chatReq, func(resp *gollama.ChatResponse) {
+		if !events.started {
+			events.send(chunk(&responseMessage{Role: "assistant"}, nil))
+		}
+		if resp.Message.Content != "" {
+			events.send(chunk(&responseMessage{Content: resp.Message.Content}, nil))
+		}
+		if resp.Done {
+			events.send(chunk(&responseMessage{}, finishReason(resp.EvalCount, maxTokens)))
+		}
+	})
+	events.finish(err)
+}
+
+// completions serves POST /v1/completions.
+func (s *Server) completions(w http.ResponseWriter, r *http.Request) {
+	var req completionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if len(req.Prompt) > 1 {
+		writeError(w, http.StatusBadRequest, "only a single prompt is supported")
+		return
+	}
+
+	genReq := &gollama.GenerateRequest{
+		Model:   req.Model,
+		Prompt:  strings.Join(req.Prompt, ""),
+		Options: modelOptions(req.MaxTokens, req.Temperature, req.TopP, req.Seed, req.Stop),
+	}
+	id := newID("cmpl-")
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		resp, err := s.client.Generate(r.Context(), genReq)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, completion{
+			ID:      id,
+			Object:  "text_completion",
+			Created: created,
+			Model:   req.Model,
+			Choices: []completionChoice{{
+				Text:         resp.Response,
+				FinishReason: finishReason(resp.EvalCount, req.MaxTokens),
+			}},
+			Usage: newUsage(resp.PromptEvalCount, resp.EvalCount),
+		})
+		return
+	}
+
+	events := newEventWriter(w)
+	err := s.client.GenerateStream(r.Context(), genReq, func(resp *gollama.GenerateResponse) {
+		var finish *string
+		if resp.Done {
+			finish = finishReason(resp.EvalCount, req.MaxTokens)
+		} else if resp.Response == "" {
+			return
+		}
+		events.send(completion{
+			ID:      id,
+			Object:  "text_completion",
+			Created: created,
+			Model:   req.Model,
+			Choices: []completionChoice{{Text: resp.Response, FinishReason: finish}},
+		})
+	})
+	events.finish(err)
+}
+
+// embeddings serves POST /v1/embeddings.
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request) {
+	var req embeddingRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if len(req.Input) == 0 {
+		writeError(w, http.StatusBadRequest, "input cannot be empty")
+		return
+	}
+
+	list := embeddingList{Object: "list", Model: req.Model}
+	for i, input := range req.Input {
+		resp, err := s.client.Embeddings(r.Context(), &gollama.EmbeddingRequest{Model: req.Model, Prompt: input})
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		list.Data = append(list.Data, embedding{Object: "embedding", Embedding: resp.Embedding, Index: i})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// models serves GET /v1/models.
+func (s *Server) models(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	models, err := s.client.List(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	list := modelList{Object: "list", Data: []model{}}
+	for _, m := range models.Models {
+		list.Data = append(list.Data, model{ID: m.Name, Object: "model", Created: m.ModifiedAt.Unix(), OwnedBy: "library"})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// modelOptions translates OpenAI sampling parameters to Ollama options.
+func modelOptions(maxTokens *int, temperature, topP *float64, seed *int, stop []string) gollama.Options {
+	opts := gollama.Options{}
+	if maxTokens != nil {
+		opts["num_predict"] = *maxTokens
+	}
+	if temperature != nil {
+		opts["temperature"] = *temperature
+	}
+	if topP != nil {
+		opts["top_p"] = *topP
+	}
+	if seed != nil {
+		opts["seed"] = *seed
+	}
+	if len(stop) > 0 {
+		opts["stop"] = stop
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// finishReason returns "length" if generation stopped at the token limit and
+// "stop" otherwise.
+func finishReason(evalCount int, maxTokens *int) *string {
+	reason := "stop"
+	if maxTokens != nil && *maxTokens > 0 && evalCount >= *maxTokens {
+		reason = "length"
+	}
+	return &reason
+}
+
+// newID returns a random response ID with the given prefix.
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// decode decodes a request body, writing an error response on failure.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the OpenAI API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	var body apiError
+	body.Error.Message = message
+	body.Error.Type = "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		body.Error.Type = "server_error"
+	}
+	writeJSON(w, status, body)
+}
+
+// writeClientError writes an error returned by the gollama client, keeping
+// the status code of errors from the Ollama server.
+func writeClientError(w http.ResponseWriter, err error) {
+	var ollamaErr *gollama.OllamaError
+	switch {
+	case errors.As(err, &ollamaErr):
+		writeError(w, ollamaErr.StatusCode, ollamaErr.Message)
+	case errors.Is(err, context.Canceled):
+		writeError(w, 499, err.Error())
+	default:
+		writeError(w, http.StatusBadGateway, err.Error())
+	}
+}
+
+// eventWriter writes server-sent events.
+type eventWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// newEventWriter creates an event writer. Headers are sent with the first
+// event, so errors before it can still be reported with a status code.
+func newEventWriter(w http.ResponseWriter) *eventWriter {
+	return &eventWriter{w: w}
+}
+
+// send writes v as a data event.
+func (e *eventWriter) send(v interface{}) {
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.started = true
+	}
+
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(e.w, "data: %s\n\n", data)
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish ends the stream. An error before the first event is written as an
+// error response; later errors are sent as an error event.
+func (e *eventWriter) finish(err error) {
+	if err != nil && !e.started {
+		writeClientError(e.w, err)
+		return
+	}
+	if err != nil {
+		var body apiError
+		body.Error.Message = err.Error()
+		body.Error.Type = "server_error"
+		e.send(body)
+	}
+	fmt.Fprint(e.w, "data: [DONE]\n\n")
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,217 @@
+package gollamaopenai
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// newTestServer starts a fake Ollama server and an OpenAI facade for it. The
+// last Ollama request body is stored in last.
+func newTestServer(t *testing.T, last *map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		*last = body
+		stream, _ := body["stream"].(bool)
+		enc := json.NewEncoder(w)
+
+		switch r.URL.Path {
+		case "/api/chat":
+			if body["model"] == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"model 'missing' not found"}`))
+				return
+			}
+			if !stream {
+				enc.Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: "Hello!"}, Done: true, PromptEvalCount: 5, EvalCount: 2})
+				return
+			}
+			enc.Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: "Hel"}})
+			enc.Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: "lo!"}})
+			enc.Encode(gollama.ChatResponse{Done: true, EvalCount: 2})
+		case "/api/generate":
+			enc.Encode(gollama.GenerateResponse{Response: "4", Done: true, PromptEvalCount: 4, EvalCount: 1})
+		case "/api/embeddings":
+			enc.Encode(gollama.EmbeddingResponse{Embedding: []float64{0.5, float64(len(body["prompt"].(string)))}})
+		case "/api/tags":
+			enc.Encode(gollama.ListModelsResponse{Models: []gollama.ModelResponse{{Name: "llama3:latest", ModifiedAt: time.Unix(1700000000, 0)}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ollama.Close)
+
+	client, err := gollama.NewClient(ollama.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server := httptest.NewServer(New(client))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	var last map[string]interface{}
+	server := newTestServer(t, &last)
+
+	resp := post(t, server.URL+"/v1/chat/completions", `{
+		"model": "llama3",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,aGk="}}
+			]}
+		],
+		"max_tokens": 2,
+		"temperature": 0.2,
+		"stop": "\n",
+		"response_format": {"type": "json_object"}
+	}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var completion chatCompletion
+	json.NewDecoder(resp.Body).Decode(&completion)
+	if completion.Object != "chat.completion" || !strings.HasPrefix(completion.ID, "chatcmpl-") {
+		t.Errorf("Unexpected completion %+v", completion)
+	}
+	if c := completion.Choices[0]; c.Message.Content != "Hello!" || *c.FinishReason != "length" {
+		t.Errorf("Unexpected choice %+v", c)
+	}
+	if completion.Usage.TotalTokens != 7 {
+		t.Errorf("Expected 7 total tokens, got %+v", completion.Usage)
+	}
+
+	messages := last["messages"].([]interface{})
+	user := messages[1].(map[string]interface{})
+	if user["content"] != "What is this?" || user["images"].([]interface{})[0] != "aGk=" {
+		t.Errorf("Expected text and image to be translated, got %v", user)
+	}
+	options := last["options"].(map[string]interface{})
+	if options["num_predict"] != 2.0 || options["temperature"] != 0.2 || options["stop"].([]interface{})[0] != "\n" {
+		t.Errorf("Expected options to be translated, got %v", options)
+	}
+	if last["format"] != "json" {
+		t.Errorf("Expected JSON format, got %v", last["format"])
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	var last map[string]interface{}
+	server := newTestServer(t, &last)
+
+	resp := post(t, server.URL+"/v1/chat/completions", `{"model":"llama3","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %q", ct)
+	}
+
+	var content strings.Builder
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		events = append(events, data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk chatCompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("Expected valid chunk, got %q", data)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+
+	if content.String() != "Hello!" {
+		t.Errorf("Expected streamed Hello!, got %q", content.String())
+	}
+	if len(events) != 5 || !strings.Contains(events[0], `"role":"assistant"`) || !strings.Contains(events[3], `"finish_reason":"stop"`) {
+		t.Errorf("Unexpected events %v", events)
+	}
+}
+
+func TestCompletionsAndEmbeddings(t *testing.T) {
+	var last map[string]interface{}
+	server := newTestServer(t, &last)
+
+	resp := post(t, server.URL+"/v1/completions", `{"model":"llama3","prompt":"2+2="}`)
+	var completion completion
+	json.NewDecoder(resp.Body).Decode(&completion)
+	if completion.Object != "text_completion" || completion.Choices[0].Text != "4" || *completion.Choices[0].FinishReason != "stop" {
+		t.Errorf("Unexpected completion %+v", completion)
+	}
+
+	resp = post(t, server.URL+"/v1/embeddings", `{"model":"nomic","input":["a","bcd"]}`)
+	var list embeddingList
+	json.NewDecoder(resp.Body).Decode(&list)
+	if len(list.Data) != 2 || list.Data[1].Index != 1 || list.Data[1].Embedding[1] != 3 {
+		t.Errorf("Unexpected embeddings %+v", list)
+	}
+
+	models, err := http.Get(server.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer models.Body.Close()
+	var ml modelList
+	json.NewDecoder(models.Body).Decode(&ml)
+	if len(ml.Data) != 1 || ml.Data[0].ID != "llama3:latest" {
+		t.Errorf("Unexpected models %+v", ml)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var last map[string]interface{}
+	server := newTestServer(t, &last)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		status  int
+		message string
+	}{
+		{"Invalid JSON", "/v1/chat/completions", `{`, http.StatusBadRequest, "invalid request body"},
+		{"Remote image", "/v1/chat/completions", `{"model":"x","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`, http.StatusBadRequest, "data URLs"},
+		{"Unknown model", "/v1/chat/completions", `{"model":"missing","messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound, "not found"},
+		{"Unknown model streaming", "/v1/chat/completions", `{"model":"missing","stream":true,"messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound, "not found"},
+		{"Several prompts", "/v1/completions", `{"model":"x","prompt":["a","b"]}`, http.StatusBadRequest, "single prompt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, server.URL+tt.path, tt.body)
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			var body apiError
+			json.NewDecoder(resp.Body).Decode(&body)
+			if !strings.Contains(body.Error.Message, tt.message) {
+				t.Errorf("Expected error containing %q, got %q", tt.message, body.Error.Message)
+			}
+		})
+	}
+}
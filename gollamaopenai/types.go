@@ -0,0 +1,209 @@
+package gollamaopenai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// chatCompletionRequest is the body of POST /v1/chat/completions.
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	MaxTokens      *int            `json:"max_tokens"`
+	MaxCompletion  *int            `json:"max_completion_tokens"`
+	Temperature    *float64        `json:"temperature"`
+	TopP           *float64        `json:"top_p"`
+	Seed           *int            `json:"seed"`
+	Stop           stringOrList    `json:"stop"`
+	ResponseFormat *responseFormat `json:"response_format"`
+}
+
+// completionRequest is the body of POST /v1/completions.
+type completionRequest struct {
+	Model       string       `json:"model"`
+	Prompt      stringOrList `json:"prompt"`
+	Stream      bool         `json:"stream"`
+	MaxTokens   *int         `json:"max_tokens"`
+	Temperature *float64     `json:"temperature"`
+	TopP        *float64     `json:"top_p"`
+	Seed        *int         `json:"seed"`
+	Stop        stringOrList `json:"stop"`
+}
+
+// embeddingRequest is the body of POST /v1/embeddings.
+type embeddingRequest struct {
+	Model string       `json:"model"`
+	Input stringOrList `json:"input"`
+}
+
+// responseFormat selects JSON mode.
+type responseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema *struct {
+		Schema json.RawMessage `json:"schema"`
+	} `json:"json_schema"`
+}
+
+// chatMessage is a message whose content is either a string or a list of
+// content parts.
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// contentPart is an element of a multi-part message content.
+type contentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	ImageURL struct {
+		URL string `json:"url"`
+	} `json:"image_url"`
+}
+
+// parse returns the text and base64-encoded images of a message. Only
+// images given as data URLs are supported.
+func (m chatMessage) parse() (string, []string, error) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return text, nil, nil
+	}
+
+	var parts []contentPart
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return "", nil, fmt.Errorf("invalid message content: %w", err)
+	}
+
+	var texts, images []string
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			texts = append(texts, part.Text)
+		case "image_url":
+			_, data, ok := strings.Cut(part.ImageURL.URL, ";base64,")
+			if !strings.HasPrefix(part.ImageURL.URL, "data:") || !ok {
+				return "", nil, fmt.Errorf("only data URLs are supported for images")
+			}
+			images = append(images, data)
+		default:
+			return "", nil, fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+	return strings.Join(texts, "\n"), images, nil
+}
+
+// stringOrList accepts a JSON string or an array of strings.
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = stringOrList{one}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or an array of strings")
+	}
+	*s = list
+	return nil
+}
+
+// chatCompletion is the response of POST /v1/chat/completions, and of each
+// streamed chunk with Object "chat.completion.chunk".
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *usage       `json:"usage,omitempty"`
+}
+
+// chatChoice is a choice of a chat completion. Message is set in complete
+// responses and Delta in streamed chunks.
+type chatChoice struct {
+	Index        int              `json:"index"`
+	Message      *responseMessage `json:"message,omitempty"`
+	Delta        *responseMessage `json:"delta,omitempty"`
+	FinishReason *string          `json:"finish_reason"`
+}
+
+// responseMessage is a message of a chat completion.
+type responseMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+// completion is the response of POST /v1/completions.
+type completion struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+	Usage   *usage             `json:"usage,omitempty"`
+}
+
+// completionChoice is a choice of a completion.
+type completionChoice struct {
+	Index        int     `json:"index"`
+	Text         string  `json:"text"`
+	Logprobs     *string `json:"logprobs"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// embeddingList is the response of POST /v1/embeddings.
+type embeddingList struct {
+	Object string      `json:"object"`
+	Data   []embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  usage       `json:"usage"`
+}
+
+// embedding is an element of an embedding list.
+type embedding struct {
+	Object    string    `json:"object"`
+	Embedding []float64 `json:"embedding"`
+	Index     int       `json:"index"`
+}
+
+// modelList is the response of GET /v1/models.
+type modelList struct {
+	Object string  `json:"object"`
+	Data   []model `json:"data"`
+}
+
+// model is an element of a model list.
+type model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// usage reports token counts.
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// newUsage creates a usage from Ollama's counts.
+func newUsage(prompt, completion int) *usage {
+	return &usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+// apiError is the error format of the OpenAI API.
+type apiError struct {
+	Error struct {
+		Message string  `json:"message"`
+		Type    string  `json:"type"`
+		Code    *string `json:"code"`
+	} `json:"error"`
+}
@@ -0,0 +1,193 @@
+// Package gollamaprom exports the model state of an Ollama server as
+// Prometheus metrics.
+//
+// The Collector scrapes the List and PS endpoints on every request and
+// writes the result in the Prometheus text exposition format, so it can be
+// mounted directly on an HTTP mux without depending on the Prometheus client
+// library:
+//
+//	client, _ := gollama.NewClient()
+//	http.Handle("/metrics", gollamaprom.NewCollector(client))
+package gollamaprom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// Metric is a single gauge sample.
+type Metric struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector scrapes an Ollama server on demand and exposes gauges for its
+// models: the number of models, the size of each model, the number of
+// running models and the VRAM used by each running model.
+type Collector struct {
+	client    *gollama.Client
+	namespace string
+	timeout   time.Duration
+}
+
+// NewCollector creates a collector for the given client. Metric names are
+// prefixed with "ollama_".
+func NewCollector(client *gollama.Client) *Collector {
+	return &Collector{
+		client:    client,
+		namespace: "ollama",
+		timeout:   10 * time.Second,
+	}
+}
+
+// WithNamespace returns a copy of the collector using a different metric
+// name prefix, for telling apart several Ollama hosts scraped by one process.
+func (c *Collector) WithNamespace(namespace string) *Collector {
+	cp := *c
+	cp.namespace = namespace
+	return &cp
+}
+
+// Collect scrapes the server and returns the current samples. If the server
+// cannot be reached, only the up metric is returned, set to 0, together with
+// the scrape error.
+func (c *Collector) Collect(ctx context.Context) ([]Metric, error) {
+	models, err := c.client.List(ctx)
+	if err != nil {
+		return []Metric{c.up(0)}, err
+	}
+	running, err := c.client.PS(ctx)
+	if err != nil {
+		return []Metric{c.up(0)}, err
+	}
+
+	metrics := []Metric{
+		c.up(1),
+		{
+			Name:  c.name("models_total"),
+			Help:  "Number of models available on the server.",
+			Value: float64(len(models.Models)),
+		},
+		{
+			Name:  c.name("running_models"),
+			Help:  "Number of models currently loaded into memory.",
+			Value: float64(len(running.Models)),
+		},
+	}
+
+	for _, model := range models.Models {
+		metrics = append(metrics, Metric{
+			Name:   c.name("model_size_bytes"),
+			Help:   "Size of the model on disk in bytes.",
+			Labels: map[string]string{"model": model.Name},
+			Value:  float64(model.Size),
+		})
+	}
+	for _, model := range running.Models {
+		metrics = append(metrics, Metric{
+			Name:   c.name("vram_bytes"),
+			Help:   "VRAM used by a running model in bytes.",
+			Labels: map[string]string{"model": model.Name},
+			Value:  float64(model.SizeVRAM),
+		}, Metric{
+			Name:   c.name("memory_bytes"),
+			Help:   "Total memory used by a running model in bytes.",
+			Labels: map[string]string{"model": model.Name},
+			Value:  float64(model.Size),
+		})
+	}
+
+	return metrics, nil
+}
+
+// WriteTo scrapes the server and writes the samples to w in the Prometheus
+// text exposition format. A failed scrape is reported through the up
+// metric rather than as an error; only write errors are returned.
+func (c *Collector) WriteTo(ctx context.Context, w io.Writer) error {
+	metrics, _ := c.Collect(ctx)
+	return writeText(w, metrics)
+}
+
+// ServeHTTP implements http.Handler, serving the current samples.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(ctx, w)
+}
+
+func (c *Collector) up(value float64) Metric {
+	return Metric{
+		Name:  c.name("up"),
+		Help:  "Whether the last scrape of the Ollama server succeeded.",
+		Value: value,
+	}
+}
+
+func (c *Collector) name(metric string) string {
+	if c.namespace == "" {
+		return metric
+	}
+	return c.namespace + "_" + metric
+}
+
+// writeText writes metrics grouped by name, each group preceded by its HELP
+// and TYPE lines.
+func writeText(w io.Writer, metrics []Metric) error {
+	var order []string
+	groups := make(map[string][]Metric)
+	for _, m := range metrics {
+		if _, ok := groups[m.Name]; !ok {
+			order = append(order, m.Name)
+		}
+		groups[m.Name] = append(groups[m.Name], m)
+	}
+
+	for _, name := range order {
+		group := groups[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, group[0].Help, name); err != nil {
+			return err
+		}
+		for _, m := range group {
+			if _, err := fmt.Fprintf(w, "%s%s %v\n", name, formatLabels(m.Labels), m.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatLabels renders a label set in a stable order.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", k, escapeLabel(labels[k])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
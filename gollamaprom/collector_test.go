@@ -0,0 +1,77 @@
+package gollamaprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+func setupMockServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[{"name":"llama2:latest","size":3825819519},{"name":"mistral:latest","size":4100000000}]}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[{"name":"mistral:latest","size":5000000000,"size_vram":2500000000}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewCollector(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	expected := []string{
+		"# TYPE ollama_up gauge\nollama_up 1\n",
+		"ollama_models_total 2\n",
+		"ollama_running_models 1\n",
+		`ollama_model_size_bytes{model="llama2:latest"} 3.825819519e+09`,
+		`ollama_vram_bytes{model="mistral:latest"} 2.5e+09`,
+		`ollama_memory_bytes{model="mistral:latest"} 5e+09`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Count(body, "# HELP ollama_model_size_bytes") != 1 {
+		t.Errorf("Expected a single HELP line per metric, got:\n%s", body)
+	}
+}
+
+func TestCollectorServerDown(t *testing.T) {
+	server := setupMockServer()
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	server.Close()
+
+	rec := httptest.NewRecorder()
+	NewCollector(client).WithNamespace("gpu1").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.Contains(rec.Body.String(), "gpu1_up 0\n") {
+		t.Errorf("Expected up metric to be 0, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestFormatLabelsEscaping(t *testing.T) {
+	got := formatLabels(map[string]string{"model": `we"ird\name`, "host": "a"})
+	if got != `{host="a",model="we\"ird\\name"}` {
+		t.Errorf("Unexpected label formatting: %s", got)
+	}
+}
@@ -0,0 +1,83 @@
+package gollamaproxy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache stores response bodies by request.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, body []byte)
+}
+
+// MemoryCache is an in-memory LRU cache with expiring entries. It is safe
+// for concurrent use.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+}
+
+// cacheEntry is an element of MemoryCache.order.
+type cacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a cache holding at most maxEntries responses for
+// up to ttl each. A ttl of zero keeps entries until they are evicted.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get returns the body stored for key.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+// Set stores body for key, evicting the least recently used entry if the
+// cache is full.
+func (c *MemoryCache) Set(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, body: body, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
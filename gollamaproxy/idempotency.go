@@ -0,0 +1,124 @@
+package gollamaproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/astrica1/gollama"
+)
+
+// idempotency holds the results of requests made with an idempotency key,
+// and the requests still in progress. It is shared by copies of a Proxy.
+type idempotency struct {
+	store Cache
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+// WithIdempotency returns a copy of the proxy that honors the
+// Idempotency-Key header sent by gollama.WithIdempotencyKey on generation,
+// chat and embedding requests. The first request with a key is forwarded
+// and its complete response, streamed or not, is kept in store. Later
+// requests with the same key get that response without reaching the
+// server, and requests arriving while the first is running wait for it, so
+// a request retried after a timeout does not generate twice.
+//
+// Keys are scoped to the API key and endpoint. Reusing a key for a
+// different request body fails with 422 Unprocessable Entity. Only
+// successful responses are kept, so a request that failed can be retried
+// with its key. Replayed responses carry an X-Gollama-Idempotent-Replay
+// header and do not count towards token quotas. Requests with a key whose
+// body exceeds 64 MiB are refused with 413 Request Entity Too Large.
+func (p *Proxy) WithIdempotency(store Cache) *Proxy {
+	cp := *p
+	cp.idempotency = &idempotency{store: store, inflight: make(map[string]chan struct{})}
+	return &cp
+}
+
+// idempotentRequest identifies a request made with an idempotency key.
+type idempotentRequest struct {
+	// key is the store key of the result
+	key string
+	// digest identifies the request body
+	digest string
+}
+
+// idempotentRequestFor returns the idempotency key and body digest of a
+// request, or nil if it has no key or its endpoint is not covered. It reads
+// the request body, up to maxRequestBodyBytes, and replaces it with a
+// copy; a larger body fails with an *http.MaxBytesError.
+func idempotentRequestFor(w http.ResponseWriter, r *http.Request, apiKey string) (*idempotentRequest, error) {
+	key := r.Header.Get(gollama.IdempotencyKeyHeader)
+	if key == "" || r.Method != http.MethodPost || !cacheablePaths[r.URL.Path] {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	keySum := sha256.Sum256([]byte("idempotency\n" + apiKey + "\n" + r.URL.Path + "\n" + key))
+	bodySum := sha256.Sum256(body)
+	return &idempotentRequest{key: hex.EncodeToString(keySum[:]), digest: hex.EncodeToString(bodySum[:])}, nil
+}
+
+// begin waits until no request with the key is running, and then either
+// returns the stored result, or marks the request as running and returns a
+// function that ends it. It returns false if r is done while waiting.
+func (s *idempotency) begin(r *http.Request, req *idempotentRequest) (result []byte, end func(), ok bool) {
+	for {
+		if result, ok := s.store.Get(req.key); ok {
+			return result, nil, true
+		}
+
+		s.mu.Lock()
+		running, busy := s.inflight[req.key]
+		if !busy {
+			done := make(chan struct{})
+			s.inflight[req.key] = done
+			s.mu.Unlock()
+			return nil, func() {
+				s.mu.Lock()
+				delete(s.inflight, req.key)
+				s.mu.Unlock()
+				close(done)
+			}, true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-running:
+		case <-r.Context().Done():
+			return nil, nil, false
+		}
+	}
+}
+
+// storedResponse encodes a response for the store: the request digest and
+// content type, each on a line of their own, followed by the body.
+func storedResponse(digest, contentType string, body []byte) []byte {
+	return append([]byte(digest+"\n"+contentType+"\n"), body...)
+}
+
+// replay writes a stored response, or an error if it was stored for a
+// different request body.
+func replay(w http.ResponseWriter, req *idempotentRequest, result []byte) {
+	digest, rest, _ := bytes.Cut(result, []byte("\n"))
+	contentType, body, _ := bytes.Cut(rest, []byte("\n"))
+	if string(digest) != req.digest {
+		writeError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+		return
+	}
+	w.Header().Set("Content-Type", string(contentType))
+	w.Header().Set("X-Gollama-Idempotent-Replay", "true")
+	w.Write(body)
+}
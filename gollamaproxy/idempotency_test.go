@@ -0,0 +1,144 @@
+package gollamaproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+func TestProxyIdempotency(t *testing.T) {
+	var calls int32
+	upstream := newUpstream(t, &calls)
+	proxy := New(upstream).WithIdempotency(NewMemoryCache(10, time.Hour))
+	client := newProxyClient(t, proxy)
+	ctx := context.Background()
+
+	stream := func(prompt, key string) (string, error) {
+		var text string
+		err := client.GenerateStream(ctx, &gollama.GenerateRequest{Prompt: prompt}, func(resp *gollama.GenerateResponse) {
+			text += resp.Response
+		}, gollama.WithIdempotencyKey(key))
+		return text, err
+	}
+
+	for i := 0; i < 2; i++ {
+		text, err := stream("hi", "order-1")
+		if err != nil || text != "hello" {
+			t.Fatalf("Expected streamed hello, got %q (%v)", text, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the retried request to be replayed, got %d upstream calls", calls)
+	}
+
+	if _, err := stream("hi", "order-2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected a new key to reach the server, got %d upstream calls", calls)
+	}
+
+	_, err := stream("something else", "order-1")
+	var ollamaErr *gollama.OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a reused key, got %v", err)
+	}
+
+	// Requests without a key are not affected
+	for i := 0; i < 2; i++ {
+		if _, err := client.Generate(ctx, &gollama.GenerateRequest{Prompt: "hi"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("Expected requests without a key to be forwarded, got %d upstream calls", calls)
+	}
+}
+
+func TestProxyIdempotencyConcurrent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, `{"error":"out of memory"}`, http.StatusInternalServerError)
+			return
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(gollama.GenerateResponse{Response: "hello", Done: true})
+	}))
+	defer server.Close()
+	upstream, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	proxy := New(upstream).WithIdempotency(NewMemoryCache(10, time.Hour))
+	client := newProxyClient(t, proxy)
+	ctx := context.Background()
+	generate := func() (*gollama.GenerateResponse, error) {
+		return client.Generate(ctx, &gollama.GenerateRequest{Prompt: "hi"}, gollama.WithIdempotencyKey("order-1"))
+	}
+
+	// Failures are not kept
+	if _, err := generate(); err == nil {
+		t.Fatal("Expected the first request to fail")
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := generate()
+			if err != nil {
+				results[i] = err.Error()
+				return
+			}
+			results[i] = resp.Response
+		}(i)
+	}
+	for atomic.LoadInt32(&calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, result := range results {
+		if result != "hello" {
+			t.Errorf("Request %d: expected hello, got %q", i, result)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected concurrent requests to share one upstream call, got %d", calls)
+	}
+}
+
+func TestProxyIdempotencyBodyLimit(t *testing.T) {
+	defer func(n int64) { maxRequestBodyBytes = n }(maxRequestBodyBytes)
+	maxRequestBodyBytes = 64
+
+	var calls int32
+	proxy := New(newUpstream(t, &calls)).WithIdempotency(NewMemoryCache(10, time.Hour))
+	client := newProxyClient(t, proxy)
+
+	_, err := client.Generate(context.Background(), &gollama.GenerateRequest{Prompt: strings.Repeat("a", 100)}, gollama.WithIdempotencyKey("order-1"))
+	var ollamaErr *gollama.OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large body, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected the request not to reach the server, got %d upstream calls", calls)
+	}
+}
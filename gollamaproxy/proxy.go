@@ -0,0 +1,526 @@
+// Package gollamaproxy fronts an Ollama server with API-key authentication,
+// per-key rate limits and token quotas, request logging, and optional
+// response caching and idempotency keys.
+//
+// The proxy serves the same /api surface as Ollama, so existing clients keep
+// working once they send a key, either as a bearer token or in the X-API-Key
+// header:
+//
+//	client, _ := gollama.NewClient("http://gpu-box:11434")
+//	proxy := gollamaproxy.New(client).
+//		WithKeys(
+//			gollamaproxy.Key{Key: os.Getenv("TEAM_A_KEY"), Name: "team-a", RequestsPerMinute: 60},
+//			gollamaproxy.Key{Key: os.Getenv("TEAM_B_KEY"), Name: "team-b", TokenQuota: 1_000_000, QuotaPeriod: 24 * time.Hour},
+//		).
+//		WithCache(gollamaproxy.NewMemoryCache(1000, time.Hour)).
+//		WithLogger(slog.Default())
+//	http.ListenAndServe(":8080", proxy)
+package gollamaproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// Key is an API key accepted by the proxy.
+type Key struct {
+	// Key is the secret sent by clients.
+	Key string
+	// Name identifies the key in logs and usage reports.
+	Name string
+	// RequestsPerMinute limits the request rate of the key. Zero means no
+	// limit.
+	RequestsPerMinute int
+	// TokenQuota limits the prompt and generated tokens of the key within
+	// QuotaPeriod. Zero means no limit. Tokens are counted when a response
+	// completes, so the request that crosses the quota is still served.
+	TokenQuota int64
+	// QuotaPeriod is the period after which used tokens reset. Zero means
+	// the quota never resets.
+	QuotaPeriod time.Duration
+}
+
+// keyState tracks the usage of a key.
+type keyState struct {
+	Key
+	// digest is the SHA-256 digest of the secret, compared in constant time
+	digest [sha256.Size]byte
+
+	mu          sync.Mutex
+	allowance   float64
+	last        time.Time
+	used        int64
+	periodStart time.Time
+}
+
+// allow takes a request from the key's rate limit.
+func (k *keyState) allow(now time.Time) bool {
+	if k.RequestsPerMinute <= 0 {
+		return true
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	burst := float64(k.RequestsPerMinute)
+	if k.last.IsZero() {
+		k.allowance = burst
+	} else {
+		k.allowance += now.Sub(k.last).Minutes() * burst
+		if k.allowance > burst {
+			k.allowance = burst
+		}
+	}
+	k.last = now
+
+	if k.allowance < 1 {
+		return false
+	}
+	k.allowance--
+	return true
+}
+
+// withinQuota reports whether the key has tokens left.
+func (k *keyState) withinQuota(now time.Time) bool {
+	if k.TokenQuota <= 0 {
+		return true
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.resetPeriod(now)
+	return k.used < k.TokenQuota
+}
+
+// addTokens records used tokens.
+func (k *keyState) addTokens(now time.Time, n int64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.resetPeriod(now)
+	k.used += n
+}
+
+// resetPeriod starts a new quota period if the current one is over. The
+// caller must hold k.mu.
+func (k *keyState) resetPeriod(now time.Time) {
+	if k.periodStart.IsZero() {
+		k.periodStart = now
+	}
+	if k.QuotaPeriod > 0 && now.Sub(k.periodStart) >= k.QuotaPeriod {
+		k.used = 0
+		k.periodStart = now
+	}
+}
+
+// Usage is the token usage of a key in its current quota period.
+type Usage struct {
+	Name   string
+	Tokens int64
+	Quota  int64
+}
+
+// maxCacheBodyBytes is the largest response the proxy caches.
+const maxCacheBodyBytes = 8 << 20
+
+// maxRequestBodyBytes is the largest request body the proxy reads to key the
+// cache or identify a request made with an idempotency key. It is a
+// variable so that tests can lower it.
+var maxRequestBodyBytes int64 = 64 << 20
+
+// Proxy is an http.Handler that forwards requests to an Ollama server.
+type Proxy struct {
+	target      *url.URL
+	proxy       *httputil.ReverseProxy
+	keys        []*keyState
+	cache       Cache
+	idempotency *idempotency
+	logger      *slog.Logger
+	nowFunc     func() time.Time
+}
+
+// New creates a proxy for the server of client. Without keys, the proxy
+// accepts every request.
+func New(client *gollama.Client) *Proxy {
+	target, _ := url.Parse(client.BaseURL())
+	p := &Proxy{target: target, nowFunc: time.Now}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Del("X-API-Key")
+		},
+		ModifyResponse: modifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("upstream unavailable: %v", err))
+		},
+	}
+	return p
+}
+
+// WithKeys returns a copy of the proxy that requires one of the given keys.
+// A key with an empty secret, such as one read from an unset environment
+// variable, matches no request, so it locks its holders out rather than
+// opening the proxy.
+func (p *Proxy) WithKeys(keys ...Key) *Proxy {
+	cp := *p
+	cp.keys = make([]*keyState, 0, len(keys))
+	for _, key := range keys {
+		cp.keys = append(cp.keys, &keyState{Key: key, digest: sha256.Sum256([]byte(key.Key))})
+	}
+	return &cp
+}
+
+// findKey returns the key whose secret is sent, or nil if there is none. The
+// secrets are compared in constant time, through their digests so that
+// their lengths do not leak either.
+func (p *Proxy) findKey(sent string) *keyState {
+	if sent == "" {
+		return nil
+	}
+	digest := sha256.Sum256([]byte(sent))
+	var found *keyState
+	for _, k := range p.keys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest[:]) == 1 && k.Key.Key != "" && found == nil {
+			found = k
+		}
+	}
+	return found
+}
+
+// WithCache returns a copy of the proxy that caches non-streaming
+// generation, chat and embedding responses, keyed by the request body.
+// Cache hits do not count towards token quotas.
+func (p *Proxy) WithCache(cache Cache) *Proxy {
+	cp := *p
+	cp.cache = cache
+	return &cp
+}
+
+// WithLogger returns a copy of the proxy that logs every request.
+func (p *Proxy) WithLogger(logger *slog.Logger) *Proxy {
+	cp := *p
+	cp.logger = logger
+	return &cp
+}
+
+// Usage returns the token usage of every key.
+func (p *Proxy) Usage() []Usage {
+	now := p.nowFunc()
+	usage := make([]Usage, 0, len(p.keys))
+	for _, k := range p.keys {
+		k.mu.Lock()
+		k.resetPeriod(now)
+		usage = append(usage, Usage{Name: k.Name, Tokens: k.used, Quota: k.TokenQuota})
+		k.mu.Unlock()
+	}
+	return usage
+}
+
+// requestInfo follows a request through the reverse proxy.
+type requestInfo struct {
+	cacheKey    string
+	cache       Cache
+	idempotent  *idempotentRequest
+	idempotency *idempotency
+	tokens      int64
+}
+
+type requestInfoKey struct{}
+
+// ServeHTTP authenticates, limits and forwards a request.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := p.nowFunc()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	info := &requestInfo{}
+
+	key := p.serve(rec, r, info)
+
+	if key != nil && info.tokens > 0 {
+		key.addTokens(p.nowFunc(), info.tokens)
+	}
+	if p.logger != nil {
+		name := ""
+		if key != nil {
+			name = key.Name
+		}
+		p.logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("key", name),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", p.nowFunc().Sub(start)),
+			slog.Int64("tokens", info.tokens),
+			slog.Bool("cached", rec.Header().Get("X-Gollama-Cache") == "hit"),
+			slog.Bool("replayed", rec.Header().Get("X-Gollama-Idempotent-Replay") != ""),
+		)
+	}
+}
+
+// serve handles a request and returns the key it was made with.
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request, info *requestInfo) *keyState {
+	var key *keyState
+	if len(p.keys) > 0 {
+		key = p.findKey(requestKey(r))
+		if key == nil {
+			writeError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return nil
+		}
+		now := p.nowFunc()
+		if !key.allow(now) {
+			w.Header().Set("Retry-After", "60")
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return key
+		}
+		if !key.withinQuota(now) {
+			writeError(w, http.StatusTooManyRequests, "token quota exceeded")
+			return key
+		}
+	}
+
+	if p.idempotency != nil {
+		req, err := idempotentRequestFor(w, r, requestKey(r))
+		if err != nil {
+			writeError(w, bodyErrorStatus(err), err.Error())
+			return key
+		}
+		if req != nil {
+			result, end, ok := p.idempotency.begin(r, req)
+			switch {
+			case !ok:
+				writeError(w, http.StatusServiceUnavailable, "request canceled while waiting for a request with the same idempotency key")
+				return key
+			case result != nil:
+				replay(w, req, result)
+				return key
+			}
+			defer end()
+			info.idempotent = req
+			info.idempotency = p.idempotency
+		}
+	}
+
+	if p.cache != nil {
+		cacheKey, err := cacheKey(w, r)
+		if err != nil {
+			writeError(w, bodyErrorStatus(err), err.Error())
+			return key
+		}
+		if cacheKey != "" {
+			if body, ok := p.cache.Get(cacheKey); ok {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Header().Set("X-Gollama-Cache", "hit")
+				w.Write(body)
+				return key
+			}
+			info.cacheKey = cacheKey
+			info.cache = p.cache
+		}
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+	p.proxy.ServeHTTP(w, r)
+	return key
+}
+
+// bodyErrorStatus returns the status of a response to a request whose body
+// could not be read.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// requestKey returns the API key sent with a request.
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// cacheablePaths are the endpoints whose non-streaming responses are cached.
+var cacheablePaths = map[string]bool{
+	"/api/generate":   true,
+	"/api/chat":       true,
+	"/api/embeddings": true,
+	"/api/embed":      true,
+}
+
+// cacheKey returns the cache key of a request, or "" if its response must not
+// be cached. It reads the request body, up to maxRequestBodyBytes, and
+// replaces it with a copy; a larger body fails with an *http.MaxBytesError.
+func cacheKey(w http.ResponseWriter, r *http.Request) (string, error) {
+	if r.Method != http.MethodPost || !cacheablePaths[r.URL.Path] {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	r.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	sum := sha256.Sum256(append([]byte(r.URL.Path+"\n"), body...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// modifyResponse wraps the body of upstream responses to count tokens, fill
+// the cache and keep the results of idempotent requests.
+func modifyResponse(resp *http.Response) error {
+	info, _ := resp.Request.Context().Value(requestInfoKey{}).(*requestInfo)
+	if info == nil {
+		return nil
+	}
+
+	// Streamed responses are sent as application/x-ndjson and never cached
+	body := &observedBody{ReadCloser: resp.Body, info: info}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if info.cacheKey != "" && resp.StatusCode == http.StatusOK && mediaType == "application/json" {
+		body.cache = &bytes.Buffer{}
+	}
+	if info.idempotent != nil && resp.StatusCode == http.StatusOK {
+		body.result = &bytes.Buffer{}
+		body.contentType = resp.Header.Get("Content-Type")
+	}
+	resp.Body = body
+	return nil
+}
+
+// observedBody scans a response for token counts as it is read, and stores
+// complete responses in the cache and as the results of idempotent
+// requests.
+type observedBody struct {
+	io.ReadCloser
+	info        *requestInfo
+	line        []byte
+	cache       *bytes.Buffer
+	result      *bytes.Buffer
+	contentType string
+	eof         bool
+}
+
+// usageLine holds the token counts of the final response object.
+type usageLine struct {
+	PromptEvalCount int64 `json:"prompt_eval_count"`
+	EvalCount       int64 `json:"eval_count"`
+}
+
+// maxLineBytes bounds the memory used to scan a response line for counts.
+const maxLineBytes = 1 << 20
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	data := p[:n]
+
+	if b.cache != nil {
+		if b.cache.Len()+n > maxCacheBodyBytes {
+			b.cache = nil
+		} else {
+			b.cache.Write(data)
+		}
+	}
+	if b.result != nil {
+		if b.result.Len()+n > maxCacheBodyBytes {
+			b.result = nil
+		} else {
+			b.result.Write(data)
+		}
+	}
+
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.appendLine(data)
+			break
+		}
+		b.appendLine(data[:i])
+		b.scanLine()
+		data = data[i+1:]
+	}
+
+	if err == io.EOF {
+		b.eof = true
+		b.scanLine()
+	}
+	return n, err
+}
+
+// appendLine adds data to the current line unless it grew too long.
+func (b *observedBody) appendLine(data []byte) {
+	if b.line != nil && len(b.line) > maxLineBytes {
+		return
+	}
+	b.line = append(b.line, data...)
+}
+
+// scanLine adds the token counts of the current line, if any.
+func (b *observedBody) scanLine() {
+	line := b.line
+	b.line = b.line[:0]
+	if len(line) > maxLineBytes || !bytes.Contains(line, []byte(`eval_count"`)) {
+		return
+	}
+	var usage usageLine
+	if json.Unmarshal(line, &usage) == nil {
+		b.info.tokens += usage.PromptEvalCount + usage.EvalCount
+	}
+}
+
+func (b *observedBody) Close() error {
+	if b.eof && b.cache != nil {
+		b.info.cache.Set(b.info.cacheKey, b.cache.Bytes())
+	}
+	if b.eof && b.result != nil {
+		req := b.info.idempotent
+		b.info.idempotency.store.Set(req.key, storedResponse(req.digest, b.contentType, b.result.Bytes()))
+	}
+	return b.ReadCloser.Close()
+}
+
+// statusRecorder records the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush forwards streamed chunks to the client immediately.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeError writes an error in the format of the Ollama API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,223 @@
+package gollamaproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// newUpstream starts a fake Ollama server that counts requests.
+func newUpstream(t *testing.T, calls *int32) *gollama.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" {
+			http.Error(w, `{"error":"key leaked upstream"}`, http.StatusBadRequest)
+			return
+		}
+
+		var req gollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		enc := json.NewEncoder(w)
+		if !req.Stream {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			enc.Encode(gollama.GenerateResponse{Response: "hello", Done: true, PromptEvalCount: 3, EvalCount: 2})
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc.Encode(gollama.GenerateResponse{Response: "hel"})
+		enc.Encode(gollama.GenerateResponse{Response: "lo", Done: true, PromptEvalCount: 3, EvalCount: 2})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+// newProxyClient starts proxy and returns a client for it.
+func newProxyClient(t *testing.T, proxy http.Handler, opts ...gollama.ClientOption) *gollama.Client {
+	t.Helper()
+
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClientWithOptions(server.URL, append([]gollama.ClientOption{gollama.WithDefaultModel("llama3")}, opts...)...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+func TestProxyAuthAndLimits(t *testing.T) {
+	var calls int32
+	upstream := newUpstream(t, &calls)
+
+	proxy := New(upstream).WithKeys(
+		Key{Key: "secret-a", Name: "a", RequestsPerMinute: 2},
+		Key{Key: "secret-b", Name: "b", TokenQuota: 5},
+		// An unset secret must not admit requests without a key
+		Key{Key: "", Name: "unset"},
+	)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	post := func(key, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/generate", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := post("", `{"model":"llama3","prompt":"hi"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without key, got %d", resp.StatusCode)
+	}
+	if resp := post("wrong", `{"model":"llama3","prompt":"hi"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with unknown key, got %d", resp.StatusCode)
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if resp := post("secret-a", `{"model":"llama3","prompt":"hi"}`); resp.StatusCode != want {
+			t.Errorf("Request %d: expected %d, got %d", i, want, resp.StatusCode)
+		}
+	}
+
+	// The first request uses the whole quota of 5 tokens
+	if resp := post("secret-b", `{"model":"llama3","prompt":"hi","stream":false}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 within quota, got %d", resp.StatusCode)
+	}
+	if resp := post("secret-b", `{"model":"llama3","prompt":"hi","stream":false}`); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 over quota, got %d", resp.StatusCode)
+	}
+
+	if resp := post("secret-a-", `{"model":"llama3","prompt":"hi"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a longer key, got %d", resp.StatusCode)
+	}
+
+	usage := make(map[string]int64)
+	for _, u := range proxy.Usage() {
+		usage[u.Name] = u.Tokens
+	}
+	if usage["a"] != 10 || usage["b"] != 5 {
+		t.Errorf("Expected token usage a=10 b=5, got %v", usage)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 upstream calls, got %d", calls)
+	}
+}
+
+func TestProxyCacheBodyLimit(t *testing.T) {
+	defer func(n int64) { maxRequestBodyBytes = n }(maxRequestBodyBytes)
+	maxRequestBodyBytes = 64
+
+	var calls int32
+	proxy := New(newUpstream(t, &calls)).WithCache(NewMemoryCache(10, time.Minute))
+	client := newProxyClient(t, proxy)
+
+	_, err := client.Generate(context.Background(), &gollama.GenerateRequest{Prompt: strings.Repeat("a", 100)})
+	var ollamaErr *gollama.OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large body, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected the request not to reach the server, got %d upstream calls", calls)
+	}
+}
+
+func TestProxyClientCompatibility(t *testing.T) {
+	var calls int32
+	upstream := newUpstream(t, &calls)
+
+	var logs bytes.Buffer
+	proxy := New(upstream).
+		WithCache(NewMemoryCache(10, time.Minute)).
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	client := newProxyClient(t, proxy)
+	ctx := context.Background()
+
+	var streamed string
+	err := client.GenerateStream(ctx, &gollama.GenerateRequest{Prompt: "hi"}, func(resp *gollama.GenerateResponse) {
+		streamed += resp.Response
+	})
+	if err != nil || streamed != "hello" {
+		t.Fatalf("Expected streamed hello, got %q (%v)", streamed, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Generate(ctx, &gollama.GenerateRequest{Prompt: "hi"})
+		if err != nil || resp.Response != "hello" {
+			t.Fatalf("Expected hello, got %+v (%v)", resp, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected the repeated request to be cached, got %d upstream calls", calls)
+	}
+
+	if !strings.Contains(logs.String(), "cached=true") || !strings.Contains(logs.String(), "tokens=5") {
+		t.Errorf("Expected request logs, got:\n%s", logs.String())
+	}
+}
+
+func TestProxyErrorFormat(t *testing.T) {
+	var calls int32
+	upstream := newUpstream(t, &calls)
+	proxy := New(upstream).WithKeys(Key{Key: "secret"})
+	ctx := context.Background()
+
+	client := newProxyClient(t, proxy, gollama.WithHeader("X-API-Key", "secret"))
+	if _, err := client.Generate(ctx, &gollama.GenerateRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("Expected no error with key, got %v", err)
+	}
+
+	client = newProxyClient(t, proxy)
+	_, err := client.Generate(ctx, &gollama.GenerateRequest{Prompt: "hi"})
+	var ollamaErr *gollama.OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected OllamaError with status 401, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "invalid or missing API key") {
+		t.Errorf("Expected error message to be parsed, got %v", err)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Get("a")
+	cache.Set("c", []byte("3"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if body, ok := cache.Get("a"); !ok || string(body) != "1" {
+		t.Errorf("Expected a to be cached, got %q", body)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected entry to expire")
+	}
+}
@@ -0,0 +1,54 @@
+// Package gollamatest provides test helpers for code that uses gollama.
+//
+// Model outputs vary between runs, so comparing them with string equality
+// makes brittle tests. AssertSemanticallySimilar compares meaning instead, by
+// embedding both strings and checking their cosine similarity:
+//
+//	func TestSummary(t *testing.T) {
+//		got, err := summarize(ctx, client, article)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		gollamatest.AssertSemanticallySimilar(t, client, got, "The city council approved the budget.", 0.8)
+//	}
+package gollamatest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+// EmbeddingModel is the model used to embed strings for comparison. If it is
+// empty, the client's default model is used.
+var EmbeddingModel = ""
+
+// SemanticSimilarity returns the cosine similarity of the embeddings of a
+// and b.
+func SemanticSimilarity(ctx context.Context, client *gollama.Client, a, b string) (float64, error) {
+	embeddings, err := client.EmbedTexts(ctx, EmbeddingModel, []string{a, b})
+	if err != nil {
+		return 0, err
+	}
+	return gollama.CosineSimilarity(embeddings[0], embeddings[1]), nil
+}
+
+// AssertSemanticallySimilar reports a test error unless the cosine similarity
+// of the embeddings of got and want is at least threshold. It returns whether
+// the assertion held. Failing to compute the embeddings is also reported as
+// an error.
+func AssertSemanticallySimilar(t testing.TB, client *gollama.Client, got, want string, threshold float64) bool {
+	t.Helper()
+
+	similarity, err := SemanticSimilarity(context.Background(), client, got, want)
+	if err != nil {
+		t.Errorf("Failed to compare %q and %q: %v", got, want, err)
+		return false
+	}
+	if similarity < threshold {
+		t.Errorf("Expected similarity of at least %.2f, got %.2f\n got: %q\nwant: %q", threshold, similarity, got, want)
+		return false
+	}
+	return true
+}
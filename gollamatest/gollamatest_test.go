@@ -0,0 +1,64 @@
+package gollamatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+// recorder captures the errors reported by an assertion.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+func TestAssertSemanticallySimilar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gollama.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "missing" {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+
+		embedding := []float64{0, 1}
+		if strings.Contains(req.Prompt, "cat") {
+			embedding = []float64{1, 0.1}
+		}
+		json.NewEncoder(w).Encode(gollama.EmbeddingResponse{Embedding: embedding})
+	}))
+	defer server.Close()
+
+	client, err := gollama.NewClientWithOptions(server.URL, gollama.WithDefaultModel("embed"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	r := &recorder{TB: t}
+	if !AssertSemanticallySimilar(r, client, "a cat sat", "the cat was sitting", 0.9) || len(r.errors) != 0 {
+		t.Errorf("Expected similar strings to pass, got %v", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	if AssertSemanticallySimilar(r, client, "a cat sat", "stock prices fell", 0.9) || len(r.errors) != 1 {
+		t.Errorf("Expected dissimilar strings to fail once, got %v", r.errors)
+	}
+
+	EmbeddingModel = "missing"
+	defer func() { EmbeddingModel = "" }()
+
+	r = &recorder{TB: t}
+	if AssertSemanticallySimilar(r, client, "a", "b", 0.5) || len(r.errors) != 1 || !strings.Contains(r.errors[0], "Failed to compare") {
+		t.Errorf("Expected embedding failure to be reported, got %v", r.errors)
+	}
+}
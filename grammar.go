@@ -0,0 +1,56 @@
+package gollama
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GBNF grammars for the Grammar request field. Grammars are honored by
+// llama.cpp-based servers that accept them; Ollama itself constrains output
+// through Format instead and ignores the field.
+const (
+	// JSONGrammar matches any JSON object.
+	JSONGrammar = `root   ::= object
+value  ::= object | array | string | number | ("true" | "false" | "null") ws
+object ::= "{" ws ( string ":" ws value ("," ws string ":" ws value)* )? "}" ws
+array  ::= "[" ws ( value ("," ws value)* )? "]" ws
+string ::= "\"" ( [^"\\\x7F\x00-\x1F] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F]) )* "\"" ws
+number ::= "-"? ("0" | [1-9] [0-9]*) ("." [0-9]+)? ([eE] [-+]? [0-9]+)? ws
+ws     ::= ([ \t\n] ws)?
+`
+
+	// YesNoGrammar matches "yes" or "no".
+	YesNoGrammar = `root ::= "yes" | "no"
+`
+)
+
+// ChoiceGrammar returns a GBNF grammar that matches exactly one of the given
+// choices.
+func ChoiceGrammar(choices ...string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("at least one choice is required")
+	}
+
+	quoted := make([]string, len(choices))
+	for i, choice := range choices {
+		if choice == "" {
+			return "", fmt.Errorf("choices cannot be empty")
+		}
+		quoted[i] = gbnfLiteral(choice)
+	}
+	return "root ::= " + strings.Join(quoted, " | ") + "\n", nil
+}
+
+// gbnfEscaper escapes the characters that are special in GBNF literals.
+var gbnfEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// gbnfLiteral quotes s as a GBNF string literal.
+func gbnfLiteral(s string) string {
+	return `"` + gbnfEscaper.Replace(s) + `"`
+}
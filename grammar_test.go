@@ -0,0 +1,57 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChoiceGrammar(t *testing.T) {
+	grammar, err := ChoiceGrammar("positive", "negative", `say "hi"`, "a\\b\nc")
+	assertNoError(t, err)
+
+	want := `root ::= "positive" | "negative" | "say \"hi\"" | "a\\b\nc"` + "\n"
+	if grammar != want {
+		t.Errorf("Expected %q, got %q", want, grammar)
+	}
+
+	_, err = ChoiceGrammar()
+	assertErrorContains(t, err, "at least one choice is required")
+	_, err = ChoiceGrammar("yes", "")
+	assertErrorContains(t, err, "choices cannot be empty")
+}
+
+func TestClientGrammar(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Grammar string `json:"grammar"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		got = append(got, req.Grammar)
+
+		if r.URL.Path == "/api/chat" {
+			json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "yes"}, Done: true})
+			return
+		}
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "{}", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "llama3", Prompt: "json", Grammar: JSONGrammar})
+	assertNoError(t, err)
+	_, err = client.Chat(ctx, &ChatRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "ok?"}}, Grammar: YesNoGrammar})
+	assertNoError(t, err)
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "llama3", Prompt: "free"})
+	assertNoError(t, err)
+
+	if len(got) != 3 || got[0] != JSONGrammar || got[1] != YesNoGrammar || got[2] != "" {
+		t.Errorf("Expected grammars to be sent as given, got %q", got)
+	}
+}
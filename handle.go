@@ -0,0 +1,138 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAborted is returned by a StreamHandle whose stream was ended with
+// Abort.
+var ErrAborted = errors.New("stream aborted")
+
+// streamHandleBuffer is the number of chunks a StreamHandle holds for a
+// slow reader before it stops reading the response stream.
+const streamHandleBuffer = 16
+
+// StreamHandle controls a stream started in the background by StartGenerate
+// or StartChat. Chunks must be read until the channel is closed, or the
+// stream aborted, since the stream waits for the reader once the buffer is
+// full. A handle is safe for concurrent use.
+type StreamHandle[T any] struct {
+	chunks chan T
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+	resp   T
+	err    error
+}
+
+// newStreamHandle runs a stream in the background, passing its chunks to
+// the handle.
+func newStreamHandle[T any](ctx context.Context, run func(ctx context.Context, fn func(T)) (T, error)) *StreamHandle[T] {
+	ctx, cancel := context.WithCancelCause(ctx)
+	h := &StreamHandle[T]{
+		chunks: make(chan T, streamHandleBuffer),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+
+	go func() {
+		resp, err := run(ctx, func(chunk T) {
+			select {
+			case h.chunks <- chunk:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil && errors.Is(context.Cause(ctx), ErrAborted) {
+			err = ErrAborted
+		}
+		cancel(nil)
+
+		// The outcome is complete before the chunks end, so that a reader
+		// that drains them sees it from Err and Response
+		h.resp, h.err = resp, err
+		close(h.done)
+		close(h.chunks)
+	}()
+	return h
+}
+
+// Chunks returns the channel of streamed chunks, which is closed when the
+// stream ends.
+func (h *StreamHandle[T]) Chunks() <-chan T {
+	return h.chunks
+}
+
+// Abort ends the stream and closes its connection. The stream then fails
+// with ErrAborted, unless it had already ended. Abort can be called any
+// number of times.
+func (h *StreamHandle[T]) Abort() {
+	h.cancel(ErrAborted)
+}
+
+// Done returns a channel that is closed when the stream has ended and Err
+// and Response hold its outcome. Its last chunks may still be unread. The
+// outcome is also available once the Chunks channel is closed.
+func (h *StreamHandle[T]) Done() <-chan struct{} {
+	return h.done
+}
+
+// Err returns the error that ended the stream, or nil if it completed or
+// is still running.
+func (h *StreamHandle[T]) Err() error {
+	select {
+	case <-h.done:
+		return h.err
+	default:
+		return nil
+	}
+}
+
+// Response returns the complete response, as returned by
+// GenerateStreamCollect or ChatStreamCollect, once the stream has
+// completed, and nil otherwise.
+func (h *StreamHandle[T]) Response() T {
+	select {
+	case <-h.done:
+		return h.resp
+	default:
+		var zero T
+		return zero
+	}
+}
+
+// Wait waits for the stream to end and returns its complete response and
+// error. Chunks that are not read are discarded.
+func (h *StreamHandle[T]) Wait() (T, error) {
+	for range h.chunks {
+	}
+	<-h.done
+	return h.resp, h.err
+}
+
+// StartGenerate starts a streaming generation in the background and
+// returns a handle to read, abort or wait for it, for callers that manage
+// many concurrent generations. Errors, including invalid requests, are
+// reported by the handle's Err.
+//
+// Example:
+//
+//	h := client.StartGenerate(ctx, req)
+//	for chunk := range h.Chunks() {
+//		fmt.Print(chunk.Response)
+//	}
+//	if err := h.Err(); err != nil {
+//		return err
+//	}
+func (c *Client) StartGenerate(ctx context.Context, req *GenerateRequest, opts ...RequestOption) *StreamHandle[*GenerateResponse] {
+	return newStreamHandle(ctx, func(ctx context.Context, fn func(*GenerateResponse)) (*GenerateResponse, error) {
+		return c.GenerateStreamCollect(ctx, req, fn, opts...)
+	})
+}
+
+// StartChat starts a streaming chat completion in the background and
+// returns a handle to read, abort or wait for it, like StartGenerate.
+func (c *Client) StartChat(ctx context.Context, req *ChatRequest, opts ...RequestOption) *StreamHandle[*ChatResponse] {
+	return newStreamHandle(ctx, func(ctx context.Context, fn func(*ChatResponse)) (*ChatResponse, error) {
+		return c.ChatStreamCollect(ctx, req, fn, opts...)
+	})
+}
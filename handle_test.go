@@ -0,0 +1,106 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientStartGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, word := range []string{"Hello", " there"} {
+			w.Write([]byte(`{"response":"` + word + `"}` + "\n"))
+		}
+		w.Write([]byte(`{"response":"","done":true,"eval_count":2}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	h := client.StartGenerate(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"})
+
+	var text strings.Builder
+	for chunk := range h.Chunks() {
+		text.WriteString(chunk.Response)
+	}
+	<-h.Done()
+	assertNoError(t, h.Err())
+
+	if text.String() != "Hello there" {
+		t.Errorf("Expected streamed text 'Hello there', got %q", text.String())
+	}
+	if resp := h.Response(); resp == nil || resp.Response != "Hello there" || resp.EvalCount != 2 {
+		t.Errorf("Expected the complete response, got %+v", resp)
+	}
+
+	// The response is post-processed like that of GenerateStreamCollect
+	h = client.StartGenerate(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"}, WithRequestPostProcessor(strings.ToUpper))
+	resp, err := h.Wait()
+	assertNoError(t, err)
+	if resp.Response != "HELLO THERE" {
+		t.Errorf("Expected post-processed response, got %q", resp.Response)
+	}
+
+	// Invalid requests are reported by the handle
+	_, err = client.StartChat(context.Background(), nil).Wait()
+	assertErrorContains(t, err, "chat request cannot be nil")
+}
+
+func TestStreamHandleErrAfterChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":"Hel"}` + "\n"))
+		w.Write([]byte(`{"error":"model crashed"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	// Err is checked right after draining the chunks, with no other wait
+	for i := 0; i < 200; i++ {
+		h := client.StartGenerate(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"})
+		for range h.Chunks() {
+		}
+		if err := h.Err(); err == nil {
+			t.Fatalf("Attempt %d: expected the stream error once the chunks are closed", i)
+		}
+	}
+}
+
+func TestStreamHandleAbort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"}}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	h := client.StartChat(context.Background(), &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}})
+
+	chunk := <-h.Chunks()
+	if chunk.Message.Content != "Hel" {
+		t.Errorf("Expected first chunk 'Hel', got %q", chunk.Message.Content)
+	}
+	if h.Err() != nil {
+		t.Errorf("Expected no error while running, got %v", h.Err())
+	}
+
+	h.Abort()
+	select {
+	case <-h.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stream to end after Abort")
+	}
+	if !errors.Is(h.Err(), ErrAborted) {
+		t.Errorf("Expected ErrAborted, got %v", h.Err())
+	}
+	h.Abort()
+}
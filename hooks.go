@@ -0,0 +1,257 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CompletionEvent describes a finished long-running operation: a Pull, Push
+// or Create, or a background job run by the jobs package.
+type CompletionEvent struct {
+	// Operation is "pull", "push", "create" or "job".
+	Operation string `json:"operation"`
+	Model     string `json:"model"`
+	// JobID is set for jobs.
+	JobID string `json:"job_id,omitempty"`
+	// Status is "success", "error" or "canceled" for model operations, and
+	// the final job status for jobs.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Bytes is the total size of the layers transferred by a pull or push.
+	Bytes int64 `json:"bytes,omitempty"`
+	// PromptTokens and CompletionTokens are set for successful jobs.
+	PromptTokens     int           `json:"prompt_tokens,omitempty"`
+	CompletionTokens int           `json:"completion_tokens,omitempty"`
+	StartedAt        time.Time     `json:"started_at"`
+	FinishedAt       time.Time     `json:"finished_at"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// WithCompletionHook registers fn to be called when a long-running
+// operation finishes, whether it succeeded or not. Hooks run in the
+// goroutine that calls NotifyCompletion: for Pull, Push and Create that is
+// the goroutine that made the call, before it returns, while the jobs
+// package notifies from a goroutine of its own so that hooks never block
+// its manager. Hooks may therefore run concurrently, and should return
+// quickly.
+func WithCompletionHook(fn func(CompletionEvent)) ClientOption {
+	return func(c *Client) {
+		c.completionHooks = append(c.completionHooks, fn)
+	}
+}
+
+// Webhook delivery settings.
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+)
+
+// webhookBackoff is the delay before the second delivery attempt of a
+// webhook. It doubles for each further attempt.
+var webhookBackoff = time.Second
+
+// WithWebhook registers a URL that receives each CompletionEvent as a JSON
+// POST request when a long-running operation finishes. Delivery happens in
+// the background and is attempted up to three times; failures are
+// reported to onError if it is not nil. FlushWebhooks waits for deliveries
+// in progress, for instance before the program exits.
+func WithWebhook(url string, onError func(CompletionEvent, error)) ClientOption {
+	hook := &webhook{
+		url:     url,
+		client:  &http.Client{Timeout: webhookTimeout},
+		onError: onError,
+	}
+	return func(c *Client) {
+		if c.webhooks == nil {
+			c.webhooks = newWebhookGroup()
+		}
+		group := c.webhooks
+		c.completionHooks = append(c.completionHooks, func(event CompletionEvent) {
+			ctx := group.start()
+			go func() {
+				defer group.done()
+				hook.deliver(ctx, event)
+			}()
+		})
+	}
+}
+
+// FlushWebhooks waits until the webhook deliveries in progress finish. If
+// ctx is done first, the remaining deliveries are canceled, their failures
+// are reported to the onError callbacks, and ctx.Err() is returned once
+// they have stopped.
+func (c *Client) FlushWebhooks(ctx context.Context) error {
+	if c.webhooks == nil {
+		return nil
+	}
+	return c.webhooks.flush(ctx)
+}
+
+// webhookGroup tracks the webhook deliveries of a client.
+type webhookGroup struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending int
+	// idle is closed when pending drops to zero
+	idle chan struct{}
+}
+
+// newWebhookGroup creates an empty webhookGroup.
+func newWebhookGroup() *webhookGroup {
+	g := &webhookGroup{idle: make(chan struct{})}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	close(g.idle)
+	return g
+}
+
+// start registers a delivery and returns the context it runs in.
+func (g *webhookGroup) start() context.Context {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pending == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.pending++
+	return g.ctx
+}
+
+// done unregisters a finished delivery.
+func (g *webhookGroup) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending--
+	if g.pending == 0 {
+		close(g.idle)
+	}
+}
+
+// flush waits for the pending deliveries, canceling them if ctx is done
+// first. Deliveries started afterwards run in a fresh context.
+func (g *webhookGroup) flush(ctx context.Context) error {
+	g.mu.Lock()
+	idle := g.idle
+	g.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	g.cancel()
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	g.mu.Unlock()
+	<-idle
+	return ctx.Err()
+}
+
+// webhook posts completion events to a URL.
+type webhook struct {
+	url     string
+	client  *http.Client
+	onError func(CompletionEvent, error)
+}
+
+// deliver posts an event, retrying failed attempts until ctx is done.
+func (w *webhook) deliver(ctx context.Context, event CompletionEvent) {
+	body, err := json.Marshal(event)
+	if err == nil {
+		backoff := webhookBackoff
+		for attempt := 1; ; attempt++ {
+			if err = w.post(ctx, body); err == nil || attempt == webhookAttempts {
+				break
+			}
+			if sleepErr := sleepContext(ctx, backoff); sleepErr != nil {
+				err = sleepErr
+				break
+			}
+			backoff *= 2
+		}
+	}
+	if err != nil && w.onError != nil {
+		w.onError(event, fmt.Errorf("failed to deliver webhook to %s: %w", w.url, err))
+	}
+}
+
+// post sends one delivery attempt.
+func (w *webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", defaultUserAgent)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// NotifyCompletion passes event to the client's completion hooks. It is
+// called by the client itself and by packages that run long operations on
+// its behalf, such as jobs.
+func (c *Client) NotifyCompletion(event CompletionEvent) {
+	for _, hook := range c.completionHooks {
+		hook(event)
+	}
+}
+
+// notifyOperation reports a finished model operation to the completion
+// hooks, if there are any.
+func (c *Client) notifyOperation(operation, model string, start time.Time, bytes int64, err error) {
+	if len(c.completionHooks) == 0 {
+		return
+	}
+
+	event := CompletionEvent{
+		Operation:  operation,
+		Model:      model,
+		Status:     "success",
+		Bytes:      bytes,
+		StartedAt:  start,
+		FinishedAt: time.Now(),
+	}
+	event.Duration = event.FinishedAt.Sub(start)
+	if err != nil {
+		event.Status = "error"
+		if errors.Is(err, context.Canceled) {
+			event.Status = "canceled"
+		}
+		event.Error = err.Error()
+	}
+	c.NotifyCompletion(event)
+}
+
+// layerTotals tracks the total size of each layer reported in progress
+// updates.
+type layerTotals map[string]int64
+
+// add records a progress update.
+func (t layerTotals) add(digest string, total int64) {
+	if digest != "" && total > t[digest] {
+		t[digest] = total
+	}
+}
+
+// sum returns the combined size of all layers.
+func (t layerTotals) sum() int64 {
+	var n int64
+	for _, total := range t {
+		n += total
+	}
+	return n
+}
@@ -0,0 +1,181 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompletionHook(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	var events []CompletionEvent
+	client, err := NewClientWithOptions(server.URL, WithCompletionHook(func(event CompletionEvent) {
+		events = append(events, event)
+	}))
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	assertNoError(t, client.Pull(ctx, "llama2", func(PullProgress) {}))
+	assertNoError(t, client.Create(ctx, "custom", "FROM llama2", func(CreateProgress) {}))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = client.Push(canceled, "user/model", func(PushProgress) {})
+	if err == nil {
+		t.Fatal("Expected error for canceled push")
+	}
+
+	// Invalid arguments fail before any request is made
+	client.Pull(ctx, "", func(PullProgress) {})
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %+v", len(events), events)
+	}
+
+	pull := events[0]
+	if pull.Operation != "pull" || pull.Model != "llama2" || pull.Status != "success" {
+		t.Errorf("Expected successful pull event, got %+v", pull)
+	}
+	if pull.Bytes != 1000 {
+		t.Errorf("Expected 1000 bytes, got %d", pull.Bytes)
+	}
+	if pull.FinishedAt.Before(pull.StartedAt) || pull.Duration != pull.FinishedAt.Sub(pull.StartedAt) {
+		t.Errorf("Expected consistent timing, got %+v", pull)
+	}
+
+	if events[1].Operation != "create" || events[1].Status != "success" {
+		t.Errorf("Expected successful create event, got %+v", events[1])
+	}
+	if events[2].Operation != "push" || events[2].Status != "canceled" || events[2].Error == "" {
+		t.Errorf("Expected canceled push event, got %+v", events[2])
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = time.Millisecond
+
+	var attempts int32
+	received := make(chan CompletionEvent, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var event CompletionEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Expected JSON body, got %v", err)
+		}
+		received <- event
+	}))
+	defer target.Close()
+
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithWebhook(target.URL, func(_ CompletionEvent, err error) {
+		t.Errorf("Expected delivery to succeed, got %v", err)
+	}))
+	assertNoError(t, err)
+	assertNoError(t, client.Pull(context.Background(), "llama2", func(PullProgress) {}))
+
+	select {
+	case event := <-received:
+		if event.Operation != "pull" || event.Model != "llama2" || event.Bytes != 1000 {
+			t.Errorf("Expected pull event, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for webhook")
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("Expected 2 delivery attempts, got %d", n)
+	}
+}
+
+func TestWebhookFailure(t *testing.T) {
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = time.Millisecond
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer target.Close()
+
+	server := setupMockServer()
+	defer server.Close()
+
+	failed := make(chan error, 1)
+	client, err := NewClientWithOptions(server.URL, WithWebhook(target.URL, func(_ CompletionEvent, err error) {
+		failed <- err
+	}))
+	assertNoError(t, err)
+	assertNoError(t, client.Create(context.Background(), "custom", "FROM llama2", func(CreateProgress) {}))
+
+	select {
+	case err := <-failed:
+		assertErrorContains(t, err, "503")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for delivery failure")
+	}
+}
+
+func TestFlushWebhooks(t *testing.T) {
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = time.Hour
+
+	var attempts int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer target.Close()
+
+	server := setupMockServer()
+	defer server.Close()
+
+	var failures int32
+	client, err := NewClientWithOptions(server.URL, WithWebhook(target.URL, func(_ CompletionEvent, err error) {
+		atomic.AddInt32(&failures, 1)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a canceled delivery, got %v", err)
+		}
+	}))
+	assertNoError(t, err)
+
+	// A successful delivery is waited for
+	assertNoError(t, client.Pull(context.Background(), "llama2", func(PullProgress) {}))
+	assertNoError(t, client.FlushWebhooks(context.Background()))
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Errorf("Expected the delivery to finish before FlushWebhooks returns, got %d attempts", n)
+	}
+
+	// A delivery waiting to retry is canceled when the flush times out
+	assertNoError(t, client.Pull(context.Background(), "llama2", func(PullProgress) {}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := client.FlushWebhooks(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the pending delivery to be canceled, waited %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&failures); n != 1 {
+		t.Errorf("Expected the canceled delivery to be reported, got %d failures", n)
+	}
+
+	// Flushing without webhooks returns at once
+	plain, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	assertNoError(t, plain.FlushWebhooks(context.Background()))
+}
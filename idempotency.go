@@ -0,0 +1,35 @@
+package gollama
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// IdempotencyKeyHeader is the header that carries the key set with
+// WithIdempotencyKey.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey attaches an idempotency key to a call, sent in the
+// Idempotency-Key header of every attempt, including retries. Servers that
+// honor the header run the first request with a key and answer later
+// requests with the same key with its result instead of generating again,
+// so a call retried after a timeout or a crash is not billed twice.
+//
+// The client itself does not deduplicate calls, and Ollama ignores the
+// header: requests are only deduplicated when they pass through a server
+// that honors it, such as a gollamaproxy.Proxy set up with WithIdempotency.
+//
+// Use a new key, for example from NewIdempotencyKey, for each logical
+// request, and the same key when retrying it.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.idempotencyKey = key
+	}
+}
+
+// NewIdempotencyKey returns a random key for WithIdempotencyKey.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,43 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) == 1 {
+			http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "hello", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL,
+		WithRetryPolicies(map[Operation]RetryPolicy{OpGenerate: {MaxAttempts: 2}}))
+	assertNoError(t, err)
+
+	key := NewIdempotencyKey()
+	if len(key) != 32 || key == NewIdempotencyKey() {
+		t.Errorf("Expected a random 32-character key, got %q", key)
+	}
+
+	_, err = client.Generate(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "hi"}, WithIdempotencyKey(key))
+	assertNoError(t, err)
+	if len(keys) != 2 || keys[0] != key || keys[1] != key {
+		t.Errorf("Expected every attempt to carry the key, got %v", keys)
+	}
+
+	_, err = client.Generate(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "hi"})
+	assertNoError(t, err)
+	if keys[2] != "" {
+		t.Errorf("Expected no key without the option, got %q", keys[2])
+	}
+}
@@ -0,0 +1,573 @@
+// Package jobs runs generations in the background so that callers can
+// submit a request, return immediately and poll for the result later.
+//
+// This suits web backends that cannot hold a connection open for the length
+// of a generation:
+//
+//	manager := jobs.New(client, &jobs.Options{Concurrency: 2})
+//	defer manager.Close()
+//
+//	id, err := manager.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: prompt})
+//	...
+//	job, err := manager.Get(id) // job.Status, job.Output so far
+//	...
+//	err = manager.Cancel(id)
+//
+// A manager created with Open keeps its jobs in a Store, so that queued and
+// running jobs survive a restart of the process and are run again:
+//
+//	store, err := jobs.NewFileStore("/var/lib/myapp/jobs")
+//	manager, err := jobs.Open(client, store, &jobs.Options{MaxAttempts: 3})
+//
+// A job that is run again starts over under the same ID. Jobs that fail
+// MaxAttempts times are moved to the DeadLetter state, where they are kept
+// until retried with Retry or deleted with Remove.
+//
+// Completion hooks and webhooks registered on the client with
+// gollama.WithCompletionHook or gollama.WithWebhook are called for every
+// job that finishes, with Operation set to "job".
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// ErrNotFound is returned for unknown or expired job IDs.
+var ErrNotFound = errors.New("job not found")
+
+// ErrClosed is returned when submitting to a closed manager.
+var ErrClosed = errors.New("job manager is closed")
+
+// Status is the state of a job.
+type Status string
+
+const (
+	// Queued jobs wait for a free slot.
+	Queued Status = "queued"
+	// Running jobs are generating.
+	Running Status = "running"
+	// Succeeded jobs completed and hold the full output.
+	Succeeded Status = "succeeded"
+	// Failed jobs hold the error that ended them and any partial output.
+	Failed Status = "failed"
+	// Canceled jobs were canceled before completing.
+	Canceled Status = "canceled"
+	// DeadLetter jobs failed on each of several attempts. They are not
+	// removed after the retention period.
+	DeadLetter Status = "dead_letter"
+)
+
+// Done reports whether the status is final.
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed || s == Canceled || s == DeadLetter
+}
+
+// Job is a snapshot of a background generation.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// Generate or Chat holds the submitted request.
+	Generate *gollama.GenerateRequest `json:"generate,omitempty"`
+	Chat     *gollama.ChatRequest     `json:"chat,omitempty"`
+	// Output is the text generated so far.
+	Output string `json:"output"`
+	// Chunks is the number of streamed chunks received so far, a rough
+	// measure of progress.
+	Chunks int `json:"chunks"`
+	// Attempts is the number of times the job was started.
+	Attempts int `json:"attempts"`
+	// PromptTokens and CompletionTokens are set once the job succeeds.
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	StartedAt        time.Time `json:"started_at,omitempty"`
+	FinishedAt       time.Time `json:"finished_at,omitempty"`
+}
+
+// Options configures a Manager.
+type Options struct {
+	// Concurrency is the number of jobs that run at once. Further jobs are
+	// queued. Defaults to 1.
+	Concurrency int
+	// Retention is how long finished jobs can be retrieved. Defaults to one
+	// hour.
+	Retention time.Duration
+	// MaxAttempts is the number of times a failing job is run before it is
+	// given up. With more than one attempt, jobs that fail every attempt
+	// end in DeadLetter instead of Failed. Defaults to 1.
+	MaxAttempts int
+	// RequestOptions are applied to every generation.
+	RequestOptions []gollama.RequestOption
+	// OnStoreError is called when the store fails to save or delete a job
+	// in the background. Errors while submitting are returned by Submit.
+	OnStoreError func(id string, err error)
+}
+
+// entry is a job tracked by a Manager.
+type entry struct {
+	job    Job
+	output strings.Builder
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager runs jobs in the background. A Manager is safe for concurrent use.
+type Manager struct {
+	client *gollama.Client
+	opts   Options
+	store  Store
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	queue   []*entry
+	running int
+	closed  bool
+}
+
+// New creates a manager that runs jobs with client and keeps them in memory.
+// A nil opts uses the defaults.
+func New(client *gollama.Client, opts *Options) *Manager {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.Retention <= 0 {
+		o.Retention = time.Hour
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		client: client,
+		opts:   o,
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*entry),
+	}
+}
+
+// Open creates a manager that keeps its jobs in store, and resumes the jobs
+// that were queued or running when the store was last used. Running jobs
+// start over, and count as a failed attempt if they already used all their
+// attempts, so that a job crashing the process ends up in DeadLetter.
+func Open(client *gollama.Client, store Store, opts *Options) (*Manager, error) {
+	saved, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].CreatedAt.Before(saved[j].CreatedAt) })
+
+	m := New(client, opts)
+	m.store = store
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range saved {
+		e := m.newEntry(*job)
+		m.jobs[job.ID] = e
+		switch job.Status {
+		case Queued:
+			m.queue = append(m.queue, e)
+		case Running:
+			if job.Attempts >= m.opts.MaxAttempts {
+				m.finishLocked(e, errors.New("interrupted by a restart"), 0, 0)
+				continue
+			}
+			e.job.Status = Queued
+			m.queue = append(m.queue, e)
+		default:
+			e.output.WriteString(job.Output)
+			close(e.done)
+		}
+	}
+	m.pruneLocked()
+	m.dispatchLocked()
+	return m, nil
+}
+
+// newEntry creates an entry for a job.
+func (m *Manager) newEntry(job Job) *entry {
+	ctx, cancel := context.WithCancel(m.ctx)
+	return &entry{job: job, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+// Submit queues a generation and returns its job ID.
+func (m *Manager) Submit(req *gollama.GenerateRequest) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("generate request cannot be nil")
+	}
+	reqCopy := *req
+	return m.submit(Job{Generate: &reqCopy})
+}
+
+// SubmitChat queues a chat request and returns its job ID.
+func (m *Manager) SubmitChat(req *gollama.ChatRequest) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("chat request cannot be nil")
+	}
+	reqCopy := *req
+	reqCopy.Messages = append([]gollama.Message(nil), req.Messages...)
+	return m.submit(Job{Chat: &reqCopy})
+}
+
+// submit registers a job and queues it.
+func (m *Manager) submit(job Job) (string, error) {
+	job.ID = newID()
+	job.Status = Queued
+	job.CreatedAt = time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return "", ErrClosed
+	}
+	if m.store != nil {
+		if err := m.store.Save(&job); err != nil {
+			return "", fmt.Errorf("failed to save job: %w", err)
+		}
+	}
+
+	e := m.newEntry(job)
+	m.pruneLocked()
+	m.jobs[job.ID] = e
+	m.queue = append(m.queue, e)
+	m.dispatchLocked()
+	return job.ID, nil
+}
+
+// dispatchLocked starts queued jobs, oldest first, while there are free
+// slots. Jobs canceled by Close while queued are finished without running.
+// The caller must hold m.mu.
+func (m *Manager) dispatchLocked() {
+	for len(m.queue) > 0 {
+		e := m.queue[0]
+		if err := e.ctx.Err(); err != nil {
+			m.queue = m.queue[1:]
+			m.finishLocked(e, err, 0, 0)
+			continue
+		}
+		if m.running >= m.opts.Concurrency {
+			return
+		}
+
+		m.queue = m.queue[1:]
+		m.running++
+		e.output.Reset()
+		e.job.Status = Running
+		e.job.Chunks = 0
+		e.job.Error = ""
+		e.job.Attempts++
+		e.job.StartedAt = time.Now()
+		m.saveLocked(e)
+
+		m.wg.Add(1)
+		go m.run(e)
+	}
+}
+
+// run executes a job.
+func (m *Manager) run(e *entry) {
+	defer m.wg.Done()
+
+	var promptTokens, completionTokens int
+	var err error
+	if e.job.Generate != nil {
+		err = m.client.GenerateStream(e.ctx, e.job.Generate, func(resp *gollama.GenerateResponse) {
+			m.progress(e, resp.Response)
+			promptTokens, completionTokens = resp.PromptEvalCount, resp.EvalCount
+		}, m.opts.RequestOptions...)
+	} else {
+		err = m.client.ChatStream(e.ctx, e.job.Chat, func(resp *gollama.ChatResponse) {
+			m.progress(e, resp.Message.Content)
+			promptTokens, completionTokens = resp.PromptEvalCount, resp.EvalCount
+		}, m.opts.RequestOptions...)
+	}
+	if err != nil && e.ctx.Err() != nil {
+		err = e.ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running--
+
+	// Jobs interrupted by Close stay running in the store and resume on Open
+	if m.closed && m.store != nil && errors.Is(err, context.Canceled) && m.ctx.Err() != nil {
+		close(e.done)
+		return
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) && e.job.Attempts < m.opts.MaxAttempts {
+		e.job.Status = Queued
+		e.job.Error = err.Error()
+		m.saveLocked(e)
+		m.queue = append(m.queue, e)
+	} else {
+		m.finishLocked(e, err, promptTokens, completionTokens)
+	}
+	m.dispatchLocked()
+}
+
+// progress records a streamed chunk.
+func (m *Manager) progress(e *entry, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.output.WriteString(text)
+	e.job.Chunks++
+}
+
+// finishLocked records the outcome of a job. The caller must hold m.mu.
+func (m *Manager) finishLocked(e *entry, err error, promptTokens, completionTokens int) {
+	e.job.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		e.job.Status = Succeeded
+		e.job.PromptTokens = promptTokens
+		e.job.CompletionTokens = completionTokens
+	case errors.Is(err, context.Canceled):
+		e.job.Status = Canceled
+		e.job.Error = err.Error()
+	case m.opts.MaxAttempts > 1:
+		e.job.Status = DeadLetter
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = Failed
+		e.job.Error = err.Error()
+	}
+	m.saveLocked(e)
+	e.cancel()
+	close(e.done)
+	m.notifyLocked(e)
+}
+
+// notifyLocked reports a finished job to the client's completion hooks.
+// Hooks run in their own goroutine so that they never block the manager;
+// Close waits for them. The caller must hold m.mu.
+func (m *Manager) notifyLocked(e *entry) {
+	job := e.job
+	event := gollama.CompletionEvent{
+		Operation:        "job",
+		JobID:            job.ID,
+		Status:           string(job.Status),
+		Error:            job.Error,
+		PromptTokens:     job.PromptTokens,
+		CompletionTokens: job.CompletionTokens,
+		StartedAt:        job.StartedAt,
+		FinishedAt:       job.FinishedAt,
+	}
+	switch {
+	case job.Generate != nil:
+		event.Model = job.Generate.Model
+	case job.Chat != nil:
+		event.Model = job.Chat.Model
+	}
+	if !job.StartedAt.IsZero() {
+		event.Duration = job.FinishedAt.Sub(job.StartedAt)
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.client.NotifyCompletion(event)
+	}()
+}
+
+// saveLocked writes a job to the store, if any. The caller must hold m.mu.
+func (m *Manager) saveLocked(e *entry) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Save(e.snapshot()); err != nil && m.opts.OnStoreError != nil {
+		m.opts.OnStoreError(e.job.ID, err)
+	}
+}
+
+// snapshot returns a copy of a job with its current output. The caller must
+// hold m.mu.
+func (e *entry) snapshot() *Job {
+	job := e.job
+	job.Output = e.output.String()
+	return &job
+}
+
+// Get returns a snapshot of a job, including its output so far.
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e.snapshot(), nil
+}
+
+// List returns snapshots of all jobs, oldest first.
+func (m *Manager) List() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		jobs = append(jobs, e.snapshot())
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// Cancel cancels a queued or running job. Canceling a finished job has no
+// effect.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.cancel()
+	for i, queued := range m.queue {
+		if queued == e {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			m.finishLocked(e, e.ctx.Err(), 0, 0)
+			break
+		}
+	}
+	return nil
+}
+
+// Retry queues a failed, canceled or dead-lettered job again, with a fresh
+// set of attempts.
+func (m *Manager) Retry(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if old.job.Status != Failed && old.job.Status != Canceled && old.job.Status != DeadLetter {
+		return fmt.Errorf("job %s is %s and cannot be retried", id, old.job.Status)
+	}
+	if m.closed {
+		return ErrClosed
+	}
+
+	job := old.job
+	job.Status = Queued
+	job.Attempts = 0
+	job.Error = ""
+	job.FinishedAt = time.Time{}
+
+	e := m.newEntry(job)
+	m.jobs[id] = e
+	m.saveLocked(e)
+	m.queue = append(m.queue, e)
+	m.dispatchLocked()
+	return nil
+}
+
+// Remove forgets a finished job and deletes it from the store.
+func (m *Manager) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if !e.job.Status.Done() {
+		return fmt.Errorf("job %s is %s and cannot be removed", id, e.job.Status)
+	}
+	delete(m.jobs, id)
+	if m.store != nil {
+		return m.store.Delete(id)
+	}
+	return nil
+}
+
+// Wait blocks until a job finishes, the manager is closed or ctx is done,
+// and returns the job.
+func (m *Manager) Wait(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	select {
+	case <-e.done:
+		return m.Get(id)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops all jobs and waits for them to stop. Without a store the jobs
+// are canceled; with a store, queued and running jobs are left to resume
+// when the store is opened again, and Wait returns them in that state.
+// Finished jobs can still be retrieved afterwards.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.cancel()
+	if m.store == nil {
+		m.dispatchLocked()
+	} else {
+		for _, e := range m.queue {
+			close(e.done)
+		}
+		m.queue = nil
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+	return nil
+}
+
+// pruneLocked forgets jobs that finished longer than the retention ago,
+// except dead letters. The caller must hold m.mu.
+func (m *Manager) pruneLocked() {
+	cutoff := time.Now().Add(-m.opts.Retention)
+	for id, e := range m.jobs {
+		if e.job.Status.Done() && e.job.Status != DeadLetter && e.job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+			if m.store != nil {
+				if err := m.store.Delete(id); err != nil && m.opts.OnStoreError != nil {
+					m.opts.OnStoreError(id, err)
+				}
+			}
+		}
+	}
+}
+
+// newID returns a random job ID.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+// newTestClient starts a server that streams "partial" and then blocks until
+// release is closed or the request is canceled before sending " output".
+func newTestClient(t *testing.T, release chan struct{}, opts ...gollama.ClientOption) *gollama.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "broken" {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(gollama.GenerateResponse{Response: "partial"})
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		enc.Encode(gollama.GenerateResponse{Response: " output", Done: true, PromptEvalCount: 3, EvalCount: 2})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClientWithOptions(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+// waitFor polls a job until cond holds.
+func waitFor(t *testing.T, m *Manager, id string, cond func(*Job) bool) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cond(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for job, last state %+v", job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManager(t *testing.T) {
+	release := make(chan struct{})
+	client := newTestClient(t, release)
+	m := New(client, nil)
+	defer m.Close()
+
+	first, err := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+
+	job := waitFor(t, m, first, func(j *Job) bool { return j.Output == "partial" })
+	if job.Status != Running || job.Chunks != 1 {
+		t.Errorf("Expected running job with partial output, got %+v", job)
+	}
+	if job, _ := m.Get(second); job.Status != Queued {
+		t.Errorf("Expected second job to be queued with concurrency 1, got %s", job.Status)
+	}
+
+	close(release)
+	job, err = m.Wait(context.Background(), first)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if job.Status != Succeeded || job.Output != "partial output" || job.CompletionTokens != 2 {
+		t.Errorf("Expected succeeded job with full output, got %+v", job)
+	}
+	if job, _ = m.Wait(context.Background(), second); job.Status != Succeeded {
+		t.Errorf("Expected queued job to run after the first, got %+v", job)
+	}
+
+	failed, _ := m.Submit(&gollama.GenerateRequest{Model: "broken", Prompt: "hi"})
+	job, _ = m.Wait(context.Background(), failed)
+	if job.Status != Failed || job.Error == "" {
+		t.Errorf("Expected failed job with error, got %+v", job)
+	}
+
+	if len(m.List()) != 3 {
+		t.Errorf("Expected 3 jobs, got %d", len(m.List()))
+	}
+	if _, err := m.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	client := newTestClient(t, make(chan struct{}))
+	m := New(client, &Options{Concurrency: 1})
+
+	running, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	queued, _ := m.SubmitChat(&gollama.ChatRequest{Model: "llama3", Messages: []gollama.Message{{Role: "user", Content: "hi"}}})
+	waitFor(t, m, running, func(j *Job) bool { return j.Output == "partial" })
+
+	if err := m.Cancel(running); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	job, _ := m.Wait(context.Background(), running)
+	if job.Status != Canceled || job.Output != "partial" {
+		t.Errorf("Expected canceled job keeping partial output, got %+v", job)
+	}
+
+	m.Close()
+	if job, _ := m.Get(queued); job.Status != Canceled {
+		t.Errorf("Expected Close to cancel remaining jobs, got %s (%s)", job.Status, job.Error)
+	}
+	if _, err := m.Submit(&gollama.GenerateRequest{Model: "llama3"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestManagerCancelQueued(t *testing.T) {
+	release := make(chan struct{})
+	client := newTestClient(t, release)
+	m := New(client, &Options{Concurrency: 1})
+	defer m.Close()
+
+	running, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	first, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	second, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	waitFor(t, m, running, func(j *Job) bool { return j.Output == "partial" })
+
+	// The job behind the head of the queue finishes without waiting its turn
+	if err := m.Cancel(second); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, second)
+	if err != nil || job.Status != Canceled {
+		t.Fatalf("Expected the queued job to be canceled at once, got %+v (error %v)", job, err)
+	}
+
+	close(release)
+	for _, id := range []string{running, first} {
+		if job, _ := m.Wait(context.Background(), id); job.Status != Succeeded {
+			t.Errorf("Expected job %s to succeed, got %s (%s)", id, job.Status, job.Error)
+		}
+	}
+}
+
+func TestManagerCompletionHook(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+
+	events := make(chan gollama.CompletionEvent, 2)
+	client := newTestClient(t, release, gollama.WithCompletionHook(func(event gollama.CompletionEvent) {
+		events <- event
+	}))
+	m := New(client, nil)
+
+	ok, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	m.Wait(context.Background(), ok)
+	failed, _ := m.Submit(&gollama.GenerateRequest{Model: "broken", Prompt: "hi"})
+	m.Wait(context.Background(), failed)
+	m.Close()
+
+	got := map[string]gollama.CompletionEvent{}
+	for i := 0; i < 2; i++ {
+		event := <-events
+		got[event.JobID] = event
+	}
+
+	if event := got[ok]; event.Operation != "job" || event.Model != "llama3" || event.Status != string(Succeeded) || event.CompletionTokens != 2 {
+		t.Errorf("Expected succeeded job event, got %+v", event)
+	}
+	if event := got[failed]; event.Status != string(Failed) || event.Error == "" {
+		t.Errorf("Expected failed job event, got %+v", event)
+	}
+}
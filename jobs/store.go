@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/astrica1/gollama"
+)
+
+// Store persists jobs for a Manager created with Open. Implementations for
+// databases such as SQLite or BoltDB only need to store each job by ID.
+// Save and Delete are called with the manager's lock held and should not
+// block for long.
+type Store interface {
+	// Save creates or replaces a job.
+	Save(job *Job) error
+	// Delete removes a job. Deleting an unknown job is not an error.
+	Delete(id string) error
+	// Load returns all stored jobs.
+	Load() ([]*Job, error)
+}
+
+// FileStore stores each job as a file in a directory, as JSON by default.
+// Files are replaced atomically, so a crash never leaves a partially written
+// job.
+type FileStore struct {
+	dir   string
+	codec gollama.Codec
+}
+
+// NewFileStore creates a store of JSON files in dir, creating the directory
+// if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	return NewFileStoreWithCodec(dir, gollama.JSONCodec{})
+}
+
+// NewFileStoreWithCodec creates a store in dir whose files are encoded with
+// codec, such as gollama.MsgpackCodec for compact storage. Only files with
+// the codec's extension are loaded, so switching codecs starts from an
+// empty store.
+func NewFileStoreWithCodec(dir string, codec gollama.Codec) (*FileStore, error) {
+	if codec == nil {
+		return nil, fmt.Errorf("codec cannot be nil")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create job store: %w", err)
+	}
+	return &FileStore{dir: dir, codec: codec}, nil
+}
+
+// path returns the file of a job.
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+"."+s.codec.Extension())
+}
+
+// Save writes a job to its file.
+func (s *FileStore) Save(job *Job) error {
+	data, err := s.codec.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".job-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(job.ID))
+}
+
+// Delete removes the file of a job.
+func (s *FileStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Load reads all job files.
+func (s *FileStore) Load() ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, "."+s.codec.Extension()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var job Job
+		if err := s.codec.Unmarshal(data, &job); err != nil {
+			return nil, fmt.Errorf("invalid job file %s: %w", name, err)
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
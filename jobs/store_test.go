@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrica1/gollama"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	job := &Job{ID: "abc", Status: Queued, Generate: &gollama.GenerateRequest{Model: "llama3", Prompt: "hi"}}
+	if err := store.Save(job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	job.Status = Running
+	if err := store.Save(job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	jobs, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != Running || jobs[0].Generate.Prompt != "hi" {
+		t.Errorf("Expected saved job to load, got %+v", jobs)
+	}
+
+	if err := store.Delete("abc"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Delete("abc"); err != nil {
+		t.Errorf("Expected deleting a missing job to succeed, got %v", err)
+	}
+	if jobs, _ := store.Load(); len(jobs) != 0 {
+		t.Errorf("Expected no jobs after delete, got %d", len(jobs))
+	}
+}
+
+func TestFileStoreWithCodec(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStoreWithCodec(dir, gollama.MsgpackCodec{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	job := &Job{ID: "abc", Status: Queued, Generate: &gollama.GenerateRequest{Model: "llama3", Prompt: "hi"}}
+	if err := store.Save(job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "abc.msgpack")); err != nil {
+		t.Errorf("Expected job in abc.msgpack, got %v", err)
+	}
+
+	jobs, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != Queued || jobs[0].Generate.Prompt != "hi" {
+		t.Errorf("Expected saved job to load, got %+v", jobs)
+	}
+
+	// JSON stores in the same directory do not see msgpack jobs
+	jsonStore, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if jobs, _ := jsonStore.Load(); len(jobs) != 0 {
+		t.Errorf("Expected no JSON jobs, got %d", len(jobs))
+	}
+
+	if _, err := NewFileStoreWithCodec(dir, nil); err == nil {
+		t.Error("Expected error for nil codec")
+	}
+}
+
+// newFlakyClient starts a server that fails the first failures requests.
+func newFlakyClient(t *testing.T, failures int32) (*gollama.Client, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			http.Error(w, `{"error":"out of memory"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(gollama.GenerateResponse{Response: "done", Done: true})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client, &calls
+}
+
+func TestManagerRetriesAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewFileStore(t.TempDir())
+
+	client, calls := newFlakyClient(t, 4)
+	m, err := Open(client, store, &Options{MaxAttempts: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer m.Close()
+
+	id, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	job, _ := m.Wait(ctx, id)
+	if job.Status != DeadLetter || job.Attempts != 3 || *calls != 3 {
+		t.Fatalf("Expected dead letter after 3 attempts, got %+v after %d calls", job, *calls)
+	}
+
+	// Dead letters are kept in the store
+	saved, _ := store.Load()
+	if len(saved) != 1 || saved[0].Status != DeadLetter {
+		t.Errorf("Expected dead letter in store, got %+v", saved)
+	}
+
+	if err := m.Retry(id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	job, _ = m.Wait(ctx, id)
+	if job.Status != Succeeded || job.Output != "done" || job.Attempts != 2 {
+		t.Errorf("Expected retried job to succeed on its second attempt, got %+v", job)
+	}
+
+	if err := m.Remove(id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved, _ := store.Load(); len(saved) != 0 {
+		t.Errorf("Expected removed job to be deleted from store, got %+v", saved)
+	}
+}
+
+func TestManagerResume(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewFileStore(t.TempDir())
+	now := time.Now()
+
+	// Jobs left behind by a crashed process
+	store.Save(&Job{ID: "queued", Status: Queued, Generate: &gollama.GenerateRequest{Model: "llama3"}, CreatedAt: now})
+	store.Save(&Job{ID: "running", Status: Running, Attempts: 1, Generate: &gollama.GenerateRequest{Model: "llama3"}, CreatedAt: now})
+	store.Save(&Job{ID: "poison", Status: Running, Attempts: 2, Generate: &gollama.GenerateRequest{Model: "llama3"}, CreatedAt: now})
+	store.Save(&Job{ID: "finished", Status: Succeeded, Output: "old", CreatedAt: now, FinishedAt: now})
+
+	client, _ := newFlakyClient(t, 0)
+	m, err := Open(client, store, &Options{MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer m.Close()
+
+	for id, want := range map[string]Status{"queued": Succeeded, "running": Succeeded, "poison": DeadLetter, "finished": Succeeded} {
+		job, err := m.Wait(ctx, id)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", id, err)
+		}
+		if job.Status != want {
+			t.Errorf("Expected %s to be %s, got %s (%s)", id, want, job.Status, job.Error)
+		}
+	}
+	if job, _ := m.Get("running"); job.Attempts != 2 || job.Output != "done" {
+		t.Errorf("Expected interrupted job to start over, got %+v", job)
+	}
+	if job, _ := m.Get("finished"); job.Output != "old" {
+		t.Errorf("Expected finished job output to be kept, got %q", job.Output)
+	}
+}
+
+func TestManagerCloseKeepsPendingJobs(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	client := newTestClient(t, make(chan struct{}))
+
+	m, _ := Open(client, store, nil)
+	running, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	queued, _ := m.Submit(&gollama.GenerateRequest{Model: "llama3", Prompt: "hi"})
+	waitFor(t, m, running, func(j *Job) bool { return j.Output == "partial" })
+	m.Close()
+
+	saved, _ := store.Load()
+	statuses := make(map[string]Status)
+	for _, job := range saved {
+		statuses[job.ID] = job.Status
+	}
+	if statuses[running] != Running || statuses[queued] != Queued {
+		t.Errorf("Expected pending jobs to stay in the store, got %v", statuses)
+	}
+
+	// Waiters are released with the state the jobs will resume in
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if job, err := m.Wait(ctx, queued); err != nil || job.Status != Queued {
+		t.Errorf("Expected Wait to return the queued job after Close, got %+v (error %v)", job, err)
+	}
+}
@@ -0,0 +1,84 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModelFilter selects models from a ListModelsResponse. Empty fields match
+// every model; all set fields must match for a model to be selected.
+type ModelFilter struct {
+	// Name is a glob pattern matched against the model name (see DeleteAll).
+	Name string
+	// Family matches ModelDetails.Family, ignoring case.
+	Family string
+	// ParameterSize matches ModelDetails.ParameterSize, ignoring case (e.g. "7B").
+	ParameterSize string
+	// QuantizationLevel matches ModelDetails.QuantizationLevel, ignoring case (e.g. "Q4_0").
+	QuantizationLevel string
+}
+
+// Match reports whether a model satisfies the filter.
+func (f ModelFilter) Match(model ModelResponse) bool {
+	if f.Name != "" && !matchModelName(f.Name, model.Name) {
+		return false
+	}
+	if f.Family != "" && !strings.EqualFold(f.Family, model.Details.Family) {
+		return false
+	}
+	if f.ParameterSize != "" && !strings.EqualFold(f.ParameterSize, model.Details.ParameterSize) {
+		return false
+	}
+	if f.QuantizationLevel != "" && !strings.EqualFold(f.QuantizationLevel, model.Details.QuantizationLevel) {
+		return false
+	}
+	return true
+}
+
+// Filter returns a new ListModelsResponse holding only the models that match
+// the filter, in their original order.
+func (r *ListModelsResponse) Filter(f ModelFilter) *ListModelsResponse {
+	filtered := &ListModelsResponse{Models: []ModelResponse{}}
+	for _, model := range r.Models {
+		if f.Match(model) {
+			filtered.Models = append(filtered.Models, model)
+		}
+	}
+	return filtered
+}
+
+// SortBySize sorts the models in place by size, largest first unless
+// ascending is set, and returns the receiver for chaining.
+func (r *ListModelsResponse) SortBySize(ascending bool) *ListModelsResponse {
+	sort.SliceStable(r.Models, func(i, j int) bool {
+		if ascending {
+			return r.Models[i].Size < r.Models[j].Size
+		}
+		return r.Models[i].Size > r.Models[j].Size
+	})
+	return r
+}
+
+// SortByModified sorts the models in place by modification time, most
+// recent first unless ascending is set, and returns the receiver for chaining.
+func (r *ListModelsResponse) SortByModified(ascending bool) *ListModelsResponse {
+	sort.SliceStable(r.Models, func(i, j int) bool {
+		if ascending {
+			return r.Models[i].ModifiedAt.Before(r.Models[j].ModifiedAt)
+		}
+		return r.Models[i].ModifiedAt.After(r.Models[j].ModifiedAt)
+	})
+	return r
+}
+
+// ListWithFilter retrieves the available models like List and returns only
+// those matching the filter.
+func (c *Client) ListWithFilter(ctx context.Context, f ModelFilter) (*ListModelsResponse, error) {
+	models, err := c.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	return models.Filter(f), nil
+}
@@ -0,0 +1,86 @@
+package gollama
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testModelList() *ListModelsResponse {
+	now := time.Now()
+	return &ListModelsResponse{
+		Models: []ModelResponse{
+			{Name: "llama2:7b", Size: 3800, ModifiedAt: now.Add(-2 * time.Hour), Details: ModelDetails{Family: "llama", ParameterSize: "7B", QuantizationLevel: "Q4_0"}},
+			{Name: "llama2:13b", Size: 7300, ModifiedAt: now.Add(-time.Hour), Details: ModelDetails{Family: "llama", ParameterSize: "13B", QuantizationLevel: "Q4_0"}},
+			{Name: "mistral:latest", Size: 4100, ModifiedAt: now, Details: ModelDetails{Family: "mistral", ParameterSize: "7B", QuantizationLevel: "Q8_0"}},
+		},
+	}
+}
+
+func TestListModelsFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   ModelFilter
+		expected []string
+	}{
+		{"Empty filter", ModelFilter{}, []string{"llama2:7b", "llama2:13b", "mistral:latest"}},
+		{"By family", ModelFilter{Family: "LLAMA"}, []string{"llama2:7b", "llama2:13b"}},
+		{"By parameter size", ModelFilter{ParameterSize: "7b"}, []string{"llama2:7b", "mistral:latest"}},
+		{"By quantization", ModelFilter{QuantizationLevel: "q8_0"}, []string{"mistral:latest"}},
+		{"By name glob", ModelFilter{Name: "llama2:*"}, []string{"llama2:7b", "llama2:13b"}},
+		{"Combined", ModelFilter{Family: "llama", ParameterSize: "13B"}, []string{"llama2:13b"}},
+		{"No match", ModelFilter{Family: "gemma"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testModelList().Filter(tt.filter)
+			if len(got.Models) != len(tt.expected) {
+				t.Fatalf("Expected %d models, got %d", len(tt.expected), len(got.Models))
+			}
+			for i, name := range tt.expected {
+				if got.Models[i].Name != name {
+					t.Errorf("Expected model %d to be %s, got %s", i, name, got.Models[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestListModelsSort(t *testing.T) {
+	list := testModelList()
+
+	list.SortBySize(false)
+	if list.Models[0].Name != "llama2:13b" || list.Models[2].Name != "llama2:7b" {
+		t.Errorf("Unexpected size order: %v", list.Models)
+	}
+
+	list.SortBySize(true)
+	if list.Models[0].Name != "llama2:7b" {
+		t.Errorf("Unexpected ascending size order: %v", list.Models)
+	}
+
+	list.SortByModified(false)
+	if list.Models[0].Name != "mistral:latest" || list.Models[2].Name != "llama2:7b" {
+		t.Errorf("Unexpected modified order: %v", list.Models)
+	}
+
+	if list.Filter(ModelFilter{Family: "llama"}).SortByModified(true).Models[0].Name != "llama2:7b" {
+		t.Errorf("Expected chained filter and sort to work")
+	}
+}
+
+func TestClientListWithFilter(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	models, err := client.ListWithFilter(context.Background(), ModelFilter{Name: "code*"})
+	assertNoError(t, err)
+
+	if len(models.Models) != 1 || models.Models[0].Name != "codellama" {
+		t.Errorf("Expected only codellama, got %v", models.Models)
+	}
+}
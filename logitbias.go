@@ -0,0 +1,109 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BanBias is the logit bias that effectively bans a token, following the
+// convention of the OpenAI API.
+const BanBias = -100
+
+// LogitBias maps token IDs to a value added to their logits before sampling.
+// Negative values make a token less likely and BanBias rules it out;
+// positive values make it more likely. Token IDs are specific to a model's
+// tokenizer; LogitBiasForWords looks them up with Tokenize.
+//
+// Not every server honors logit bias. Servers that don't ignore it.
+type LogitBias map[int]float64
+
+// WithLogitBias returns a copy of the options with bias set under
+// "logit_bias", as a list of [token, bias] pairs ordered by token ID.
+func (o Options) WithLogitBias(bias LogitBias) Options {
+	opts := make(Options, len(o)+1)
+	for k, v := range o {
+		opts[k] = v
+	}
+
+	tokens := make([]int, 0, len(bias))
+	for token := range bias {
+		tokens = append(tokens, token)
+	}
+	sort.Ints(tokens)
+
+	pairs := make([][2]float64, len(tokens))
+	for i, token := range tokens {
+		pairs[i] = [2]float64{float64(token), bias[token]}
+	}
+	opts["logit_bias"] = pairs
+	return opts
+}
+
+// TokenizeRequest is the request body for the `/api/tokenize` endpoint.
+type TokenizeRequest struct {
+	Model string `json:"model"`
+	Text  string `json:"text"`
+}
+
+// TokenizeResponse holds the token IDs returned by `/api/tokenize`.
+type TokenizeResponse struct {
+	Tokens []int `json:"tokens"`
+}
+
+// Tokenize converts text into token IDs using the model's tokenizer. It makes
+// a POST request to the `/api/tokenize` endpoint, which is not available on
+// every server version.
+func (c *Client) Tokenize(ctx context.Context, model, text string) ([]int, error) {
+	model = c.modelOrDefault(ctx, model)
+	if model == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+
+	var response TokenizeResponse
+	req := &TokenizeRequest{Model: model, Text: text}
+	if err := c.do(ctx, http.MethodPost, "/api/tokenize", req, &response); err != nil {
+		return nil, fmt.Errorf("failed to tokenize text: %w", err)
+	}
+	return response.Tokens, nil
+}
+
+// LogitBiasForWords builds a LogitBias that applies bias to the given words,
+// for use with Options.WithLogitBias. Pass BanBias to keep the model from
+// writing the words.
+//
+// Each word is tokenized as written, with a leading space and capitalized,
+// since most tokenizers encode these differently. Words that span several
+// tokens are biased through their first token, which also affects other
+// words starting with that token.
+func (c *Client) LogitBiasForWords(ctx context.Context, model string, bias float64, words ...string) (LogitBias, error) {
+	result := make(LogitBias)
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		for _, variant := range wordVariants(word) {
+			tokens, err := c.Tokenize(ctx, model, variant)
+			if err != nil {
+				return nil, err
+			}
+			if len(tokens) > 0 {
+				result[tokens[0]] = bias
+			}
+		}
+	}
+	return result, nil
+}
+
+// wordVariants returns the spellings of word that LogitBiasForWords biases.
+func wordVariants(word string) []string {
+	variants := []string{word, " " + word}
+	r, size := utf8.DecodeRuneInString(word)
+	if title := string(unicode.ToUpper(r)) + word[size:]; title != word {
+		variants = append(variants, title, " "+title)
+	}
+	return variants
+}
@@ -0,0 +1,77 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOptionsWithLogitBias(t *testing.T) {
+	base := Options{"temperature": 0.2}
+	opts := base.WithLogitBias(LogitBias{42: BanBias, 7: 2.5})
+
+	want := [][2]float64{{7, 2.5}, {42, -100}}
+	if !reflect.DeepEqual(opts["logit_bias"], want) {
+		t.Errorf("Expected %v, got %v", want, opts["logit_bias"])
+	}
+	if opts["temperature"] != 0.2 {
+		t.Errorf("Expected other options to be kept, got %v", opts)
+	}
+	if _, ok := base["logit_bias"]; ok {
+		t.Error("Expected the original options to be unchanged")
+	}
+}
+
+func TestClientLogitBiasForWords(t *testing.T) {
+	vocab := map[string][]int{
+		"foo":  {1},
+		" foo": {2},
+		"Foo":  {3},
+		" Foo": {3},
+		"bar":  {10, 11},
+		" bar": {12},
+		"Bar":  {13},
+		" Bar": {14},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tokenize" {
+			http.NotFound(w, r)
+			return
+		}
+		var req TokenizeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3" {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(TokenizeResponse{Tokens: vocab[req.Text]})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	tokens, err := client.Tokenize(ctx, "llama3", "bar")
+	assertNoError(t, err)
+	if !reflect.DeepEqual(tokens, []int{10, 11}) {
+		t.Errorf("Expected [10 11], got %v", tokens)
+	}
+
+	bias, err := client.LogitBiasForWords(ctx, "llama3", BanBias, "foo", "bar")
+	assertNoError(t, err)
+	want := LogitBias{1: BanBias, 2: BanBias, 3: BanBias, 10: BanBias, 12: BanBias, 13: BanBias, 14: BanBias}
+	if !reflect.DeepEqual(bias, want) {
+		t.Errorf("Expected %v, got %v", want, bias)
+	}
+
+	_, err = client.LogitBiasForWords(ctx, "missing", BanBias, "foo")
+	if err == nil {
+		t.Error("Expected error from failed tokenize")
+	}
+	_, err = client.Tokenize(ctx, "", "foo")
+	assertErrorContains(t, err, "model name cannot be empty")
+}
@@ -0,0 +1,150 @@
+package gollama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MarkdownBuffer accumulates streamed markdown and tracks its block
+// structure, so that incremental renderers only ever draw complete
+// constructs. Text becomes safe to render at the end of each complete line,
+// except inside a fenced code block, which only becomes safe once its
+// closing fence arrives. Partial lines are held back because their inline
+// markup, such as ** or `, may still be open.
+//
+// Renderers that append output use Next; renderers that redraw the whole
+// document use Snapshot. A MarkdownBuffer is not safe for concurrent use.
+//
+// Example:
+//
+//	md := gollama.NewMarkdownBuffer()
+//	err := client.ChatStream(ctx, req, func(resp *gollama.ChatResponse) {
+//		md.Write(resp.Message.Content)
+//		render(md.Next())
+//	})
+//	render(md.Flush())
+type MarkdownBuffer struct {
+	text strings.Builder
+	// parsed is the offset up to which complete lines have been parsed
+	parsed int
+	// safe is the offset of the last safe flush point
+	safe int
+	// emitted is the offset up to which Next has returned text
+	emitted int
+
+	fence     string
+	fenceOpen string
+	inList    bool
+}
+
+// NewMarkdownBuffer creates an empty MarkdownBuffer.
+func NewMarkdownBuffer() *MarkdownBuffer {
+	return &MarkdownBuffer{}
+}
+
+var (
+	markdownFencePattern    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	markdownListItemPattern = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])(\s|$)`)
+)
+
+// Write appends a chunk of streamed text.
+func (m *MarkdownBuffer) Write(chunk string) {
+	m.text.WriteString(chunk)
+
+	text := m.text.String()
+	for {
+		end := strings.IndexByte(text[m.parsed:], '\n')
+		if end < 0 {
+			return
+		}
+		line := text[m.parsed : m.parsed+end]
+		m.parsed += end + 1
+		m.parseLine(line)
+		if m.fence == "" {
+			m.safe = m.parsed
+		}
+	}
+}
+
+// parseLine updates the block state with a complete line.
+func (m *MarkdownBuffer) parseLine(line string) {
+	if m.fence != "" {
+		// A closing fence uses the same character, at least as many times,
+		// and nothing else
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, m.fence) && strings.Trim(trimmed, m.fence[:1]) == "" {
+			m.fence = ""
+			m.fenceOpen = ""
+		}
+		return
+	}
+
+	if match := markdownFencePattern.FindStringSubmatch(line); match != nil {
+		m.fence = match[1]
+		m.fenceOpen = line
+		return
+	}
+
+	switch {
+	case markdownListItemPattern.MatchString(line):
+		m.inList = true
+	case strings.TrimSpace(line) == "":
+		// Blank lines may separate items of a loose list
+	case line[0] == ' ' || line[0] == '\t':
+		// Indented lines continue the current list item
+	default:
+		m.inList = false
+	}
+}
+
+// Next returns the text that has become safe to render since the previous
+// call, or an empty string if there is none.
+func (m *MarkdownBuffer) Next() string {
+	if m.safe <= m.emitted {
+		return ""
+	}
+	text := m.text.String()[m.emitted:m.safe]
+	m.emitted = m.safe
+	return text
+}
+
+// Flush returns all text not yet returned by Next, whether or not it is
+// complete. It is meant for the end of the stream.
+func (m *MarkdownBuffer) Flush() string {
+	text := m.text.String()[m.emitted:]
+	m.emitted = m.text.Len()
+	return text
+}
+
+// Snapshot returns the whole text received so far in a form that renders
+// cleanly: an open code block is closed, so that its partial contents show
+// as code rather than spilling into the rest of the document.
+func (m *MarkdownBuffer) Snapshot() string {
+	text := m.text.String()
+	if m.fence == "" {
+		return text
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text + m.fence + "\n"
+}
+
+// InCodeBlock reports whether the text ends inside an open fenced code block.
+func (m *MarkdownBuffer) InCodeBlock() bool {
+	return m.fence != ""
+}
+
+// CodeBlockInfo returns the info string, usually the language, of the open
+// code block, or an empty string if there is none.
+func (m *MarkdownBuffer) CodeBlockInfo() string {
+	if m.fence == "" {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(m.fenceOpen), m.fence[:1]))
+}
+
+// InList reports whether the last complete line belongs to a list.
+func (m *MarkdownBuffer) InList() bool {
+	return m.inList
+}
@@ -0,0 +1,79 @@
+package gollama
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownBuffer(t *testing.T) {
+	chunks := []string{
+		"Here is **bo", "ld** text.\n\n", "```go\nfunc main() {\n", "\tfmt.Println(\"hi\")\n}\n`", "``\n",
+		"Steps:\n", "1. Build\n", "   the code\n", "2. Run it\n", "\nDone",
+	}
+
+	md := NewMarkdownBuffer()
+	var rendered []string
+
+	steps := []struct {
+		next   string
+		inCode bool
+		inList bool
+	}{
+		{"", false, false},
+		{"Here is **bold** text.\n\n", false, false},
+		{"", true, false},
+		{"", true, false},
+		{"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n", false, false},
+		{"Steps:\n", false, false},
+		{"1. Build\n", false, true},
+		{"   the code\n", false, true},
+		{"2. Run it\n", false, true},
+		{"\n", false, true},
+	}
+
+	for i, chunk := range chunks {
+		md.Write(chunk)
+		next := md.Next()
+		rendered = append(rendered, next)
+
+		if next != steps[i].next {
+			t.Errorf("Chunk %d: expected safe text %q, got %q", i, steps[i].next, next)
+		}
+		if md.InCodeBlock() != steps[i].inCode {
+			t.Errorf("Chunk %d: expected InCodeBlock=%v", i, steps[i].inCode)
+		}
+		if md.InList() != steps[i].inList {
+			t.Errorf("Chunk %d: expected InList=%v", i, steps[i].inList)
+		}
+	}
+
+	rendered = append(rendered, md.Flush())
+	if strings.Join(rendered, "") != strings.Join(chunks, "") {
+		t.Errorf("Expected rendered text to add up to the input, got %q", strings.Join(rendered, ""))
+	}
+}
+
+func TestMarkdownBufferSnapshot(t *testing.T) {
+	md := NewMarkdownBuffer()
+	md.Write("Intro\n~~~~python\nprint(1)")
+
+	if !md.InCodeBlock() || md.CodeBlockInfo() != "python" {
+		t.Errorf("Expected open python code block, got InCodeBlock=%v info=%q", md.InCodeBlock(), md.CodeBlockInfo())
+	}
+
+	expected := "Intro\n~~~~python\nprint(1)\n~~~~\n"
+	if snapshot := md.Snapshot(); snapshot != expected {
+		t.Errorf("Expected snapshot %q, got %q", expected, snapshot)
+	}
+
+	// A shorter fence does not close the block
+	md.Write("\n~~~\n")
+	if !md.InCodeBlock() {
+		t.Errorf("Expected code block to stay open after a shorter fence")
+	}
+
+	md.Write("~~~~\n")
+	if md.InCodeBlock() || md.Snapshot() != "Intro\n~~~~python\nprint(1)\n~~~\n~~~~\n" {
+		t.Errorf("Expected closed code block, got %q", md.Snapshot())
+	}
+}
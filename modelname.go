@@ -0,0 +1,150 @@
+package gollama
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidModelName is returned when a model name cannot be parsed.
+var ErrInvalidModelName = errors.New("invalid model name")
+
+// defaultTag is the tag implied by model names that do not carry one.
+const defaultTag = "latest"
+
+// ModelName is a model reference of the form [registry/][namespace/]name[:tag],
+// for example "llama2", "llama2:13b", "user/model:v1" or
+// "registry.local:5000/team/model:q4_0".
+//
+// Client methods take model names as plain strings, so a ModelName is
+// passed with a conversion: client.Show(ctx, string(name)). Methods that
+// store a model under a name, such as Copy, Create and Push, validate it as
+// a ModelName first. Use ParseModelName to validate untrusted input.
+type ModelName string
+
+// ParseModelName parses and validates a model name. It returns an error
+// wrapping ErrInvalidModelName if any component is empty or contains
+// characters the Ollama registry does not allow.
+func ParseModelName(s string) (ModelName, error) {
+	name := ModelName(s)
+	if err := name.Validate(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Validate checks that the name is well-formed.
+func (n ModelName) Validate() error {
+	if n == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidModelName)
+	}
+
+	registry, namespace, model, tag := n.split()
+	if registry != "" && !validNamePart(registry, ".-:") {
+		return fmt.Errorf("%w: %q has an invalid registry %q", ErrInvalidModelName, string(n), registry)
+	}
+	if strings.Count(n.BaseName(), "/") > 2 || (registry == "" && strings.Count(n.BaseName(), "/") > 1) {
+		return fmt.Errorf("%w: %q has too many path components", ErrInvalidModelName, string(n))
+	}
+	if namespace != "" && !validNamePart(namespace, "_.-") {
+		return fmt.Errorf("%w: %q has an invalid namespace %q", ErrInvalidModelName, string(n), namespace)
+	}
+	if !validNamePart(model, "_.-") {
+		return fmt.Errorf("%w: %q has an invalid model %q", ErrInvalidModelName, string(n), model)
+	}
+	if strings.HasSuffix(string(n), ":") || !validNamePart(tag, "_.-") || len(tag) > 128 {
+		return fmt.Errorf("%w: %q has an invalid tag %q", ErrInvalidModelName, string(n), tag)
+	}
+	return nil
+}
+
+// String returns the name as written.
+func (n ModelName) String() string {
+	return string(n)
+}
+
+// Registry returns the registry host, or an empty string if the name uses
+// the default registry.
+func (n ModelName) Registry() string {
+	registry, _, _, _ := n.split()
+	return registry
+}
+
+// Namespace returns the namespace, or "library" if the name has none.
+func (n ModelName) Namespace() string {
+	_, namespace, _, _ := n.split()
+	if namespace == "" {
+		return "library"
+	}
+	return namespace
+}
+
+// Model returns the bare model name without registry, namespace or tag.
+func (n ModelName) Model() string {
+	_, _, model, _ := n.split()
+	return model
+}
+
+// Tag returns the tag, or "latest" if the name has none.
+func (n ModelName) Tag() string {
+	_, _, _, tag := n.split()
+	return tag
+}
+
+// BaseName returns the name without its tag, keeping registry and namespace.
+func (n ModelName) BaseName() string {
+	s := string(n)
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		return s[:i]
+	}
+	return s
+}
+
+// WithTag returns a copy of the name with its tag replaced.
+func (n ModelName) WithTag(tag string) ModelName {
+	return ModelName(n.BaseName() + ":" + tag)
+}
+
+// Normalize returns the name with the implicit ":latest" tag made explicit,
+// so that "llama2" and "llama2:latest" compare equal.
+func (n ModelName) Normalize() ModelName {
+	return n.WithTag(n.Tag())
+}
+
+// split breaks the name into its components, applying the default tag.
+func (n ModelName) split() (registry, namespace, model, tag string) {
+	s := string(n)
+	tag = defaultTag
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		s, tag = s[:i], s[i+1:]
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, parts = parts[0], parts[1:]
+	}
+	if len(parts) > 1 {
+		namespace = strings.Join(parts[:len(parts)-1], "/")
+	}
+	model = parts[len(parts)-1]
+	return registry, namespace, model, tag
+}
+
+// validNamePart reports whether s is non-empty, starts with a letter or
+// digit and otherwise contains only letters, digits and the given extra
+// characters.
+func validNamePart(s, extra string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		alnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if alnum {
+			continue
+		}
+		if i == 0 || !strings.ContainsRune(extra, r) {
+			return false
+		}
+	}
+	return true
+}
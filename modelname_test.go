@@ -0,0 +1,112 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseModelName(t *testing.T) {
+	tests := []struct {
+		input     string
+		registry  string
+		namespace string
+		model     string
+		tag       string
+		baseName  string
+	}{
+		{"llama2", "", "library", "llama2", "latest", "llama2"},
+		{"llama2:13b", "", "library", "llama2", "13b", "llama2"},
+		{"user/model:v1", "", "user", "model", "v1", "user/model"},
+		{"registry.local:5000/team/model:q4_0", "registry.local:5000", "team", "model", "q4_0", "registry.local:5000/team/model"},
+		{"localhost/model", "localhost", "library", "model", "latest", "localhost/model"},
+		{"llama3.1:8b-instruct-q4_K_M", "", "library", "llama3.1", "8b-instruct-q4_K_M", "llama3.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			name, err := ParseModelName(tt.input)
+			assertNoError(t, err)
+
+			if name.Registry() != tt.registry {
+				t.Errorf("Expected registry %q, got %q", tt.registry, name.Registry())
+			}
+			if name.Namespace() != tt.namespace {
+				t.Errorf("Expected namespace %q, got %q", tt.namespace, name.Namespace())
+			}
+			if name.Model() != tt.model {
+				t.Errorf("Expected model %q, got %q", tt.model, name.Model())
+			}
+			if name.Tag() != tt.tag {
+				t.Errorf("Expected tag %q, got %q", tt.tag, name.Tag())
+			}
+			if name.BaseName() != tt.baseName {
+				t.Errorf("Expected base name %q, got %q", tt.baseName, name.BaseName())
+			}
+			if name.String() != tt.input {
+				t.Errorf("Expected String() to round-trip %q, got %q", tt.input, name.String())
+			}
+		})
+	}
+}
+
+func TestParseModelNameInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"llama 2",
+		"llama2:",
+		":latest",
+		"user//model",
+		"a/b/c",
+		"-model",
+		"_model",
+		"user/_model",
+		"model:tag!",
+		"registry.local/a/b/c",
+	}
+
+	for _, input := range invalid {
+		if _, err := ParseModelName(input); !errors.Is(err, ErrInvalidModelName) {
+			t.Errorf("Expected ErrInvalidModelName for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestModelNameHelpers(t *testing.T) {
+	name := ModelName("user/model:v1")
+
+	if got := name.WithTag("v2"); got != "user/model:v2" {
+		t.Errorf("Expected WithTag to replace tag, got %q", got)
+	}
+	if got := ModelName("llama2").Normalize(); got != "llama2:latest" {
+		t.Errorf("Expected Normalize to add latest tag, got %q", got)
+	}
+	if got := ModelName("registry.local:5000/model").WithTag("q4"); got != "registry.local:5000/model:q4" {
+		t.Errorf("Expected registry port to be preserved, got %q", got)
+	}
+}
+
+func TestClientRejectsInvalidModelNames(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	err = client.Copy(ctx, "llama2", "llama2 backup")
+	if !errors.Is(err, ErrInvalidModelName) {
+		t.Errorf("Expected ErrInvalidModelName from Copy, got %v", err)
+	}
+
+	err = client.Create(ctx, "_model", "FROM llama2", func(CreateProgress) {})
+	if !errors.Is(err, ErrInvalidModelName) {
+		t.Errorf("Expected ErrInvalidModelName from Create, got %v", err)
+	}
+
+	err = client.Push(ctx, "user/model:", func(PushProgress) {})
+	if !errors.Is(err, ErrInvalidModelName) {
+		t.Errorf("Expected ErrInvalidModelName from Push, got %v", err)
+	}
+}
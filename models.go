@@ -0,0 +1,291 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// EnsureOptions holds optional settings for EnsureModelWithOptions.
+type EnsureOptions struct {
+	// Force issues a pull even if the model is already present locally.
+	// The server only downloads layers that changed, so this is a cheap way
+	// to pick up a newer version published under the same tag.
+	Force bool
+	// Pull is passed through to PullWithOptions when a pull is needed.
+	Pull *PullOptions
+}
+
+// EnsureModel makes sure a model is available on the server, pulling it only
+// if it is missing. It is intended for application startup sequences.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - modelName: The name of the model that must be available
+//   - fn: Callback function that receives pull progress updates (can be nil)
+//
+// Returns true if a pull was performed, or an error if the model could not be
+// checked or pulled.
+func (c *Client) EnsureModel(ctx context.Context, modelName string, fn func(PullProgress)) (bool, error) {
+	return c.EnsureModelWithOptions(ctx, modelName, nil, fn)
+}
+
+// EnsureModelWithOptions behaves like EnsureModel, applying the given
+// EnsureOptions. When opts.Force is set the model is pulled even if present,
+// and the returned bool reports whether the local digest changed as a result.
+func (c *Client) EnsureModelWithOptions(ctx context.Context, modelName string, opts *EnsureOptions, fn func(PullProgress)) (bool, error) {
+	if modelName == "" {
+		return false, fmt.Errorf("model name cannot be empty")
+	}
+	if opts == nil {
+		opts = &EnsureOptions{}
+	}
+	if fn == nil {
+		fn = func(PullProgress) {}
+	}
+
+	before, err := c.findModel(ctx, modelName)
+	if err != nil {
+		return false, fmt.Errorf("failed to ensure model %q: %w", modelName, err)
+	}
+	if before != nil && !opts.Force {
+		return false, nil
+	}
+
+	if err := c.PullWithOptions(ctx, modelName, opts.Pull, fn); err != nil {
+		return false, fmt.Errorf("failed to ensure model %q: %w", modelName, err)
+	}
+	if before == nil {
+		return true, nil
+	}
+
+	after, err := c.findModel(ctx, modelName)
+	if err != nil {
+		return false, fmt.Errorf("failed to ensure model %q: %w", modelName, err)
+	}
+	return after == nil || after.Digest != before.Digest, nil
+}
+
+// findModel looks up a locally available model by name using List.
+// It returns nil without an error if the model is not present.
+func (c *Client) findModel(ctx context.Context, modelName string) (*ModelResponse, error) {
+	models, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listedModel(models, modelName), nil
+}
+
+// listedModel returns the model of a List response with the given name, or
+// nil if there is none.
+func listedModel(models *ListModelsResponse, modelName string) *ModelResponse {
+	want := ModelName(modelName).Normalize()
+	for i := range models.Models {
+		if ModelName(models.Models[i].Name).Normalize() == want {
+			return &models.Models[i]
+		}
+	}
+	return nil
+}
+
+// verifyPulledModel checks a completed pull. If expectedDigest is set, the
+// model's digest as reported by List must match it. Otherwise each layer
+// digest seen during the pull must exist as a blob on the server.
+func (c *Client) verifyPulledModel(ctx context.Context, modelName, expectedDigest string, layers []string) error {
+	if expectedDigest != "" {
+		model, err := c.findModel(ctx, modelName)
+		if err != nil {
+			return fmt.Errorf("failed to verify model %q: %w", modelName, err)
+		}
+		if model == nil {
+			return fmt.Errorf("%w: model %q not found after pull", ErrDigestMismatch, modelName)
+		}
+		if trimDigest(model.Digest) != trimDigest(expectedDigest) {
+			return fmt.Errorf("%w: model %q has digest %s, expected %s", ErrDigestMismatch, modelName, model.Digest, expectedDigest)
+		}
+		return nil
+	}
+
+	for _, digest := range layers {
+		err := c.do(ctx, http.MethodHead, "/api/blobs/"+digest, nil, nil)
+		if err == nil {
+			continue
+		}
+		var ollamaErr *OllamaError
+		if errors.As(err, &ollamaErr) && ollamaErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: layer %s of model %q is missing", ErrDigestMismatch, digest, modelName)
+		}
+		return fmt.Errorf("failed to verify model %q: %w", modelName, err)
+	}
+	return nil
+}
+
+// trimDigest strips the algorithm prefix from a digest for comparison.
+func trimDigest(digest string) string {
+	return strings.TrimPrefix(digest, "sha256:")
+}
+
+// appendUnique appends s to list unless it is already present.
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// checkDiskBudget refuses a pull with ErrInsufficientSpace if the model,
+// as sized by its registry manifest, would push the combined size of the
+// listed models over opts.DiskBudget. Models that are already present are
+// not checked, since pulling them only fetches changed layers.
+func (c *Client) checkDiskBudget(ctx context.Context, modelName string, opts *PullOptions) error {
+	models, err := c.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check disk space for model %q: %w", modelName, err)
+	}
+
+	var used int64
+	want := ModelName(modelName).Normalize()
+	for _, model := range models.Models {
+		if ModelName(model.Name).Normalize() == want {
+			return nil
+		}
+		used += model.Size
+	}
+
+	manifest, err := c.Registry().fetchManifest(ctx, modelName, opts.Insecure)
+	if err != nil {
+		return fmt.Errorf("failed to check disk space for model %q: %w", modelName, err)
+	}
+
+	if size := manifest.totalSize(); used+size > opts.DiskBudget {
+		return fmt.Errorf("%w: model %q needs %d bytes, %d of %d bytes already in use",
+			ErrInsufficientSpace, modelName, size, used, opts.DiskBudget)
+	}
+	return nil
+}
+
+// DeleteReport summarizes the outcome of a batch delete.
+type DeleteReport struct {
+	// Deleted lists the names of the models that were removed, or that
+	// would have been in a dry run.
+	Deleted []string
+	// FreedBytes is the combined size of the removed models as reported by
+	// List. Layers shared with remaining models are not actually freed.
+	FreedBytes int64
+	// Errors maps model names to the error that prevented their removal.
+	Errors map[string]error
+	// DryRun is set if no model was actually removed.
+	DryRun bool
+}
+
+// DeleteAll removes every model matching one of the given names. Names may be
+// glob patterns as understood by path.Match (e.g. "llama2:*" or "*-backup"),
+// and a name without a tag also matches its ":latest" variant.
+//
+// Failed deletions do not stop the batch; they are recorded in the report and
+// reflected in the returned error.
+func (c *Client) DeleteAll(ctx context.Context, names []string) (*DeleteReport, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one model name is required")
+	}
+
+	return c.deleteWhere(ctx, false, func(model ModelResponse) bool {
+		return matchAnyModelName(names, model.Name)
+	})
+}
+
+// DeleteMatching removes every model whose name matches glob, following the
+// same rules as DeleteAll: "llama2:*-backup" matches every backup tag of
+// llama2. With dryRun set, nothing is removed and the report lists the
+// models that would be, so that a pattern can be checked before use.
+func (c *Client) DeleteMatching(ctx context.Context, glob string, dryRun bool) (*DeleteReport, error) {
+	if glob == "" {
+		return nil, fmt.Errorf("pattern cannot be empty")
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", glob, err)
+	}
+
+	return c.deleteWhere(ctx, dryRun, func(model ModelResponse) bool {
+		return matchModelName(glob, model.Name)
+	})
+}
+
+// Prune removes every model that does not match one of the keep patterns and
+// was last modified more than olderThan ago. An olderThan of zero prunes
+// regardless of age. Patterns follow the same rules as DeleteAll. Since
+// pruning with neither would remove every model, at least one keep pattern
+// or a positive olderThan is required; use DeleteMatching with "*" to clear
+// the server.
+func (c *Client) Prune(ctx context.Context, keep []string, olderThan time.Duration) (*DeleteReport, error) {
+	if len(keep) == 0 && olderThan <= 0 {
+		return nil, fmt.Errorf("at least one keep pattern or a positive age is required")
+	}
+	cutoff := time.Now().Add(-olderThan)
+
+	return c.deleteWhere(ctx, false, func(model ModelResponse) bool {
+		if matchAnyModelName(keep, model.Name) {
+			return false
+		}
+		return olderThan <= 0 || model.ModifiedAt.Before(cutoff)
+	})
+}
+
+// deleteWhere lists the models on the server and deletes those for which
+// match returns true, or only reports them if dryRun is set.
+func (c *Client) deleteWhere(ctx context.Context, dryRun bool, match func(ModelResponse) bool) (*DeleteReport, error) {
+	models, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeleteReport{Errors: make(map[string]error), DryRun: dryRun}
+	for _, model := range models.Models {
+		if !match(model) {
+			continue
+		}
+		if dryRun {
+			report.Deleted = append(report.Deleted, model.Name)
+			report.FreedBytes += model.Size
+			continue
+		}
+		if err := c.Delete(ctx, model.Name); err != nil {
+			report.Errors[model.Name] = err
+			continue
+		}
+		report.Deleted = append(report.Deleted, model.Name)
+		report.FreedBytes += model.Size
+	}
+
+	if len(report.Errors) > 0 {
+		return report, fmt.Errorf("failed to delete %d of %d models", len(report.Errors), len(report.Errors)+len(report.Deleted))
+	}
+	return report, nil
+}
+
+// matchAnyModelName reports whether name matches any of the glob patterns.
+func matchAnyModelName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchModelName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelName reports whether a model name matches a glob pattern,
+// treating untagged names and patterns as carrying the ":latest" tag.
+func matchModelName(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	ok, _ := path.Match(string(ModelName(pattern).Normalize()), string(ModelName(name).Normalize()))
+	return ok
+}
@@ -0,0 +1,328 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientEnsureModel(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		model        string
+		opts         *EnsureOptions
+		expectPulled bool
+		expectEvents bool
+	}{
+		{
+			name:         "Model already present",
+			model:        "llama2",
+			expectPulled: false,
+		},
+		{
+			name:         "Model present with explicit latest tag",
+			model:        "llama2:latest",
+			expectPulled: false,
+		},
+		{
+			name:         "Missing model is pulled",
+			model:        "mistral",
+			expectPulled: true,
+			expectEvents: true,
+		},
+		{
+			name:         "Forced pull with unchanged digest",
+			model:        "llama2",
+			opts:         &EnsureOptions{Force: true},
+			expectPulled: false,
+			expectEvents: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events int32
+			pulled, err := client.EnsureModelWithOptions(ctx, tt.model, tt.opts, func(PullProgress) {
+				atomic.AddInt32(&events, 1)
+			})
+			assertNoError(t, err)
+
+			if pulled != tt.expectPulled {
+				t.Errorf("Expected pulled=%v, got %v", tt.expectPulled, pulled)
+			}
+			if (events > 0) != tt.expectEvents {
+				t.Errorf("Expected progress events=%v, got %d events", tt.expectEvents, events)
+			}
+		})
+	}
+
+	_, err = client.EnsureModel(ctx, "", nil)
+	assertErrorContains(t, err, "model name cannot be empty")
+}
+
+func TestClientPullVerifyDigest(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	noop := func(PullProgress) {}
+
+	err = client.PullWithOptions(ctx, "llama2", &PullOptions{ExpectedDigest: "sha256:1a838c4c"}, noop)
+	assertNoError(t, err)
+
+	err = client.PullWithOptions(ctx, "llama2", &PullOptions{ExpectedDigest: "1a838c4c"}, noop)
+	assertNoError(t, err)
+
+	err = client.PullWithOptions(ctx, "llama2", &PullOptions{ExpectedDigest: "sha256:deadbeef"}, noop)
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+
+	err = client.PullWithOptions(ctx, "llama2", &PullOptions{VerifyDigest: true}, noop)
+	assertNoError(t, err)
+}
+
+func TestClientPullVerifyMissingLayer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pull":
+			w.Write([]byte(`{"status":"pulling","digest":"sha256:aaaa","total":10,"completed":10}` + "\n"))
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	err = client.PullWithOptions(context.Background(), "llama2", &PullOptions{VerifyDigest: true}, func(PullProgress) {})
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestClientPullDiskBudget(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/library/mistral/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"config":{"size":1000},"layers":[{"digest":"sha256:aa","size":4000000000}]}`))
+	}))
+	defer registry.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	client.registryURL = registry.URL
+
+	ctx := context.Background()
+	noop := func(PullProgress) {}
+
+	// The mock server lists two models of 3825819519 bytes each
+	err = client.PullWithOptions(ctx, "mistral", &PullOptions{DiskBudget: 10000000000}, noop)
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+	}
+
+	err = client.PullWithOptions(ctx, "mistral", &PullOptions{DiskBudget: 20000000000}, noop)
+	assertNoError(t, err)
+
+	// Already present models are never refused
+	err = client.PullWithOptions(ctx, "llama2", &PullOptions{DiskBudget: 1}, noop)
+	assertNoError(t, err)
+
+	// EnsureModel passes the budget through to the pull
+	_, err = client.EnsureModelWithOptions(ctx, "mistral", &EnsureOptions{Pull: &PullOptions{DiskBudget: 1}}, noop)
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace from EnsureModel, got %v", err)
+	}
+}
+
+func TestClientDeleteAllAndPrune(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	report, err := client.DeleteAll(ctx, []string{"llama2:latest"})
+	assertNoError(t, err)
+	if len(report.Deleted) != 1 || report.Deleted[0] != "llama2" {
+		t.Errorf("Expected llama2 to be deleted, got %v", report.Deleted)
+	}
+	if report.FreedBytes != 3825819519 {
+		t.Errorf("Expected 3825819519 freed bytes, got %d", report.FreedBytes)
+	}
+
+	report, err = client.DeleteAll(ctx, []string{"*llama*"})
+	assertNoError(t, err)
+	if len(report.Deleted) != 2 {
+		t.Errorf("Expected both models to match glob, got %v", report.Deleted)
+	}
+
+	report, err = client.Prune(ctx, []string{"code*"}, 0)
+	assertNoError(t, err)
+	if len(report.Deleted) != 1 || report.Deleted[0] != "llama2" {
+		t.Errorf("Expected only llama2 to be pruned, got %v", report.Deleted)
+	}
+
+	// Mock models were modified just now, so nothing is old enough to prune
+	report, err = client.Prune(ctx, nil, time.Hour)
+	assertNoError(t, err)
+	if len(report.Deleted) != 0 {
+		t.Errorf("Expected no models to be pruned, got %v", report.Deleted)
+	}
+
+	// Pruning without a keep pattern or age would delete every model
+	_, err = client.Prune(ctx, nil, 0)
+	assertErrorContains(t, err, "at least one keep pattern or a positive age is required")
+
+	_, err = client.DeleteAll(ctx, nil)
+	assertErrorContains(t, err, "at least one model name is required")
+}
+
+func TestMatchModelName(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"llama2", "llama2:latest", true},
+		{"llama2:latest", "llama2", true},
+		{"llama2:*", "llama2:13b", true},
+		{"llama2:*-backup", "llama2:7b-backup", true},
+		{"llama2:*-backup", "llama2:7b", false},
+		{"mistral", "llama2", false},
+		{"user/*", "user/model:v1", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchModelName(tt.pattern, tt.name); got != tt.match {
+			t.Errorf("matchModelName(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.match)
+		}
+	}
+}
+
+func TestClientCopyWithOptions(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		opts        *CopyOptions
+		expectErr   error
+	}{
+		{
+			name:        "New destination",
+			source:      "llama2",
+			destination: "llama2-backup",
+		},
+		{
+			name:        "Existing destination",
+			source:      "llama2",
+			destination: "codellama:latest",
+			expectErr:   ErrModelExists,
+		},
+		{
+			name:        "Existing destination with overwrite",
+			source:      "llama2",
+			destination: "codellama",
+			opts:        &CopyOptions{Overwrite: true},
+		},
+		{
+			name:        "Missing source",
+			source:      "mistral",
+			destination: "mistral-backup",
+			expectErr:   ErrModelNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.CopyWithOptions(ctx, tt.source, tt.destination, tt.opts)
+			if tt.expectErr == nil {
+				assertNoError(t, err)
+			} else if !errors.Is(err, tt.expectErr) {
+				t.Errorf("Expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestClientDeleteMatching(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[
+				{"name":"llama2:latest","size":100},
+				{"name":"llama2:7b-backup","size":200},
+				{"name":"llama2:13b-backup","size":300},
+				{"name":"mistral:7b-backup","size":400}
+			]}`))
+		case "/api/delete":
+			var req DeleteRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			deleted = append(deleted, req.Model)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	report, err := client.DeleteMatching(ctx, "llama2:*-backup", true)
+	assertNoError(t, err)
+	if !report.DryRun || len(report.Deleted) != 2 || report.FreedBytes != 500 {
+		t.Errorf("Expected a dry run reporting 2 models and 500 bytes, got %+v", report)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("Expected no deletions in a dry run, got %v", deleted)
+	}
+
+	report, err = client.DeleteMatching(ctx, "llama2:*-backup", false)
+	assertNoError(t, err)
+	if report.DryRun || len(deleted) != 2 || deleted[0] != "llama2:7b-backup" || deleted[1] != "llama2:13b-backup" {
+		t.Errorf("Expected both llama2 backups to be deleted, got %v", deleted)
+	}
+
+	_, err = client.DeleteMatching(ctx, "llama2:[", true)
+	assertErrorContains(t, err, "invalid pattern")
+
+	_, err = client.DeleteMatching(ctx, "", true)
+	assertErrorContains(t, err, "pattern cannot be empty")
+}
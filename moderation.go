@@ -0,0 +1,194 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrContentBlocked is returned when a Moderator rejects generated text.
+var ErrContentBlocked = errors.New("content blocked by moderation")
+
+// Moderator inspects generated text before it is returned to the caller. It
+// returns the text to use in its place, which may be redacted or rewritten,
+// or an error wrapping ErrContentBlocked to reject it altogether.
+//
+// Moderators are installed with WithModerator and run on the final text of
+// Generate and Chat, and on the text of each GenerateStream and ChatStream
+// chunk. Streamed text is held back to the end of the last complete word, so
+// a single word is never split between two calls, but a phrase may be.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (string, error)
+}
+
+// ModeratorFunc adapts an ordinary function to the Moderator interface.
+type ModeratorFunc func(ctx context.Context, text string) (string, error)
+
+// Moderate calls f(ctx, text).
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+// ModerationPipeline runs moderators in order, passing the output of each to
+// the next. It stops at the first error.
+type ModerationPipeline []Moderator
+
+// Moderate runs text through every moderator of the pipeline.
+func (p ModerationPipeline) Moderate(ctx context.Context, text string) (string, error) {
+	for _, m := range p {
+		var err error
+		if text, err = m.Moderate(ctx, text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// WithModerator adds a moderator to the client's moderation pipeline. It
+// may be given several times; moderators run in the order they were added.
+func WithModerator(m Moderator) ClientOption {
+	return func(c *Client) {
+		c.moderators = append(c.moderators, m)
+	}
+}
+
+// DefaultRedaction is the text Blocklist.Redact puts in place of a match.
+const DefaultRedaction = "[redacted]"
+
+// Blocklist is a Moderator driven by regular expression rules, which are
+// applied in the order they were added. Build one with NewBlocklist:
+//
+//	blocklist := gollama.NewBlocklist().
+//		BlockWords("project-nightingale").
+//		Redact(regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)).
+//		Replace(regexp.MustCompile(`(?i)\bdarn\b`), "d**n")
+type Blocklist struct {
+	rules []blocklistRule
+}
+
+// blocklistRule is a single Blocklist rule. A nil replacement blocks the
+// text instead of rewriting it.
+type blocklistRule struct {
+	pattern     *regexp.Regexp
+	replacement *string
+}
+
+// NewBlocklist creates an empty blocklist.
+func NewBlocklist() *Blocklist {
+	return &Blocklist{}
+}
+
+// Block rejects any text matching pattern with ErrContentBlocked.
+func (b *Blocklist) Block(pattern *regexp.Regexp) *Blocklist {
+	b.rules = append(b.rules, blocklistRule{pattern: pattern})
+	return b
+}
+
+// BlockWords rejects any text containing one of the given words, matched
+// case-insensitively as whole words.
+func (b *Blocklist) BlockWords(words ...string) *Blocklist {
+	if len(words) == 0 {
+		return b
+	}
+	return b.Block(wordsPattern(words))
+}
+
+// Redact replaces every match of pattern with DefaultRedaction.
+func (b *Blocklist) Redact(pattern *regexp.Regexp) *Blocklist {
+	return b.Replace(pattern, DefaultRedaction)
+}
+
+// RedactWords replaces the given words, matched case-insensitively as whole
+// words, with DefaultRedaction.
+func (b *Blocklist) RedactWords(words ...string) *Blocklist {
+	if len(words) == 0 {
+		return b
+	}
+	return b.Redact(wordsPattern(words))
+}
+
+// Replace replaces every match of pattern with replacement, which may refer
+// to submatches as described in regexp.Regexp.Expand.
+func (b *Blocklist) Replace(pattern *regexp.Regexp, replacement string) *Blocklist {
+	b.rules = append(b.rules, blocklistRule{pattern: pattern, replacement: &replacement})
+	return b
+}
+
+// Moderate applies the blocklist rules to text.
+func (b *Blocklist) Moderate(ctx context.Context, text string) (string, error) {
+	for _, rule := range b.rules {
+		if rule.replacement == nil {
+			if match := rule.pattern.FindString(text); match != "" {
+				return "", fmt.Errorf("%w: matched %q", ErrContentBlocked, match)
+			}
+			continue
+		}
+		text = rule.pattern.ReplaceAllString(text, *rule.replacement)
+	}
+	return text, nil
+}
+
+// wordsPattern builds a case-insensitive regular expression matching any of
+// the given words as whole words.
+func wordsPattern(words []string) *regexp.Regexp {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// moderateText runs text through the client's moderation pipeline, if any.
+func (c *Client) moderateText(ctx context.Context, text string) (string, error) {
+	if len(c.moderators) == 0 {
+		return text, nil
+	}
+	return ModerationPipeline(c.moderators).Moderate(ctx, text)
+}
+
+// moderationStream moderates streamed text, holding back the trailing
+// partial word of each chunk until it is complete.
+type moderationStream struct {
+	client  *Client
+	pending string
+}
+
+// newModerationStream returns a moderationStream for the client, or nil if
+// the client has no moderators.
+func (c *Client) newModerationStream() *moderationStream {
+	if len(c.moderators) == 0 {
+		return nil
+	}
+	return &moderationStream{client: c}
+}
+
+// push adds a chunk of text to the stream and returns the moderated text
+// that is ready to be passed on. When done is true, all held back text is
+// released.
+func (s *moderationStream) push(ctx context.Context, text string, done bool) (string, error) {
+	if s == nil {
+		return text, nil
+	}
+
+	s.pending += text
+	cut := len(s.pending)
+	if !done {
+		cut = strings.LastIndexFunc(s.pending, unicode.IsSpace)
+		if cut < 0 {
+			return "", nil
+		}
+		_, size := utf8.DecodeRuneInString(s.pending[cut:])
+		cut += size
+	}
+
+	ready := s.pending[:cut]
+	s.pending = s.pending[cut:]
+	if ready == "" {
+		return "", nil
+	}
+	return s.client.moderateText(ctx, ready)
+}
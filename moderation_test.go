@@ -0,0 +1,124 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	blocklist := NewBlocklist().
+		BlockWords("nightingale").
+		Redact(regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)).
+		Replace(regexp.MustCompile(`(?i)\bdarn\b`), "d**n")
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		blocked  bool
+	}{
+		{"Clean text", "The sky is blue.", "The sky is blue.", false},
+		{"Redacted number", "My SSN is 123-45-6789.", "My SSN is [redacted].", false},
+		{"Replaced word", "Darn it.", "d**n it.", false},
+		{"Blocked word", "Project NIGHTINGALE launches soon.", "", true},
+		{"Partial word is not blocked", "nightingales sing", "nightingales sing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := blocklist.Moderate(ctx, tt.input)
+			if tt.blocked {
+				if !errors.Is(err, ErrContentBlocked) {
+					t.Errorf("Expected ErrContentBlocked, got %v", err)
+				}
+				return
+			}
+			assertNoError(t, err)
+			if output != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestModerationPipeline(t *testing.T) {
+	upper := ModeratorFunc(func(ctx context.Context, text string) (string, error) {
+		return strings.ToUpper(text), nil
+	})
+	pipeline := ModerationPipeline{NewBlocklist().RedactWords("secret"), upper}
+
+	output, err := pipeline.Moderate(context.Background(), "a secret plan")
+	assertNoError(t, err)
+	if output != "A [REDACTED] PLAN" {
+		t.Errorf("Expected moderators to run in order, got %q", output)
+	}
+}
+
+func TestClientModeration(t *testing.T) {
+	chunks := []string{"The pass", "word is sec", "ret and the", " code is 4", "2."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		encoder := json.NewEncoder(w)
+		if !req.Stream {
+			encoder.Encode(GenerateResponse{Model: req.Model, Response: strings.Join(chunks, ""), Done: true})
+			return
+		}
+		for _, chunk := range chunks {
+			encoder.Encode(GenerateResponse{Model: req.Model, Response: chunk})
+		}
+		encoder.Encode(GenerateResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	request := &GenerateRequest{Model: "llama2", Prompt: "Tell me"}
+	expected := "The password is [redacted] and the code is 42."
+
+	client, err := NewClientWithOptions(server.URL, WithModerator(NewBlocklist().RedactWords("secret")))
+	assertNoError(t, err)
+
+	t.Run("Final response", func(t *testing.T) {
+		resp, err := client.Generate(ctx, request)
+		assertNoError(t, err)
+		if resp.Response != expected {
+			t.Errorf("Expected %q, got %q", expected, resp.Response)
+		}
+	})
+
+	t.Run("Streamed chunks", func(t *testing.T) {
+		var text strings.Builder
+		err := client.GenerateStream(ctx, request, func(resp *GenerateResponse) {
+			text.WriteString(resp.Response)
+		})
+		assertNoError(t, err)
+		if text.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, text.String())
+		}
+	})
+
+	t.Run("Blocked stream", func(t *testing.T) {
+		client, err := NewClientWithOptions(server.URL, WithModerator(NewBlocklist().BlockWords("code")))
+		assertNoError(t, err)
+
+		var text strings.Builder
+		err = client.GenerateStream(ctx, request, func(resp *GenerateResponse) {
+			text.WriteString(resp.Response)
+		})
+		if !errors.Is(err, ErrContentBlocked) {
+			t.Errorf("Expected ErrContentBlocked, got %v", err)
+		}
+		if strings.Contains(text.String(), "code") {
+			t.Errorf("Expected blocked text not to be delivered, got %q", text.String())
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ServerState is the reachability of a server as seen by Monitor.
+type ServerState string
+
+// States reported by Monitor.
+const (
+	// ServerUp means the server answered the last check in time.
+	ServerUp ServerState = "up"
+	// ServerDegraded means the server answered the last check, but slowly
+	// or with an error status, as when it is overloaded or behind a proxy
+	// whose backend is failing.
+	ServerDegraded ServerState = "degraded"
+	// ServerDown means the server could not be reached or did not answer
+	// within the check interval.
+	ServerDown ServerState = "down"
+)
+
+// defaultSlowThreshold is the latency above which Monitor reports a server
+// as degraded if MonitorOptions does not set one.
+const defaultSlowThreshold = 2 * time.Second
+
+// ServerStatus describes the outcome of a check by Monitor.
+type ServerStatus struct {
+	State ServerState
+	// Version is the server version reported by the last check that
+	// received one.
+	Version string
+	// Latency is the duration of the check.
+	Latency time.Duration
+	// Err is the reason the server is degraded or down.
+	Err  error
+	Time time.Time
+}
+
+// MonitorOptions holds optional settings for MonitorWithOptions.
+type MonitorOptions struct {
+	// SlowThreshold is the latency above which a server that answers is
+	// reported as degraded. The default is 2 seconds.
+	SlowThreshold time.Duration
+}
+
+// Monitor checks the server every interval in the background, by asking
+// for its version, and calls onChange with the previous and the new status
+// whenever its state or version changes, for surfacing outages in an
+// application promptly. The first call, after the first check, has a zero
+// previous status.
+//
+// Each check may take up to interval. onChange is called from the
+// monitor's goroutine, one call at a time. Monitoring stops when ctx is
+// done.
+//
+// Example:
+//
+//	err := client.Monitor(ctx, 10*time.Second, func(prev, cur gollama.ServerStatus) {
+//		if cur.State == gollama.ServerDown {
+//			ui.ShowBanner("Ollama is not running")
+//		}
+//	})
+func (c *Client) Monitor(ctx context.Context, interval time.Duration, onChange func(prev, cur ServerStatus)) error {
+	return c.MonitorWithOptions(ctx, interval, nil, onChange)
+}
+
+// MonitorWithOptions behaves like Monitor, applying the given
+// MonitorOptions.
+func (c *Client) MonitorWithOptions(ctx context.Context, interval time.Duration, opts *MonitorOptions, onChange func(prev, cur ServerStatus)) error {
+	if interval <= 0 {
+		return fmt.Errorf("monitor interval must be positive")
+	}
+	if onChange == nil {
+		return fmt.Errorf("monitor callback cannot be nil")
+	}
+	slow := defaultSlowThreshold
+	if opts != nil && opts.SlowThreshold > 0 {
+		slow = opts.SlowThreshold
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var prev ServerStatus
+		for {
+			cur := c.checkServer(ctx, interval, slow)
+			if ctx.Err() != nil {
+				return
+			}
+			if cur.Version == "" {
+				cur.Version = prev.Version
+			}
+			if cur.State != prev.State || cur.Version != prev.Version {
+				onChange(prev, cur)
+			}
+			prev = cur
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// checkServer asks for the server's version, waiting at most timeout, and
+// returns the resulting status.
+func (c *Client) checkServer(ctx context.Context, timeout, slow time.Duration) ServerStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	version, err := c.Version(ctx)
+	status := ServerStatus{Version: version, Latency: time.Since(start), Err: err, Time: time.Now()}
+
+	var apiErr *OllamaError
+	switch {
+	case err == nil && status.Latency > slow:
+		status.State = ServerDegraded
+		status.Err = fmt.Errorf("server answered in %v", status.Latency.Round(time.Millisecond))
+	case err == nil:
+		status.State = ServerUp
+	case errors.As(err, &apiErr):
+		status.State = ServerDegraded
+	default:
+		status.State = ServerDown
+	}
+	return status
+}
@@ -0,0 +1,134 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// monitoredServer answers version requests according to a settable mode:
+// "up" with its version, "slow" after a delay, "error" with 503, and
+// "down" by dropping the connection.
+type monitoredServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	mode    string
+	version string
+}
+
+func newMonitoredServer(t *testing.T) *monitoredServer {
+	t.Helper()
+	s := &monitoredServer{mode: "up", version: "0.5.7"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		mode, version := s.mode, s.version
+		s.mu.Unlock()
+
+		switch mode {
+		case "slow":
+			time.Sleep(30 * time.Millisecond)
+		case "error":
+			http.Error(w, "backend unavailable", http.StatusServiceUnavailable)
+			return
+		case "down":
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"version":"` + version + `"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *monitoredServer) set(mode, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode, s.version = mode, version
+}
+
+func TestClientMonitor(t *testing.T) {
+	server := newMonitoredServer(t)
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type change struct{ prev, cur ServerStatus }
+	changes := make(chan change, 16)
+	err = client.MonitorWithOptions(ctx, 50*time.Millisecond, &MonitorOptions{SlowThreshold: 20 * time.Millisecond}, func(prev, cur ServerStatus) {
+		changes <- change{prev, cur}
+	})
+	assertNoError(t, err)
+
+	next := func() change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a status change")
+			return change{}
+		}
+	}
+
+	c := next()
+	if c.prev.State != "" || c.cur.State != ServerUp || c.cur.Version != "0.5.7" {
+		t.Errorf("Expected initial up status with version, got %+v after %+v", c.cur, c.prev)
+	}
+
+	server.set("down", "0.5.7")
+	c = next()
+	if c.prev.State != ServerUp || c.cur.State != ServerDown || c.cur.Err == nil {
+		t.Errorf("Expected up to down with error, got %+v after %+v", c.cur, c.prev)
+	}
+	if c.cur.Version != "0.5.7" {
+		t.Errorf("Expected last known version to be kept, got %q", c.cur.Version)
+	}
+
+	server.set("error", "0.5.7")
+	c = next()
+	if c.cur.State != ServerDegraded || c.cur.Err == nil {
+		t.Errorf("Expected degraded status for error response, got %+v", c.cur)
+	}
+
+	server.set("up", "0.6.0")
+	c = next()
+	if c.cur.State != ServerUp || c.prev.Version != "0.5.7" || c.cur.Version != "0.6.0" {
+		t.Errorf("Expected up with new version, got %+v after %+v", c.cur, c.prev)
+	}
+
+	server.set("slow", "0.6.0")
+	c = next()
+	if c.cur.State != ServerDegraded || c.cur.Latency < 20*time.Millisecond {
+		t.Errorf("Expected degraded status for slow response, got %+v", c.cur)
+	}
+
+	// Unchanged statuses are not reported
+	server.set("up", "0.6.0")
+	c = next()
+	if c.cur.State != ServerUp {
+		t.Errorf("Expected up status, got %+v", c.cur)
+	}
+	time.Sleep(150 * time.Millisecond)
+	select {
+	case c := <-changes:
+		t.Errorf("Expected no change while up, got %+v after %+v", c.cur, c.prev)
+	default:
+	}
+}
+
+func TestClientMonitorInvalid(t *testing.T) {
+	client, err := createTestClient("http://localhost:11434")
+	assertNoError(t, err)
+	ctx := context.Background()
+	if err := client.Monitor(ctx, 0, func(prev, cur ServerStatus) {}); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+	if err := client.Monitor(ctx, time.Second, nil); err == nil {
+		t.Error("Expected error for nil callback")
+	}
+}
@@ -0,0 +1,428 @@
+package gollama
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// ClientOption configures a Client created with NewClientWithOptions.
+type ClientOption func(*Client)
+
+// WithMaxResponseBytes limits how much of a response the client reads into
+// memory. Unary responses larger than n bytes, and streamed objects (a
+// single chunk of a streaming response) larger than n bytes, fail with
+// ErrResponseTooLarge. A value of zero or less disables the limit, which is
+// the default.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request, so that
+// server logs and gateways can attribute traffic to an application. The
+// default is "gollama/<Version>".
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader sets a header sent with every request, such as an API key
+// expected by a gateway in front of the server. Headers set by the client
+// itself, like Content-Type, cannot be replaced.
+func WithHeader(name, value string) ClientOption {
+	return func(c *Client) {
+		if c.header == nil {
+			c.header = make(http.Header)
+		}
+		c.header.Set(name, value)
+	}
+}
+
+// WithDefaultModel sets the model used by Generate, Chat, Embeddings and
+// their streaming variants when the request leaves Model empty. A model named
+// in the request always takes precedence.
+func WithDefaultModel(model string) ClientOption {
+	return func(c *Client) {
+		c.defaultModel = model
+	}
+}
+
+// modelOrDefault returns the model set on ctx by ContextWithModel, or else
+// model, or the client's default model if it is empty.
+func (c *Client) modelOrDefault(ctx context.Context, model string) string {
+	if override := contextModel(ctx); override != "" {
+		return override
+	}
+	if model == "" {
+		return c.defaultModel
+	}
+	return model
+}
+
+// WithDefaultOptions sets model options, such as temperature or num_ctx,
+// that apply to every Generate and Chat request. Options set on a request
+// take precedence over the defaults.
+func WithDefaultOptions(opts Options) ClientOption {
+	return func(c *Client) {
+		c.defaultOptions = make(Options, len(opts))
+		for k, v := range opts {
+			c.defaultOptions[k] = v
+		}
+	}
+}
+
+// mergeOptions returns opts layered over the client's default options, with
+// the model options of any WithModelOptions in reqOpts layered on top. The
+// given map is never modified; it is returned as is if there is nothing to
+// merge.
+func (c *Client) mergeOptions(opts Options, reqOpts []RequestOption) Options {
+	overrides := newRequestConfig(reqOpts).modelOptions
+	if len(c.defaultOptions) == 0 && len(overrides) == 0 {
+		return opts
+	}
+
+	merged := make(Options, len(c.defaultOptions)+len(opts)+len(overrides))
+	for _, layer := range []Options{c.defaultOptions, opts, overrides} {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// WithTimeout sets the total time limit of non-streaming calls, 30 seconds
+// by default. Streaming calls such as GenerateStream or Pull are not subject
+// to it; use WithStreamIdleTimeout or per-request options to bound them. A
+// value of zero disables the limit.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithDialTimeout limits how long establishing a TCP connection to the
+// server may take, 30 seconds by default.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.dialTimeout = d
+	}
+}
+
+// WithDialContext replaces the function used to open network connections to
+// the server, for example to use a custom resolver or DNS cache, or to dial
+// through an SSH tunnel. The dial timeout still applies through the context
+// passed to fn.
+//
+// Example:
+//
+//	dialer := &net.Dialer{Resolver: &net.Resolver{PreferGo: true}}
+//	client, err := gollama.NewClientWithOptions(host, gollama.WithDialContext(dialer.DialContext))
+func WithDialContext(fn func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(c *Client) {
+		c.dialContext = fn
+	}
+}
+
+// WithTLSHandshakeTimeout limits how long a TLS handshake with the server
+// may take, 10 seconds by default.
+func WithTLSHandshakeTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.tlsHandshakeTimeout = d
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the server,
+// for example to trust a private certificate authority or to present a
+// client certificate.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// WithResponseHeaderTimeout limits how long any call waits for the server to
+// start responding once the request has been sent. It is disabled by
+// default, because the server does not respond to a non-streaming
+// generation until it is complete. WithHeaderTimeout sets the same limit
+// for a single call.
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.responseHeaderTimeout = d
+	}
+}
+
+// WithStreamIdleTimeout limits how long a streaming call waits for the next
+// piece of data before failing with ErrIdleTimeout. Unlike a total timeout,
+// it does not limit how long a healthy stream may run. It is disabled by
+// default.
+func WithStreamIdleTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.streamIdleTimeout = d
+	}
+}
+
+// streamOptions prepends the client's streaming defaults to opts.
+func (c *Client) streamOptions(opts []RequestOption) []RequestOption {
+	if c.streamIdleTimeout <= 0 {
+		return opts
+	}
+	idle := func(cfg *requestConfig) {
+		cfg.idleTimeout = c.streamIdleTimeout
+	}
+	return append([]RequestOption{idle}, opts...)
+}
+
+// limitReader wraps r so that reading more than the configured maximum
+// fails with ErrResponseTooLarge. Without a limit, r is returned unchanged.
+func (c *Client) limitReader(r io.Reader) io.Reader {
+	return limitBody(r, c.maxResponseBytes)
+}
+
+// limitBody wraps r so that reading more than max bytes fails with
+// ErrResponseTooLarge. If max is zero or less, r is returned unchanged.
+func limitBody(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, max: max}
+}
+
+// limitedReader fails with ErrResponseTooLarge once more than max bytes have
+// been read. Streams limit each line separately instead, in readLines.
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.read > l.max {
+		return 0, ErrResponseTooLarge
+	}
+	// Allow one byte past the limit so that exceeding it can be detected
+	if remaining := l.max + 1 - l.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// RequestOption configures a single API call, such as its timeouts.
+type RequestOption func(*requestConfig)
+
+// requestConfig holds the settings applied by RequestOptions.
+type requestConfig struct {
+	timeout        time.Duration
+	headerTimeout  time.Duration
+	streamTimeout  time.Duration
+	idleTimeout    time.Duration
+	stopPattern    *regexp.Regexp
+	latency        LatencyClass
+	modelOptions   Options
+	resumeAttempts int
+	sessionID      string
+	postProcessors []PostProcessor
+	outputLimit    *OutputLimit
+	idempotencyKey string
+}
+
+// newRequestConfig applies opts to an empty requestConfig.
+func newRequestConfig(opts []RequestOption) requestConfig {
+	var cfg requestConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithRequestTimeout limits the total duration of a call, from sending the
+// request to reading the last byte of the response. It is applied in
+// addition to any deadline on the context, whichever expires first, and
+// replaces the client's WithTimeout limit for the call.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.timeout = d
+	}
+}
+
+// WithHeaderTimeout limits how long a call waits for the server to start
+// responding. For generation this covers loading the model and evaluating
+// the prompt, but not producing the output. The call fails with
+// ErrHeaderTimeout when it expires.
+func WithHeaderTimeout(d time.Duration) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.headerTimeout = d
+	}
+}
+
+// WithStreamTimeout limits how long a call may spend reading the response
+// once the server has started responding, which for streaming calls is the
+// duration of the stream. The call fails with ErrStreamTimeout when it
+// expires.
+func WithStreamTimeout(d time.Duration) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.streamTimeout = d
+	}
+}
+
+// WithStopPattern ends a generation or chat as soon as the accumulated output
+// matches pattern, for conditions that the server's stop strings cannot
+// express, such as a closing code fence or the end of a JSON document.
+//
+// Streaming calls deliver the text up to and including the match, mark that
+// chunk as Done and stop reading, which aborts generation on the server.
+// Non-streaming calls truncate the response text after the match. Since the
+// pattern is checked as text arrives, greedy patterns match as early as
+// possible: `\{.*\}` stops at the first closing brace.
+func WithStopPattern(pattern *regexp.Regexp) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.stopPattern = pattern
+	}
+}
+
+// WithModelOptions sets model options, such as seed or temperature, for a
+// single Generate or Chat call, overriding those of the request and the
+// client's defaults. It lets callers vary the options of requests built by
+// helpers like Classify or Extract. Repeated uses are combined.
+func WithModelOptions(opts Options) RequestOption {
+	return func(cfg *requestConfig) {
+		merged := make(Options, len(cfg.modelOptions)+len(opts))
+		for k, v := range cfg.modelOptions {
+			merged[k] = v
+		}
+		for k, v := range opts {
+			merged[k] = v
+		}
+		cfg.modelOptions = merged
+	}
+}
+
+// requestScope applies the timeouts of a requestConfig to a single request.
+// A nil scope, used when no options are given, does nothing.
+type requestScope struct {
+	ctx           context.Context
+	cancel        context.CancelCauseFunc
+	stop          context.CancelFunc
+	timer         *time.Timer
+	idle          *time.Timer
+	streamTimeout time.Duration
+	idleTimeout   time.Duration
+}
+
+// newRequestScope derives the context for a request from ctx and starts
+// the header timeout, if any.
+func newRequestScope(ctx context.Context, opts []RequestOption) (context.Context, *requestScope) {
+	if len(opts) == 0 {
+		return ctx, nil
+	}
+
+	cfg := newRequestConfig(opts)
+	scope := &requestScope{
+		streamTimeout: cfg.streamTimeout,
+		idleTimeout:   cfg.idleTimeout,
+		stop:          func() {},
+	}
+	ctx, scope.cancel = context.WithCancelCause(ctx)
+	if cfg.timeout > 0 {
+		ctx, scope.stop = context.WithTimeout(ctx, cfg.timeout)
+	}
+	if cfg.headerTimeout > 0 {
+		scope.timer = time.AfterFunc(cfg.headerTimeout, func() {
+			scope.cancel(ErrHeaderTimeout)
+		})
+	}
+	scope.ctx = ctx
+	return ctx, scope
+}
+
+// headersReceived stops the header timeout and starts the stream and idle
+// timeouts.
+func (s *requestScope) headersReceived() {
+	if s == nil {
+		return
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.streamTimeout > 0 {
+		s.timer = time.AfterFunc(s.streamTimeout, func() {
+			s.cancel(ErrStreamTimeout)
+		})
+	}
+	if s.idleTimeout > 0 {
+		s.idle = time.AfterFunc(s.idleTimeout, func() {
+			s.cancel(ErrIdleTimeout)
+		})
+	}
+}
+
+// dataReceived restarts the idle timeout.
+func (s *requestScope) dataReceived() {
+	if s.idle != nil {
+		s.idle.Reset(s.idleTimeout)
+	}
+}
+
+// err replaces an error caused by one of the scope's timeouts with the
+// matching sentinel error.
+func (s *requestScope) err(err error) error {
+	if s == nil {
+		return err
+	}
+	switch cause := context.Cause(s.ctx); cause {
+	case ErrHeaderTimeout, ErrStreamTimeout, ErrIdleTimeout:
+		return cause
+	}
+	return err
+}
+
+// release stops all timers and frees the scope's context.
+func (s *requestScope) release() {
+	if s == nil {
+		return
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	s.stop()
+	s.cancel(context.Canceled)
+}
+
+// scopedBody is a response body that reports the scope's timeouts as read
+// errors and releases the scope when closed.
+type scopedBody struct {
+	io.ReadCloser
+	scope *requestScope
+}
+
+func (b *scopedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.scope.dataReceived()
+	}
+	if err != nil && err != io.EOF {
+		err = b.scope.err(err)
+	}
+	return n, err
+}
+
+func (b *scopedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.scope.release()
+	return err
+}
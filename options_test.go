@@ -0,0 +1,436 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientMaxResponseBytes(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClientWithOptions(server.URL, WithMaxResponseBytes(64))
+	assertNoError(t, err)
+
+	_, err = client.List(ctx)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+	}
+
+	client, err = NewClientWithOptions(server.URL, WithMaxResponseBytes(1<<20))
+	assertNoError(t, err)
+
+	_, err = client.List(ctx)
+	assertNoError(t, err)
+}
+
+func TestClientMaxResponseBytesStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		encoder := json.NewEncoder(w)
+		// Many small chunks that add up to more than the limit are fine...
+		for i := 0; i < 50; i++ {
+			encoder.Encode(GenerateResponse{Model: "llama2", Response: "token"})
+		}
+		if req.Prompt == "huge" {
+			// ...but a single oversized chunk is not
+			encoder.Encode(GenerateResponse{Model: "llama2", Response: strings.Repeat("x", 4096)})
+		}
+		encoder.Encode(GenerateResponse{Model: "llama2", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithMaxResponseBytes(1024))
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	var chunks int
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(*GenerateResponse) {
+		chunks++
+	})
+	assertNoError(t, err)
+	if chunks != 51 {
+		t.Errorf("Expected 51 chunks, got %d", chunks)
+	}
+
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "huge"}, func(*GenerateResponse) {})
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestNewClientWithOptionsDefaults(t *testing.T) {
+	client, err := NewClientWithOptions("")
+	assertNoError(t, err)
+
+	if client.BaseURL() != "http://localhost:11434" {
+		t.Errorf("Expected default base URL, got %s", client.BaseURL())
+	}
+	if client.maxResponseBytes != 0 {
+		t.Errorf("Expected no response limit by default, got %d", client.maxResponseBytes)
+	}
+}
+
+func TestClientDefaultModel(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClientWithOptions(server.URL, WithDefaultModel("mistral"))
+	assertNoError(t, err)
+
+	generated, err := client.Generate(ctx, &GenerateRequest{Prompt: "Hi"})
+	assertNoError(t, err)
+	if generated.Model != "mistral" {
+		t.Errorf("Expected default model mistral, got %s", generated.Model)
+	}
+
+	generated, err = client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"})
+	assertNoError(t, err)
+	if generated.Model != "llama2" {
+		t.Errorf("Expected request model llama2 to win, got %s", generated.Model)
+	}
+
+	var streamed string
+	err = client.ChatStream(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}, func(resp *ChatResponse) {
+		streamed = resp.Model
+	})
+	assertNoError(t, err)
+	if streamed != "mistral" {
+		t.Errorf("Expected default model mistral in stream, got %s", streamed)
+	}
+
+	_, err = client.Embeddings(ctx, &EmbeddingRequest{Prompt: "Hi"})
+	assertNoError(t, err)
+
+	client, err = NewClientWithOptions(server.URL)
+	assertNoError(t, err)
+
+	_, err = client.Chat(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "Hi"}}})
+	assertErrorContains(t, err, "model name cannot be empty")
+}
+
+func TestClientDefaultOptions(t *testing.T) {
+	var received Options
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		received = req.Options
+		json.NewEncoder(w).Encode(GenerateResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	defaults := Options{"temperature": 0.2, "num_ctx": 8192}
+	client, err := NewClientWithOptions(server.URL, WithDefaultOptions(defaults))
+	assertNoError(t, err)
+
+	// Changing the caller's map afterwards does not affect the client
+	defaults["num_ctx"] = 1
+
+	ctx := context.Background()
+
+	request := &GenerateRequest{Model: "llama2", Prompt: "Hi", Options: Options{"temperature": 0.9}}
+	_, err = client.Generate(ctx, request)
+	assertNoError(t, err)
+
+	if received["temperature"] != json.Number("0.9") {
+		t.Errorf("Expected request temperature 0.9 to win, got %v", received["temperature"])
+	}
+	if received["num_ctx"] != json.Number("8192") {
+		t.Errorf("Expected default num_ctx 8192, got %v", received["num_ctx"])
+	}
+	if len(request.Options) != 1 {
+		t.Errorf("Expected request options to be left unchanged, got %v", request.Options)
+	}
+
+	err = client.ChatStream(ctx, &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}, func(*ChatResponse) {})
+	assertNoError(t, err)
+	if received["temperature"] != json.Number("0.2") {
+		t.Errorf("Expected default temperature 0.2, got %v", received["temperature"])
+	}
+}
+
+func TestClientRequestTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if req.Prompt == "slow start" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+
+		encoder := json.NewEncoder(w)
+		if !req.Stream {
+			time.Sleep(250 * time.Millisecond)
+			encoder.Encode(GenerateResponse{Model: req.Model, Response: "text", Done: true})
+			return
+		}
+		for i := 0; i < 5; i++ {
+			encoder.Encode(GenerateResponse{Model: req.Model, Response: "token"})
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+		encoder.Encode(GenerateResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	noop := func(*GenerateResponse) {}
+
+	t.Run("Header timeout", func(t *testing.T) {
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "slow start"}, noop,
+			WithHeaderTimeout(50*time.Millisecond))
+		if !errors.Is(err, ErrHeaderTimeout) {
+			t.Errorf("Expected ErrHeaderTimeout, got %v", err)
+		}
+	})
+
+	t.Run("Header timeout does not limit the stream", func(t *testing.T) {
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, noop,
+			WithHeaderTimeout(100*time.Millisecond))
+		assertNoError(t, err)
+	})
+
+	t.Run("Stream timeout", func(t *testing.T) {
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "slow start"}, noop,
+			WithStreamTimeout(100*time.Millisecond))
+		if !errors.Is(err, ErrStreamTimeout) {
+			t.Errorf("Expected ErrStreamTimeout, got %v", err)
+		}
+	})
+
+	t.Run("Request timeout", func(t *testing.T) {
+		_, err := client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"},
+			WithRequestTimeout(100*time.Millisecond))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Generous timeouts", func(t *testing.T) {
+		_, err := client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "slow start"},
+			WithRequestTimeout(5*time.Second), WithHeaderTimeout(time.Second), WithStreamTimeout(time.Second))
+		assertNoError(t, err)
+	})
+}
+
+func TestClientPhaseTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		encoder := json.NewEncoder(w)
+		if !req.Stream {
+			time.Sleep(200 * time.Millisecond)
+			encoder.Encode(GenerateResponse{Model: req.Model, Done: true})
+			return
+		}
+		for i := 0; i < 6; i++ {
+			encoder.Encode(GenerateResponse{Model: req.Model, Response: "token"})
+			w.(http.Flusher).Flush()
+			if req.Prompt == "stall" && i == 2 {
+				time.Sleep(300 * time.Millisecond)
+			}
+			time.Sleep(40 * time.Millisecond)
+		}
+		encoder.Encode(GenerateResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	noop := func(*GenerateResponse) {}
+
+	client, err := NewClientWithOptions(server.URL,
+		WithTimeout(100*time.Millisecond),
+		WithStreamIdleTimeout(150*time.Millisecond),
+	)
+	assertNoError(t, err)
+
+	t.Run("Total timeout applies to unary calls", func(t *testing.T) {
+		_, err := client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Per-request timeout overrides total timeout", func(t *testing.T) {
+		_, err := client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, WithRequestTimeout(time.Second))
+		assertNoError(t, err)
+	})
+
+	t.Run("Streams are exempt from total timeout", func(t *testing.T) {
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, noop)
+		assertNoError(t, err)
+	})
+
+	t.Run("Stalled stream hits idle timeout", func(t *testing.T) {
+		var chunks int
+		err := client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "stall"}, func(*GenerateResponse) {
+			chunks++
+		})
+		if !errors.Is(err, ErrIdleTimeout) {
+			t.Errorf("Expected ErrIdleTimeout, got %v", err)
+		}
+		if chunks != 3 {
+			t.Errorf("Expected 3 chunks before the stall, got %d", chunks)
+		}
+	})
+}
+
+func TestClientTransportTimeouts(t *testing.T) {
+	client, err := NewClientWithOptions("",
+		WithDialTimeout(time.Second),
+		WithTLSHandshakeTimeout(2*time.Second),
+		WithResponseHeaderTimeout(3*time.Second),
+	)
+	assertNoError(t, err)
+
+	if client.httpClient.Timeout != 0 {
+		t.Errorf("Expected no overall HTTP client timeout, got %v", client.httpClient.Timeout)
+	}
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected *http.Transport, got %T", client.httpClient.Transport)
+	}
+	if transport.TLSHandshakeTimeout != 2*time.Second {
+		t.Errorf("Expected TLS handshake timeout 2s, got %v", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 3*time.Second {
+		t.Errorf("Expected response header timeout 3s, got %v", transport.ResponseHeaderTimeout)
+	}
+	if client.timeout != defaultTimeout {
+		t.Errorf("Expected default total timeout %v, got %v", defaultTimeout, client.timeout)
+	}
+}
+
+func TestClientDialContext(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	target := strings.TrimPrefix(server.URL, "http://")
+
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("Expected dial timeout to be applied to the context")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, target)
+	}
+
+	// The host name only resolves through the custom dialer
+	client, err := NewClientWithOptions("http://ollama.internal:11434", WithDialContext(dial))
+	assertNoError(t, err)
+
+	_, err = client.List(context.Background())
+	assertNoError(t, err)
+
+	if len(dialed) != 1 || dialed[0] != "ollama.internal:11434" {
+		t.Errorf("Expected one dial to ollama.internal:11434, got %v", dialed)
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClientWithOptions(server.URL)
+	assertNoError(t, err)
+	_, err = client.List(ctx)
+	assertNoError(t, err)
+
+	client, err = NewClientWithOptions(server.URL, WithUserAgent("billing-service/2.3"))
+	assertNoError(t, err)
+	_, err = client.List(ctx)
+	assertNoError(t, err)
+
+	expected := []string{"gollama/" + Version, "billing-service/2.3"}
+	if len(userAgents) != len(expected) {
+		t.Fatalf("Expected %d requests, got %d", len(expected), len(userAgents))
+	}
+	for i := range expected {
+		if userAgents[i] != expected[i] {
+			t.Errorf("Expected User-Agent %q, got %q", expected[i], userAgents[i])
+		}
+	}
+}
+
+func TestClientHeader(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL,
+		WithHeader("Authorization", "Bearer secret"),
+		WithHeader("Content-Type", "text/plain"),
+	)
+	assertNoError(t, err)
+	_, err = client.List(context.Background())
+	assertNoError(t, err)
+
+	if got := header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Expected Authorization header, got %q", got)
+	}
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type to be kept, got %q", got)
+	}
+}
+
+func TestClientModelOptions(t *testing.T) {
+	var got Options
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = req.Options
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithDefaultOptions(Options{"temperature": 0.1, "top_k": 20}))
+	assertNoError(t, err)
+
+	req := &GenerateRequest{Model: "llama3", Prompt: "hi", Options: Options{"temperature": 0.2, "seed": 1}}
+	_, err = client.Generate(context.Background(), req,
+		WithModelOptions(Options{"temperature": 0.9}),
+		WithModelOptions(Options{"seed": 7}),
+	)
+	assertNoError(t, err)
+
+	want := map[string]string{"temperature": "0.9", "seed": "7", "top_k": "20"}
+	for k, v := range want {
+		if fmt.Sprint(got[k]) != v {
+			t.Errorf("Expected %s=%s, got %v", k, v, got[k])
+		}
+	}
+	if req.Options["temperature"] != 0.2 {
+		t.Error("Expected the request options to be unchanged")
+	}
+}
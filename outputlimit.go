@@ -0,0 +1,150 @@
+package gollama
+
+import (
+	"unicode/utf8"
+)
+
+// DefaultTruncationMarker is appended to output cut short by an OutputLimit
+// that does not set its own Marker.
+const DefaultTruncationMarker = "\n\n[output truncated]"
+
+// OutputLimit caps the length of generated text on the client side, so that
+// a runaway generation cannot flood a UI or fill up storage even when
+// num_predict is missing or set too high. Output that reaches the limit is
+// cut, followed by Marker, and the response is marked Truncated.
+//
+// Streaming calls count each non-empty chunk of the answer as a token, as
+// the server sends one token per chunk, and stop reading once the limit is
+// reached, which aborts generation on the server. Non-streaming calls can
+// only cut the complete text; its token count is taken from EvalCount,
+// assuming tokens of equal length, or estimated at four characters per
+// token. Set num_predict as well to keep the server from generating text
+// that is then discarded. Thinking traces are not limited.
+type OutputLimit struct {
+	// Tokens is the maximum number of tokens of the answer, or zero for no
+	// limit.
+	Tokens int
+	// Chars is the maximum number of characters of the answer, or zero for
+	// no limit.
+	Chars int
+	// Marker is appended to truncated output. DefaultTruncationMarker is
+	// used if it is empty.
+	Marker string
+}
+
+// marker returns the text appended to truncated output.
+func (l *OutputLimit) marker() string {
+	if l.Marker == "" {
+		return DefaultTruncationMarker
+	}
+	return l.Marker
+}
+
+// WithOutputLimit caps the length of the text generated by every Generate
+// and Chat call and their streaming variants. WithRequestOutputLimit
+// replaces the limit for a single call.
+func WithOutputLimit(limit OutputLimit) ClientOption {
+	return func(c *Client) {
+		c.outputLimit = &limit
+	}
+}
+
+// WithRequestOutputLimit caps the length of the text generated by a single
+// call, replacing the client's WithOutputLimit. A zero OutputLimit lifts the
+// client's limit for the call.
+func WithRequestOutputLimit(limit OutputLimit) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.outputLimit = &limit
+	}
+}
+
+// outputLimitFor returns the output limit of a call, or nil if there is
+// none.
+func (c *Client) outputLimitFor(opts []RequestOption) *OutputLimit {
+	limit := c.outputLimit
+	if override := newRequestConfig(opts).outputLimit; override != nil {
+		limit = override
+	}
+	if limit == nil || (limit.Tokens <= 0 && limit.Chars <= 0) {
+		return nil
+	}
+	return limit
+}
+
+// truncateOutput applies the call's output limit to the complete text of a
+// non-streaming response whose answer took evalCount tokens, reporting
+// whether the text was cut.
+func (c *Client) truncateOutput(text string, evalCount int, opts []RequestOption) (string, bool) {
+	limit := c.outputLimitFor(opts)
+	if limit == nil {
+		return text, false
+	}
+
+	chars := utf8.RuneCountInString(text)
+	keep := chars
+	if limit.Chars > 0 && keep > limit.Chars {
+		keep = limit.Chars
+	}
+	if limit.Tokens > 0 {
+		tokens := evalCount
+		if tokens <= 0 {
+			tokens = EstimateTokens(text)
+		}
+		if tokens > limit.Tokens {
+			if n := chars * limit.Tokens / tokens; n < keep {
+				keep = n
+			}
+		}
+	}
+	if keep == chars {
+		return text, false
+	}
+	return truncateRunes(text, keep) + limit.marker(), true
+}
+
+// truncateRunes returns the first n runes of text.
+func truncateRunes(text string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return text[:i]
+}
+
+// outputLimiter applies an OutputLimit to streamed text.
+type outputLimiter struct {
+	limit  *OutputLimit
+	tokens int
+	chars  int
+}
+
+// newOutputLimiter returns an outputLimiter for the call's output limit, or
+// nil if there is none.
+func (c *Client) newOutputLimiter(opts []RequestOption) *outputLimiter {
+	limit := c.outputLimitFor(opts)
+	if limit == nil {
+		return nil
+	}
+	return &outputLimiter{limit: limit}
+}
+
+// push counts a chunk of the answer. It returns the part of the chunk
+// within the limit, followed by the marker if the limit was exceeded, and
+// whether it was.
+func (l *outputLimiter) push(chunk string) (string, bool) {
+	if l == nil || chunk == "" {
+		return chunk, false
+	}
+	if l.limit.Tokens > 0 && l.tokens >= l.limit.Tokens {
+		return l.limit.marker(), true
+	}
+	l.tokens++
+
+	n := utf8.RuneCountInString(chunk)
+	if l.limit.Chars > 0 && l.chars+n > l.limit.Chars {
+		return truncateRunes(chunk, l.limit.Chars-l.chars) + l.limit.marker(), true
+	}
+	l.chars += n
+	return chunk, false
+}
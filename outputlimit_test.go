@@ -0,0 +1,118 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutputLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     OutputLimit
+		chunks    []string
+		expected  string
+		truncated bool
+	}{
+		{"Within limits", OutputLimit{Tokens: 3, Chars: 20}, []string{"a", "b", "c"}, "abc", false},
+		{"Tokens", OutputLimit{Tokens: 2, Marker: "…"}, []string{"a", "", "b", "c", "d"}, "ab…", true},
+		{"Chars", OutputLimit{Chars: 5, Marker: "…"}, []string{"héll", "o w", "orld"}, "héllo…", true},
+		{"Default marker", OutputLimit{Chars: 1}, []string{"ab"}, "a" + DefaultTruncationMarker, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &outputLimiter{limit: &tt.limit}
+			var output strings.Builder
+			var truncated bool
+			for _, chunk := range tt.chunks {
+				text, cut := limiter.push(chunk)
+				output.WriteString(text)
+				if cut {
+					truncated = true
+					break
+				}
+			}
+			if output.String() != tt.expected || truncated != tt.truncated {
+				t.Errorf("Expected %q (truncated %v), got %q (truncated %v)", tt.expected, tt.truncated, output.String(), truncated)
+			}
+		})
+	}
+}
+
+func TestClientOutputLimit(t *testing.T) {
+	chunks := []string{"one ", "two ", "three ", "four ", "five"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		encoder := json.NewEncoder(w)
+		if !req.Stream {
+			encoder.Encode(GenerateResponse{Response: strings.Join(chunks, ""), Done: true, EvalCount: len(chunks)})
+			return
+		}
+		for _, chunk := range chunks {
+			encoder.Encode(GenerateResponse{Response: chunk})
+		}
+		encoder.Encode(GenerateResponse{Done: true, EvalCount: len(chunks)})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithOutputLimit(OutputLimit{Tokens: 2, Marker: " [...]"}))
+	assertNoError(t, err)
+	ctx := context.Background()
+	request := &GenerateRequest{Model: "llama2", Prompt: "count"}
+
+	t.Run("Final response", func(t *testing.T) {
+		resp, err := client.Generate(ctx, request)
+		assertNoError(t, err)
+		// 2 of 5 tokens of 24 characters
+		if resp.Response != "one two t [...]" || !resp.Truncated {
+			t.Errorf("Expected truncated response, got %q (truncated %v)", resp.Response, resp.Truncated)
+		}
+	})
+
+	t.Run("Streamed chunks", func(t *testing.T) {
+		resp, err := client.GenerateStreamCollect(ctx, request, nil)
+		assertNoError(t, err)
+		if resp.Response != "one two  [...]" || !resp.Truncated || !resp.Done {
+			t.Errorf("Expected truncated stream, got %q (truncated %v)", resp.Response, resp.Truncated)
+		}
+	})
+
+	t.Run("Request limit", func(t *testing.T) {
+		resp, err := client.Generate(ctx, request, WithRequestOutputLimit(OutputLimit{Chars: 7}))
+		assertNoError(t, err)
+		if resp.Response != "one two"+DefaultTruncationMarker || !resp.Truncated {
+			t.Errorf("Expected request limit to replace the client's, got %q", resp.Response)
+		}
+
+		resp, err = client.Generate(ctx, request, WithRequestOutputLimit(OutputLimit{}))
+		assertNoError(t, err)
+		if resp.Response != strings.Join(chunks, "") || resp.Truncated {
+			t.Errorf("Expected zero limit to lift the client's, got %q", resp.Response)
+		}
+	})
+
+	t.Run("Chat", func(t *testing.T) {
+		chatServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoder := json.NewEncoder(w)
+			for _, chunk := range chunks {
+				encoder.Encode(ChatResponse{Message: Message{Role: "assistant", Content: chunk}})
+			}
+			encoder.Encode(ChatResponse{Done: true})
+		}))
+		defer chatServer.Close()
+
+		client, err := NewClientWithOptions(chatServer.URL, WithOutputLimit(OutputLimit{Chars: 10, Marker: "!"}))
+		assertNoError(t, err)
+		resp, err := client.ChatStreamCollect(ctx, &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "count"}}}, nil)
+		assertNoError(t, err)
+		if resp.Message.Content != "one two th!" || !resp.Truncated {
+			t.Errorf("Expected truncated chat, got %q (truncated %v)", resp.Message.Content, resp.Truncated)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+)
+
+// Context keys of the per-call overrides.
+type (
+	contextModelKey   struct{}
+	contextHeaderKey  struct{}
+	contextOptionsKey struct{}
+)
+
+// ContextWithModel returns a context that makes calls use the given model,
+// overriding the model named in the request and the client's default model.
+// It lets frameworks that only pass a context through redirect calls made
+// deep inside libraries. The override applies to every call made with the
+// context, including those of helpers that use a second model, such as a
+// judge.
+//
+// Example:
+//
+//	ctx = gollama.ContextWithModel(ctx, "mistral")
+//	summary, err := someLibrary.Summarize(ctx, client, text)
+func ContextWithModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, contextModelKey{}, model)
+}
+
+// ContextWithOptions returns a context that layers the given model options
+// over those of every Generate and Chat call made with it, as
+// WithModelOptions does for a single call. Repeated uses are combined.
+func ContextWithOptions(ctx context.Context, opts Options) context.Context {
+	return ContextWithRequestOptions(ctx, WithModelOptions(opts))
+}
+
+// ContextWithHeader returns a context that adds a header to every request
+// made with it, such as a tracing or tenant header. It takes precedence over
+// headers set with WithHeader; headers set by the client itself, like
+// Content-Type, cannot be replaced.
+func ContextWithHeader(ctx context.Context, name, value string) context.Context {
+	header := make(http.Header)
+	if parent, ok := ctx.Value(contextHeaderKey{}).(http.Header); ok {
+		header = parent.Clone()
+	}
+	header.Set(name, value)
+	return context.WithValue(ctx, contextHeaderKey{}, header)
+}
+
+// ContextWithRequestOptions returns a context that applies the given request
+// options, such as WithRequestTimeout, to every call made with it. They are
+// applied after the options passed to the call, and so take precedence.
+// Repeated uses are combined.
+func ContextWithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	parent, _ := ctx.Value(contextOptionsKey{}).([]RequestOption)
+	combined := make([]RequestOption, 0, len(parent)+len(opts))
+	combined = append(append(combined, parent...), opts...)
+	return context.WithValue(ctx, contextOptionsKey{}, combined)
+}
+
+// contextOptions returns opts followed by the request options of ctx.
+func contextOptions(ctx context.Context, opts []RequestOption) []RequestOption {
+	overrides, _ := ctx.Value(contextOptionsKey{}).([]RequestOption)
+	if len(overrides) == 0 {
+		return opts
+	}
+	combined := make([]RequestOption, 0, len(opts)+len(overrides))
+	return append(append(combined, opts...), overrides...)
+}
+
+// contextModel returns the model set by ContextWithModel, if any.
+func contextModel(ctx context.Context) string {
+	model, _ := ctx.Value(contextModelKey{}).(string)
+	return model
+}
+
+// contextHeader returns the headers set by ContextWithHeader, if any.
+func contextHeader(ctx context.Context) http.Header {
+	header, _ := ctx.Value(contextHeaderKey{}).(http.Header)
+	return header
+}
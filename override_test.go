@@ -0,0 +1,72 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContextOverrides(t *testing.T) {
+	var got ChatRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Model == "slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Model: got.Model, Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithHeader("X-Tenant", "default"))
+	assertNoError(t, err)
+
+	ctx := ContextWithModel(context.Background(), "mistral")
+	ctx = ContextWithOptions(ctx, Options{"temperature": 0.9})
+	ctx = ContextWithOptions(ctx, Options{"seed": 7})
+	ctx = ContextWithHeader(ctx, "X-Tenant", "acme")
+	ctx = ContextWithHeader(ctx, "X-Trace", "abc")
+
+	req := &ChatRequest{
+		Model:    "llama3",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+		Options:  Options{"temperature": 0.1, "top_k": 20},
+	}
+	resp, err := client.Chat(ctx, req, WithModelOptions(Options{"temperature": 0.5}))
+	assertNoError(t, err)
+
+	if resp.Model != "mistral" {
+		t.Errorf("Expected context model to override the request, got %s", resp.Model)
+	}
+	want := map[string]string{"temperature": "0.9", "seed": "7", "top_k": "20"}
+	for k, v := range want {
+		if fmt.Sprint(got.Options[k]) != v {
+			t.Errorf("Expected %s=%s, got %v", k, v, got.Options[k])
+		}
+	}
+	if header.Get("X-Tenant") != "acme" || header.Get("X-Trace") != "abc" {
+		t.Errorf("Expected context headers, got %v", header)
+	}
+
+	// The parent context is unaffected by headers added to a child
+	parent := ContextWithHeader(context.Background(), "X-Trace", "parent")
+	ContextWithHeader(parent, "X-Trace", "child")
+	_, err = client.Chat(parent, req)
+	assertNoError(t, err)
+	if header.Get("X-Trace") != "parent" || header.Get("X-Tenant") != "default" {
+		t.Errorf("Expected parent headers only, got %v", header)
+	}
+
+	// Context request options apply to raw calls and take precedence
+	ctx = ContextWithRequestOptions(ContextWithModel(context.Background(), "slow"), WithRequestTimeout(50*time.Millisecond))
+	_, err = client.Chat(ctx, req, WithRequestTimeout(time.Minute))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context timeout to apply, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package gollama
+
+import "fmt"
+
+// PlacementKind classifies where a running model is held in memory.
+type PlacementKind string
+
+// Placement kinds reported by RunningModel.Placement.
+const (
+	PlacementGPU     PlacementKind = "gpu"
+	PlacementPartial PlacementKind = "partial"
+	PlacementCPU     PlacementKind = "cpu"
+)
+
+// Placement describes how a running model is split between GPU and system
+// memory.
+type Placement struct {
+	Name string
+	Kind PlacementKind
+	// GPUPercent is the share of the model held in VRAM, from 0 to 100.
+	GPUPercent float64
+	GPUBytes   int64
+	CPUBytes   int64
+}
+
+// String formats the placement the way `ollama ps` does, e.g. "100% GPU",
+// "100% CPU" or "48%/52% CPU/GPU".
+func (p Placement) String() string {
+	switch p.Kind {
+	case PlacementGPU:
+		return "100% GPU"
+	case PlacementCPU:
+		return "100% CPU"
+	}
+	gpu := int(p.GPUPercent + 0.5)
+	return fmt.Sprintf("%d%%/%d%% CPU/GPU", 100-gpu, gpu)
+}
+
+// Placement interprets the model's Size and SizeVRAM into a placement.
+// A model is reported as fully on the GPU only if all of it is in VRAM.
+func (m *RunningModel) Placement() Placement {
+	p := Placement{Name: m.Name}
+
+	gpu := m.SizeVRAM
+	if gpu > m.Size {
+		gpu = m.Size
+	}
+	if gpu < 0 {
+		gpu = 0
+	}
+	p.GPUBytes = gpu
+	p.CPUBytes = m.Size - gpu
+
+	switch {
+	case m.Size <= 0 || gpu == 0:
+		p.Kind = PlacementCPU
+	case gpu == m.Size:
+		p.Kind = PlacementGPU
+		p.GPUPercent = 100
+	default:
+		p.Kind = PlacementPartial
+		p.GPUPercent = float64(gpu) / float64(m.Size) * 100
+	}
+	return p
+}
+
+// Placements returns the placement of every running model.
+func (r *PSResponse) Placements() []Placement {
+	placements := make([]Placement, 0, len(r.Models))
+	for i := range r.Models {
+		placements = append(placements, r.Models[i].Placement())
+	}
+	return placements
+}
@@ -0,0 +1,57 @@
+package gollama
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunningModelPlacement(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    RunningModel
+		kind     PlacementKind
+		percent  float64
+		cpuBytes int64
+		text     string
+	}{
+		{"Fully on GPU", RunningModel{Size: 1000, SizeVRAM: 1000}, PlacementGPU, 100, 0, "100% GPU"},
+		{"CPU only", RunningModel{Size: 1000, SizeVRAM: 0}, PlacementCPU, 0, 1000, "100% CPU"},
+		{"Partially offloaded", RunningModel{Size: 1000, SizeVRAM: 520}, PlacementPartial, 52, 480, "48%/52% CPU/GPU"},
+		{"Reported VRAM above size", RunningModel{Size: 1000, SizeVRAM: 1200}, PlacementGPU, 100, 0, "100% GPU"},
+		{"Empty model", RunningModel{}, PlacementCPU, 0, 0, "100% CPU"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.model.Placement()
+			if p.Kind != tt.kind {
+				t.Errorf("Expected kind %s, got %s", tt.kind, p.Kind)
+			}
+			if p.GPUPercent != tt.percent {
+				t.Errorf("Expected %.1f%% on GPU, got %.1f%%", tt.percent, p.GPUPercent)
+			}
+			if p.CPUBytes != tt.cpuBytes {
+				t.Errorf("Expected %d bytes on CPU, got %d", tt.cpuBytes, p.CPUBytes)
+			}
+			if p.String() != tt.text {
+				t.Errorf("Expected %q, got %q", tt.text, p.String())
+			}
+		})
+	}
+}
+
+func TestPSResponsePlacements(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	status, err := client.PS(context.Background())
+	assertNoError(t, err)
+
+	placements := status.Placements()
+	if len(placements) != 1 || placements[0].Name != "llama2" || placements[0].Kind != PlacementGPU {
+		t.Errorf("Unexpected placements: %+v", placements)
+	}
+}
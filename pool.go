@@ -0,0 +1,63 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize bounds the buffers kept in bufferPool so that a single
+// large request or response does not pin its memory for the process lifetime.
+const maxPooledBufferSize = 4 << 20
+
+// bufferPool recycles buffers used for request marshaling and response
+// reading across calls.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool unless it has grown too large.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// pooledBody is a request body backed by a pooled buffer. The HTTP transport
+// closes request bodies once they have been written, which is the earliest
+// point at which the buffer can safely be reused.
+type pooledBody struct {
+	*bytes.Reader
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+// Close returns the underlying buffer to the pool.
+func (b *pooledBody) Close() error {
+	b.once.Do(func() {
+		putBuffer(b.buf)
+	})
+	return nil
+}
+
+// newJSONBody encodes v into a pooled buffer and returns it as a request
+// body together with its length.
+func newJSONBody(v interface{}) (io.ReadCloser, int64, error) {
+	buf := getBuffer()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		putBuffer(buf)
+		return nil, 0, err
+	}
+	return &pooledBody{Reader: bytes.NewReader(buf.Bytes()), buf: buf}, int64(buf.Len()), nil
+}
@@ -0,0 +1,123 @@
+package gollama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PostProcessor rewrites the final text of a generation, for example to
+// trim whitespace or remove tokens a model leaks into its output. Any
+// func(string) string can be used, such as strings.TrimSpace or
+// StripThinking.
+//
+// Post-processors are installed on a client with WithPostProcessor, or for
+// a single call with WithRequestPostProcessor, and run on the text returned
+// by Generate and Chat and on the complete response returned by
+// GenerateStreamCollect and ChatStreamCollect. They are not applied to the
+// chunks of a stream, since most of them need the whole text. The client's
+// post-processors run first, then the call's, each in the order added.
+// Moderators see the post-processed text of Generate and Chat, but the
+// chunks of a stream as they arrive.
+type PostProcessor func(text string) string
+
+// ChainPostProcessors returns a post-processor that runs ps in order,
+// passing the output of each to the next.
+func ChainPostProcessors(ps ...PostProcessor) PostProcessor {
+	return func(text string) string {
+		for _, p := range ps {
+			text = p(text)
+		}
+		return text
+	}
+}
+
+// WithPostProcessor adds a post-processor applied to the final text of
+// every generation. It may be given several times; post-processors run in
+// the order they were added.
+//
+// Example:
+//
+//	client, err := gollama.NewClientWithOptions(host,
+//		gollama.WithPostProcessor(gollama.StripThinking),
+//		gollama.WithPostProcessor(gollama.StripSpecialTokens()),
+//		gollama.WithPostProcessor(strings.TrimSpace))
+func WithPostProcessor(p PostProcessor) ClientOption {
+	return func(c *Client) {
+		c.postProcessors = append(c.postProcessors, p)
+	}
+}
+
+// WithRequestPostProcessor adds a post-processor applied to the final text
+// of a single call, after those of the client. Repeated uses are combined.
+func WithRequestPostProcessor(p PostProcessor) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.postProcessors = append(cfg.postProcessors, p)
+	}
+}
+
+// postProcess runs text through the client's post-processors and those set
+// in opts.
+func (c *Client) postProcess(text string, opts []RequestOption) string {
+	for _, p := range c.postProcessors {
+		text = p(text)
+	}
+	for _, p := range newRequestConfig(opts).postProcessors {
+		text = p(text)
+	}
+	return text
+}
+
+// DefaultSpecialTokens are the control tokens of common model families that
+// StripSpecialTokens removes when given none.
+var DefaultSpecialTokens = []string{
+	"<|im_start|>", "<|im_end|>", // ChatML (Qwen, Yi, ...)
+	"<|begin_of_text|>", "<|start_header_id|>", "<|end_header_id|>", "<|eot_id|>", "<|eom_id|>", // Llama 3
+	"<s>", "</s>", "[INST]", "[/INST]", // Llama 2, Mistral
+	"<start_of_turn>", "<end_of_turn>", // Gemma
+	"<|user|>", "<|assistant|>", "<|system|>", "<|end|>", // Phi
+	"<|endoftext|>",
+}
+
+// StripSpecialTokens returns a post-processor that removes every occurrence
+// of the given tokens, or of DefaultSpecialTokens if none are given. Models
+// sometimes leak such tokens into their output when run with a mismatched
+// template.
+func StripSpecialTokens(tokens ...string) PostProcessor {
+	if len(tokens) == 0 {
+		tokens = DefaultSpecialTokens
+	}
+	pairs := make([]string, 0, 2*len(tokens))
+	for _, token := range tokens {
+		pairs = append(pairs, token, "")
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return replacer.Replace
+}
+
+// Patterns matching a first line that only introduces the answer.
+var (
+	preambleInterjection = regexp.MustCompile(`(?i)^(?:sure|certainly|of course|absolutely|okay)[!.,]*$`)
+	preambleIntroduction = regexp.MustCompile(`(?i)^(?:(?:sure|certainly|of course|absolutely|okay)[!.,]*\s+)?here(?:'s|’s| is| are)\b.*:$`)
+)
+
+// StripPreamble removes a first line that only introduces the answer, such
+// as "Sure!" or "Sure! Here's a haiku about autumn:", along with the blank
+// lines after it. Text consisting of that line alone, or whose first line
+// goes on to answer, is returned unchanged.
+func StripPreamble(text string) string {
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	first, rest, ok := strings.Cut(trimmed, "\n")
+	if !ok {
+		return text
+	}
+	first = strings.TrimSpace(first)
+	if !preambleInterjection.MatchString(first) && !preambleIntroduction.MatchString(first) {
+		return text
+	}
+	// Leading indentation of the answer, as in code, is kept
+	rest = strings.TrimLeft(rest, "\r\n")
+	if strings.TrimSpace(rest) == "" {
+		return text
+	}
+	return rest
+}
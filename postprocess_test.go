@@ -0,0 +1,111 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStripPreamble(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Interjection", "Sure!\n\nThe answer is 4.", "The answer is 4."},
+		{"Introduction", "Sure! Here's a haiku about autumn:\nLeaves fall", "Leaves fall"},
+		{"Introduction alone", "Here is the code:\n\n    x := 1", "    x := 1"},
+		{"Answer on the first line", "Sure, the answer is 4.\nBecause 2+2.", "Sure, the answer is 4.\nBecause 2+2."},
+		{"Preamble only", "Sure!", "Sure!"},
+		{"No preamble", "Paris is the capital.", "Paris is the capital."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if output := StripPreamble(tt.input); output != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestStripSpecialTokens(t *testing.T) {
+	if output := StripSpecialTokens()("<|im_start|>Hello</s><|eot_id|>"); output != "Hello" {
+		t.Errorf("Expected default tokens to be removed, got %q", output)
+	}
+	if output := StripSpecialTokens("<END>")("Hello<END></s>"); output != "Hello</s>" {
+		t.Errorf("Expected only the given tokens to be removed, got %q", output)
+	}
+}
+
+func TestChainPostProcessors(t *testing.T) {
+	chain := ChainPostProcessors(StripThinking, StripPreamble, strings.TrimSpace)
+	output := chain("<think>Easy.</think>\nCertainly.\nThe answer is 4.\n\n")
+	if output != "The answer is 4." {
+		t.Errorf("Expected post-processors to run in order, got %q", output)
+	}
+}
+
+func TestClientPostProcessors(t *testing.T) {
+	chunks := []string{"  Sure!\n", "Hello<|im_end|>", " world  "}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoder := json.NewEncoder(w)
+		if r.URL.Path == "/api/chat" {
+			encoder.Encode(ChatResponse{Message: Message{Role: "assistant", Content: strings.Join(chunks, "")}, Done: true})
+			return
+		}
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			encoder.Encode(GenerateResponse{Response: strings.Join(chunks, ""), Done: true})
+			return
+		}
+		for _, chunk := range chunks {
+			encoder.Encode(GenerateResponse{Response: chunk})
+		}
+		encoder.Encode(GenerateResponse{Done: true})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL,
+		WithPostProcessor(StripSpecialTokens()),
+		WithPostProcessor(strings.TrimSpace))
+	assertNoError(t, err)
+	ctx := context.Background()
+	request := &GenerateRequest{Model: "llama2", Prompt: "hi"}
+
+	resp, err := client.Generate(ctx, request)
+	assertNoError(t, err)
+	if resp.Response != "Sure!\nHello world" {
+		t.Errorf("Expected client post-processors to apply, got %q", resp.Response)
+	}
+
+	// Request post-processors run after the client's
+	resp, err = client.Generate(ctx, request, WithRequestPostProcessor(StripPreamble))
+	assertNoError(t, err)
+	if resp.Response != "Hello world" {
+		t.Errorf("Expected request post-processor to apply, got %q", resp.Response)
+	}
+
+	chat, err := client.Chat(ctx, &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "hi"}}})
+	assertNoError(t, err)
+	if chat.Message.Content != "Sure!\nHello world" {
+		t.Errorf("Expected chat post-processing, got %q", chat.Message.Content)
+	}
+
+	// Chunks are delivered as is, and the collected response is processed
+	var streamed strings.Builder
+	collected, err := client.GenerateStreamCollect(ctx, request, func(resp *GenerateResponse) {
+		streamed.WriteString(resp.Response)
+	})
+	assertNoError(t, err)
+	if streamed.String() != strings.Join(chunks, "") {
+		t.Errorf("Expected chunks to be left alone, got %q", streamed.String())
+	}
+	if collected.Response != "Sure!\nHello world" {
+		t.Errorf("Expected collected response to be post-processed, got %q", collected.Response)
+	}
+}
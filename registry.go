@@ -0,0 +1,318 @@
+package gollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRegistryURL is the registry used for model names without a host.
+	defaultRegistryURL = "https://registry.ollama.ai"
+	// defaultLibraryURL is the public model library website used for search.
+	defaultLibraryURL = "https://ollama.com"
+)
+
+// Media types of the layers that make up an Ollama model.
+const (
+	MediaTypeModel     = "application/vnd.ollama.image.model"
+	MediaTypeProjector = "application/vnd.ollama.image.projector"
+	MediaTypeAdapter   = "application/vnd.ollama.image.adapter"
+	MediaTypeTemplate  = "application/vnd.ollama.image.template"
+	MediaTypeSystem    = "application/vnd.ollama.image.system"
+	MediaTypeParams    = "application/vnd.ollama.image.params"
+	MediaTypeLicense   = "application/vnd.ollama.image.license"
+	MediaTypeMessages  = "application/vnd.ollama.image.messages"
+)
+
+// RegistryClient is a companion client for the public Ollama model library.
+// It talks to the model registry for tags and manifests, and to the library
+// website for search, so applications can browse models before pulling them
+// with Client.Pull.
+type RegistryClient struct {
+	// httpClient is the underlying HTTP client used for making requests
+	httpClient *http.Client
+	// registryURL is the base URL of the model registry
+	registryURL string
+	// libraryURL is the base URL of the library website
+	libraryURL string
+	// userAgent is sent as the User-Agent header of every request
+	userAgent string
+	// maxResponseBytes limits the size of a response body, or is zero for
+	// no limit
+	maxResponseBytes int64
+}
+
+// NewRegistryClient creates a new registry client.
+//
+// Empty URLs default to "https://registry.ollama.ai" for the registry and
+// "https://ollama.com" for the library website.
+func NewRegistryClient(registryURL, libraryURL string) (*RegistryClient, error) {
+	if registryURL == "" {
+		registryURL = defaultRegistryURL
+	}
+	if libraryURL == "" {
+		libraryURL = defaultLibraryURL
+	}
+
+	return &RegistryClient{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		registryURL: registryURL,
+		libraryURL:  libraryURL,
+		userAgent:   defaultUserAgent,
+	}, nil
+}
+
+// Registry returns a RegistryClient that shares the client's HTTP settings,
+// including its WithMaxResponseBytes limit.
+func (c *Client) Registry() *RegistryClient {
+	return &RegistryClient{
+		httpClient:       &http.Client{Transport: c.httpClient.Transport, Timeout: c.timeout},
+		registryURL:      c.registryURL,
+		libraryURL:       defaultLibraryURL,
+		userAgent:        c.userAgent,
+		maxResponseBytes: c.maxResponseBytes,
+	}
+}
+
+// RegistryModel is a search result from the model library.
+type RegistryModel struct {
+	Name         string   `json:"name"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Sizes        []string `json:"sizes,omitempty"`
+	PullCount    string   `json:"pull_count,omitempty"`
+}
+
+// RegistryLayer describes a single blob referenced by a model manifest.
+type RegistryLayer struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// RegistryModelInfo holds the metadata of a model tag as published in the
+// registry.
+type RegistryModelInfo struct {
+	Name string `json:"name"`
+	// Digest is the manifest digest, comparable to ModelResponse.Digest of
+	// a model pulled from this tag.
+	Digest            string          `json:"digest"`
+	Size              int64           `json:"size"`
+	Layers            []RegistryLayer `json:"layers"`
+	Format            string          `json:"format,omitempty"`
+	Family            string          `json:"family,omitempty"`
+	ParameterSize     string          `json:"parameter_size,omitempty"`
+	QuantizationLevel string          `json:"quantization_level,omitempty"`
+	// Capabilities holds capabilities derivable from the manifest, such as
+	// "vision" for models that ship a projector layer.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// registryManifest is the subset of an OCI image manifest served by the
+// model registry that the client needs.
+type registryManifest struct {
+	Config RegistryLayer   `json:"config"`
+	Layers []RegistryLayer `json:"layers"`
+	// digest is computed from the raw manifest bytes
+	digest string
+}
+
+// totalSize returns the combined size of the config and all layers.
+func (m *registryManifest) totalSize() int64 {
+	total := m.Config.Size
+	for _, layer := range m.Layers {
+		total += layer.Size
+	}
+	return total
+}
+
+// Search queries the model library for models matching query.
+//
+// The library website has no JSON API, so results are extracted from the
+// search page markup. Fields the page does not provide are left empty.
+func (r *RegistryClient) Search(ctx context.Context, query string) ([]RegistryModel, error) {
+	u := strings.TrimSuffix(r.libraryURL, "/") + "/search?q=" + url.QueryEscape(query)
+	body, err := r.get(ctx, u, "text/html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search models: %w", err)
+	}
+	return parseSearchResults(string(body)), nil
+}
+
+// Tags lists the tags published for a model, e.g. "llama2" or "user/model".
+func (r *RegistryClient) Tags(ctx context.Context, modelName string) ([]string, error) {
+	name := ModelName(modelName)
+	u := r.repositoryURL(name, false) + "/tags/list"
+
+	body, err := r.get(ctx, u, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags for %q: %w", modelName, err)
+	}
+
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tag list: %w", err)
+	}
+	return tags.Tags, nil
+}
+
+// Info fetches the registry metadata of a model tag: its manifest digest,
+// total download size, layers, and the format, family, parameter size and
+// quantization recorded in its config blob.
+func (r *RegistryClient) Info(ctx context.Context, modelName string) (*RegistryModelInfo, error) {
+	manifest, err := r.fetchManifest(ctx, modelName, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry info for %q: %w", modelName, err)
+	}
+
+	info := &RegistryModelInfo{
+		Name:   modelName,
+		Digest: manifest.digest,
+		Size:   manifest.totalSize(),
+		Layers: manifest.Layers,
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == MediaTypeProjector {
+			info.Capabilities = appendUnique(info.Capabilities, "vision")
+		}
+	}
+
+	if manifest.Config.Digest != "" {
+		u := r.repositoryURL(ModelName(modelName), false) + "/blobs/" + manifest.Config.Digest
+		body, err := r.get(ctx, u, "application/json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get registry info for %q: %w", modelName, err)
+		}
+
+		var config struct {
+			ModelFormat string `json:"model_format"`
+			ModelFamily string `json:"model_family"`
+			ModelType   string `json:"model_type"`
+			FileType    string `json:"file_type"`
+		}
+		if err := json.Unmarshal(body, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal model config: %w", err)
+		}
+		info.Format = config.ModelFormat
+		info.Family = config.ModelFamily
+		info.ParameterSize = config.ModelType
+		info.QuantizationLevel = config.FileType
+	}
+
+	return info, nil
+}
+
+// repositoryURL builds the registry URL of a model repository. Names without
+// a registry host resolve against the client's default registry.
+func (r *RegistryClient) repositoryURL(name ModelName, insecure bool) string {
+	base := r.registryURL
+	if registry := name.Registry(); registry != "" {
+		scheme := "https://"
+		if insecure {
+			scheme = "http://"
+		}
+		base = scheme + registry
+	}
+	return strings.TrimSuffix(base, "/") + "/v2/" + name.Namespace() + "/" + name.Model()
+}
+
+// manifestURL builds the registry manifest URL for a model.
+func (r *RegistryClient) manifestURL(modelName string, insecure bool) string {
+	name := ModelName(modelName)
+	return r.repositoryURL(name, insecure) + "/manifests/" + name.Tag()
+}
+
+// fetchManifest retrieves the registry manifest for a model.
+func (r *RegistryClient) fetchManifest(ctx context.Context, modelName string, insecure bool) (*registryManifest, error) {
+	body, err := r.get(ctx, r.manifestURL(modelName, insecure), "application/vnd.docker.distribution.manifest.v2+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest registryManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal registry manifest: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	manifest.digest = "sha256:" + hex.EncodeToString(sum[:])
+	return &manifest, nil
+}
+
+// get performs a GET request and returns the response body, turning non-2xx
+// responses into an OllamaError.
+func (r *RegistryClient) get(ctx context.Context, u, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute registry request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := readErrorBody(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("registry request failed with status %d and could not read response body: %w", resp.StatusCode, err)
+		}
+		return nil, parseErrorResponse(resp.StatusCode, body)
+	}
+
+	body, err := io.ReadAll(limitBody(resp.Body, r.maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry response: %w", err)
+	}
+	return body, nil
+}
+
+var (
+	searchTitlePattern      = regexp.MustCompile(`x-test-search-response-title[^>]*>([^<]+)<`)
+	searchCapabilityPattern = regexp.MustCompile(`x-test-capability[^>]*>([^<]+)<`)
+	searchSizePattern       = regexp.MustCompile(`x-test-size[^>]*>([^<]+)<`)
+	searchPullCountPattern  = regexp.MustCompile(`x-test-pull-count[^>]*>([^<]+)<`)
+)
+
+// parseSearchResults extracts models from the library search page, which
+// marks each result with an x-test-model attribute.
+func parseSearchResults(page string) []RegistryModel {
+	results := []RegistryModel{}
+	for _, item := range strings.Split(page, "x-test-model")[1:] {
+		title := searchTitlePattern.FindStringSubmatch(item)
+		if title == nil {
+			continue
+		}
+
+		model := RegistryModel{Name: cleanHTMLText(title[1])}
+		for _, m := range searchCapabilityPattern.FindAllStringSubmatch(item, -1) {
+			model.Capabilities = append(model.Capabilities, cleanHTMLText(m[1]))
+		}
+		for _, m := range searchSizePattern.FindAllStringSubmatch(item, -1) {
+			model.Sizes = append(model.Sizes, cleanHTMLText(m[1]))
+		}
+		if m := searchPullCountPattern.FindStringSubmatch(item); m != nil {
+			model.PullCount = cleanHTMLText(m[1])
+		}
+		results = append(results, model)
+	}
+	return results
+}
+
+// cleanHTMLText unescapes and trims text extracted from HTML markup.
+func cleanHTMLText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(s))
+}
@@ -0,0 +1,147 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSearchPage = `<ul>
+<li x-test-model class="flex">
+  <a href="/library/llama3.1"><span x-test-search-response-title>llama3.1</span></a>
+  <span x-test-capability class="badge">tools</span>
+  <span x-test-size class="badge">8b</span>
+  <span x-test-size class="badge">70b</span>
+  <span x-test-pull-count>95.2M</span>
+</li>
+<li x-test-model class="flex">
+  <a href="/library/llava"><span x-test-search-response-title>llava</span></a>
+  <span x-test-capability class="badge">vision</span>
+  <span x-test-size class="badge">7b</span>
+</li>
+</ul>`
+
+func setupMockRegistry() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			if r.URL.Query().Get("q") != "llama" {
+				w.Write([]byte("<ul></ul>"))
+				return
+			}
+			w.Write([]byte(testSearchPage))
+		case "/v2/library/llava/tags/list":
+			w.Write([]byte(`{"name":"library/llava","tags":["latest","7b","13b"]}`))
+		case "/v2/library/llava/manifests/7b":
+			w.Write([]byte(`{"config":{"digest":"sha256:cfg","size":100},"layers":[` +
+				`{"mediaType":"application/vnd.ollama.image.model","digest":"sha256:m","size":4000},` +
+				`{"mediaType":"application/vnd.ollama.image.projector","digest":"sha256:p","size":600}]}`))
+		case "/v2/library/llava/blobs/sha256:cfg":
+			w.Write([]byte(`{"model_format":"gguf","model_family":"llama","model_type":"7B","file_type":"Q4_0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRegistryClientSearch(t *testing.T) {
+	server := setupMockRegistry()
+	defer server.Close()
+
+	registry, err := NewRegistryClient(server.URL, server.URL)
+	assertNoError(t, err)
+
+	results, err := registry.Search(context.Background(), "llama")
+	assertNoError(t, err)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Name != "llama3.1" || len(results[0].Sizes) != 2 || results[0].PullCount != "95.2M" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if len(results[1].Capabilities) != 1 || results[1].Capabilities[0] != "vision" {
+		t.Errorf("Expected vision capability, got %+v", results[1])
+	}
+
+	results, err = registry.Search(context.Background(), "nothing")
+	assertNoError(t, err)
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %+v", results)
+	}
+}
+
+func TestRegistryClientTagsAndInfo(t *testing.T) {
+	server := setupMockRegistry()
+	defer server.Close()
+
+	registry, err := NewRegistryClient(server.URL, server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+
+	tags, err := registry.Tags(ctx, "llava")
+	assertNoError(t, err)
+	if len(tags) != 3 {
+		t.Errorf("Expected 3 tags, got %v", tags)
+	}
+
+	info, err := registry.Info(ctx, "llava:7b")
+	assertNoError(t, err)
+	if info.Size != 4700 {
+		t.Errorf("Expected size 4700, got %d", info.Size)
+	}
+	if info.Family != "llama" || info.ParameterSize != "7B" || info.QuantizationLevel != "Q4_0" {
+		t.Errorf("Unexpected config metadata: %+v", info)
+	}
+	if len(info.Capabilities) != 1 || info.Capabilities[0] != "vision" {
+		t.Errorf("Expected vision capability from projector layer, got %v", info.Capabilities)
+	}
+	if len(info.Digest) != len("sha256:")+64 {
+		t.Errorf("Expected manifest digest, got %q", info.Digest)
+	}
+
+	_, err = registry.Info(ctx, "missing")
+	if err == nil {
+		t.Errorf("Expected error for missing model")
+	}
+}
+
+func TestRegistryManifestURL(t *testing.T) {
+	registry, err := NewRegistryClient("", "")
+	assertNoError(t, err)
+
+	tests := []struct {
+		model    string
+		insecure bool
+		expected string
+	}{
+		{"llama2", false, "https://registry.ollama.ai/v2/library/llama2/manifests/latest"},
+		{"llama2:13b", false, "https://registry.ollama.ai/v2/library/llama2/manifests/13b"},
+		{"user/model:v1", false, "https://registry.ollama.ai/v2/user/model/manifests/v1"},
+		{"registry.local:5000/team/model", true, "http://registry.local:5000/v2/team/model/manifests/latest"},
+		{"example.com/model:q4", false, "https://example.com/v2/library/model/manifests/q4"},
+	}
+
+	for _, tt := range tests {
+		if got := registry.manifestURL(tt.model, tt.insecure); got != tt.expected {
+			t.Errorf("manifestURL(%q) = %q, expected %q", tt.model, got, tt.expected)
+		}
+	}
+}
+
+func TestRegistryMaxResponseBytes(t *testing.T) {
+	server := setupMockRegistry()
+	defer server.Close()
+
+	client, err := NewClientWithOptions("http://localhost:11434", WithMaxResponseBytes(32))
+	assertNoError(t, err)
+	client.registryURL = server.URL
+
+	_, err = client.Registry().Tags(context.Background(), "llava")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+	}
+}
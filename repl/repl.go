@@ -0,0 +1,237 @@
+// Package repl implements an interactive terminal chat on top of a
+// gollama.ChatSession.
+//
+// Replies are streamed as they are generated. Input spanning several lines
+// is entered between triple quotes, or by ending lines with a backslash.
+// Lines starting with a slash are commands:
+//
+//	/model [NAME]     show or change the model
+//	/system [PROMPT]  show or change the system prompt
+//	/save FILE        save the session to a file
+//	/load FILE        load a session from a file
+//	/clear            forget the conversation
+//	/help             list the commands
+//	/bye              leave the chat
+//
+// Example:
+//
+//	session := gollama.NewChatSession(client, "llama3")
+//	err := repl.Run(ctx, session, os.Stdin, os.Stdout)
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/astrica1/gollama"
+)
+
+// Prompts printed before each line of input.
+const (
+	Prompt             = ">>> "
+	ContinuationPrompt = "... "
+)
+
+// errExit is returned by a command that ends the chat.
+var errExit = errors.New("exit")
+
+// repl holds the state of a running chat.
+type repl struct {
+	session *gollama.ChatSession
+	in      *bufio.Reader
+	out     io.Writer
+}
+
+// Run reads messages from in and prints the streamed replies to out until in
+// is exhausted, /bye is entered or ctx is done. Failed requests are reported
+// to out and do not end the chat.
+func Run(ctx context.Context, session *gollama.ChatSession, in io.Reader, out io.Writer) error {
+	r := &repl{session: session, in: bufio.NewReader(in), out: out}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		input, err := r.read()
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(input, "/") {
+			err = r.command(input)
+		} else {
+			err = r.send(ctx, input)
+		}
+		if err == errExit {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+// read reads one message. A line starting with triple quotes begins a block
+// that ends with the next line ending in triple quotes, and a line ending in
+// a backslash continues on the next line.
+func (r *repl) read() (string, error) {
+	fmt.Fprint(r.out, Prompt)
+	line, err := r.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if rest, ok := strings.CutPrefix(line, `"""`); ok {
+		var lines []string
+		for {
+			if body, ok := strings.CutSuffix(rest, `"""`); ok {
+				lines = append(lines, body)
+				return strings.TrimSpace(strings.Join(lines, "\n")), nil
+			}
+			lines = append(lines, rest)
+
+			fmt.Fprint(r.out, ContinuationPrompt)
+			if rest, err = r.readLine(); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var lines []string
+	for {
+		body, more := strings.CutSuffix(line, `\`)
+		lines = append(lines, body)
+		if !more {
+			return strings.Join(lines, "\n"), nil
+		}
+
+		fmt.Fprint(r.out, ContinuationPrompt)
+		if line, err = r.readLine(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// readLine reads a line without its line ending. A final line without a line
+// ending is returned before io.EOF.
+func (r *repl) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// send sends a message and streams the reply.
+func (r *repl) send(ctx context.Context, input string) error {
+	err := r.session.SendStream(ctx, input, func(resp *gollama.ChatResponse) {
+		fmt.Fprint(r.out, resp.Message.Content)
+	})
+	fmt.Fprintln(r.out)
+	return err
+}
+
+// command runs a slash command.
+func (r *repl) command(input string) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(input), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/model":
+		if arg == "" {
+			fmt.Fprintf(r.out, "model: %s\n", r.session.Model)
+			return nil
+		}
+		r.session.Model = arg
+		fmt.Fprintf(r.out, "switched to %s\n", arg)
+	case "/system":
+		if arg == "" {
+			fmt.Fprintf(r.out, "system: %s\n", r.session.System)
+			return nil
+		}
+		r.session.System = arg
+		fmt.Fprintln(r.out, "system prompt set")
+	case "/save":
+		if arg == "" {
+			return fmt.Errorf("usage: /save FILE")
+		}
+		return r.save(arg)
+	case "/load":
+		if arg == "" {
+			return fmt.Errorf("usage: /load FILE")
+		}
+		return r.load(arg)
+	case "/clear":
+		r.session.Reset()
+		fmt.Fprintln(r.out, "conversation cleared")
+	case "/help", "/?":
+		r.help()
+	case "/bye", "/exit", "/quit":
+		return errExit
+	default:
+		return fmt.Errorf("unknown command %s, type /help for a list", name)
+	}
+	return nil
+}
+
+// save writes the session to a file.
+func (r *repl) save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.session.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "saved %d messages to %s\n", len(r.session.Messages), path)
+	return nil
+}
+
+// load replaces the session with one from a file.
+func (r *repl) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.session.Load(f); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "loaded %d messages with %s\n", len(r.session.Messages), r.session.Model)
+	return nil
+}
+
+// help lists the commands.
+func (r *repl) help() {
+	fmt.Fprint(r.out, `Commands:
+  /model [NAME]     show or change the model
+  /system [PROMPT]  show or change the system prompt
+  /save FILE        save the session to a file
+  /load FILE        load a session from a file
+  /clear            forget the conversation
+  /bye              leave the chat
+
+Enter text between """ to write several lines, or end a line with \ to continue it.
+`)
+}
@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+func TestRun(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req.Model)
+
+		last := req.Messages[len(req.Messages)-1].Content
+		json.NewEncoder(w).Encode(gollama.ChatResponse{Message: gollama.Message{Role: "assistant", Content: "<" + last + ">"}})
+		json.NewEncoder(w).Encode(gollama.ChatResponse{Done: true})
+	}))
+	defer server.Close()
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	input := strings.Join([]string{
+		"hello",
+		`"""first`,
+		`second"""`,
+		`one \`,
+		"two",
+		"/model mistral",
+		"again",
+		"/system be brief",
+		"/save " + path,
+		"/clear",
+		"/frobnicate",
+		"/load " + path,
+		"/bye",
+		"never sent",
+	}, "\n")
+
+	session := gollama.NewChatSession(client, "llama2")
+	var out bytes.Buffer
+	if err := Run(context.Background(), session, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"<hello>\n",
+		"<first\nsecond>\n",
+		"<one \ntwo>\n",
+		"switched to mistral",
+		"saved 8 messages",
+		"conversation cleared",
+		"unknown command /frobnicate",
+		"loaded 8 messages with mistral",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "never sent") {
+		t.Error("Expected /bye to end the chat")
+	}
+
+	if strings.Join(models, ",") != "llama2,llama2,llama2,mistral" {
+		t.Errorf("Expected model switch, got %v", models)
+	}
+	if session.System != "be brief" || len(session.Messages) != 8 {
+		t.Errorf("Expected loaded session, got %+v", session)
+	}
+}
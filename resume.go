@@ -0,0 +1,107 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// resumeBackoff is the delay before the first resumption of a stream. Each
+// further attempt waits that much longer.
+var resumeBackoff = 500 * time.Millisecond
+
+// WithResume makes GenerateStream and ChatStream resume a stream that is
+// cut off by a transient network failure, up to maxAttempts times. The
+// request is reissued primed with the output received so far: a generation
+// continues from its prompt rendered with the request's Template and System,
+// or else the model's (see RenderTemplate), followed by the output, and a
+// chat continues from its history followed by the partial assistant
+// message. The callback keeps receiving chunks as if the stream had not been
+// interrupted, and the statistics of the final chunk cover only the last
+// attempt.
+//
+// Requests that enable thinking, use a Suffix, carry Context tokens or hold
+// images from ImageReader are not resumed, since the server ignores Context
+// for the raw prompt a resumption sends. Chat resumption relies on the server
+// continuing a final assistant message, which recent versions of Ollama do.
+func WithResume(maxAttempts int) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.resumeAttempts = maxAttempts
+	}
+}
+
+// shouldResume reports whether a stream that ended with err after attempt
+// resumptions should be resumed, after waiting out the backoff. A nil err
+// means that the stream ended without a final chunk.
+func shouldResume(ctx context.Context, err error, attempt, maxAttempts int) bool {
+	if attempt >= maxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	// Timeouts and cancellations of the call, which also satisfy net.Error,
+	// are not network failures
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrHeaderTimeout) || errors.Is(err, ErrStreamTimeout) {
+		return false
+	}
+
+	var netErr net.Error
+	transient := err == nil ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, ErrIdleTimeout) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr)
+	if !transient {
+		return false
+	}
+
+	timer := time.NewTimer(resumeBackoff * time.Duration(attempt+1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// resumeGenerateRequest returns req primed with the output generated so far.
+func (c *Client) resumeGenerateRequest(ctx context.Context, req *GenerateRequest, output string) (*GenerateRequest, error) {
+	resumed := *req
+	if !req.Raw {
+		tmpl, system := req.Template, req.System
+		if tmpl == "" || system == "" {
+			info, err := c.Show(ctx, req.Model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render template of model %q: %w", req.Model, err)
+			}
+			if tmpl == "" {
+				tmpl = info.Template
+			}
+			if system == "" {
+				system = info.System
+			}
+		}
+		prompt, err := ExecuteTemplate(tmpl, system, []Message{{Role: "user", Content: req.Prompt}})
+		if err != nil {
+			return nil, err
+		}
+		resumed.Prompt = prompt
+		resumed.Raw = true
+		resumed.Template = ""
+		resumed.System = ""
+	}
+	resumed.Prompt += output
+	return &resumed, nil
+}
+
+// resumeChatRequest returns req with the partial assistant message appended.
+func resumeChatRequest(req *ChatRequest, output string) *ChatRequest {
+	resumed := *req
+	resumed.Messages = append(append([]Message(nil), req.Messages...), Message{Role: "assistant", Content: output})
+	return &resumed
+}
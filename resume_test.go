@@ -0,0 +1,208 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cutStream writes the first lines of a streaming response and then drops
+// the connection.
+func cutStream(t *testing.T, w http.ResponseWriter, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		w.Write([]byte(line + "\n"))
+	}
+	w.(http.Flusher).Flush()
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestClientResumeGenerate(t *testing.T) {
+	defer func(d time.Duration) { resumeBackoff = d }(resumeBackoff)
+	resumeBackoff = 0
+
+	var prompts []string
+	var raw []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/show" {
+			w.Write([]byte(`{"template":"[INST] {{ .Prompt }} [/INST]"}`))
+			return
+		}
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+		raw = append(raw, req.Raw)
+		if len(prompts) == 1 {
+			cutStream(t, w, `{"response":"Once upon"}`, `{"response":" a time"}`)
+			return
+		}
+		w.Write([]byte(`{"response":" there was"}` + "\n"))
+		w.Write([]byte(`{"response":"","done":true,"eval_count":2}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var streamed strings.Builder
+	resp, err := client.GenerateStreamCollect(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Tell a story"}, func(r *GenerateResponse) {
+		streamed.WriteString(r.Response)
+	}, WithResume(2))
+	assertNoError(t, err)
+
+	if resp.Response != "Once upon a time there was" || streamed.String() != resp.Response {
+		t.Errorf("Expected the stitched response, got %q (streamed %q)", resp.Response, streamed.String())
+	}
+	if len(prompts) != 2 || prompts[1] != "[INST] Tell a story [/INST]Once upon a time" || !raw[1] {
+		t.Errorf("Expected a raw resumed prompt, got %q (raw %v)", prompts, raw)
+	}
+}
+
+func TestClientResumeGenerateOverrides(t *testing.T) {
+	defer func(d time.Duration) { resumeBackoff = d }(resumeBackoff)
+	resumeBackoff = 0
+
+	var requests []GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/show" {
+			w.Write([]byte(`{"template":"[INST] {{ .System }} {{ .Prompt }} [/INST]","system":"Be brief."}`))
+			return
+		}
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			cutStream(t, w, `{"response":"Once"}`)
+			return
+		}
+		w.Write([]byte(`{"response":" upon","done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	t.Run("System", func(t *testing.T) {
+		requests = nil
+		req := &GenerateRequest{Model: "llama2", Prompt: "Tell a story", System: "You are a poet."}
+		_, err := client.GenerateStreamCollect(context.Background(), req, nil, WithResume(1))
+		assertNoError(t, err)
+		if len(requests) != 2 || requests[1].Prompt != "[INST] You are a poet. Tell a story [/INST]Once" || requests[1].System != "" || !requests[1].Raw {
+			t.Errorf("Expected the request's system prompt to be rendered, got %+v", requests)
+		}
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		requests = nil
+		req := &GenerateRequest{Model: "llama2", Prompt: "Tell a story", Template: "{{ .System }}|{{ .Prompt }}|"}
+		_, err := client.GenerateStreamCollect(context.Background(), req, nil, WithResume(1))
+		assertNoError(t, err)
+		if len(requests) != 2 || requests[1].Prompt != "Be brief.|Tell a story|Once" || requests[1].Template != "" {
+			t.Errorf("Expected the request's template to be rendered, got %+v", requests)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		requests = nil
+		req := &GenerateRequest{Model: "llama2", Prompt: "And then?", Context: []int{1, 2, 3}}
+		_, err := client.GenerateStreamCollect(context.Background(), req, nil, WithResume(3))
+		if err == nil || len(requests) != 1 {
+			t.Errorf("Expected a single failed attempt with context tokens, got %d (error %v)", len(requests), err)
+		}
+		if len(requests) > 0 && len(requests[0].Context) != 3 {
+			t.Errorf("Expected the context tokens to be sent, got %v", requests[0].Context)
+		}
+	})
+}
+
+func TestClientResumeTimeout(t *testing.T) {
+	defer func(d time.Duration) { resumeBackoff = d }(resumeBackoff)
+	resumeBackoff = 0
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Write([]byte(`{"response":"Once"}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	for name, opt := range map[string]RequestOption{
+		"Request timeout": WithRequestTimeout(100 * time.Millisecond),
+		"Stream timeout":  WithStreamTimeout(100 * time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			err := client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Tell a story"}, func(*GenerateResponse) {}, opt, WithResume(3))
+			if err == nil {
+				t.Fatal("Expected the timed-out stream to fail")
+			}
+			if n := atomic.LoadInt32(&attempts); n != 1 {
+				t.Errorf("Expected a timed-out stream not to be resumed, got %d attempts", n)
+			}
+		})
+	}
+}
+
+func TestClientResumeChat(t *testing.T) {
+	defer func(d time.Duration) { resumeBackoff = d }(resumeBackoff)
+	resumeBackoff = 0
+
+	var requests int32
+	var last ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		json.NewDecoder(r.Body).Decode(&last)
+		if n <= 2 {
+			cutStream(t, w, `{"message":{"role":"assistant","content":"Hel"}}`)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"lo!"},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	req := &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	resp, err := client.ChatStreamCollect(context.Background(), req, nil, WithResume(3))
+	assertNoError(t, err)
+	if resp.Message.Content != "HelHello!" {
+		t.Errorf("Expected the chunks of every attempt, got %q", resp.Message.Content)
+	}
+	if n := len(last.Messages); n != 2 || last.Messages[1].Role != "assistant" || last.Messages[1].Content != "HelHel" {
+		t.Errorf("Expected the partial assistant message to be appended, got %+v", last.Messages)
+	}
+	if len(req.Messages) != 1 {
+		t.Errorf("Expected the request to be unchanged, got %+v", req.Messages)
+	}
+
+	// Without WithResume the cut is not retried
+	atomic.StoreInt32(&requests, 0)
+	_, err = client.ChatStreamCollect(context.Background(), req, nil)
+	if err == nil && atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected a single attempt, got %d", requests)
+	}
+
+	// Image readers cannot be sent again
+	atomic.StoreInt32(&requests, 0)
+	req = &ChatRequest{Model: "llava", Messages: []Message{{Role: "user", Content: "What is this?", ImageSources: []ImageSource{ImageReader(strings.NewReader("png"))}}}}
+	_, err = client.ChatStreamCollect(context.Background(), req, nil, WithResume(3))
+	if err == nil || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected a single failed attempt with an image reader, got %d (error %v)", requests, err)
+	}
+}
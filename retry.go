@@ -0,0 +1,237 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation names a class of API calls for per-operation configuration,
+// such as retry policies.
+type Operation string
+
+// Operations of the Ollama API. OpDefault stands for every operation that
+// has no configuration of its own.
+const (
+	OpDefault    Operation = "default"
+	OpList       Operation = "list"
+	OpShow       Operation = "show"
+	OpPS         Operation = "ps"
+	OpCopy       Operation = "copy"
+	OpDelete     Operation = "delete"
+	OpPull       Operation = "pull"
+	OpPush       Operation = "push"
+	OpCreate     Operation = "create"
+	OpGenerate   Operation = "generate"
+	OpChat       Operation = "chat"
+	OpEmbeddings Operation = "embeddings"
+	OpTokenize   Operation = "tokenize"
+	OpBlobs      Operation = "blobs"
+)
+
+// operationPaths maps API endpoint paths to their operations.
+var operationPaths = map[string]Operation{
+	"/api/tags":       OpList,
+	"/api/show":       OpShow,
+	"/api/ps":         OpPS,
+	"/api/copy":       OpCopy,
+	"/api/delete":     OpDelete,
+	"/api/pull":       OpPull,
+	"/api/push":       OpPush,
+	"/api/create":     OpCreate,
+	"/api/generate":   OpGenerate,
+	"/api/chat":       OpChat,
+	"/api/embeddings": OpEmbeddings,
+	"/api/tokenize":   OpTokenize,
+}
+
+// pathOperation returns the operation of an API endpoint path, or
+// OpDefault for paths that gollama does not know.
+func pathOperation(path string) Operation {
+	if op, ok := operationPaths[path]; ok {
+		return op
+	}
+	if strings.HasPrefix(path, "/api/blobs/") {
+		return OpBlobs
+	}
+	return OpDefault
+}
+
+// RetryPolicy describes how a failed request is retried. Only failures that
+// happen before a response is received are retried: connection errors and
+// responses with status 429, 502, 503 or 504. A response that fails once
+// it is being read, such as a stream cut halfway, is never retried; see
+// WithResume for generation and chat streams.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Zero or one disables retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled before each
+	// further retry. A Retry-After header of a 429 or 503 response
+	// lengthens the delay if it asks for more.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, if greater than zero.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay before the given retry, counting from one.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// WithRetryPolicies sets the retry policy of each operation. Operations
+// missing from policies use the policy of OpDefault, if any, and are not
+// retried otherwise, which is the default for every operation. Calling it
+// again adds to or replaces the policies set before.
+//
+// Example:
+//
+//	client, err := gollama.NewClientWithOptions(host, gollama.WithRetryPolicies(
+//		map[gollama.Operation]gollama.RetryPolicy{
+//			gollama.OpList:       {MaxAttempts: 5, Backoff: 100 * time.Millisecond},
+//			gollama.OpShow:       {MaxAttempts: 5, Backoff: 100 * time.Millisecond},
+//			gollama.OpEmbeddings: {MaxAttempts: 5, Backoff: 100 * time.Millisecond},
+//			gollama.OpPull:       {MaxAttempts: 3, Backoff: 30 * time.Second},
+//		},
+//	))
+//
+// Requests holding ImageReader sources must not be retried, since their
+// images can only be read once.
+func WithRetryPolicies(policies map[Operation]RetryPolicy) ClientOption {
+	return func(c *Client) {
+		if c.retryPolicies == nil {
+			c.retryPolicies = make(map[Operation]RetryPolicy, len(policies))
+		}
+		for op, policy := range policies {
+			c.retryPolicies[op] = policy
+		}
+	}
+}
+
+// retryPolicy returns the retry policy of op.
+func (c *Client) retryPolicy(op Operation) RetryPolicy {
+	if policy, ok := c.retryPolicies[op]; ok {
+		return policy
+	}
+	return c.retryPolicies[OpDefault]
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay asked for by the Retry-After header of resp,
+// in seconds, or zero.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// discard drains and closes a response body so that its connection can be
+// reused.
+func discard(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryableError reports whether a failed attempt is worth retrying, which
+// it is unless ctx ended it.
+func retryableError(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// requestError wraps a failure to build a request, which send returns as is
+// rather than as a failure to execute it.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// send builds and executes a request, retrying it as allowed by the retry
+// policy of its operation. The response status is not checked, except to
+// decide whether to retry.
+func (c *Client) send(ctx context.Context, method, path string, reqBody interface{}) (*http.Response, error) {
+	policy := c.retryPolicy(pathOperation(path))
+	if sendsOnce(reqBody) {
+		policy.MaxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, method, path, reqBody)
+		if err != nil {
+			return nil, &requestError{err}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if attempt >= policy.MaxAttempts {
+			return resp, err
+		}
+
+		delay := policy.delay(attempt)
+		retry := Event{Type: EventRetry, Method: method, Path: path, Attempt: attempt, Err: err}
+		switch {
+		case err != nil:
+			if !retryableError(ctx, err) {
+				return nil, err
+			}
+		case retryableStatus(resp.StatusCode):
+			if after := retryAfter(resp); after > delay {
+				delay = after
+			}
+			retry.Status = resp.StatusCode
+			discard(resp.Body)
+		default:
+			return resp, nil
+		}
+		retry.Duration = delay
+		c.emit(retry)
+
+		if sleepContext(ctx, delay) != nil {
+			// Report the last failure rather than the cancellation
+			if err != nil {
+				return nil, err
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetryPolicies(t *testing.T) {
+	var failures, calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/chat":
+			w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":true}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithRetryPolicies(map[Operation]RetryPolicy{
+		OpList: {MaxAttempts: 3, Backoff: time.Millisecond},
+	}))
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	chat := &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	tests := []struct {
+		name        string
+		failures    int32
+		call        func() error
+		expectCalls int32
+		expectError bool
+	}{
+		{
+			name:        "List is retried",
+			failures:    2,
+			call:        func() error { _, err := client.List(ctx); return err },
+			expectCalls: 3,
+		},
+		{
+			name:        "List gives up after its attempts",
+			failures:    3,
+			call:        func() error { _, err := client.List(ctx); return err },
+			expectCalls: 3,
+			expectError: true,
+		},
+		{
+			name:        "Chat is not retried",
+			failures:    1,
+			call:        func() error { _, err := client.Chat(ctx, chat); return err },
+			expectCalls: 1,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&failures, tt.failures)
+			atomic.StoreInt32(&calls, 0)
+
+			err := tt.call()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.expectCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectCalls, got)
+			}
+		})
+	}
+
+	// OpDefault applies to operations without a policy of their own
+	client, err = NewClientWithOptions(server.URL, WithRetryPolicies(map[Operation]RetryPolicy{
+		OpDefault: {MaxAttempts: 2},
+	}))
+	assertNoError(t, err)
+
+	atomic.StoreInt32(&failures, 1)
+	atomic.StoreInt32(&calls, 0)
+	_, err = client.Chat(ctx, chat)
+	assertNoError(t, err)
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := policy.delay(i + 1); got != want {
+			t.Errorf("Expected delay %v before retry %d, got %v", want, i+1, got)
+		}
+	}
+}
+
+func TestClientRetryImageReader(t *testing.T) {
+	var calls int32
+	var images [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		images = append(images, req.Images)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"response":"a cat","done":true}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, WithRetryPolicies(map[Operation]RetryPolicy{
+		OpGenerate: {MaxAttempts: 2},
+	}))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	// A reader cannot be sent again, so the request is not retried
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "llava", Prompt: "What is this?", ImageSources: []ImageSource{ImageReader(strings.NewReader("png"))}})
+	assertErrorContains(t, err, "busy")
+	if calls != 1 {
+		t.Errorf("Expected a single attempt with an image reader, got %d", calls)
+	}
+
+	// Files are read again for each attempt
+	path := filepath.Join(t.TempDir(), "cat.png")
+	assertNoError(t, os.WriteFile(path, []byte("png"), 0o644))
+	atomic.StoreInt32(&calls, 0)
+	images = nil
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "llava", Prompt: "What is this?", ImageSources: []ImageSource{ImageFile(path)}})
+	assertNoError(t, err)
+	if calls != 2 || len(images) != 2 || len(images[1]) != 1 || images[1][0] != "cG5n" {
+		t.Errorf("Expected the image to be sent with the retry, got %v", images)
+	}
+}
@@ -0,0 +1,219 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// LatencyClass describes how quickly a caller needs a response, for routing
+// with a Router. Set it per call with WithLatencyClass.
+type LatencyClass int
+
+const (
+	// LatencyAny is the default class and matches any route.
+	LatencyAny LatencyClass = iota
+	// LatencyInteractive requests are waited on by a user.
+	LatencyInteractive
+	// LatencyBatch requests run in the background.
+	LatencyBatch
+)
+
+// WithLatencyClass tags a call with a latency class, which a Router uses to
+// pick a model. Other methods ignore it.
+func WithLatencyClass(class LatencyClass) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.latency = class
+	}
+}
+
+// RouteInfo describes the characteristics of a request that routes match on.
+type RouteInfo struct {
+	// PromptLength is the length of the prompt in bytes; for chats, the
+	// combined length of all messages.
+	PromptLength int
+	// HasImages is true if the request includes images.
+	HasImages bool
+	// JSONOutput is true if the request sets a Format.
+	JSONOutput bool
+	// Latency is the class set with WithLatencyClass.
+	Latency LatencyClass
+}
+
+// Route sends matching requests to Model, trying Fallbacks in order if it
+// fails. All conditions must hold for a route to match; zero values match
+// any request.
+type Route struct {
+	// Name identifies the route in RouteDecision.
+	Name      string
+	Model     string
+	Fallbacks []string
+
+	// MinPromptLength and MaxPromptLength bound the prompt length. A
+	// MaxPromptLength of zero means no upper bound.
+	MinPromptLength int
+	MaxPromptLength int
+	// RequireImages matches only requests with images.
+	RequireImages bool
+	// RequireJSON matches only requests that set a Format.
+	RequireJSON bool
+	// Latency matches only requests of the given class, unless LatencyAny.
+	Latency LatencyClass
+	// Match, if set, is an additional custom condition.
+	Match func(RouteInfo) bool
+}
+
+// matches reports whether the route applies to a request.
+func (r *Route) matches(info RouteInfo) bool {
+	switch {
+	case info.PromptLength < r.MinPromptLength:
+		return false
+	case r.MaxPromptLength > 0 && info.PromptLength > r.MaxPromptLength:
+		return false
+	case r.RequireImages && !info.HasImages:
+		return false
+	case r.RequireJSON && !info.JSONOutput:
+		return false
+	case r.Latency != LatencyAny && r.Latency != info.Latency:
+		return false
+	case r.Match != nil && !r.Match(info):
+		return false
+	}
+	return true
+}
+
+// RouteDecision records how a Router served a request.
+type RouteDecision struct {
+	// Route is the name of the matched route, or empty for the default.
+	Route string
+	// Model is the model that produced the response.
+	Model string
+	// Attempts maps each model that was tried and failed to its error.
+	Attempts map[string]error
+}
+
+// Router picks a model for each request using declarative rules, so that an
+// application can, for example, send short interactive queries to a small
+// model and long analyses to a large one:
+//
+//	router := gollama.NewRouter(client, "llama3:8b",
+//		gollama.Route{Name: "vision", RequireImages: true, Model: "llava"},
+//		gollama.Route{Name: "quick", MaxPromptLength: 500, Latency: gollama.LatencyInteractive, Model: "llama3.2:3b"},
+//		gollama.Route{Name: "analysis", MinPromptLength: 4000, Model: "llama3:70b", Fallbacks: []string{"llama3:8b"}},
+//	)
+//	resp, decision, err := router.Generate(ctx, req, gollama.WithLatencyClass(gollama.LatencyInteractive))
+//
+// Routes are evaluated in order and the first match wins; requests that
+// match no route use the default model. The Model field of routed requests
+// is ignored.
+type Router struct {
+	client       *Client
+	defaultModel string
+	fallbacks    []string
+	routes       []Route
+}
+
+// NewRouter creates a router that uses defaultModel for requests no route
+// matches.
+func NewRouter(client *Client, defaultModel string, routes ...Route) *Router {
+	return &Router{
+		client:       client,
+		defaultModel: defaultModel,
+		routes:       routes,
+	}
+}
+
+// WithFallbacks returns a copy of the router that tries the given models in
+// order when the default model fails.
+func (r *Router) WithFallbacks(models ...string) *Router {
+	cp := *r
+	cp.fallbacks = models
+	return &cp
+}
+
+// Select returns the name of the matching route, empty for the default, and
+// the models to try for a request, in order.
+func (r *Router) Select(info RouteInfo) (string, []string) {
+	for i := range r.routes {
+		route := &r.routes[i]
+		if route.matches(info) {
+			return route.Name, append([]string{route.Model}, route.Fallbacks...)
+		}
+	}
+	return "", append([]string{r.defaultModel}, r.fallbacks...)
+}
+
+// Generate routes a generation request and returns the response along with
+// the routing decision.
+func (r *Router) Generate(ctx context.Context, req *GenerateRequest, opts ...RequestOption) (*GenerateResponse, *RouteDecision, error) {
+	if req == nil {
+		return nil, nil, fmt.Errorf("generate request cannot be nil")
+	}
+
+	info := RouteInfo{
+		PromptLength: len(req.Prompt),
+		HasImages:    len(req.Images)+len(req.ImageSources) > 0,
+		JSONOutput:   req.Format != nil,
+		Latency:      newRequestConfig(opts).latency,
+	}
+
+	var resp *GenerateResponse
+	decision, err := r.try(ctx, info, func(model string) error {
+		reqCopy := *req
+		reqCopy.Model = model
+		var err error
+		resp, err = r.client.Generate(ctx, &reqCopy, opts...)
+		return err
+	})
+	return resp, decision, err
+}
+
+// Chat routes a chat request and returns the response along with the routing
+// decision.
+func (r *Router) Chat(ctx context.Context, req *ChatRequest, opts ...RequestOption) (*ChatResponse, *RouteDecision, error) {
+	if req == nil {
+		return nil, nil, fmt.Errorf("chat request cannot be nil")
+	}
+
+	info := RouteInfo{
+		JSONOutput: req.Format != nil,
+		Latency:    newRequestConfig(opts).latency,
+	}
+	for _, msg := range req.Messages {
+		info.PromptLength += len(msg.Content)
+		info.HasImages = info.HasImages || len(msg.Images)+len(msg.ImageSources) > 0
+	}
+
+	var resp *ChatResponse
+	decision, err := r.try(ctx, info, func(model string) error {
+		reqCopy := *req
+		reqCopy.Model = model
+		var err error
+		resp, err = r.client.Chat(ctx, &reqCopy, opts...)
+		return err
+	})
+	return resp, decision, err
+}
+
+// try calls fn with each selected model until one succeeds. Cancellation of
+// ctx, and moderation blocks, are not retried with another model.
+func (r *Router) try(ctx context.Context, info RouteInfo, fn func(model string) error) (*RouteDecision, error) {
+	name, models := r.Select(info)
+	decision := &RouteDecision{Route: name}
+
+	var err error
+	for _, model := range models {
+		if err = fn(model); err == nil {
+			decision.Model = model
+			return decision, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrContentBlocked) {
+			break
+		}
+		if decision.Attempts == nil {
+			decision.Attempts = make(map[string]error)
+		}
+		decision.Attempts[model] = err
+	}
+	return decision, err
+}
@@ -0,0 +1,80 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterSelect(t *testing.T) {
+	router := NewRouter(nil, "llama3:8b",
+		Route{Name: "vision", RequireImages: true, Model: "llava"},
+		Route{Name: "quick", MaxPromptLength: 100, Latency: LatencyInteractive, Model: "llama3.2:3b"},
+		Route{Name: "json", RequireJSON: true, Model: "qwen2.5"},
+		Route{Name: "analysis", MinPromptLength: 1000, Model: "llama3:70b", Fallbacks: []string{"llama3:8b"}},
+	).WithFallbacks("mistral")
+
+	tests := []struct {
+		name   string
+		info   RouteInfo
+		route  string
+		models []string
+	}{
+		{"Images", RouteInfo{PromptLength: 50, HasImages: true}, "vision", []string{"llava"}},
+		{"Short interactive", RouteInfo{PromptLength: 50, Latency: LatencyInteractive}, "quick", []string{"llama3.2:3b"}},
+		{"Short batch", RouteInfo{PromptLength: 50, Latency: LatencyBatch}, "", []string{"llama3:8b", "mistral"}},
+		{"JSON output", RouteInfo{PromptLength: 5000, JSONOutput: true}, "json", []string{"qwen2.5"}},
+		{"Long prompt", RouteInfo{PromptLength: 5000}, "analysis", []string{"llama3:70b", "llama3:8b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, models := router.Select(tt.info)
+			if route != tt.route {
+				t.Errorf("Expected route %q, got %q", tt.route, route)
+			}
+			if strings.Join(models, ",") != strings.Join(tt.models, ",") {
+				t.Errorf("Expected models %v, got %v", tt.models, models)
+			}
+		})
+	}
+}
+
+func TestRouterFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "llama3:70b" {
+			http.Error(w, `{"error":"model requires more system memory"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Model: req.Model, Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	router := NewRouter(client, "llama3:8b",
+		Route{Name: "analysis", MinPromptLength: 20, Model: "llama3:70b", Fallbacks: []string{"llama3:8b"}},
+	)
+	ctx := context.Background()
+
+	resp, decision, err := router.Chat(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "Analyze this long document please"}}})
+	assertNoError(t, err)
+	if resp.Model != "llama3:8b" || decision.Model != "llama3:8b" || decision.Route != "analysis" {
+		t.Errorf("Expected analysis route to fall back to llama3:8b, got %+v", decision)
+	}
+	if _, ok := decision.Attempts["llama3:70b"]; !ok || len(decision.Attempts) != 1 {
+		t.Errorf("Expected the failed attempt to be recorded, got %v", decision.Attempts)
+	}
+
+	_, decision, err = router.Generate(ctx, &GenerateRequest{Prompt: "Hi"})
+	assertNoError(t, err)
+	if decision.Route != "" || decision.Model != "llama3:8b" || len(decision.Attempts) != 0 {
+		t.Errorf("Expected short prompt to use the default model, got %+v", decision)
+	}
+}
@@ -0,0 +1,147 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Candidate is one of the generations made by GenerateN.
+type Candidate struct {
+	// Seed is the seed the candidate was generated with.
+	Seed     int
+	Response *GenerateResponse
+	// Err is set if the generation failed, in which case Response is nil.
+	Err      error
+	Duration time.Duration
+	// Score is set by GenerateNOptions.Score.
+	Score float64
+	// Rank is the position assigned by the score or the judge, starting at
+	// 1, or 0 if the candidates were not ranked.
+	Rank int
+}
+
+// GenerateNOptions holds optional settings for GenerateNWithOptions.
+type GenerateNOptions struct {
+	// Concurrency is the number of generations run at once. Defaults to
+	// all of them.
+	Concurrency int
+	// Score, if set, scores each successful candidate; higher is better.
+	// It takes precedence over JudgeModel.
+	Score func(*GenerateResponse) float64
+	// JudgeModel, if set, is asked to rank the successful candidates from
+	// best to worst, as in EnsembleOptions.
+	JudgeModel    string
+	JudgeCriteria string
+	// RequestOptions are passed through to every call.
+	RequestOptions []RequestOption
+}
+
+// GenerateN runs n generations of the same request concurrently, each with a
+// different seed, and returns all candidates in the order they were
+// started. Seeds count up from the request's "seed" option, or from a
+// random seed if it has none. Failures of individual generations are
+// reported in their candidates; an error is only returned if every
+// generation failed.
+func (c *Client) GenerateN(ctx context.Context, req *GenerateRequest, n int) ([]Candidate, error) {
+	return c.GenerateNWithOptions(ctx, req, n, nil)
+}
+
+// GenerateNWithOptions behaves like GenerateN, applying the given
+// GenerateNOptions. With a score function or judge model, candidates are
+// sorted best first, with failed and unranked candidates last. If ranking
+// fails, the unsorted candidates are returned along with the error.
+func (c *Client) GenerateNWithOptions(ctx context.Context, req *GenerateRequest, n int, opts *GenerateNOptions) ([]Candidate, error) {
+	if req == nil {
+		return nil, fmt.Errorf("generate request cannot be nil")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("n must be at least 1")
+	}
+	if opts == nil {
+		opts = &GenerateNOptions{}
+	}
+
+	seed, ok := optionInt(req.Options, "seed")
+	if !ok {
+		seed = rand.Intn(1 << 30)
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 || concurrency > n {
+		concurrency = n
+	}
+	sem := make(chan struct{}, concurrency)
+
+	candidates := make([]Candidate, n)
+	var wg sync.WaitGroup
+	for i := range candidates {
+		candidates[i].Seed = seed + i
+		wg.Add(1)
+		go func(candidate *Candidate) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			reqCopy := *req
+			reqCopy.Options = make(Options, len(req.Options)+1)
+			for k, v := range req.Options {
+				reqCopy.Options[k] = v
+			}
+			reqCopy.Options["seed"] = candidate.Seed
+
+			start := time.Now()
+			candidate.Response, candidate.Err = c.Generate(ctx, &reqCopy, opts.RequestOptions...)
+			candidate.Duration = time.Since(start)
+		}(&candidates[i])
+	}
+	wg.Wait()
+
+	var succeeded []*Candidate
+	for i := range candidates {
+		if candidates[i].Err == nil {
+			succeeded = append(succeeded, &candidates[i])
+		}
+	}
+	if len(succeeded) == 0 {
+		return candidates, fmt.Errorf("all %d generations failed: %w", n, candidates[0].Err)
+	}
+
+	switch {
+	case opts.Score != nil:
+		for _, candidate := range succeeded {
+			candidate.Score = opts.Score(candidate.Response)
+		}
+		sort.SliceStable(succeeded, func(i, j int) bool {
+			return succeeded[i].Score > succeeded[j].Score
+		})
+		for i, candidate := range succeeded {
+			candidate.Rank = i + 1
+		}
+	case opts.JudgeModel != "" && len(succeeded) > 1:
+		responses := make([]string, len(succeeded))
+		for i, candidate := range succeeded {
+			responses[i] = candidate.Response.Response
+		}
+		ranks, err := c.judgeRanking(ctx, opts.JudgeModel, opts.JudgeCriteria, req.Prompt, responses, opts.RequestOptions)
+		if err != nil {
+			return candidates, fmt.Errorf("failed to rank candidates: %w", err)
+		}
+		for i, rank := range ranks {
+			succeeded[i].Rank = rank
+		}
+	default:
+		return candidates, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := candidates[i].Rank, candidates[j].Rank
+		if ri == 0 || rj == 0 {
+			return rj == 0 && ri != 0
+		}
+		return ri < rj
+	})
+	return candidates, nil
+}
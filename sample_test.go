@@ -0,0 +1,79 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientGenerateN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			var req GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			seed := req.Options["seed"].(json.Number).String()
+			if seed == "11" {
+				http.Error(w, `{"error":"out of memory"}`, http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(GenerateResponse{Response: "answer " + strings.Repeat("!", len(seed)) + seed, Done: true})
+		case "/api/chat":
+			json.NewEncoder(w).Encode(ChatResponse{
+				Message: Message{Role: "assistant", Content: "RANKING: 2, 1"},
+				Done:    true,
+			})
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	req := &GenerateRequest{Model: "llama3", Prompt: "Name a color", Options: Options{"seed": 10}}
+
+	candidates, err := client.GenerateN(ctx, req, 3)
+	assertNoError(t, err)
+	if len(candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, got %d", len(candidates))
+	}
+	for i, candidate := range candidates {
+		if candidate.Seed != 10+i {
+			t.Errorf("Expected seed %d, got %d", 10+i, candidate.Seed)
+		}
+	}
+	if candidates[1].Err == nil || candidates[0].Response.Response != "answer !!10" {
+		t.Errorf("Unexpected candidates %+v", candidates)
+	}
+	if req.Options["seed"] != 10 {
+		t.Error("Expected the request options to be unchanged")
+	}
+
+	candidates, err = client.GenerateNWithOptions(ctx, req, 3, &GenerateNOptions{
+		Concurrency: 1,
+		Score: func(resp *GenerateResponse) float64 {
+			var n float64
+			fmt.Sscanf(resp.Response[len(resp.Response)-2:], "%g", &n)
+			return n
+		},
+	})
+	assertNoError(t, err)
+	if candidates[0].Seed != 12 || candidates[0].Rank != 1 || candidates[0].Score != 12 ||
+		candidates[1].Seed != 10 || candidates[1].Rank != 2 || candidates[2].Rank != 0 {
+		t.Errorf("Expected candidates sorted by score, got %+v", candidates)
+	}
+
+	candidates, err = client.GenerateNWithOptions(ctx, req, 3, &GenerateNOptions{JudgeModel: "judge"})
+	assertNoError(t, err)
+	if candidates[0].Seed != 12 || candidates[0].Rank != 1 || candidates[1].Seed != 10 {
+		t.Errorf("Expected candidates sorted by the judge, got %+v", candidates)
+	}
+
+	_, err = client.GenerateN(ctx, req, 0)
+	assertErrorContains(t, err, "n must be at least 1")
+}
@@ -0,0 +1,145 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ChatSession keeps the history of a multi-turn conversation and sends it
+// with every message. It can be saved and loaded as JSON to persist a
+// conversation.
+//
+// Example:
+//
+//	session := gollama.NewChatSession(client, "llama3")
+//	session.System = "You are a helpful assistant."
+//	reply, err := session.Send(ctx, "Hi, I'm Sam.")
+//	reply, err = session.Send(ctx, "What's my name?")
+//
+// A ChatSession is not safe for concurrent use.
+type ChatSession struct {
+	// Model is the model to chat with. If it is empty, the client's default
+	// model is used.
+	Model string `json:"model"`
+	// System is sent as the first message of every request if not empty.
+	System string `json:"system,omitempty"`
+	// Messages is the conversation so far, without the system prompt.
+	Messages []Message `json:"messages"`
+	// Options are model parameters sent with every request.
+	Options Options `json:"options,omitempty"`
+
+	client *Client
+}
+
+// NewChatSession creates an empty session with model.
+func NewChatSession(client *Client, model string) *ChatSession {
+	return &ChatSession{Model: model, client: client}
+}
+
+// Send adds a user message to the conversation and returns the reply, which
+// is added to the conversation as well. If the request fails, the
+// conversation is left unchanged.
+func (s *ChatSession) Send(ctx context.Context, content string, opts ...RequestOption) (*ChatResponse, error) {
+	resp, err := s.client.Chat(ctx, s.request(content), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.Messages = append(s.Messages, Message{Role: "user", Content: content}, resp.Message)
+	return resp, nil
+}
+
+// SendStream behaves like Send, but streams the reply to fn as it is
+// generated. The full reply is added to the conversation once the stream
+// completes.
+func (s *ChatSession) SendStream(ctx context.Context, content string, fn func(*ChatResponse), opts ...RequestOption) error {
+	if fn == nil {
+		return fmt.Errorf("callback function cannot be nil")
+	}
+
+	var reply strings.Builder
+	role := "assistant"
+	err := s.client.ChatStream(ctx, s.request(content), func(resp *ChatResponse) {
+		reply.WriteString(resp.Message.Content)
+		if resp.Message.Role != "" {
+			role = resp.Message.Role
+		}
+		fn(resp)
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	s.Messages = append(s.Messages, Message{Role: "user", Content: content}, Message{Role: role, Content: reply.String()})
+	return nil
+}
+
+// request builds a chat request for the conversation followed by content.
+func (s *ChatSession) request(content string) *ChatRequest {
+	messages := make([]Message, 0, len(s.Messages)+2)
+	if s.System != "" {
+		messages = append(messages, Message{Role: "system", Content: s.System})
+	}
+	messages = append(messages, s.Messages...)
+	messages = append(messages, Message{Role: "user", Content: content})
+
+	return &ChatRequest{Model: s.Model, Messages: messages, Options: s.Options}
+}
+
+// Reset clears the conversation, keeping the model, system prompt and
+// options.
+func (s *ChatSession) Reset() {
+	s.Messages = nil
+}
+
+// Save writes the session as JSON to w.
+func (s *ChatSession) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("failed to save chat session: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the session with one previously written by Save.
+func (s *ChatSession) Load(r io.Reader) error {
+	var loaded ChatSession
+	if err := json.NewDecoder(r).Decode(&loaded); err != nil {
+		return fmt.Errorf("failed to load chat session: %w", err)
+	}
+	loaded.client = s.client
+	*s = loaded
+	return nil
+}
+
+// SaveWithCodec writes the session to w encoded with codec, for example
+// MsgpackCodec for compact storage of many sessions.
+func (s *ChatSession) SaveWithCodec(w io.Writer, codec Codec) error {
+	data, err := codec.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to save chat session: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to save chat session: %w", err)
+	}
+	return nil
+}
+
+// LoadWithCodec replaces the session with one previously written by
+// SaveWithCodec with the same codec.
+func (s *ChatSession) LoadWithCodec(r io.Reader, codec Codec) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to load chat session: %w", err)
+	}
+	var loaded ChatSession
+	if err := codec.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to load chat session: %w", err)
+	}
+	loaded.client = s.client
+	*s = loaded
+	return nil
+}
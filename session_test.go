@@ -0,0 +1,88 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatSession(t *testing.T) {
+	var lastRequest ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = ChatRequest{}
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+
+		last := lastRequest.Messages[len(lastRequest.Messages)-1].Content
+		if last == "fail" {
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+			return
+		}
+		reply := "you said " + last
+		if !lastRequest.Stream {
+			json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: reply}, Done: true})
+			return
+		}
+		for _, word := range strings.SplitAfter(reply, " ") {
+			json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: word}})
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	session := NewChatSession(client, "llama2")
+	session.System = "be brief"
+
+	resp, err := session.Send(ctx, "hi")
+	assertNoError(t, err)
+	if resp.Message.Content != "you said hi" {
+		t.Errorf("Expected reply, got %q", resp.Message.Content)
+	}
+
+	var streamed strings.Builder
+	err = session.SendStream(ctx, "bye", func(resp *ChatResponse) {
+		streamed.WriteString(resp.Message.Content)
+	})
+	assertNoError(t, err)
+	if streamed.String() != "you said bye" {
+		t.Errorf("Expected streamed reply, got %q", streamed.String())
+	}
+
+	if len(lastRequest.Messages) != 4 || lastRequest.Messages[0].Role != "system" || lastRequest.Messages[2].Content != "you said hi" {
+		t.Errorf("Expected system prompt and history to be sent, got %+v", lastRequest.Messages)
+	}
+	if len(session.Messages) != 4 || session.Messages[3].Content != "you said bye" {
+		t.Errorf("Expected 4 messages in history, got %+v", session.Messages)
+	}
+
+	_, err = session.Send(ctx, "fail")
+	assertErrorContains(t, err, "boom")
+	if len(session.Messages) != 4 {
+		t.Errorf("Expected failed turn to leave history unchanged, got %d messages", len(session.Messages))
+	}
+
+	var buf bytes.Buffer
+	assertNoError(t, session.Save(&buf))
+
+	loaded := NewChatSession(client, "")
+	assertNoError(t, loaded.Load(&buf))
+	if loaded.Model != "llama2" || loaded.System != "be brief" || len(loaded.Messages) != 4 {
+		t.Errorf("Expected loaded session to match, got %+v", loaded)
+	}
+	_, err = loaded.Send(ctx, "again")
+	assertNoError(t, err)
+
+	loaded.Reset()
+	if len(loaded.Messages) != 0 || loaded.Model != "llama2" {
+		t.Errorf("Expected reset to clear only messages, got %+v", loaded)
+	}
+
+	assertErrorContains(t, loaded.Load(strings.NewReader("not json")), "failed to load chat session")
+}
@@ -0,0 +1,59 @@
+package gollama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// stopMatcher watches streamed text for a WithStopPattern match.
+type stopMatcher struct {
+	pattern *regexp.Regexp
+	text    strings.Builder
+	emitted int
+}
+
+// newStopMatcher returns a stopMatcher for the pattern set in opts, or nil
+// if there is none.
+func newStopMatcher(opts []RequestOption) *stopMatcher {
+	pattern := newRequestConfig(opts).stopPattern
+	if pattern == nil {
+		return nil
+	}
+	return &stopMatcher{pattern: pattern}
+}
+
+// push adds a chunk to the accumulated text. It returns the part of the
+// chunk up to the end of a match, and whether the pattern has matched.
+func (m *stopMatcher) push(chunk string) (string, bool) {
+	if m == nil {
+		return chunk, false
+	}
+
+	m.text.WriteString(chunk)
+	text := m.text.String()
+	loc := m.pattern.FindStringIndex(text)
+	if loc == nil {
+		m.emitted = len(text)
+		return chunk, false
+	}
+
+	// The text is checked after every chunk, so a match always ends in the
+	// current one
+	if loc[1] <= m.emitted {
+		return "", true
+	}
+	return text[m.emitted:loc[1]], true
+}
+
+// truncateAtStop cuts text after the first match of the WithStopPattern
+// pattern in opts, if any.
+func truncateAtStop(text string, opts []RequestOption) string {
+	pattern := newRequestConfig(opts).stopPattern
+	if pattern == nil {
+		return text
+	}
+	if loc := pattern.FindStringIndex(text); loc != nil {
+		return text[:loc[1]]
+	}
+	return text
+}
@@ -0,0 +1,80 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestClientStopPattern(t *testing.T) {
+	chunks := []string{"Sure:\n``", "`go\nfmt.Println", "(\"hi\")\n`", "``\nThis prints", " hi."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		encoder := json.NewEncoder(w)
+		if !req.Stream {
+			encoder.Encode(ChatResponse{Model: req.Model, Message: Message{Role: "assistant", Content: strings.Join(chunks, "")}, Done: true})
+			return
+		}
+		for _, chunk := range chunks {
+			if err := encoder.Encode(ChatResponse{Model: req.Model, Message: Message{Role: "assistant", Content: chunk}}); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+		encoder.Encode(ChatResponse{Model: req.Model, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	request := &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Print hi in Go"}}}
+	closingFence := WithStopPattern(regexp.MustCompile("(?s)```\\w*\\n.*?\\n```"))
+	expected := "Sure:\n```go\nfmt.Println(\"hi\")\n```"
+
+	t.Run("Streaming", func(t *testing.T) {
+		var text strings.Builder
+		var done, calls int
+		err := client.ChatStream(ctx, request, func(resp *ChatResponse) {
+			calls++
+			text.WriteString(resp.Message.Content)
+			if resp.Done {
+				done++
+			}
+		}, closingFence)
+		assertNoError(t, err)
+
+		if text.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, text.String())
+		}
+		if calls != 4 || done != 1 {
+			t.Errorf("Expected 4 chunks with the last marked done, got %d chunks and %d done", calls, done)
+		}
+	})
+
+	t.Run("Non-streaming", func(t *testing.T) {
+		resp, err := client.Chat(ctx, request, closingFence)
+		assertNoError(t, err)
+		if resp.Message.Content != expected {
+			t.Errorf("Expected %q, got %q", expected, resp.Message.Content)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		var text strings.Builder
+		err := client.ChatStream(ctx, request, func(resp *ChatResponse) {
+			text.WriteString(resp.Message.Content)
+		}, WithStopPattern(regexp.MustCompile(`never`)))
+		assertNoError(t, err)
+		if text.String() != strings.Join(chunks, "") {
+			t.Errorf("Expected the full text, got %q", text.String())
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// StreamError is returned when the server reports an error in the middle of
+// a streaming response, after it has already responded with a success
+// status, for example when the model runner crashes during generation.
+type StreamError struct {
+	Message string
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("server error during stream: %s", e.Message)
+}
+
+// DecodeError is returned when a line of a streaming response cannot be
+// decoded, for example because the stream was corrupted by a proxy.
+type DecodeError struct {
+	// Line is the undecodable line. It is only set for the handler of
+	// WithOnDecodeError.
+	Line []byte
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode stream line: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// PartialResponseError is returned by streaming generation and chat calls
+// that fail after part of the response was received, for example because
+// the connection dropped or the server crashed. It carries the output and
+// statistics received so far, so that callers can keep, retry or discard
+// the partial response. It wraps the error that ended the stream.
+//
+// Example:
+//
+//	err := client.ChatStream(ctx, req, fn)
+//	var partial *gollama.PartialResponseError
+//	if errors.As(err, &partial) {
+//		log.Printf("kept %d chunks: %q", partial.Chunks, partial.Chat.Message.Content)
+//	}
+type PartialResponseError struct {
+	Err error
+	// Chunks is the number of chunks received before the failure.
+	Chunks int
+	// Generate holds the partial response of GenerateStream and
+	// GenerateStreamCollect, and Chat that of ChatStream and
+	// ChatStreamCollect.
+	Generate *GenerateResponse
+	Chat     *ChatResponse
+}
+
+func (e *PartialResponseError) Error() string {
+	return fmt.Sprintf("stream failed after %d chunks: %v", e.Chunks, e.Err)
+}
+
+func (e *PartialResponseError) Unwrap() error {
+	return e.Err
+}
+
+// WithOnDecodeError sets a handler for lines of streaming responses that
+// cannot be decoded. By default such a line ends the stream with an error
+// wrapping the *DecodeError. If the handler returns nil, the line is
+// skipped and the stream continues; any other error ends the stream
+// instead. The handler is called on the goroutine reading the stream.
+//
+// Example:
+//
+//	gollama.WithOnDecodeError(func(err *gollama.DecodeError) error {
+//		log.Printf("skipping corrupted line %q: %v", err.Line, err.Err)
+//		return nil
+//	})
+func WithOnDecodeError(fn func(err *DecodeError) error) ClientOption {
+	return func(c *Client) {
+		c.onDecodeError = fn
+	}
+}
+
+// decodeStreamLine decodes a line of a streaming response into v, returning
+// a *DecodeError if it cannot.
+func decodeStreamLine(line []byte, v interface{}) error {
+	if err := json.Unmarshal(line, v); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+// streamLineError returns a *StreamError if line is an error object, such as
+// {"error":"model runner has unexpectedly stopped"}, and nil otherwise.
+func streamLineError(line []byte) error {
+	if !bytes.Contains(line, []byte(`"error"`)) {
+		return nil
+	}
+	var resp ErrorResponse
+	if json.Unmarshal(line, &resp) != nil || resp.Error == "" {
+		return nil
+	}
+	return &StreamError{Message: resp.Error}
+}
@@ -0,0 +1,118 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientStreamErrorLine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llama2","response":"Hel"}` + "\n"))
+		w.Write([]byte(`{"error":"model runner has unexpectedly stopped"}` + "\n"))
+		w.Write([]byte(`{"model":"llama2","response":"lo","done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	var chunks int
+	err = client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama2", Prompt: "Hi"}, func(*GenerateResponse) {
+		chunks++
+	})
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) || streamErr.Message != "model runner has unexpectedly stopped" {
+		t.Fatalf("Expected a StreamError, got %v", err)
+	}
+	if chunks != 1 {
+		t.Errorf("Expected the chunk before the error to be delivered, got %d", chunks)
+	}
+}
+
+func TestClientOnDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"}}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assis` + "\n"))
+		w.Write([]byte(`{"message":{"content":42}}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"lo"},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	req := &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	// Fail fast by default
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	err = client.ChatStream(context.Background(), req, func(*ChatResponse) {})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("Expected a DecodeError, got %v", err)
+	}
+	assertErrorContains(t, err, "error reading chat response stream")
+
+	// A handler can skip undecodable lines
+	var skipped []string
+	client, err = NewClientWithOptions(server.URL, WithOnDecodeError(func(err *DecodeError) error {
+		skipped = append(skipped, string(err.Line))
+		return nil
+	}))
+	assertNoError(t, err)
+	resp, err := client.ChatStreamCollect(context.Background(), req, nil)
+	assertNoError(t, err)
+	if resp.Message.Content != "Hello" {
+		t.Errorf("Expected the valid chunks to be assembled, got %q", resp.Message.Content)
+	}
+	if len(skipped) != 2 || skipped[1] != `{"message":{"content":42}}` {
+		t.Errorf("Expected both bad lines to be reported, got %q", skipped)
+	}
+
+	// Or end the stream with its own error
+	stop := errors.New("corrupted")
+	client, err = NewClientWithOptions(server.URL, WithOnDecodeError(func(*DecodeError) error {
+		return stop
+	}))
+	assertNoError(t, err)
+	err = client.ChatStream(context.Background(), req, func(*ChatResponse) {})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected the handler's error, got %v", err)
+	}
+}
+
+func TestClientPartialResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			w.Write([]byte(`{"model":"llama2","response":"Once upon"}` + "\n"))
+			w.Write([]byte(`{"model":"llama2","response":" a time"}` + "\n"))
+		case "/api/chat":
+			w.Write([]byte(`{"model":"llama2","message":{"role":"assistant","content":"Hel"}}` + "\n"))
+		}
+		w.Write([]byte(`{"error":"model runner has unexpectedly stopped"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "llama2", Prompt: "Tell a story"}, func(*GenerateResponse) {})
+	var partial *PartialResponseError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected a PartialResponseError, got %v", err)
+	}
+	if partial.Chunks != 2 || partial.Generate.Response != "Once upon a time" || partial.Chat != nil {
+		t.Errorf("Expected the partial generation, got %+v", partial)
+	}
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) {
+		t.Errorf("Expected the cause to be kept, got %v", err)
+	}
+
+	_, err = client.ChatStreamCollect(ctx, &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}, nil)
+	if !errors.As(err, &partial) || partial.Chat.Message.Content != "Hel" {
+		t.Errorf("Expected the partial chat message, got %v", err)
+	}
+}
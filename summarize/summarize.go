@@ -0,0 +1,157 @@
+// Package summarize summarizes documents too long for a model's context
+// window with a map-reduce approach: the text is split into chunks that are
+// summarized concurrently, and the partial summaries are combined into one.
+//
+//	s := summarize.New(client, "llama3")
+//	s.TargetWords = 150
+//	summary, err := s.Summarize(ctx, report)
+//
+// If the partial summaries are still too long to combine in one request,
+// they are summarized again in chunks until they fit.
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/astrica1/gollama"
+)
+
+// Default prompts. "{text}" is replaced by the text to summarize and
+// "{words}" by the target length.
+const (
+	DefaultChunkPrompt = "Summarize the following part of a longer document. " +
+		"Keep all key facts, names, figures and conclusions. Reply with the summary only.\n\n{text}"
+	DefaultReducePrompt = "Write a single coherent summary of about {words} words of the following text. " +
+		"Reply with the summary only.\n\n{text}"
+)
+
+// maxRounds limits how often partial summaries are summarized again.
+const maxRounds = 5
+
+// Summarizer summarizes long text with a model. Its fields can be changed
+// after New and before use.
+type Summarizer struct {
+	client *gollama.Client
+	model  string
+
+	// ChunkTokens is the size of the chunks the text is split into. The
+	// default of 1500 fits the server's default num_ctx of 2048 along with
+	// the prompt and summary; raise it together with num_ctx.
+	ChunkTokens int
+	// Overlap is the number of tokens consecutive chunks share.
+	Overlap int
+	// Concurrency is the number of chunks summarized at once.
+	Concurrency int
+	// TargetWords is the approximate length of the final summary.
+	TargetWords int
+	// ChunkPrompt is used to summarize each chunk, and ReducePrompt to
+	// combine the partial summaries, or to summarize a text that fits in
+	// one chunk.
+	ChunkPrompt  string
+	ReducePrompt string
+	// RequestOptions are applied to every request.
+	RequestOptions []gollama.RequestOption
+}
+
+// New creates a summarizer that uses model, with default settings.
+func New(client *gollama.Client, model string) *Summarizer {
+	return &Summarizer{
+		client:       client,
+		model:        model,
+		ChunkTokens:  1500,
+		Overlap:      50,
+		Concurrency:  4,
+		TargetWords:  200,
+		ChunkPrompt:  DefaultChunkPrompt,
+		ReducePrompt: DefaultReducePrompt,
+	}
+}
+
+// Summarize returns a summary of text.
+func (s *Summarizer) Summarize(ctx context.Context, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("text cannot be empty")
+	}
+
+	for round := 0; gollama.EstimateTokens(text) > s.ChunkTokens && round < maxRounds; round++ {
+		chunks := gollama.ChunkText(text, s.ChunkTokens, s.Overlap)
+		summaries, err := s.summarizeChunks(ctx, chunks)
+		if err != nil {
+			return "", err
+		}
+		text = strings.Join(summaries, "\n\n")
+	}
+
+	summary, err := s.generate(ctx, s.ReducePrompt, text)
+	if err != nil {
+		return "", fmt.Errorf("failed to combine summaries: %w", err)
+	}
+	return summary, nil
+}
+
+// summarizeChunks summarizes chunks concurrently, returning the summaries in
+// order. The first failure cancels the remaining chunks.
+func (s *Summarizer) summarizeChunks(ctx context.Context, chunks []string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	summaries := make([]string, len(chunks))
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			summary, err := s.generate(ctx, s.ChunkPrompt, chunk)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to summarize chunk %d of %d: %w", i+1, len(chunks), err)
+					cancel()
+				})
+				return
+			}
+			summaries[i] = summary
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// generate fills in a prompt and returns the model's reply.
+func (s *Summarizer) generate(ctx context.Context, prompt, text string) (string, error) {
+	prompt = strings.NewReplacer(
+		"{text}", text,
+		"{words}", fmt.Sprint(s.TargetWords),
+	).Replace(prompt)
+
+	reply, err := s.client.GenerateText(ctx, s.model, prompt, s.RequestOptions...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(reply), nil
+}
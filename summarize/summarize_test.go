@@ -0,0 +1,97 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/astrica1/gollama"
+)
+
+// newTestClient starts a server that answers chunk prompts with "S<n>" for
+// the nth chunk it sees, and reduce prompts with "FINAL(<text>)".
+func newTestClient(t *testing.T, prompts *[]string) *gollama.Client {
+	t.Helper()
+
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Prompt, "fail") {
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+			return
+		}
+
+		mu.Lock()
+		*prompts = append(*prompts, req.Prompt)
+		n := len(*prompts)
+		mu.Unlock()
+
+		reply := fmt.Sprintf("S%d", n)
+		if text, ok := strings.CutPrefix(req.Prompt, "REDUCE "); ok {
+			reply = "FINAL(" + text + ")"
+		}
+		json.NewEncoder(w).Encode(gollama.GenerateResponse{Response: reply, Done: true})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gollama.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+func TestSummarize(t *testing.T) {
+	var prompts []string
+	s := New(newTestClient(t, &prompts), "llama3")
+	s.ChunkTokens = 5
+	s.Overlap = 0
+	s.ChunkPrompt = "CHUNK {text}"
+	s.ReducePrompt = "REDUCE {words}: {text}"
+	s.TargetWords = 50
+
+	ctx := context.Background()
+	summary, err := s.Summarize(ctx, "short")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if summary != "FINAL(50: short)" || len(prompts) != 1 {
+		t.Errorf("Expected a single reduce request, got %q after %v", summary, prompts)
+	}
+
+	prompts = nil
+	doc := "Paragraph one here.\n\nParagraph two here.\n\nParagraph three."
+	summary, err = s.Summarize(ctx, doc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(prompts) != 4 {
+		t.Fatalf("Expected 3 chunk requests and 1 reduce, got %v", prompts)
+	}
+	if !strings.HasPrefix(summary, "FINAL(50: S") || strings.Count(summary, "S") != 3 {
+		t.Errorf("Expected the partial summaries to be combined, got %q", summary)
+	}
+}
+
+func TestSummarizeError(t *testing.T) {
+	var prompts []string
+	s := New(newTestClient(t, &prompts), "llama3")
+	s.ChunkTokens = 5
+	s.ChunkPrompt = "{text}"
+
+	_, err := s.Summarize(context.Background(), "Paragraph one here.\n\nThis will fail.")
+	if err == nil || !strings.Contains(err.Error(), "failed to summarize chunk 2 of 2") {
+		t.Errorf("Expected chunk error, got %v", err)
+	}
+
+	_, err = s.Summarize(context.Background(), " ")
+	if err == nil {
+		t.Error("Expected error for empty text")
+	}
+}
@@ -0,0 +1,213 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SyncMethod selects how SyncModels copies a model to the destination.
+type SyncMethod int
+
+const (
+	// SyncPull makes the destination pull the model from its registry,
+	// checking that it gets the source's version. It only works for
+	// published models and needs registry access on the destination.
+	SyncPull SyncMethod = iota
+	// SyncTransfer exports the model from the source and imports it into
+	// the destination, streaming it through the calling process. It works
+	// for any model, but needs the source's models directory, see
+	// ExportOptions.
+	SyncTransfer
+)
+
+// Reasons for which SyncModels copies a model.
+const (
+	SyncMissing  = "missing"
+	SyncOutdated = "outdated"
+)
+
+// SyncOptions holds optional settings for SyncModelsWithOptions.
+type SyncOptions struct {
+	// Method selects how models are copied. The default is SyncPull.
+	Method SyncMethod
+	// Export configures how SyncTransfer reads models from the source.
+	Export *ExportOptions
+	// Insecure allows pulls from registries with unverifiable TLS
+	// certificates with SyncPull.
+	Insecure bool
+}
+
+// SyncProgress reports the progress of copying one model.
+type SyncProgress struct {
+	Model     string `json:"model"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// SyncResult describes the outcome for one model of SyncModels.
+type SyncResult struct {
+	Model string
+	// Reason is SyncMissing or SyncOutdated for models that were copied,
+	// and empty for models that were already in sync.
+	Reason string
+	// Err is set if the model could not be copied.
+	Err error
+}
+
+// SyncModels makes dst hold the same models as src, for keeping a fleet of
+// servers consistent. Models of src matching one of the filter patterns,
+// or all models for an empty filter, are pulled on dst if dst lacks them or
+// has another version. Patterns follow the same rules as DeleteAll. Models
+// that only exist on dst are left alone.
+//
+// fn, which may be nil, receives progress updates for each model. Failures
+// do not stop the sync; they are recorded in the results and reflected in
+// the returned error.
+func SyncModels(ctx context.Context, src, dst *Client, filter []string, fn func(SyncProgress)) ([]SyncResult, error) {
+	return SyncModelsWithOptions(ctx, src, dst, filter, nil, fn)
+}
+
+// SyncModelsWithOptions behaves like SyncModels, applying the given
+// SyncOptions.
+//
+// With SyncTransfer, an imported model does not keep its digest (see
+// ImportModel), so a model counts as in sync if dst has a model of that
+// name and all of its weights. Changes to other layers alone, such as
+// the template, are not detected.
+func SyncModelsWithOptions(ctx context.Context, src, dst *Client, filter []string, opts *SyncOptions, fn func(SyncProgress)) ([]SyncResult, error) {
+	if src == nil || dst == nil {
+		return nil, fmt.Errorf("source and destination clients cannot be nil")
+	}
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	if fn == nil {
+		fn = func(SyncProgress) {}
+	}
+
+	srcModels, err := src.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source models: %w", err)
+	}
+	dstModels, err := dst.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination models: %w", err)
+	}
+
+	var results []SyncResult
+	var failed int
+	for _, model := range srcModels.Models {
+		if len(filter) > 0 && !matchAnyModelName(filter, model.Name) {
+			continue
+		}
+
+		result := SyncResult{Model: model.Name}
+		existing := listedModel(dstModels, model.Name)
+		switch {
+		case existing == nil:
+			result.Reason = SyncMissing
+		case trimDigest(existing.Digest) != trimDigest(model.Digest):
+			result.Reason = SyncOutdated
+		}
+
+		progress := func(status, digest string, completed, total int64) {
+			fn(SyncProgress{Model: model.Name, Status: status, Digest: digest, Completed: completed, Total: total})
+		}
+		if result.Reason == SyncOutdated && opts.Method == SyncTransfer {
+			inSync, err := syncedByTransfer(ctx, src, dst, model.Name, opts.Export)
+			if err != nil {
+				result.Err = err
+			} else if inSync {
+				result.Reason = ""
+			}
+		}
+		if result.Reason != "" && result.Err == nil {
+			result.Err = syncModel(ctx, src, dst, model, opts, progress)
+		}
+
+		if result.Err != nil {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("failed to sync %d of %d models", failed, len(results))
+	}
+	return results, nil
+}
+
+// syncModel copies a model from src to dst.
+func syncModel(ctx context.Context, src, dst *Client, model ModelResponse, opts *SyncOptions, progress func(status, digest string, completed, total int64)) error {
+	if opts.Method != SyncTransfer {
+		pullOpts := &PullOptions{Insecure: opts.Insecure, ExpectedDigest: model.Digest}
+		err := dst.PullWithOptions(ctx, model.Name, pullOpts, func(p PullProgress) {
+			progress(p.Status, p.Digest, p.Completed, p.Total)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync model %q: %w", model.Name, err)
+		}
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		err := src.ExportModelWithOptions(ctx, model.Name, pw, opts.Export, func(p ArchiveProgress) {
+			progress(p.Status, p.Digest, p.Completed, p.Total)
+		})
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	err := dst.ImportModelWithOptions(ctx, pr, &ImportOptions{Name: model.Name}, func(p ArchiveProgress) {
+		// Export progress already covers the blobs
+		if p.Digest == "" {
+			progress(p.Status, "", 0, 0)
+		}
+	})
+	// A failed import stops the export, and a failed export fails the
+	// import with the export's error
+	pr.CloseWithError(errors.New("import ended"))
+	exportErr := <-exported
+	if err != nil {
+		return fmt.Errorf("failed to sync model %q: %w", model.Name, err)
+	}
+	if exportErr != nil {
+		return fmt.Errorf("failed to sync model %q: %w", model.Name, exportErr)
+	}
+	return nil
+}
+
+// syncedByTransfer reports whether dst has every weight blob of a model of
+// src, which is how a model imported from src is recognized.
+func syncedByTransfer(ctx context.Context, src, dst *Client, modelName string, exportOpts *ExportOptions) (bool, error) {
+	source, err := src.exportSource(exportOpts)
+	if err != nil {
+		return false, err
+	}
+	manifest, _, err := readManifest(ctx, source, modelName)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync model %q: %w", modelName, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case MediaTypeModel, MediaTypeProjector, MediaTypeAdapter:
+		default:
+			continue
+		}
+		exists, err := dst.hasBlob(ctx, layer.Digest)
+		if err != nil {
+			return false, fmt.Errorf("failed to sync model %q: %w", modelName, err)
+		}
+		if !exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
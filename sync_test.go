@@ -0,0 +1,152 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// listServer serves a model list from digests and records pulls, which set
+// the pulled model's digest from pulled.
+func listServer(t *testing.T, mu *sync.Mutex, digests, pulled map[string]string, pulls *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/tags":
+			var list ListModelsResponse
+			for _, name := range []string{"alpha:latest", "beta:latest", "gamma:latest"} {
+				if digest, ok := digests[name]; ok {
+					list.Models = append(list.Models, ModelResponse{Name: name, Digest: digest})
+				}
+			}
+			json.NewEncoder(w).Encode(list)
+		case "/api/pull":
+			var req PullRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			*pulls = append(*pulls, req.Model)
+			digests[req.Model] = pulled[req.Model]
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSyncModels(t *testing.T) {
+	var mu sync.Mutex
+	srcDigests := map[string]string{"alpha:latest": "aaaa", "beta:latest": "bbbb", "gamma:latest": "cccc"}
+	dstDigests := map[string]string{"alpha:latest": "aaaa", "beta:latest": "old"}
+	// The registry has moved gamma on since the source pulled it
+	registry := map[string]string{"beta:latest": "bbbb", "gamma:latest": "newer"}
+
+	var pulls []string
+	src := listServer(t, &mu, srcDigests, nil, new([]string))
+	dst := listServer(t, &mu, dstDigests, registry, &pulls)
+
+	srcClient, err := createTestClient(src.URL)
+	assertNoError(t, err)
+	dstClient, err := createTestClient(dst.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	var progress []SyncProgress
+	results, err := SyncModels(ctx, srcClient, dstClient, nil, func(p SyncProgress) {
+		progress = append(progress, p)
+	})
+	assertErrorContains(t, err, "failed to sync 1 of 3 models")
+
+	expected := []struct {
+		reason string
+		failed bool
+	}{{"", false}, {SyncOutdated, false}, {SyncMissing, true}}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, want := range expected {
+		if results[i].Reason != want.reason || (results[i].Err != nil) != want.failed {
+			t.Errorf("Expected %s to have reason %q and failed=%v, got %+v", results[i].Model, want.reason, want.failed, results[i])
+		}
+	}
+	if !errors.Is(results[2].Err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch for gamma, got %v", results[2].Err)
+	}
+	if fmt.Sprint(pulls) != "[beta:latest gamma:latest]" {
+		t.Errorf("Expected beta and gamma to be pulled, got %v", pulls)
+	}
+	if len(progress) != 2 || progress[0].Model != "beta:latest" {
+		t.Errorf("Expected progress for each pull, got %+v", progress)
+	}
+
+	// Filters select the models to sync
+	pulls = nil
+	results, err = SyncModels(ctx, srcClient, dstClient, []string{"alpha", "beta"}, nil)
+	assertNoError(t, err)
+	if len(results) != 2 || len(pulls) != 0 {
+		t.Errorf("Expected alpha and beta in sync, got %+v and pulls %v", results, pulls)
+	}
+}
+
+func TestSyncModelsTransfer(t *testing.T) {
+	model := newTestModel(t)
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"models":[{"name":"tiny:latest","digest":%q}]}`, model.digest())
+	}))
+	defer src.Close()
+
+	var mu sync.Mutex
+	blobs := make(map[string]bool)
+	var creates int
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/api/tags":
+			if creates > 0 {
+				// Imported models get a digest of their own
+				w.Write([]byte(`{"models":[{"name":"tiny:latest","digest":"recreated"}]}`))
+				return
+			}
+			w.Write([]byte(`{"models":[]}`))
+		case r.URL.Path == "/api/create":
+			creates++
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		case r.Method == http.MethodHead:
+			if !blobs[r.URL.Path[len("/api/blobs/"):]] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPost:
+			blobs[r.URL.Path[len("/api/blobs/"):]] = true
+		}
+	}))
+	defer dst.Close()
+
+	srcClient, err := createTestClient(src.URL)
+	assertNoError(t, err)
+	dstClient, err := createTestClient(dst.URL)
+	assertNoError(t, err)
+
+	opts := &SyncOptions{Method: SyncTransfer, Export: &ExportOptions{ModelsDir: model.dir}}
+	results, err := SyncModelsWithOptions(context.Background(), srcClient, dstClient, nil, opts, nil)
+	assertNoError(t, err)
+	if len(results) != 1 || results[0].Reason != SyncMissing || creates != 1 || len(blobs) != len(model.blobs) {
+		t.Errorf("Expected tiny to be transferred, got %+v with %d creates and %d blobs", results, creates, len(blobs))
+	}
+
+	// The imported model is recognized by its weights
+	results, err = SyncModelsWithOptions(context.Background(), srcClient, dstClient, nil, opts, nil)
+	assertNoError(t, err)
+	if len(results) != 1 || results[0].Reason != "" || creates != 1 {
+		t.Errorf("Expected tiny to be in sync, got %+v with %d creates", results, creates)
+	}
+}
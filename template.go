@@ -0,0 +1,175 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RenderTemplate builds the prompt the server would send to a model for the
+// given chat messages, using the template and default system prompt reported
+// by Show. The result can be inspected when debugging prompt issues, or
+// edited and sent with GenerateRequest.Raw.
+func (c *Client) RenderTemplate(ctx context.Context, model string, messages []Message) (string, error) {
+	info, err := c.Show(ctx, model)
+	if err != nil {
+		return "", fmt.Errorf("failed to render template of model %q: %w", model, err)
+	}
+	return ExecuteTemplate(info.Template, info.System, messages)
+}
+
+// ExecuteTemplate renders a model template, in the Go text/template syntax
+// used by Modelfiles, for the given chat messages. If the messages contain no
+// system message, system is used as one.
+//
+// It follows the server's rules: consecutive messages with the same role
+// are joined, templates that range over .Messages are executed once, and
+// older templates that use .Prompt and .Response are executed for each turn,
+// with the output of the last turn cut after .Response. An empty template
+// renders the prompt alone.
+func ExecuteTemplate(tmpl, system string, messages []Message) (string, error) {
+	if tmpl == "" {
+		tmpl = "{{ .Prompt }}"
+	}
+	t, err := template.New("").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	if system != "" && !hasSystemMessage(messages) {
+		messages = append([]Message{{Role: "system", Content: system}}, messages...)
+	}
+	system, collated := collateMessages(messages)
+
+	var b strings.Builder
+	if strings.Contains(tmpl, ".Messages") {
+		values := templateValues{System: system, Messages: collated}
+		if err := t.Execute(&b, values); err != nil {
+			return "", fmt.Errorf("failed to execute template: %w", err)
+		}
+		return b.String(), nil
+	}
+
+	var turn templateValues
+	execute := func() error {
+		err := t.Execute(&b, turn)
+		turn = templateValues{}
+		return err
+	}
+	for _, m := range collated {
+		switch m.Role {
+		case "system":
+			if turn.Prompt != "" || turn.Response != "" {
+				if err := execute(); err != nil {
+					return "", fmt.Errorf("failed to execute template: %w", err)
+				}
+			}
+			turn.System = m.Content
+		case "user":
+			if turn.Response != "" {
+				if err := execute(); err != nil {
+					return "", fmt.Errorf("failed to execute template: %w", err)
+				}
+			}
+			turn.Prompt = m.Content
+		case "assistant":
+			turn.Response = m.Content
+		}
+	}
+
+	// The last turn ends where the model's response starts. A marker
+	// stands in for the response so that the output can be cut there.
+	response := turn.Response
+	turn.Response = templateResponseMarker
+	var last strings.Builder
+	if err := t.Execute(&last, turn); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	out := last.String()
+	if i := strings.Index(out, templateResponseMarker); i >= 0 {
+		out = out[:i] + response
+	}
+	b.WriteString(out)
+	return b.String(), nil
+}
+
+// templateResponseMarker marks where the response goes in the last turn of
+// a template.
+const templateResponseMarker = "\x00gollama-response\x00"
+
+// templateValues holds the data a model template is executed with.
+type templateValues struct {
+	System   string
+	Prompt   string
+	Response string
+	Suffix   string
+	Messages []templateMessage
+	Tools    []interface{}
+	// Think and IsThinkSet are referenced by the templates of thinking
+	// models
+	Think      bool
+	IsThinkSet bool
+}
+
+// templateMessage is a chat message as seen by a model template.
+type templateMessage struct {
+	Role      string
+	Content   string
+	Thinking  string
+	Images    []string
+	ToolCalls []interface{}
+	ToolName  string
+}
+
+// templateFuncs are the functions available to model templates besides the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	},
+	"currentDate": func() string {
+		return time.Now().Format("2006-01-02")
+	},
+}
+
+// hasSystemMessage reports whether messages contain a system message.
+func hasSystemMessage(messages []Message) bool {
+	for _, m := range messages {
+		if m.Role == "system" {
+			return true
+		}
+	}
+	return false
+}
+
+// collateMessages joins consecutive messages with the same role, and returns
+// them along with the combined content of the system messages.
+func collateMessages(messages []Message) (string, []templateMessage) {
+	var system []string
+	var collated []templateMessage
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+		}
+		if n := len(collated); n > 0 && collated[n-1].Role == m.Role {
+			collated[n-1].Content += "\n\n" + m.Content
+			continue
+		}
+		var toolCalls []interface{}
+		for _, call := range m.ToolCalls {
+			toolCalls = append(toolCalls, call)
+		}
+		collated = append(collated, templateMessage{
+			Role:      m.Role,
+			Content:   m.Content,
+			Thinking:  m.Thinking,
+			Images:    m.Images,
+			ToolCalls: toolCalls,
+		})
+	}
+	return strings.Join(system, "\n\n"), collated
+}
@@ -0,0 +1,85 @@
+package gollama
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecuteTemplate(t *testing.T) {
+	legacy := `{{ if .System }}<<{{ .System }}>>{{ end }}[INST] {{ .Prompt }} [/INST]{{ .Response }}</s>`
+	messagesTemplate := `{{- range .Messages }}<|{{ .Role }}|>{{ .Content }}
+{{ end }}<|assistant|>`
+
+	tests := []struct {
+		name     string
+		template string
+		system   string
+		messages []Message
+		want     string
+	}{
+		{
+			name:     "Empty template",
+			messages: []Message{{Role: "user", Content: "hi"}},
+			want:     "hi",
+		},
+		{
+			name:     "Legacy single turn with default system",
+			template: legacy,
+			system:   "Be brief.",
+			messages: []Message{{Role: "user", Content: "hi"}},
+			want:     "<<Be brief.>>[INST] hi [/INST]",
+		},
+		{
+			name:     "Legacy multiple turns",
+			template: legacy,
+			system:   "ignored",
+			messages: []Message{
+				{Role: "system", Content: "sys"},
+				{Role: "user", Content: "hi"},
+				{Role: "assistant", Content: "hello"},
+				{Role: "user", Content: "bye"},
+			},
+			want: "<<sys>>[INST] hi [/INST]hello</s>[INST] bye [/INST]",
+		},
+		{
+			name:     "Legacy continuing an assistant message",
+			template: legacy,
+			messages: []Message{{Role: "user", Content: "count"}, {Role: "assistant", Content: "1, 2,"}},
+			want:     "[INST] count [/INST]1, 2,",
+		},
+		{
+			name:     "Messages template collates roles",
+			template: messagesTemplate,
+			system:   "Be brief.",
+			messages: []Message{{Role: "user", Content: "a"}, {Role: "user", Content: "b"}},
+			want:     "<|system|>Be brief.\n<|user|>a\n\nb\n<|assistant|>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExecuteTemplate(tt.template, tt.system, tt.messages)
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	_, err := ExecuteTemplate("{{ .Prompt", "", nil)
+	assertErrorContains(t, err, "failed to parse template")
+}
+
+func TestClientRenderTemplate(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	prompt, err := client.RenderTemplate(context.Background(), "llama2", []Message{{Role: "user", Content: "Why is the sky blue?"}})
+	assertNoError(t, err)
+	if prompt != "[INST] Why is the sky blue? [/INST]" {
+		t.Errorf("Expected rendered prompt, got %q", prompt)
+	}
+}
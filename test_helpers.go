@@ -43,6 +43,10 @@ func setupMockServer() *httptest.Server {
 		case "/api/ps":
 			handlePS(w, r)
 		default:
+			if strings.HasPrefix(r.URL.Path, "/api/blobs/") {
+				handleBlob(w, r)
+				return
+			}
 			http.NotFound(w, r)
 		}
 	}))
@@ -98,6 +102,32 @@ func handleShowModel(w http.ResponseWriter, r *http.Request) {
 		ModifiedAt: time.Now(),
 		Size:       7323310500,
 		Digest:     "sha256:bc07c81de745",
+		Details: ModelDetails{
+			Format:            "gguf",
+			Family:            "llama",
+			ParameterSize:     "7B",
+			QuantizationLevel: "Q4_0",
+		},
+		License:    "LLAMA 2 COMMUNITY LICENSE AGREEMENT",
+		Modelfile:  "FROM llama2\nTEMPLATE {{ .Prompt }}",
+		Parameters: "stop \"[INST]\"",
+		Template:   "[INST] {{ .Prompt }} [/INST]",
+		ModelInfo: map[string]interface{}{
+			"general.architecture":   "llama",
+			"llama.context_length":   4096,
+			"llama.embedding_length": 4096,
+		},
+	}
+	if req.Verbose {
+		response.ModelInfo["tokenizer.ggml.tokens"] = []string{"<s>", "</s>"}
+	}
+	if req.Model == "llava" {
+		response.Capabilities = []string{"completion", "vision"}
+		response.ProjectorInfo = map[string]interface{}{
+			"clip.has_vision_encoder": true,
+		}
+	} else {
+		response.Capabilities = []string{"completion", "tools"}
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -258,6 +288,20 @@ func handlePullModel(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(progress)
 }
 
+func handleBlob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.TrimPrefix(r.URL.Path, "/api/blobs/") != "sha256:1a838c4c" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func handleCreateModel(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -306,12 +350,14 @@ func handlePS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := PSResponse{
-		Models: []ModelResponse{
+		Models: []RunningModel{
 			{
-				Name:       "llama2",
-				Size:       3825819519,
-				Digest:     "sha256:1a838c4c",
-				ModifiedAt: time.Now().Add(-time.Hour),
+				Name:      "llama2",
+				Model:     "llama2",
+				Size:      3825819519,
+				Digest:    "sha256:1a838c4c",
+				ExpiresAt: time.Now().Add(5 * time.Minute),
+				SizeVRAM:  3825819519,
 			},
 		},
 	}
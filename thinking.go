@@ -0,0 +1,127 @@
+package gollama
+
+import "strings"
+
+// Tags that delimit a reasoning trace written inline by thinking models.
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// SplitThinking separates a reasoning trace that a model wrote inline, between
+// <think> and </think> tags at the start of its output, from the answer that
+// follows. Text without a leading <think> tag is returned as the answer.
+//
+// Servers that support the Think request field return the trace separately,
+// in which case the client leaves the output alone. SplitThinking is applied
+// when Think is set and the server returned the trace inline instead.
+func SplitThinking(text string) (thinking, answer string) {
+	rest := strings.TrimLeft(text, " \t\r\n")
+	rest, ok := strings.CutPrefix(rest, thinkOpenTag)
+	if !ok {
+		return "", text
+	}
+	thinking, answer, ok = strings.Cut(rest, thinkCloseTag)
+	if !ok {
+		// The trace was cut short, e.g. by num_predict
+		return strings.TrimSpace(rest), ""
+	}
+	return strings.TrimSpace(thinking), strings.TrimLeft(answer, " \t\r\n")
+}
+
+// StripThinking removes an inline reasoning trace from text, returning only
+// the answer.
+func StripThinking(text string) string {
+	_, answer := SplitThinking(text)
+	return answer
+}
+
+// thinkingEnabled reports whether a Think request field asks for thinking.
+func thinkingEnabled(think *bool) bool {
+	return think != nil && *think
+}
+
+// thinkingSplitter states.
+const (
+	thinkingDetect = iota // waiting to see whether the output starts with a tag
+	thinkingInside        // inside the trace
+	thinkingAfter         // skipping whitespace after the closing tag
+	thinkingAnswer        // in the answer
+)
+
+// thinkingSplitter moves an inline reasoning trace out of streamed text. Tags
+// may be split across chunks, so text that could be the start of a tag is
+// held back until the next chunk.
+type thinkingSplitter struct {
+	state   int
+	pending string
+}
+
+// newThinkingSplitter returns a thinkingSplitter if think enables thinking,
+// or nil otherwise.
+func newThinkingSplitter(think *bool) *thinkingSplitter {
+	if !thinkingEnabled(think) {
+		return nil
+	}
+	return &thinkingSplitter{}
+}
+
+// push adds a chunk and returns the parts of the text seen so far that are
+// known to belong to the trace and to the answer. When done is set, any text
+// held back is returned.
+func (s *thinkingSplitter) push(chunk string, done bool) (thinking, answer string) {
+	s.pending += chunk
+	for {
+		switch s.state {
+		case thinkingDetect:
+			trimmed := strings.TrimLeft(s.pending, " \t\r\n")
+			if rest, ok := strings.CutPrefix(trimmed, thinkOpenTag); ok {
+				s.pending = strings.TrimLeft(rest, " \t\r\n")
+				s.state = thinkingInside
+				continue
+			}
+			if !done && strings.HasPrefix(thinkOpenTag, trimmed) {
+				return thinking, answer
+			}
+			s.state = thinkingAnswer
+
+		case thinkingInside:
+			if i := strings.Index(s.pending, thinkCloseTag); i >= 0 {
+				thinking += strings.TrimRight(s.pending[:i], " \t\r\n")
+				s.pending = s.pending[i+len(thinkCloseTag):]
+				s.state = thinkingAfter
+				continue
+			}
+			keep := 0
+			if !done {
+				keep = partialSuffix(s.pending, thinkCloseTag)
+			}
+			thinking += s.pending[:len(s.pending)-keep]
+			s.pending = s.pending[len(s.pending)-keep:]
+			return thinking, answer
+
+		case thinkingAfter:
+			s.pending = strings.TrimLeft(s.pending, " \t\r\n")
+			if s.pending == "" {
+				return thinking, answer
+			}
+			s.state = thinkingAnswer
+
+		default:
+			answer += s.pending
+			s.pending = ""
+			return thinking, answer
+		}
+	}
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialSuffix(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSplitThinking(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		thinking string
+		answer   string
+	}{
+		{"No trace", "The answer is 4.", "", "The answer is 4."},
+		{"Trace and answer", "<think>\n2+2 is 4\n</think>\n\nThe answer is 4.", "2+2 is 4", "The answer is 4."},
+		{"Leading whitespace", "\n<think>hmm</think>4", "hmm", "4"},
+		{"Unterminated trace", "<think>still thinking", "still thinking", ""},
+		{"Tag not at start", "Use <think> tags", "", "Use <think> tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thinking, answer := SplitThinking(tt.text)
+			if thinking != tt.thinking || answer != tt.answer {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tt.thinking, tt.answer, thinking, answer)
+			}
+			if got := StripThinking(tt.text); got != tt.answer {
+				t.Errorf("Expected StripThinking to return %q, got %q", tt.answer, got)
+			}
+		})
+	}
+}
+
+func TestThinkingSplitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		thinking string
+		answer   string
+	}{
+		{"Split tags", []string{"<th", "ink>\nfirst ", "step</th", "ink>", "\n\n", "Answer", "."}, "first step", "Answer."},
+		{"No trace", []string{" <", "b>bold</b>"}, "", " <b>bold</b>"},
+		{"Lone bracket at end", []string{"<"}, "", "<"},
+		{"Separate fields", []string{"", "", "Answer"}, "", "Answer"},
+	}
+
+	think := true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newThinkingSplitter(&think)
+			var thinking, answer strings.Builder
+			for i, chunk := range tt.chunks {
+				trace, text := s.push(chunk, i == len(tt.chunks)-1)
+				thinking.WriteString(trace)
+				answer.WriteString(text)
+			}
+			if thinking.String() != tt.thinking || answer.String() != tt.answer {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tt.thinking, tt.answer, thinking.String(), answer.String())
+			}
+		})
+	}
+
+	if newThinkingSplitter(nil) != nil {
+		t.Error("Expected no splitter without Think")
+	}
+}
+
+func TestClientThinking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["think"] != true {
+			t.Errorf("Expected think to be sent, got %v", req["think"])
+		}
+
+		enc := json.NewEncoder(w)
+		switch {
+		case r.URL.Path == "/api/chat":
+			// A server that returns the trace in its own field
+			enc.Encode(ChatResponse{Message: Message{Role: "assistant", Thinking: "hmm"}})
+			enc.Encode(ChatResponse{Message: Message{Role: "assistant", Content: "4"}, Done: true})
+		case req["stream"] == true:
+			// A server that returns the trace inline
+			enc.Encode(GenerateResponse{Response: "<think>hmm"})
+			enc.Encode(GenerateResponse{Response: "</think>4", Done: true})
+		default:
+			enc.Encode(GenerateResponse{Response: "<think>hmm</think>4", Done: true})
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	think := true
+
+	resp, err := client.Generate(ctx, &GenerateRequest{Model: "deepseek-r1", Prompt: "2+2?", Think: &think})
+	assertNoError(t, err)
+	if resp.Thinking != "hmm" || resp.Response != "4" {
+		t.Errorf("Expected separated trace, got %+v", resp)
+	}
+
+	var thinking, answer string
+	err = client.GenerateStream(ctx, &GenerateRequest{Model: "deepseek-r1", Prompt: "2+2?", Think: &think}, func(r *GenerateResponse) {
+		thinking += r.Thinking
+		answer += r.Response
+	})
+	assertNoError(t, err)
+	if thinking != "hmm" || answer != "4" {
+		t.Errorf("Expected streamed trace %q and answer %q, got %q and %q", "hmm", "4", thinking, answer)
+	}
+
+	thinking, answer = "", ""
+	err = client.ChatStream(ctx, &ChatRequest{Model: "deepseek-r1", Messages: []Message{{Role: "user", Content: "2+2?"}}, Think: &think}, func(r *ChatResponse) {
+		thinking += r.Message.Thinking
+		answer += r.Message.Content
+	})
+	assertNoError(t, err)
+	if thinking != "hmm" || answer != "4" {
+		t.Errorf("Expected chat trace %q and answer %q, got %q and %q", "hmm", "4", thinking, answer)
+	}
+}
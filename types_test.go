@@ -163,6 +163,38 @@ func TestGenerateRequestStructure(t *testing.T) {
 	}
 }
 
+func TestOptionsRoundTrip(t *testing.T) {
+	input := `{"model":"llama2","prompt":"Hi","options":{"num_ctx":4096,"seed":1234567890123456789,"stop":["\n"],"temperature":0.1}}`
+
+	var request GenerateRequest
+	err := json.Unmarshal([]byte(input), &request)
+	assertNoError(t, err)
+
+	seed, ok := request.Options["seed"].(json.Number)
+	if !ok {
+		t.Fatalf("Expected seed to be a json.Number, got %T", request.Options["seed"])
+	}
+	if seed.String() != "1234567890123456789" {
+		t.Errorf("Expected seed 1234567890123456789, got %s", seed)
+	}
+	if n, err := request.Options["num_ctx"].(json.Number).Int64(); err != nil || n != 4096 {
+		t.Errorf("Expected num_ctx 4096, got %v (%v)", n, err)
+	}
+
+	jsonData, err := json.Marshal(request)
+	assertNoError(t, err)
+	if string(jsonData) != input {
+		t.Errorf("Expected round-trip to preserve options:\n%s\ngot:\n%s", input, jsonData)
+	}
+
+	var chat ChatRequest
+	err = json.Unmarshal([]byte(`{"model":"llama2","messages":[],"options":{"seed":42}}`), &chat)
+	assertNoError(t, err)
+	if chat.Options["seed"] != json.Number("42") {
+		t.Errorf("Expected chat seed 42 as json.Number, got %#v", chat.Options["seed"])
+	}
+}
+
 func TestChatRequestStructure(t *testing.T) {
 	request := ChatRequest{
 		Model: "llama2",
@@ -363,11 +395,13 @@ func TestPullProgressStructure(t *testing.T) {
 }
 
 func TestRunningModelStructure(t *testing.T) {
-	model := ModelResponse{
-		Name:       "llama2:7b",
-		Size:       3825819519,
-		Digest:     "sha256:1a838c4c",
-		ModifiedAt: time.Now(),
+	model := RunningModel{
+		Name:      "llama2:7b",
+		Model:     "llama2:7b",
+		Size:      3825819519,
+		Digest:    "sha256:1a838c4c",
+		ExpiresAt: time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second),
+		SizeVRAM:  2000000000,
 	}
 
 	// Test JSON marshaling
@@ -375,16 +409,25 @@ func TestRunningModelStructure(t *testing.T) {
 	assertNoError(t, err)
 
 	// Test JSON unmarshaling
-	var unmarshaled ModelResponse
+	var unmarshaled RunningModel
 	err = json.Unmarshal(jsonData, &unmarshaled)
 	assertNoError(t, err)
 
-	if unmarshaled.Name != model.Name {
-		t.Errorf("Expected name %s, got %s", model.Name, unmarshaled.Name)
+	if !reflect.DeepEqual(model, unmarshaled) {
+		t.Errorf("Expected %+v, got %+v", model, unmarshaled)
 	}
 
-	if unmarshaled.Size != model.Size {
-		t.Errorf("Expected size %d, got %d", model.Size, unmarshaled.Size)
+	// Test decoding the wire format of /api/ps
+	raw := `{"name":"mistral:latest","model":"mistral:latest","size":5137025024,"digest":"2ae6f6dd",` +
+		`"expires_at":"2024-06-04T14:38:31.83753-07:00","size_vram":5137025024}`
+	err = json.Unmarshal([]byte(raw), &unmarshaled)
+	assertNoError(t, err)
+
+	if unmarshaled.SizeVRAM != 5137025024 {
+		t.Errorf("Expected size_vram 5137025024, got %d", unmarshaled.SizeVRAM)
+	}
+	if unmarshaled.ExpiresAt.IsZero() {
+		t.Errorf("Expected expires_at to be parsed")
 	}
 }
 
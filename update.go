@@ -0,0 +1,238 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ModelUpdate reports whether a local model is behind the registry version
+// of its tag.
+type ModelUpdate struct {
+	Name         string
+	LocalDigest  string
+	RemoteDigest string
+	// Stale is true when the registry publishes a different manifest for
+	// the model's tag.
+	Stale bool
+	// DownloadSize estimates the bytes a pull would transfer, counting only
+	// layers the server does not already have. It is only set when
+	// requested through UpdateCheckOptions.
+	DownloadSize int64
+	// Err is set if the model could not be checked, for example because it
+	// was created locally and does not exist in the registry.
+	Err error
+}
+
+// UpdateCheckOptions holds optional settings for CheckForUpdatesWithOptions.
+type UpdateCheckOptions struct {
+	// Filter limits the check to matching models.
+	Filter ModelFilter
+	// EstimateSize computes DownloadSize for stale models. This issues one
+	// request per layer against the server's blob endpoint.
+	EstimateSize bool
+	// Insecure allows plain HTTP for models from custom registries.
+	Insecure bool
+}
+
+// CheckForUpdates compares the digest of every local model with the latest
+// manifest published under the same tag and reports which models are stale.
+//
+// Models that cannot be checked are reported with Err set rather than failing
+// the whole call. Returns an error only if the local models cannot be listed.
+func (c *Client) CheckForUpdates(ctx context.Context) ([]ModelUpdate, error) {
+	return c.CheckForUpdatesWithOptions(ctx, nil)
+}
+
+// CheckForUpdatesWithOptions behaves like CheckForUpdates, applying the given
+// UpdateCheckOptions. A nil opts is equivalent to calling CheckForUpdates.
+func (c *Client) CheckForUpdatesWithOptions(ctx context.Context, opts *UpdateCheckOptions) ([]ModelUpdate, error) {
+	if opts == nil {
+		opts = &UpdateCheckOptions{}
+	}
+
+	models, err := c.ListWithFilter(ctx, opts.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	registry := c.Registry()
+	updates := make([]ModelUpdate, 0, len(models.Models))
+	for _, model := range models.Models {
+		update := ModelUpdate{Name: model.Name, LocalDigest: model.Digest}
+
+		manifest, err := registry.fetchManifest(ctx, model.Name, opts.Insecure)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			update.Err = err
+			updates = append(updates, update)
+			continue
+		}
+
+		update.RemoteDigest = manifest.digest
+		update.Stale = trimDigest(model.Digest) != trimDigest(manifest.digest)
+		if update.Stale && opts.EstimateSize {
+			update.DownloadSize, update.Err = c.missingLayerSize(ctx, manifest)
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// missingLayerSize sums the sizes of the manifest blobs that the server
+// does not have yet.
+func (c *Client) missingLayerSize(ctx context.Context, manifest *registryManifest) (int64, error) {
+	var size int64
+	for _, layer := range append([]RegistryLayer{manifest.Config}, manifest.Layers...) {
+		err := c.do(ctx, http.MethodHead, "/api/blobs/"+layer.Digest, nil, nil)
+		if err == nil {
+			continue
+		}
+		var ollamaErr *OllamaError
+		if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusNotFound {
+			return 0, err
+		}
+		size += layer.Size
+	}
+	return size, nil
+}
+
+// UpdateResult reports the outcome of updating a single model.
+type UpdateResult struct {
+	Name           string
+	PreviousDigest string
+	NewDigest      string
+	// Updated is true if the new version was pulled and verified.
+	Updated bool
+	// Err is set if the model could not be checked or updated. When an
+	// update fails after the pull started, the previous version is restored.
+	Err error
+}
+
+// UpdateAllOptions holds optional settings for UpdateAllWithOptions.
+type UpdateAllOptions struct {
+	// Filter limits the update to matching models.
+	Filter ModelFilter
+	// Concurrency is the number of models updated in parallel. Values below
+	// one update models sequentially.
+	Concurrency int
+	// Insecure allows plain HTTP for models from custom registries.
+	Insecure bool
+}
+
+// UpdateAll pulls the latest version of every stale model as reported by
+// CheckForUpdates. Each model is first copied to a backup name; the new
+// version must match the registry digest before the backup is removed,
+// otherwise the previous version is restored from the backup.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeouts
+//   - fn: Callback receiving pull progress together with the model name (can be nil)
+//
+// Returns one result per checked model, or an error if the models cannot be listed.
+func (c *Client) UpdateAll(ctx context.Context, fn func(string, PullProgress)) ([]UpdateResult, error) {
+	return c.UpdateAllWithOptions(ctx, nil, fn)
+}
+
+// UpdateAllWithOptions behaves like UpdateAll, applying the given
+// UpdateAllOptions. With a Concurrency above one, fn may be called from
+// several goroutines at once.
+func (c *Client) UpdateAllWithOptions(ctx context.Context, opts *UpdateAllOptions, fn func(string, PullProgress)) ([]UpdateResult, error) {
+	if opts == nil {
+		opts = &UpdateAllOptions{}
+	}
+	if fn == nil {
+		fn = func(string, PullProgress) {}
+	}
+
+	updates, err := c.CheckForUpdatesWithOptions(ctx, &UpdateCheckOptions{
+		Filter:   opts.Filter,
+		Insecure: opts.Insecure,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]UpdateResult, len(updates))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, update := range updates {
+		results[i] = UpdateResult{
+			Name:           update.Name,
+			PreviousDigest: update.LocalDigest,
+			NewDigest:      update.LocalDigest,
+			Err:            update.Err,
+		}
+		if update.Err != nil || !update.Stale {
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *UpdateResult, update ModelUpdate) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result.Err = c.updateModel(ctx, update, opts.Insecure, func(p PullProgress) {
+				fn(update.Name, p)
+			})
+			if result.Err == nil {
+				result.Updated = true
+				result.NewDigest = update.RemoteDigest
+			}
+		}(&results[i], update)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// updateModel pulls a stale model, keeping a backup copy of the previous
+// version until the new one has been verified against the registry digest.
+func (c *Client) updateModel(ctx context.Context, update ModelUpdate, insecure bool, fn func(PullProgress)) error {
+	name := ModelName(update.Name)
+	backup := string(name.WithTag(name.Tag() + "-update-backup"))
+
+	// A backup left behind by an interrupted update is stale
+	overwrite := &CopyOptions{Overwrite: true}
+	if err := c.CopyWithOptions(ctx, update.Name, backup, overwrite); err != nil {
+		return fmt.Errorf("failed to back up model %q: %w", update.Name, err)
+	}
+
+	err := c.PullWithOptions(ctx, update.Name, &PullOptions{
+		Insecure:       insecure,
+		ExpectedDigest: update.RemoteDigest,
+	}, fn)
+	if err != nil {
+		if restoreErr := c.restoreFromBackup(ctx, backup, update.Name); restoreErr != nil {
+			return fmt.Errorf("failed to update model %q: %w (restore from %q also failed: %v)", update.Name, err, backup, restoreErr)
+		}
+		return fmt.Errorf("failed to update model %q: %w", update.Name, err)
+	}
+
+	if err := c.Delete(ctx, backup); err != nil {
+		return fmt.Errorf("model %q updated but backup %q could not be removed: %w", update.Name, backup, err)
+	}
+	return nil
+}
+
+// restoreFromBackup copies backup over name and removes the backup. It runs
+// even if ctx is canceled, since it undoes a change that ctx interrupted.
+func (c *Client) restoreFromBackup(ctx context.Context, backup, name string) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := c.CopyWithOptions(ctx, backup, name, &CopyOptions{Overwrite: true}); err != nil {
+		return err
+	}
+	c.Delete(ctx, backup)
+	return nil
+}
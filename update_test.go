@@ -0,0 +1,188 @@
+package gollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const testManifest = `{"config":{"digest":"sha256:cfg","size":100},"layers":[` +
+	`{"mediaType":"application/vnd.ollama.image.model","digest":"sha256:1a838c4c","size":4000},` +
+	`{"mediaType":"application/vnd.ollama.image.params","digest":"sha256:new","size":50}]}`
+
+func TestClientCheckForUpdates(t *testing.T) {
+	sum := sha256.Sum256([]byte(testManifest))
+	currentDigest := hex.EncodeToString(sum[:])
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/llama2/manifests/latest", "/v2/library/codellama/manifests/latest":
+			w.Write([]byte(testManifest))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[` +
+				`{"name":"llama2:latest","digest":"` + currentDigest + `"},` +
+				`{"name":"codellama:latest","digest":"0ld"},` +
+				`{"name":"my-custom:latest","digest":"abc"}]}`))
+		case "/api/blobs/sha256:1a838c4c":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	client.registryURL = registry.URL
+
+	updates, err := client.CheckForUpdatesWithOptions(context.Background(), &UpdateCheckOptions{EstimateSize: true})
+	assertNoError(t, err)
+
+	if len(updates) != 3 {
+		t.Fatalf("Expected 3 update entries, got %d", len(updates))
+	}
+	if updates[0].Stale || updates[0].Err != nil {
+		t.Errorf("Expected llama2 to be up to date, got %+v", updates[0])
+	}
+	if !updates[1].Stale {
+		t.Errorf("Expected codellama to be stale, got %+v", updates[1])
+	}
+	if updates[1].DownloadSize != 150 {
+		t.Errorf("Expected download estimate of 150 bytes, got %d", updates[1].DownloadSize)
+	}
+	if updates[2].Err == nil {
+		t.Errorf("Expected error for model missing from registry, got %+v", updates[2])
+	}
+
+	updates, err = client.CheckForUpdates(context.Background())
+	assertNoError(t, err)
+	if updates[1].DownloadSize != 0 {
+		t.Errorf("Expected no download estimate without EstimateSize, got %d", updates[1].DownloadSize)
+	}
+}
+
+func TestClientUpdateAll(t *testing.T) {
+	manifests := map[string]string{
+		"llama2":    testManifest,
+		"codellama": testManifest,
+		"broken":    testManifest,
+	}
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for model, manifest := range manifests {
+			if r.URL.Path == "/v2/library/"+model+"/manifests/latest" {
+				w.Write([]byte(manifest))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer registry.Close()
+
+	sum := sha256.Sum256([]byte(testManifest))
+	latest := hex.EncodeToString(sum[:])
+
+	var mu sync.Mutex
+	digests := map[string]string{
+		"llama2:latest":    latest,
+		"codellama:latest": "old",
+		"broken:latest":    "old",
+	}
+	var calls []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			Model       string `json:"model"`
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/api/tags":
+			var list ListModelsResponse
+			for _, name := range []string{"llama2:latest", "codellama:latest", "broken:latest"} {
+				list.Models = append(list.Models, ModelResponse{Name: name, Digest: digests[name]})
+			}
+			for name, digest := range digests {
+				if strings.HasSuffix(name, "-update-backup") {
+					list.Models = append(list.Models, ModelResponse{Name: name, Digest: digest})
+				}
+			}
+			json.NewEncoder(w).Encode(list)
+		case "/api/copy":
+			calls = append(calls, "copy "+body.Source+" "+body.Destination)
+			digests[body.Destination] = digests[body.Source]
+		case "/api/delete":
+			calls = append(calls, "delete "+body.Model)
+			delete(digests, body.Model)
+		case "/api/pull":
+			calls = append(calls, "pull "+body.Model)
+			if body.Model == "codellama:latest" {
+				digests[body.Model] = latest
+			} else {
+				digests[body.Model] = "corrupt"
+			}
+			json.NewEncoder(w).Encode(PullProgress{Status: "success"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	client.registryURL = registry.URL
+
+	var progress int32
+	results, err := client.UpdateAllWithOptions(context.Background(), &UpdateAllOptions{Concurrency: 2}, func(name string, p PullProgress) {
+		atomic.AddInt32(&progress, 1)
+	})
+	assertNoError(t, err)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Updated || results[0].Err != nil {
+		t.Errorf("Expected up-to-date llama2 to be left alone, got %+v", results[0])
+	}
+	if !results[1].Updated || results[1].Err != nil {
+		t.Errorf("Expected codellama to be updated, got %+v", results[1])
+	}
+	if results[2].Updated || !errors.Is(results[2].Err, ErrDigestMismatch) {
+		t.Errorf("Expected broken update to fail verification, got %+v", results[2])
+	}
+	if progress != 2 {
+		t.Errorf("Expected 2 progress events, got %d", progress)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if digests["broken:latest"] != "old" {
+		t.Errorf("Expected previous version of broken to be restored, got %q", digests["broken:latest"])
+	}
+	if _, ok := digests["codellama:latest-update-backup"]; ok {
+		t.Errorf("Expected backup to be removed after successful update")
+	}
+	if _, ok := digests["broken:latest-update-backup"]; ok {
+		t.Errorf("Expected backup to be removed after restore")
+	}
+}
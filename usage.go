@@ -0,0 +1,99 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DiskUsageReport summarizes how much storage and memory the models on an
+// Ollama server use.
+type DiskUsageReport struct {
+	// TotalBytes is the disk space used by all models, counting models that
+	// share a digest (e.g. copies made with Copy) only once. Models that
+	// share only some layers, such as variants of one base model, are
+	// counted in full, since the server does not report layers, so this is
+	// an upper bound.
+	TotalBytes int64
+	// CopySavings is the size that would be used on top of TotalBytes if
+	// models sharing a digest were stored separately.
+	CopySavings int64
+	// Models lists every model with its size, largest first.
+	Models []ModelUsage
+	// Running lists the models currently loaded into memory.
+	Running []RunningUsage
+	// VRAMBytes is the combined VRAM used by running models.
+	VRAMBytes int64
+}
+
+// ModelUsage describes the storage used by a single model.
+type ModelUsage struct {
+	Name   string
+	Digest string
+	Size   int64
+	// SharedWith lists other model names that point at the same digest.
+	SharedWith []string
+}
+
+// RunningUsage describes the memory used by a loaded model.
+type RunningUsage struct {
+	Name     string
+	Size     int64
+	SizeVRAM int64
+}
+
+// DiskUsage assembles a DiskUsageReport from the List and PS endpoints.
+//
+// Returns the report, or an error if either endpoint fails.
+func (c *Client) DiskUsage(ctx context.Context) (*DiskUsageReport, error) {
+	models, err := c.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	running, err := c.PS(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	report := &DiskUsageReport{}
+
+	byDigest := make(map[string][]string)
+	for _, model := range models.Models {
+		if model.Digest != "" {
+			byDigest[model.Digest] = append(byDigest[model.Digest], model.Name)
+		}
+	}
+
+	counted := make(map[string]bool)
+	for _, model := range models.Models {
+		usage := ModelUsage{Name: model.Name, Digest: model.Digest, Size: model.Size}
+		for _, name := range byDigest[model.Digest] {
+			if name != model.Name {
+				usage.SharedWith = append(usage.SharedWith, name)
+			}
+		}
+		report.Models = append(report.Models, usage)
+
+		if model.Digest != "" && counted[model.Digest] {
+			report.CopySavings += model.Size
+			continue
+		}
+		counted[model.Digest] = true
+		report.TotalBytes += model.Size
+	}
+	sort.SliceStable(report.Models, func(i, j int) bool {
+		return report.Models[i].Size > report.Models[j].Size
+	})
+
+	for _, model := range running.Models {
+		report.Running = append(report.Running, RunningUsage{
+			Name:     model.Name,
+			Size:     model.Size,
+			SizeVRAM: model.SizeVRAM,
+		})
+		report.VRAMBytes += model.SizeVRAM
+	}
+
+	return report, nil
+}
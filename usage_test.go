@@ -0,0 +1,48 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientDiskUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[
+				{"name":"llama2:latest","size":3000,"digest":"sha256:aa"},
+				{"name":"llama2-backup:latest","size":3000,"digest":"sha256:aa"},
+				{"name":"mistral:latest","size":4000,"digest":"sha256:bb"}
+			]}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[{"name":"mistral:latest","size":5000,"size_vram":2500}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	report, err := client.DiskUsage(context.Background())
+	assertNoError(t, err)
+
+	if report.TotalBytes != 7000 {
+		t.Errorf("Expected total of 7000 bytes, got %d", report.TotalBytes)
+	}
+	if report.CopySavings != 3000 {
+		t.Errorf("Expected copy savings of 3000 bytes, got %d", report.CopySavings)
+	}
+	if len(report.Models) != 3 || report.Models[0].Name != "mistral:latest" {
+		t.Errorf("Expected models sorted by size, got %+v", report.Models)
+	}
+	if len(report.Models[1].SharedWith) != 1 {
+		t.Errorf("Expected shared digest to be reported, got %+v", report.Models[1])
+	}
+	if report.VRAMBytes != 2500 || len(report.Running) != 1 {
+		t.Errorf("Expected 2500 bytes of VRAM from one model, got %d from %d", report.VRAMBytes, len(report.Running))
+	}
+}
@@ -0,0 +1,8 @@
+package gollama
+
+// Version is the version of the gollama library. It is sent as part of the
+// default User-Agent header.
+const Version = "0.1.0"
+
+// defaultUserAgent is the User-Agent header sent unless WithUserAgent is used.
+const defaultUserAgent = "gollama/" + Version
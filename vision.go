@@ -0,0 +1,197 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers GIF decoding
+	"image/jpeg"
+	_ "image/png" // registers PNG decoding
+	"io"
+	"os"
+)
+
+// DefaultImageDimension is the longest side, in pixels, that DescribeImage
+// and AskAboutImage scale images down to. Vision models work at much lower
+// resolutions internally, so larger images only cost upload time and memory.
+const DefaultImageDimension = 1024
+
+// DescribeImage asks a vision model to describe an image file and returns
+// the description. The image is scaled down as by LoadImage. An empty model
+// uses the client's default model.
+func (c *Client) DescribeImage(ctx context.Context, model, imagePath string, opts ...RequestOption) (string, error) {
+	return c.AskAboutImage(ctx, model, imagePath, "Describe this image in detail.", opts...)
+}
+
+// AskAboutImage asks a vision model a question about an image file and
+// returns the answer. The image is scaled down as by LoadImage. An empty
+// model uses the client's default model.
+func (c *Client) AskAboutImage(ctx context.Context, model, imagePath, question string, opts ...RequestOption) (string, error) {
+	img, err := LoadImage(imagePath, DefaultImageDimension)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Chat(ctx, &ChatRequest{
+		Model:    model,
+		Messages: []Message{{Role: "user", Content: question, Images: []string{img}}},
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// ImageSource is an image that is read and base64-encoded only while the
+// request that holds it is being sent, so that callers need not keep
+// several full-size encoded images in memory. Requests with image sources
+// are always sent with a streamed body (see WithStreamingRequestBodies).
+// Unlike LoadImage, image sources are sent as is, without scaling.
+type ImageSource struct {
+	path   string
+	reader io.Reader
+}
+
+// ImageFile returns an image source that reads the file at path each time
+// a request holding it is sent.
+func ImageFile(path string) ImageSource {
+	return ImageSource{path: path}
+}
+
+// ImageReader returns an image source that reads r when a request holding
+// it is sent. A reader can only be read once, so requests holding one are
+// not retried or resumed, and must not be reused, for example by keeping
+// its message in a chat history; use ImageFile for images that are sent
+// more than once.
+func ImageReader(r io.Reader) ImageSource {
+	return ImageSource{reader: r}
+}
+
+// writeJSON writes the image to w as a base64-encoded JSON string.
+func (s ImageSource) writeJSON(w io.Writer) error {
+	r := s.reader
+	if s.path != "" {
+		f, err := os.Open(s.path)
+		if err != nil {
+			return fmt.Errorf("failed to load image: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	if r == nil {
+		return fmt.Errorf("image source is empty")
+	}
+
+	if _, err := io.WriteString(w, `"`); err != nil {
+		return err
+	}
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, r); err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, `"`)
+	return err
+}
+
+// LoadImage reads an image file and returns it base64-encoded, as expected
+// by Message.Images and GenerateRequest.Images. See EncodeImage.
+func LoadImage(path string, maxDimension int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load image: %w", err)
+	}
+	defer f.Close()
+
+	img, err := EncodeImage(f, maxDimension)
+	if err != nil {
+		return "", fmt.Errorf("failed to load image %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// EncodeImage reads an image and returns it base64-encoded. JPEG, PNG and
+// GIF images whose longest side exceeds maxDimension pixels are scaled down
+// to fit and re-encoded as JPEG; other images are encoded unchanged. A
+// maxDimension of zero or less disables scaling.
+func EncodeImage(r io.Reader, maxDimension int) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	if maxDimension > 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		switch {
+		case errors.Is(err, image.ErrFormat):
+			// Left to the server, which may support more formats
+		case err != nil:
+			return "", fmt.Errorf("failed to decode image: %w", err)
+		case config.Width > maxDimension || config.Height > maxDimension:
+			if data, err = scaleImage(data, config, maxDimension); err != nil {
+				return "", err
+			}
+		}
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// scaleImage decodes an image and re-encodes it as JPEG, scaled so that its
+// longest side is maxDimension pixels.
+func scaleImage(data []byte, config image.Config, maxDimension int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := maxDimension, maxDimension
+	if config.Width > config.Height {
+		height = max(1, config.Height*maxDimension/config.Width)
+	} else {
+		width = max(1, config.Width*maxDimension/config.Height)
+	}
+
+	// JPEG has no transparency, so the image is drawn over white
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), downscale(src, width, height), image.Point{}, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale shrinks an image to the given size by averaging the source
+// pixels that fall into each destination pixel.
+func downscale(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/width)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,99 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestPNG writes a solid red PNG of the given size and returns its path.
+func writeTestPNG(t *testing.T, width, height int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadImage(t *testing.T) {
+	large := writeTestPNG(t, 2000, 1000)
+	encoded, err := LoadImage(large, 1024)
+	assertNoError(t, err)
+
+	data, _ := base64.StdEncoding.DecodeString(encoded)
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	assertNoError(t, err)
+	if format != "jpeg" || config.Width != 1024 || config.Height != 512 {
+		t.Errorf("Expected 1024x512 jpeg, got %dx%d %s", config.Width, config.Height, format)
+	}
+	img, _, _ := image.Decode(bytes.NewReader(data))
+	if r, g, _, _ := img.At(10, 10).RGBA(); r>>8 < 240 || g>>8 > 15 {
+		t.Errorf("Expected red pixels to survive scaling, got r=%d g=%d", r>>8, g>>8)
+	}
+
+	small := writeTestPNG(t, 100, 50)
+	encoded, err = LoadImage(small, 1024)
+	assertNoError(t, err)
+	original, _ := os.ReadFile(small)
+	if encoded != base64.StdEncoding.EncodeToString(original) {
+		t.Error("Expected small image to be sent unchanged")
+	}
+
+	// Unknown formats are passed through
+	encoded, err = EncodeImage(strings.NewReader("not an image"), 1024)
+	assertNoError(t, err)
+	if encoded != base64.StdEncoding.EncodeToString([]byte("not an image")) {
+		t.Error("Expected unknown format to be sent unchanged")
+	}
+
+	_, err = LoadImage(filepath.Join(t.TempDir(), "missing.png"), 1024)
+	assertErrorContains(t, err, "failed to load image")
+}
+
+func TestClientAskAboutImage(t *testing.T) {
+	var got ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "A red square."}, Done: true})
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	path := writeTestPNG(t, 10, 10)
+	description, err := client.DescribeImage(context.Background(), "llava", path)
+	assertNoError(t, err)
+	if description != "A red square." {
+		t.Errorf("Expected description, got %q", description)
+	}
+	if len(got.Messages) != 1 || len(got.Messages[0].Images) != 1 || got.Messages[0].Content == "" {
+		t.Errorf("Expected one message with a prompt and an image, got %+v", got.Messages)
+	}
+
+	_, err = client.AskAboutImage(context.Background(), "llava", path, "What color is it?")
+	assertNoError(t, err)
+	if got.Messages[0].Content != "What color is it?" {
+		t.Errorf("Expected the question to be sent, got %q", got.Messages[0].Content)
+	}
+}
@@ -0,0 +1,64 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// WarmupOptions holds optional settings for WarmupWithOptions.
+type WarmupOptions struct {
+	// CheckVersion warms up with a version request, which fails unless the
+	// server answers as an Ollama server, rather than with any response.
+	CheckVersion bool
+}
+
+// Warmup opens a connection to the server, completing the TCP and TLS
+// handshakes, and leaves it idle for the next call, so that the first real
+// request does not pay for them. It only fails if the server cannot be
+// reached; any response counts.
+func (c *Client) Warmup(ctx context.Context) error {
+	return c.WarmupWithOptions(ctx, nil)
+}
+
+// WarmupWithOptions behaves like Warmup, applying the given WarmupOptions.
+func (c *Client) WarmupWithOptions(ctx context.Context, opts *WarmupOptions) error {
+	if opts != nil && opts.CheckVersion {
+		if _, err := c.Version(ctx); err != nil {
+			return fmt.Errorf("failed to warm up connection to %s: %w", c.baseURL, err)
+		}
+		return nil
+	}
+
+	err := c.do(ctx, http.MethodHead, "/", nil, nil)
+	var apiErr *OllamaError
+	if err != nil && !errors.As(err, &apiErr) {
+		return fmt.Errorf("failed to warm up connection to %s: %w", c.baseURL, err)
+	}
+	return nil
+}
+
+// Warmup opens a connection to every host of the cluster at once, like
+// Client.Warmup. Hosts that cannot be reached are reported in the returned
+// error, but are not marked unhealthy.
+func (cc *ClusterClient) Warmup(ctx context.Context) error {
+	return cc.WarmupWithOptions(ctx, nil)
+}
+
+// WarmupWithOptions behaves like Warmup, applying the given WarmupOptions.
+func (cc *ClusterClient) WarmupWithOptions(ctx context.Context, opts *WarmupOptions) error {
+	hosts := cc.snapshot()
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h *clusterHost) {
+			defer wg.Done()
+			errs[i] = h.client.WarmupWithOptions(ctx, opts)
+		}(i, h)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
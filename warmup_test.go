@@ -0,0 +1,111 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingServer serves a version and generations, counting the connections
+// it accepts.
+func countingServer(t *testing.T, conns *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte("Ollama is running"))
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.5.7"}`))
+		case "/api/generate":
+			w.Write([]byte(`{"model":"llama2","response":"hi","done":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(conns, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientWarmup(t *testing.T) {
+	var conns int32
+	server := countingServer(t, &conns)
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	assertNoError(t, client.Warmup(ctx))
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Fatalf("Expected 1 connection after warmup, got %d", n)
+	}
+
+	// The first request reuses the warm connection
+	_, err = client.Generate(ctx, &GenerateRequest{Model: "llama2", Prompt: "hi"})
+	assertNoError(t, err)
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("Expected request to reuse the connection, got %d connections", n)
+	}
+
+	assertNoError(t, client.WarmupWithOptions(ctx, &WarmupOptions{CheckVersion: true}))
+	version, err := client.Version(ctx)
+	assertNoError(t, err)
+	if version != "0.5.7" {
+		t.Errorf("Expected version 0.5.7, got %q", version)
+	}
+}
+
+func TestClientWarmupErrors(t *testing.T) {
+	ctx := context.Background()
+
+	// Any response warms up the connection, but a version check needs an
+	// Ollama server
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+	assertNoError(t, client.Warmup(ctx))
+	err = client.WarmupWithOptions(ctx, &WarmupOptions{CheckVersion: true})
+	var apiErr *OllamaError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 from version check, got %v", err)
+	}
+
+	client, err = createTestClient(downServer())
+	assertNoError(t, err)
+	assertErrorContains(t, client.Warmup(ctx), "failed to warm up connection")
+}
+
+func TestClusterClientWarmup(t *testing.T) {
+	var connsA, connsB int32
+	a := countingServer(t, &connsA)
+	b := countingServer(t, &connsB)
+	down := downServer()
+	ctx := context.Background()
+
+	cluster, err := NewClusterClient([]string{a.URL, b.URL})
+	assertNoError(t, err)
+	assertNoError(t, cluster.WarmupWithOptions(ctx, &WarmupOptions{CheckVersion: true}))
+	if atomic.LoadInt32(&connsA) != 1 || atomic.LoadInt32(&connsB) != 1 {
+		t.Errorf("Expected 1 connection to each host, got %d and %d", connsA, connsB)
+	}
+
+	cluster, err = NewClusterClient([]string{a.URL, down})
+	assertNoError(t, err)
+	err = cluster.Warmup(ctx)
+	if err == nil || !strings.Contains(err.Error(), down) || strings.Contains(err.Error(), a.URL) {
+		t.Errorf("Expected error naming only the unreachable host, got %v", err)
+	}
+	if !cluster.Hosts()[1].Healthy {
+		t.Error("Expected warmup not to change host health")
+	}
+}
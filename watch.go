@@ -0,0 +1,143 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PSEventType identifies the kind of change reported by WatchPS.
+type PSEventType string
+
+// Event types emitted by WatchPS.
+const (
+	// PSEventLoaded is emitted when a model appears in the process list.
+	PSEventLoaded PSEventType = "loaded"
+	// PSEventUnloaded is emitted when a model disappears from the process list.
+	PSEventUnloaded PSEventType = "unloaded"
+	// PSEventExpiring is emitted once per load when a model is about to be
+	// unloaded by the server because its keep-alive is running out.
+	PSEventExpiring PSEventType = "expiring"
+	// PSEventVRAMChanged is emitted when the VRAM used by a model changes.
+	PSEventVRAMChanged PSEventType = "vram_changed"
+	// PSEventError is emitted when polling the process list fails.
+	PSEventError PSEventType = "error"
+)
+
+// PSEvent describes a change between two successive PS snapshots.
+type PSEvent struct {
+	Type PSEventType
+	// Model is the model the event refers to. For PSEventUnloaded it holds
+	// the last known state of the model.
+	Model RunningModel
+	// Previous holds the earlier state for PSEventVRAMChanged events.
+	Previous *RunningModel
+	// Err is set for PSEventError events.
+	Err  error
+	Time time.Time
+}
+
+// WatchPS polls the process status endpoint every interval and emits an
+// event for every change between successive snapshots: models loading and
+// unloading, VRAM changes, and models whose keep-alive will run out within
+// the next two polls.
+//
+// The first snapshot reports every running model as loaded. Polling errors
+// are delivered as PSEventError events and do not stop the watcher. The
+// returned channel is closed once ctx is done.
+func (c *Client) WatchPS(ctx context.Context, interval time.Duration) (<-chan PSEvent, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch interval must be positive")
+	}
+
+	events := make(chan PSEvent)
+	go func() {
+		defer close(events)
+
+		watcher := newPSWatcher(2 * interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			var batch []PSEvent
+			status, err := c.PS(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				batch = []PSEvent{{Type: PSEventError, Err: err, Time: time.Now()}}
+			default:
+				batch = watcher.update(status.Models, time.Now())
+			}
+
+			for _, event := range batch {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// psWatcher keeps the state needed to diff successive PS snapshots.
+type psWatcher struct {
+	threshold time.Duration
+	models    map[string]RunningModel
+	warned    map[string]time.Time
+}
+
+func newPSWatcher(threshold time.Duration) *psWatcher {
+	return &psWatcher{
+		threshold: threshold,
+		models:    make(map[string]RunningModel),
+		warned:    make(map[string]time.Time),
+	}
+}
+
+// update records a new snapshot and returns the events describing the
+// changes since the previous one.
+func (w *psWatcher) update(snapshot []RunningModel, now time.Time) []PSEvent {
+	var events []PSEvent
+	current := make(map[string]RunningModel, len(snapshot))
+
+	for _, model := range snapshot {
+		current[model.Name] = model
+
+		prev, seen := w.models[model.Name]
+		switch {
+		case !seen:
+			events = append(events, PSEvent{Type: PSEventLoaded, Model: model, Time: now})
+		case prev.SizeVRAM != model.SizeVRAM:
+			p := prev
+			events = append(events, PSEvent{Type: PSEventVRAMChanged, Model: model, Previous: &p, Time: now})
+		}
+
+		// Warn once per expiry time; a refreshed keep-alive re-arms the warning
+		if !model.ExpiresAt.IsZero() && model.ExpiresAt.Sub(now) <= w.threshold {
+			if warnedAt, ok := w.warned[model.Name]; !ok || !warnedAt.Equal(model.ExpiresAt) {
+				w.warned[model.Name] = model.ExpiresAt
+				events = append(events, PSEvent{Type: PSEventExpiring, Model: model, Time: now})
+			}
+		}
+	}
+
+	for name, model := range w.models {
+		if _, ok := current[name]; !ok {
+			delete(w.warned, name)
+			events = append(events, PSEvent{Type: PSEventUnloaded, Model: model, Time: now})
+		}
+	}
+
+	w.models = current
+	return events
+}
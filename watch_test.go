@@ -0,0 +1,83 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPSWatcherDiff(t *testing.T) {
+	now := time.Now()
+	watcher := newPSWatcher(time.Minute)
+
+	llama := RunningModel{Name: "llama2", SizeVRAM: 100, ExpiresAt: now.Add(10 * time.Minute)}
+	mistral := RunningModel{Name: "mistral", SizeVRAM: 200, ExpiresAt: now.Add(30 * time.Second)}
+
+	events := watcher.update([]RunningModel{llama, mistral}, now)
+	assertEventTypes(t, events, PSEventLoaded, PSEventLoaded, PSEventExpiring)
+
+	// No change and an already announced expiry produce no events
+	events = watcher.update([]RunningModel{llama, mistral}, now)
+	assertEventTypes(t, events)
+
+	llama.SizeVRAM = 50
+	events = watcher.update([]RunningModel{llama}, now)
+	assertEventTypes(t, events, PSEventVRAMChanged, PSEventUnloaded)
+	if events[0].Previous == nil || events[0].Previous.SizeVRAM != 100 {
+		t.Errorf("Expected previous state on VRAM change, got %+v", events[0].Previous)
+	}
+	if events[1].Model.Name != "mistral" {
+		t.Errorf("Expected mistral to be unloaded, got %s", events[1].Model.Name)
+	}
+}
+
+func assertEventTypes(t *testing.T, events []PSEvent, expected ...PSEventType) {
+	t.Helper()
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, typ := range expected {
+		if events[i].Type != typ {
+			t.Errorf("Expected event %d to be %s, got %s", i, typ, events[i].Type)
+		}
+	}
+}
+
+func TestClientWatchPS(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := PSResponse{Models: []RunningModel{}}
+		if atomic.AddInt32(&polls, 1) == 1 {
+			response.Models = append(response.Models, RunningModel{Name: "llama2", ExpiresAt: time.Now().Add(time.Hour)})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.WatchPS(ctx, 10*time.Millisecond)
+	assertNoError(t, err)
+
+	first := <-events
+	second := <-events
+	if first.Type != PSEventLoaded || second.Type != PSEventUnloaded {
+		t.Errorf("Expected loaded then unloaded, got %s then %s", first.Type, second.Type)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	_, err = client.WatchPS(ctx, 0)
+	assertErrorContains(t, err, "watch interval must be positive")
+}
@@ -0,0 +1,167 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamWriterOptions holds optional settings for StreamToWriterWithOptions
+// and StreamChatToWriterWithOptions.
+type StreamWriterOptions struct {
+	// FlushInterval limits how often the writer is flushed. Zero flushes
+	// after every chunk, which gives the lowest latency.
+	FlushInterval time.Duration
+	// DisableFlush never flushes the writer, leaving buffering to it.
+	DisableFlush bool
+	// RequestOptions are passed through to the streaming call.
+	RequestOptions []RequestOption
+}
+
+// StreamToWriter streams a generation into w, writing each chunk of text as
+// it arrives. If w is an http.ResponseWriter or another writer with a Flush
+// method, such as a bufio.Writer, it is flushed after every chunk so that
+// the text reaches the reader immediately.
+//
+// It returns the final response, carrying the generation statistics, with
+// Response set to the complete text. A failed write aborts the generation
+// and is returned as the error.
+//
+// Example:
+//
+//	http.HandleFunc("/generate", func(w http.ResponseWriter, r *http.Request) {
+//		req := &gollama.GenerateRequest{Model: "llama3", Prompt: r.FormValue("q")}
+//		if _, err := gollama.StreamToWriter(r.Context(), client, req, w); err != nil {
+//			log.Print(err)
+//		}
+//	})
+func StreamToWriter(ctx context.Context, client *Client, req *GenerateRequest, w io.Writer) (*GenerateResponse, error) {
+	return StreamToWriterWithOptions(ctx, client, req, w, nil)
+}
+
+// StreamToWriterWithOptions behaves like StreamToWriter, applying the given
+// StreamWriterOptions.
+func StreamToWriterWithOptions(ctx context.Context, client *Client, req *GenerateRequest, w io.Writer, opts *StreamWriterOptions) (*GenerateResponse, error) {
+	if opts == nil {
+		opts = &StreamWriterOptions{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sw := newStreamWriter(w, opts, cancel)
+	var final GenerateResponse
+	err := client.GenerateStream(ctx, req, func(resp *GenerateResponse) {
+		sw.write(resp.Response, resp.Done)
+		if resp.Done {
+			final = *resp
+		}
+	}, opts.RequestOptions...)
+	if sw.err != nil {
+		return nil, sw.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	final.Response = sw.text.String()
+	return &final, nil
+}
+
+// StreamChatToWriter streams a chat response into w like StreamToWriter. It
+// returns the final response with Message.Content set to the complete text.
+func StreamChatToWriter(ctx context.Context, client *Client, req *ChatRequest, w io.Writer) (*ChatResponse, error) {
+	return StreamChatToWriterWithOptions(ctx, client, req, w, nil)
+}
+
+// StreamChatToWriterWithOptions behaves like StreamChatToWriter, applying
+// the given StreamWriterOptions.
+func StreamChatToWriterWithOptions(ctx context.Context, client *Client, req *ChatRequest, w io.Writer, opts *StreamWriterOptions) (*ChatResponse, error) {
+	if opts == nil {
+		opts = &StreamWriterOptions{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sw := newStreamWriter(w, opts, cancel)
+	var final ChatResponse
+	err := client.ChatStream(ctx, req, func(resp *ChatResponse) {
+		sw.write(resp.Message.Content, resp.Done)
+		if resp.Done {
+			final = *resp
+		}
+	}, opts.RequestOptions...)
+	if sw.err != nil {
+		return nil, sw.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	final.Message.Role = "assistant"
+	final.Message.Content = sw.text.String()
+	return &final, nil
+}
+
+// streamWriter writes streamed text to a writer, flushing it as configured.
+// After a failed write it cancels the stream and ignores further text.
+type streamWriter struct {
+	w         io.Writer
+	flush     func() error
+	interval  time.Duration
+	lastFlush time.Time
+	cancel    context.CancelFunc
+	text      strings.Builder
+	err       error
+}
+
+// newStreamWriter wraps w, detecting whether and how it can be flushed.
+func newStreamWriter(w io.Writer, opts *StreamWriterOptions, cancel context.CancelFunc) *streamWriter {
+	sw := &streamWriter{w: w, interval: opts.FlushInterval, cancel: cancel}
+	if !opts.DisableFlush {
+		switch f := w.(type) {
+		case http.Flusher:
+			sw.flush = func() error {
+				f.Flush()
+				return nil
+			}
+		case interface{ Flush() error }:
+			sw.flush = f.Flush
+		}
+	}
+	return sw
+}
+
+// write writes a chunk of text and flushes if due. The final chunk is
+// always flushed.
+func (sw *streamWriter) write(text string, done bool) {
+	if sw.err != nil {
+		return
+	}
+	sw.text.WriteString(text)
+
+	if text != "" {
+		if _, err := io.WriteString(sw.w, text); err != nil {
+			sw.fail(fmt.Errorf("failed to write stream: %w", err))
+			return
+		}
+	}
+
+	if sw.flush == nil {
+		return
+	}
+	if now := time.Now(); done || now.Sub(sw.lastFlush) >= sw.interval {
+		sw.lastFlush = now
+		if err := sw.flush(); err != nil {
+			sw.fail(fmt.Errorf("failed to flush stream: %w", err))
+		}
+	}
+}
+
+// fail records err and stops the stream.
+func (sw *streamWriter) fail(err error) {
+	sw.err = err
+	sw.cancel()
+}
@@ -0,0 +1,67 @@
+package gollama
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamToWriter(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	ctx := context.Background()
+	req := &GenerateRequest{Model: "llama2", Prompt: "Hi"}
+
+	recorder := httptest.NewRecorder()
+	final, err := StreamToWriter(ctx, client, req, recorder)
+	assertNoError(t, err)
+
+	if !final.Done {
+		t.Errorf("Expected final response to be done")
+	}
+	if final.Response == "" || recorder.Body.String() != final.Response {
+		t.Errorf("Expected written text %q to match final response %q", recorder.Body.String(), final.Response)
+	}
+	if !recorder.Flushed {
+		t.Errorf("Expected response writer to be flushed")
+	}
+
+	var sb strings.Builder
+	buffered := bufio.NewWriter(&sb)
+	chat, err := StreamChatToWriter(ctx, client, &ChatRequest{Model: "llama2", Messages: []Message{{Role: "user", Content: "Hi"}}}, buffered)
+	assertNoError(t, err)
+	if sb.String() == "" || sb.String() != chat.Message.Content {
+		t.Errorf("Expected bufio.Writer to be flushed with %q, got %q", chat.Message.Content, sb.String())
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection reset")
+}
+
+func TestStreamToWriterWriteError(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	client, err := createTestClient(server.URL)
+	assertNoError(t, err)
+
+	w := &failingWriter{}
+	_, err = StreamToWriter(context.Background(), client, &GenerateRequest{Model: "llama2", Prompt: "Hi"}, w)
+	assertErrorContains(t, err, "connection reset")
+	if w.writes != 1 {
+		t.Errorf("Expected the stream to stop after the first failed write, got %d writes", w.writes)
+	}
+}